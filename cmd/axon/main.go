@@ -7,6 +7,7 @@ import (
 
 	"github.com/toyz/axon/internal/cli"
 	"github.com/toyz/axon/internal/utils"
+	"github.com/toyz/axon/pkg/axon"
 )
 
 func main() {
@@ -16,6 +17,8 @@ func main() {
 		verboseFlag = flag.Bool("verbose", false, "Enable verbose output and detailed error reporting")
 		quietFlag   = flag.Bool("quiet", false, "Only show errors and final results")
 		cleanFlag   = flag.Bool("clean", false, "Delete all autogen_module.go files from the specified directories")
+		etagFlag    = flag.Bool("etag", false, "Set ETags on every route returning data or *axon.Response (opt out with -ETag=false)")
+		maxBodyFlag = flag.String("max-body-size", "", "Default request body limit for every route, e.g. 10MB, or 0 for none (default 4MB; override with -MaxBodySize)")
 		helpFlag    = flag.Bool("help", false, "Show help information")
 	)
 
@@ -40,6 +43,8 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  %s --verbose ./internal/...                   # Enable detailed output\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s --quiet ./...                              # Minimal output\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s --clean ./...                              # Delete all autogen_module.go files\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --etag ./...                               # ETags and conditional requests on data routes\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --max-body-size 10MB ./...                 # Reject request bodies over 10MB with 413\n", os.Args[0])
 	}
 
 	flag.Parse()
@@ -91,6 +96,15 @@ func main() {
 	if *moduleFlag != "" {
 		generator.SetCustomModule(*moduleFlag)
 	}
+	generator.SetDefaultETag(*etagFlag)
+	if *maxBodyFlag != "" {
+		maxBodySize, err := axon.ParseByteSize(*maxBodyFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: -max-body-size: %v\n", err)
+			os.Exit(1)
+		}
+		generator.SetDefaultMaxBodySize(maxBodySize)
+	}
 
 	// Run the generation process
 	err := generator.Generate(args)
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Config holds application configuration
@@ -10,6 +11,12 @@ type Config struct {
 	Port        int    `json:"port"`
 	DatabaseURL string `json:"database_url"`
 	LogLevel    string `json:"log_level"`
+
+	// CookieSecrets are base64 keyring secrets for signed/encrypted cookies, newest first
+	CookieSecrets []string `json:"-"`
+
+	// TrustedProxies are the CIDRs of load balancers whose forwarding headers are trusted
+	TrustedProxies []string `json:"trusted_proxies"`
 }
 
 // LoadConfig loads configuration from environment variables
@@ -21,11 +28,18 @@ func LoadConfig() *Config {
 		}
 	}
 
-	return &Config{
+	cfg := &Config{
 		Port:        port,
 		DatabaseURL: getEnvOrDefault("DATABASE_URL", "sqlite://./app.db"),
 		LogLevel:    getEnvOrDefault("LOG_LEVEL", "info"),
 	}
+	if secrets := os.Getenv("COOKIE_SECRETS"); secrets != "" {
+		cfg.CookieSecrets = strings.Split(secrets, ",")
+	}
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		cfg.TrustedProxies = strings.Split(proxies, ",")
+	}
+	return cfg
 }
 
 func getEnvOrDefault(key, defaultValue string) string {
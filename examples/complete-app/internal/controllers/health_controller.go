@@ -1,7 +1,11 @@
 package controllers
 
 import (
+	"fmt"
+	"net/http"
+
 	"github.com/toyz/axon/examples/complete-app/internal/services"
+	"github.com/toyz/axon/pkg/axon"
 )
 
 //axon::controller
@@ -11,19 +15,33 @@ type HealthController struct {
 }
 
 //axon::route GET /health
-func (c *HealthController) GetHealth() (map[string]interface{}, error) {
+func (c *HealthController) GetHealth() map[string]interface{} {
 	return map[string]interface{}{
 		"status":   "healthy",
 		"database": c.DatabaseService.Health(),
-	}, nil
+	}
 }
 
 //axon::route GET /ready
-func (c *HealthController) GetReadiness() (map[string]interface{}, error) {
+func (c *HealthController) GetReadiness() (map[string]interface{}, int, error) {
 	ready := c.DatabaseService.IsConnected()
-	
+
+	// Load balancers take instances out of rotation on 503
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
 	return map[string]interface{}{
 		"ready":    ready,
 		"database": ready,
-	}, nil
-}
\ No newline at end of file
+	}, status, nil
+}
+
+//axon::route GET /status -PassContext -CSP=statusPage
+func (c *HealthController) GetStatusPage(ctx axon.RequestContext) error {
+	// The statusPage policy only allows inline scripts carrying this request's nonce
+	return ctx.Response().HTML(http.StatusOK, fmt.Sprintf(
+		`<!doctype html><p id="status">checking...</p><script nonce="%s">document.getElementById("status").textContent = "database connected: %t"</script>`,
+		axon.CSPNonce(ctx), c.DatabaseService.IsConnected(),
+	))
+}
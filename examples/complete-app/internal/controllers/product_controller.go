@@ -1,7 +1,11 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 
 	"github.com/google/uuid"
 	"github.com/toyz/axon/pkg/axon"
@@ -16,8 +20,8 @@ type ProductController struct {
 	DatabaseService *services.DatabaseService
 }
 
-// Using built-in UUID parser (this will use the built-in uuid.UUID parser)
-//axon::route GET /products/{id:UUID}
+// Using built-in UUID parser (this will use the built-in uuid.UUID parser), cached for 30 seconds
+//axon::route GET /products/{id:UUID} -Cache=30s
 func (c *ProductController) GetProduct(id uuid.UUID) (*models.Product, error) {
 	// Mock implementation - in real app this would query the database
 	product := &models.Product{
@@ -42,8 +46,8 @@ func (c *ProductController) GetProductByCode(code parsers.ProductCode) (*models.
 	return product, nil
 }
 
-// Using custom DateRange parser with multiple middleware
-//axon::route GET /products/sales/{dateRange:DateRange} -Middleware=AuthMiddleware,LoggingMiddleware
+// Using custom DateRange parser with multiple middleware, cached per authenticated user
+//axon::route GET /products/sales/{dateRange:DateRange} -Middleware=AuthMiddleware,LoggingMiddleware -Cache=1m -CacheVary=principal -Coalesce -CoalesceKey=principal
 func (c *ProductController) GetProductSales(dateRange parsers.DateRange) ([]models.Product, error) {
 	// Mock implementation showing custom date range parser
 	products := []models.Product{
@@ -59,7 +63,7 @@ func (c *ProductController) GetProductSales(dateRange parsers.DateRange) ([]mode
 
 // Mixed parameter types with auth middleware and PassContext
 //axon::route POST /products/{categoryId:UUID}/items -Middleware=AuthMiddleware -PassContext
-func (c *ProductController) CreateProductInCategory(ctx axon.RequestContext, categoryId uuid.UUID, req models.CreateProductRequest) (*models.Product, error) {
+func (c *ProductController) CreateProductInCategory(ctx axon.RequestContext, categoryId uuid.UUID, req models.CreateProductRequest) (models.CreatedProduct, error) {
 	// Mock implementation showing built-in UUID parser
 	product := &models.Product{
 		ID:          uuid.New(),
@@ -68,7 +72,8 @@ func (c *ProductController) CreateProductInCategory(ctx axon.RequestContext, cat
 		Description: req.Description,
 		Price:       req.Price,
 	}
-	return product, nil
+	// Answered with 201 and a Location header
+	return models.CreatedProduct{Product: product}, nil
 }
 
 //axon::route PUT /products/{id:UUID}
@@ -87,4 +92,24 @@ func (c *ProductController) UpdateProduct(id uuid.UUID, req models.UpdateProduct
 func (c *ProductController) DeleteProduct(id uuid.UUID) error {
 	// Mock implementation using built-in UUID parser
 	return nil
-}
\ No newline at end of file
+}
+
+// Bulk import streams newline-delimited JSON products without buffering the upload
+//axon::route POST /products/import -Middleware=AuthMiddleware -MaxBodySize=5MB
+func (c *ProductController) ImportProducts(body axon.BodyStream) (map[string]int, error) {
+	decoder := json.NewDecoder(body)
+	imported := 0
+	for {
+		var req models.CreateProductRequest
+		if err := decoder.Decode(&req); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			if errors.Is(err, axon.ErrBodyTooLarge) {
+				return nil, err
+			}
+			return nil, axon.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid product on line %d: %v", imported+1, err))
+		}
+		imported++
+	}
+	return map[string]int{"imported": imported}, nil
+}
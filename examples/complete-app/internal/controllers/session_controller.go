@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/toyz/axon/examples/complete-app/internal/services"
 	"github.com/toyz/axon/pkg/axon"
@@ -19,7 +20,7 @@ type SessionController struct {
 
 // StartSession creates a new session for a user
 //axon::route POST /sessions/{userID:int} -Middleware=LoggingMiddleware
-func (c *SessionController) StartSession(userID int) (*axon.Response, error) {
+func (c *SessionController) StartSession(userID int, session axon.Session) (*axon.Response, error) {
 	// Get a fresh SessionService instance for this request
 	sessionService := c.SessionFactory()
 	
@@ -34,6 +35,11 @@ func (c *SessionController) StartSession(userID int) (*axon.Response, error) {
 	
 	// Start a new session with the fresh service instance
 	sessionID := sessionService.StartSession(userID)
+
+	// Persist the login in the server-side session; the ID is rotated because the user changed
+	session.Set("user_id", strconv.Itoa(userID))
+	session.Regenerate()
+	session.AddFlash("notice", "Welcome back, "+user.Name)
 	
 	return &axon.Response{
 		StatusCode: http.StatusCreated,
@@ -66,6 +72,21 @@ func (c *SessionController) GetSessionInfo(userID int) (map[string]interface{},
 	return info, nil
 }
 
+// GetCurrentSession returns the server-side session stored by StartSession
+// The session is loaded from the axon_session cookie and saved after the handler returns
+//axon::route GET /sessions/current
+func (c *SessionController) GetCurrentSession(session axon.Session) (map[string]interface{}, error) {
+	userID, ok := session.Get("user_id")
+	if !ok {
+		return nil, axon.NewHTTPError(http.StatusUnauthorized, "no active session")
+	}
+
+	return map[string]interface{}{
+		"user_id": userID,
+		"notices": session.Flashes("notice"), // shown once
+	}, nil
+}
+
 // CompareSessionInstances demonstrates that different requests get different instances
 //axon::route GET /sessions/compare -PassContext
 func (c *SessionController) CompareSessionInstances(ctx axon.RequestContext) error {
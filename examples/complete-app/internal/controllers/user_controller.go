@@ -1,7 +1,12 @@
 package controllers
 
 import (
+	"fmt"
+	"iter"
+	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/toyz/axon/examples/complete-app/internal/models"
 	"github.com/toyz/axon/examples/complete-app/internal/services"
@@ -29,17 +34,41 @@ func (c *UserController) SearchUsers(ctx axon.RequestContext, query axon.QueryMa
 	return c.UserService.SearchUsers(name, age, active)
 }
 
-//axon::route GET /{userId:int} -Priority=50
-func (c *UserController) GetUser(userId int) (*models.User, error) {
+//axon::route GET /export -Priority=10
+func (c *UserController) ExportUsers() (iter.Seq[*models.User], error) {
+	users, err := c.UserService.GetAllUsers()
+	if err != nil {
+		return nil, err
+	}
+	// Streamed as a JSON array, or as NDJSON for Accept: application/x-ndjson
+	return slices.Values(users), nil
+}
+
+//axon::route GET /paged -Priority=10 -Sortable=id,name,created_at -Filterable=name,email -Fields
+func (c *UserController) ListUsersPage(page axon.PageRequest, query axon.ListQuery) (axon.Page[*models.User], error) {
+	keep, err := userFilter(query.Filters)
+	if err != nil {
+		return axon.Page[*models.User]{}, err
+	}
+	// Rendered with page metadata and Link headers to the next and previous
+	// pages; ?fields=id,name trims the items down to those members
+	users, total := c.UserService.ListUsers(page.Offset, page.Limit, keep, userOrder(query.Sort))
+	return axon.NewOffsetPage(page, users, total), nil
+}
+
+//axon::route GET /{userId:int} -Priority=50 -ETag
+func (c *UserController) GetUser(userId int, log *slog.Logger) (*models.User, error) {
 	user, err := c.UserService.GetUser(userId)
 	if err != nil {
+		// log carries the request ID, route template and controller
+		log.Warn("user lookup failed", "user_id", userId, "error", err)
 		// Example of using axon.HttpError for better error responses
 		return nil, axon.ErrNotFound("User not found")
 	}
 	return user, nil
 }
 
-//axon::route POST /
+//axon::route POST / -Idempotent
 func (c *UserController) CreateUser(req models.CreateUserRequest) (*axon.Response, error) {
 	user, err := c.UserService.CreateUser(req)
 	if err != nil {
@@ -50,26 +79,46 @@ func (c *UserController) CreateUser(req models.CreateUserRequest) (*axon.Respons
 	return axon.Created(user).
 		WithHeader("Location", "/users/"+string(rune(user.ID))).
 		WithHeader("X-Created-At", user.CreatedAt.Format("2006-01-02T15:04:05Z")).
-		WithSimpleCookie("last-created-user", string(rune(user.ID))), nil
+		WithSignedCookie("last-created-user", string(rune(user.ID)), "/", 3600), nil
 }
 
 //axon::route PUT /{id:int}
-func (c *UserController) UpdateUser(id int, req models.UpdateUserRequest) (*axon.Response, error) {
+func (c *UserController) UpdateUser(id int, req models.UpdateUserRequest) (*axon.TypedResponse[*models.User], error) {
 	user, err := c.UserService.UpdateUser(id, req)
 	if err != nil {
-		return &axon.Response{
-			StatusCode: http.StatusBadRequest,
-			Body:       map[string]string{"error": err.Error()},
-		}, nil
+		return nil, axon.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
-	
-	return &axon.Response{
-		StatusCode: http.StatusOK,
-		Body:       user,
+
+	// Typed responses declare their body type to the generator
+	return axon.TypedOK(user).WithCacheControl("no-store"), nil
+}
+
+//axon::route PATCH /{id:int}
+func (c *UserController) PatchUser(id int, patch axon.Patch[models.User]) (*models.User, error) {
+	// Accepts application/merge-patch+json and application/json-patch+json
+	return c.UserService.PatchUser(id, patch.Apply)
+}
+
+//axon::route POST /{id:int}/avatar -MaxBodySize=20MB
+func (c *UserController) UploadAvatar(id int, upload models.AvatarUpload) (map[string]interface{}, error) {
+	extras := make([]string, 0, len(upload.Extras))
+	for _, extra := range upload.Extras {
+		extras = append(extras, extra.Filename)
+	}
+
+	// The files are removed once the handler returns; a real service would
+	// store them with SaveTo or Open here
+	return map[string]interface{}{
+		"user_id":      id,
+		"caption":      upload.Caption,
+		"avatar":       upload.Avatar.Filename,
+		"size":         upload.Avatar.Size,
+		"content_type": upload.Avatar.ContentType,
+		"extras":       extras,
 	}, nil
 }
 
-//axon::route DELETE /{id:int}
+//axon::route DELETE /{id:int} -Roles=admin -Permissions=users:write
 func (c *UserController) DeleteUser(ctx axon.RequestContext, id int) error {
 	err := c.UserService.DeleteUser(id)
 	if err != nil {
@@ -77,4 +126,61 @@ func (c *UserController) DeleteUser(ctx axon.RequestContext, id int) error {
 	}
 	
 	return ctx.Response().JSON(http.StatusNoContent, nil)
-}
\ No newline at end of file
+}
+
+// userFilter translates list query filters, such as ?filter[name][contains]=jo,
+// into a predicate on users
+func userFilter(filters []axon.Filter) (func(*models.User) bool, error) {
+	var conditions []func(*models.User) bool
+	for _, filter := range filters {
+		field := func(u *models.User) string { return u.Name }
+		if filter.Field == "email" {
+			field = func(u *models.User) string { return u.Email }
+		}
+		switch filter.Operator {
+		case axon.FilterEq, axon.FilterIn:
+			conditions = append(conditions, func(u *models.User) bool { return slices.Contains(filter.Values, field(u)) })
+		case axon.FilterNe:
+			conditions = append(conditions, func(u *models.User) bool { return field(u) != filter.Value() })
+		case axon.FilterContains:
+			conditions = append(conditions, func(u *models.User) bool {
+				return strings.Contains(strings.ToLower(field(u)), strings.ToLower(filter.Value()))
+			})
+		default:
+			return nil, axon.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Cannot filter %s with %s", filter.Field, filter.Operator))
+		}
+	}
+	return func(u *models.User) bool {
+		for _, condition := range conditions {
+			if !condition(u) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// userOrder translates list query sort keys, such as ?sort=-created_at,name,
+// into a comparison of users
+func userOrder(sort []axon.SortField) func(a, b *models.User) int {
+	return func(a, b *models.User) int {
+		for _, key := range sort {
+			var order int
+			switch key.Field {
+			case "id":
+				order = a.ID - b.ID
+			case "name":
+				order = strings.Compare(a.Name, b.Name)
+			case "created_at":
+				order = a.CreatedAt.Compare(b.CreatedAt)
+			}
+			if key.Descending {
+				order = -order
+			}
+			if order != 0 {
+				return order
+			}
+		}
+		return 0
+	}
+}
@@ -40,6 +40,11 @@ func (m *AuthMiddleware) Handle(next axon.HandlerFunc) axon.HandlerFunc {
 		// Set user context (in real app, decode from JWT)
 		c.Set("user_id", 1)
 		c.Set("user_name", "authenticated_user")
+		axon.SetPrincipal(c, &axon.BasicPrincipal{
+			Subject:     "1",
+			Roles:       []string{"admin"},
+			Permissions: []string{"users:read", "users:write"},
+		})
 
 		return next(c)
 	}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/google/uuid"
@@ -29,6 +30,23 @@ func (p *Product) Validate() error {
 	return nil
 }
 
+// CreatedProduct is a product that was just created. Handlers returning it
+// answer with 201 and its location, as it implements axon.StatusCoder and
+// axon.Headerer.
+type CreatedProduct struct {
+	*Product
+}
+
+// StatusCode returns 201 Created
+func (p CreatedProduct) StatusCode() int {
+	return http.StatusCreated
+}
+
+// Headers returns the location of the product
+func (p CreatedProduct) Headers() map[string]string {
+	return map[string]string{"Location": "/products/" + p.ID.String()}
+}
+
 // CreateProductRequest represents a request to create a product
 type CreateProductRequest struct {
 	Name        string  `json:"name"`
@@ -3,6 +3,8 @@ package models
 import (
 	"fmt"
 	"time"
+
+	"github.com/toyz/axon/pkg/axon"
 )
 
 // User represents a user in the system
@@ -34,4 +36,10 @@ type CreateUserRequest struct {
 type UpdateUserRequest struct {
 	Name  string `json:"name,omitempty"`
 	Email string `json:"email,omitempty"`
-}
\ No newline at end of file
+}
+// AvatarUpload is the multipart form of an avatar upload
+type AvatarUpload struct {
+	Caption string               `form:"caption"`
+	Avatar  *axon.UploadedFile   `form:"avatar" maxSize:"2MB" accept:"image/png,image/jpeg" required:"true"`
+	Extras  []*axon.UploadedFile `form:"extras" maxCount:"3" maxSize:"5MB"`
+}
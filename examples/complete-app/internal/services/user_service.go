@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -74,6 +75,28 @@ func (s *UserService) GetAllUsers() ([]*models.User, error) {
 	return users, nil
 }
 
+// ListUsers returns up to limit users matching keep, ordered by compare and
+// then by ID, starting at offset, and the number of matching users
+func (s *UserService) ListUsers(offset, limit int, keep func(*models.User) bool, compare func(a, b *models.User) int) ([]*models.User, int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matching := make([]*models.User, 0, len(s.users))
+	for _, user := range s.users {
+		if keep(user) {
+			matching = append(matching, user)
+		}
+	}
+	slices.SortFunc(matching, func(a, b *models.User) int {
+		if order := compare(a, b); order != 0 {
+			return order
+		}
+		return a.ID - b.ID
+	})
+
+	return matching[min(offset, len(matching)):min(offset+limit, len(matching))], len(matching)
+}
+
 // CreateUser creates a new user
 func (s *UserService) CreateUser(req models.CreateUserRequest) (*models.User, error) {
 	s.mu.Lock()
@@ -120,6 +143,30 @@ func (s *UserService) UpdateUser(id int, req models.UpdateUserRequest) (*models.
 	return user, nil
 }
 
+// PatchUser updates an existing user with apply, which changes a copy of it
+func (s *UserService) PatchUser(id int, apply func(*models.User) error) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[id]
+	if !exists {
+		return nil, fmt.Errorf("user with id %d not found", id)
+	}
+
+	patched := *user
+	if err := apply(&patched); err != nil {
+		return nil, err
+	}
+	// The ID and creation time are not for clients to change
+	patched.ID, patched.CreatedAt = user.ID, user.CreatedAt
+	if err := patched.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.users[id] = &patched
+	return &patched, nil
+}
+
 // DeleteUser deletes a user by ID
 func (s *UserService) DeleteUser(id int) error {
 	s.mu.Lock()
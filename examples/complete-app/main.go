@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -59,24 +61,108 @@ func main() {
 			return cfg
 		}),
 
+		// Provide the keyring for signed and encrypted cookies
+		fx.Provide(func(cfg *config.Config) (*axon.Keyring, error) {
+			if len(cfg.CookieSecrets) == 0 {
+				// Development fallback: cookies do not survive a restart
+				secret := make([]byte, axon.MinKeyringSecretSize)
+				if _, err := rand.Read(secret); err != nil {
+					return nil, err
+				}
+				return axon.NewKeyring(secret)
+			}
+			return axon.NewKeyringFromBase64(cfg.CookieSecrets...)
+		}),
+
+		// Only forwarding headers from these proxies are used for RealIP, Scheme and Host
+		fx.Provide(func(cfg *config.Config) (*axon.TrustedProxies, error) {
+			return axon.NewTrustedProxies(cfg.TrustedProxies...)
+		}),
+
+		// Panics and 5xx errors are recovered by every adapter and reported here
+		fx.Provide(func(logger *slog.Logger) axon.ErrorReporter {
+			return axon.ErrorReporterFunc(func(_ axon.RequestContext, report axon.ErrorReport) {
+				attrs := []any{"error", report.Err, "status", report.Status, "route", report.Route.Path,
+					"request_id", report.RequestID, "principal", report.Principal}
+				if report.Panic != nil {
+					attrs = append(attrs, "stack", string(report.Panic.Stack))
+				}
+				logger.Error("request failed", attrs...)
+			})
+		}),
+
 		// Provide selected adapter as WebServerInterface
-		fx.Provide(func() axon.WebServerInterface {
+		fx.Provide(func(keyring *axon.Keyring, proxies *axon.TrustedProxies, reporter axon.ErrorReporter) axon.WebServerInterface {
+			opts := []adapters.AdapterOption{
+				adapters.WithKeyring(keyring),
+				adapters.WithTrustedProxies(proxies),
+				adapters.WithErrorReporter(reporter),
+				adapters.WithoutFrameworkLogger(), // requests are logged by axon.AccessLogModule
+			}
 			switch *adapter {
 			case "gin":
 				fmt.Println("Using Gin web framework")
-				return adapters.NewDefaultGinAdapter()
+				return adapters.NewDefaultGinAdapter(opts...)
 			case "echo":
 				fmt.Println("Using Echo web framework")
-				return adapters.NewDefaultEchoAdapter()
+				return adapters.NewDefaultEchoAdapter(opts...)
 			case "fiber":
 				fmt.Println("Using Fiber web framework")
-				return adapters.NewDefaultFiberAdapter()
+				return adapters.NewDefaultFiberAdapter(opts...)
 			default:
 				// This should never happen due to validation above
 				panic(fmt.Sprintf("Unknown adapter: %s", *adapter))
 			}
 		}),
 
+		// Server-side sessions for handlers that take an axon.Session parameter
+		fx.Provide(func() *axon.SessionManager {
+			return axon.NewSessionManager(axon.SessionConfig{
+				InsecureCookie: true, // the example runs over plain HTTP
+				IdleTimeout:    30 * time.Minute,
+			}, axon.NewMemorySessionStore())
+		}),
+
+		// Enforce -Roles and -Permissions against the principal set by AuthMiddleware
+		fx.Provide(axon.NewRoleAuthorizer),
+
+		// Request IDs and request-scoped loggers built on the application logger
+		fx.Provide(func(logger *logging.AppLogger) *slog.Logger {
+			return logger.Logger()
+		}),
+		axon.RequestIDModule,
+
+		// One access log format for every adapter, with credentials redacted
+		fx.Supply(&axon.AccessLogConfig{
+			Headers:  []string{"User-Agent", "Authorization"},
+			LogQuery: true,
+			LogBody:  true,
+		}),
+		axon.AccessLogModule,
+
+		// Security headers on every response; -CSP=statusPage selects the relaxed policy
+		fx.Supply(&axon.SecurityHeadersConfig{
+			DisableHSTS: true, // the example runs over plain HTTP
+			CSPPolicies: map[string]string{
+				"statusPage": "default-src 'self'; script-src 'nonce-{nonce}'",
+			},
+		}),
+		axon.SecurityHeadersModule,
+
+		// Compress responses with br, zstd or gzip, and accept gzip-encoded request bodies
+		fx.Supply(&axon.CompressionConfig{DecompressRequests: true}),
+		axon.CompressionModule,
+
+		// Response cache for routes annotated with -Cache, served stale for a minute while refreshing
+		fx.Supply(&axon.ResponseCacheConfig{StaleWhileRevalidate: time.Minute}),
+		axon.ResponseCacheModule,
+
+		// Replays responses of POSTs retried with the same Idempotency-Key (-Idempotent)
+		axon.IdempotencyModule,
+
+		// Binds axon.PageRequest parameters and renders axon.Page results, signing cursors with the keyring
+		axon.PaginationModule,
+
 		// Include generated modules
 		controllers.AutogenModule,
 		services.AutogenModule,
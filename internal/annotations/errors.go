@@ -338,6 +338,32 @@ func generateRouteValidationSuggestion(parameter, expected, actual string) strin
 		return "Middleware should be comma-separated names. Example: -Middleware=Auth,Logging"
 	case "PassContext":
 		return "PassContext is a boolean flag. Use: -PassContext (no value needed)"
+	case "NoCSRF":
+		return "NoCSRF is a boolean flag. Use: -NoCSRF (no value needed)"
+	case "CSP":
+		return "CSP should name a policy from SecurityHeadersConfig.CSPPolicies. Example: -CSP=admin"
+	case "Cache":
+		return "Cache should be a duration of at least 1s on a GET route. Example: -Cache=30s"
+	case "CacheVary":
+		return "CacheVary should be comma-separated header names or 'principal', used with -Cache. Example: -CacheVary=Accept-Language,principal"
+	case "ETag":
+		return "ETag is a boolean flag. Use: -ETag, or -ETag=false to opt out of a controller or global setting"
+	case "Coalesce":
+		return "Coalesce is a boolean flag for GET routes. Use: -Coalesce (no value needed)"
+	case "CoalesceKey":
+		return "CoalesceKey should be comma-separated header names or 'principal', used with -Coalesce. Example: -CoalesceKey=X-Tenant,principal"
+	case "Idempotent":
+		return "Idempotent is a boolean flag for POST, PUT, PATCH and DELETE routes. Use: -Idempotent (no value needed)"
+	case "Compress":
+		return "Compress is a boolean flag. Use: -Compress=false to exempt the route from response compression"
+	case "MaxBodySize":
+		return "MaxBodySize should be a size in bytes, KB, MB or GB, or 0 for no limit. Example: -MaxBodySize=10MB"
+	case "Sortable":
+		return "Sortable should be comma-separated field names, used with an axon.ListQuery parameter. Example: -Sortable=created_at,name"
+	case "Filterable":
+		return "Filterable should be comma-separated field names, used with an axon.ListQuery parameter. Example: -Filterable=status,age"
+	case "Fields":
+		return "Fields is a flag, optionally with the comma-separated JSON paths clients can select. Example: -Fields or -Fields=id,name,address.city"
 	default:
 		return fmt.Sprintf("Route annotation parameter '%s' should be %s, got '%s'", parameter, expected, actual)
 	}
@@ -350,6 +376,8 @@ func generateControllerValidationSuggestion(parameter, expected, actual string)
 		return "Controller path should be a base URL path. Example: -Path=/api/v1"
 	case "Middleware":
 		return "Middleware should be comma-separated names. Example: -Middleware=Auth,Logging"
+	case "MaxBodySize":
+		return "MaxBodySize should be a size in bytes, KB, MB or GB, or 0 for no limit. Example: -MaxBodySize=10MB"
 	default:
 		return fmt.Sprintf("Controller annotation parameter '%s' should be %s, got '%s'", parameter, expected, actual)
 	}
@@ -391,9 +419,9 @@ func generateSchemaSuggestion(msg string, annotationType AnnotationType) string
 		case CoreAnnotation:
 			return "Core annotation supports: Mode, Init, Manual parameters"
 		case RouteAnnotation:
-			return "Route annotation supports: method, path, Middleware, PassContext parameters"
+			return "Route annotation supports: method, path, Middleware, PassContext, Roles, Permissions, NoCSRF, CSP, Cache, CacheVary, ETag, Coalesce, CoalesceKey, Idempotent, Compress, MaxBodySize, Sortable, Filterable, Fields parameters"
 		case ControllerAnnotation:
-			return "Controller annotation supports: Path, Middleware parameters"
+			return "Controller annotation supports: Path, Middleware, Roles, Permissions, ETag, MaxBodySize parameters"
 		case MiddlewareAnnotation:
 			return "Middleware annotation supports: Priority, Global parameters"
 		case InterfaceAnnotation:
@@ -57,6 +57,21 @@ var RouteAnnotationSchema = AnnotationSchema{
 		"Middleware":  MiddlewareParameterSpec(),
 		"PassContext": PassContextParameterSpec(),
 		"Priority":    PriorityParameterSpec(),
+		"Roles":       RolesParameterSpec(),
+		"Permissions": PermissionsParameterSpec(),
+		"NoCSRF":      NoCSRFParameterSpec(),
+		"CSP":         CSPParameterSpec(),
+		"Cache":       CacheParameterSpec(),
+		"CacheVary":   CacheVaryParameterSpec(),
+		"ETag":        ETagParameterSpec(),
+		"Coalesce":    CoalesceParameterSpec(),
+		"CoalesceKey": CoalesceKeyParameterSpec(),
+		"Idempotent":  IdempotentParameterSpec(),
+		"Compress":    CompressParameterSpec(),
+		"MaxBodySize": MaxBodySizeParameterSpec(),
+		"Sortable":    SortableParameterSpec(),
+		"Filterable":  FilterableParameterSpec(),
+		"Fields":      FieldsParameterSpec(),
 	},
 	Examples: []string{
 		"//axon::route GET /users",
@@ -67,6 +82,20 @@ var RouteAnnotationSchema = AnnotationSchema{
 		"//axon::route GET /health -PassContext",
 		"//axon::route POST /users -Middleware=Auth,Validation -PassContext",
 		"//axon::route GET /users/profile -Priority=10  // Higher priority than /users/{id}",
+		"//axon::route DELETE /users/{id:int} -Middleware=Auth -Roles=admin,ops",
+		"//axon::route POST /users -Middleware=Auth -Permissions=users:write",
+		"//axon::route POST /webhooks/stripe -NoCSRF",
+		"//axon::route GET /admin -Middleware=Auth -CSP=admin",
+		"//axon::route GET /products -Cache=30s",
+		"//axon::route GET /me -Middleware=Auth -Cache=1m -CacheVary=Accept-Language,principal",
+		"//axon::route PUT /users/{id:int} -ETag",
+		"//axon::route GET /reports/{id:int} -Coalesce -CoalesceKey=X-Tenant,principal",
+		"//axon::route POST /orders -Idempotent",
+		"//axon::route GET /account/token -Compress=false",
+		"//axon::route PUT /files/{name} -MaxBodySize=1GB",
+		"//axon::route GET /users -Sortable=created_at,name -Filterable=status,age",
+		"//axon::route GET /users/{id:int} -Fields",
+		"//axon::route GET /users/{id:int} -Fields=id,name,address.city",
 	},
 }
 
@@ -75,9 +104,13 @@ var ControllerAnnotationSchema = AnnotationSchema{
 	Type:        ControllerAnnotation,
 	Description: "Marks a struct as a controller for HTTP request handling",
 	Parameters: map[string]ParameterSpec{
-		"Prefix":     PrefixParameterSpec(),
-		"Middleware": MiddlewareParameterSpec(),
-		"Priority":   PriorityParameterSpec(),
+		"Prefix":      PrefixParameterSpec(),
+		"Middleware":  MiddlewareParameterSpec(),
+		"Priority":    PriorityParameterSpec(),
+		"Roles":       RolesParameterSpec(),
+		"Permissions": PermissionsParameterSpec(),
+		"ETag":        ETagParameterSpec(),
+		"MaxBodySize": MaxBodySizeParameterSpec(),
 	},
 	Examples: []string{
 		"//axon::controller",
@@ -88,6 +121,9 @@ var ControllerAnnotationSchema = AnnotationSchema{
 		"//axon::controller -Priority=50",
 		"//axon::controller -Priority=999 -Prefix=/ // Catch-all route, loads last",
 		"//axon::controller -Prefix=/users/{userId:int} -Middleware=Auth",
+		"//axon::controller -Prefix=/admin -Middleware=Auth -Roles=admin",
+		"//axon::controller -Prefix=/api/v1/users -ETag",
+		"//axon::controller -Prefix=/api/v1/imports -MaxBodySize=50MB",
 	},
 }
 
@@ -163,6 +163,104 @@ func TestRouteAnnotationSchema(t *testing.T) {
 	}
 }
 
+func TestRouteAnnotationSchema_Cache(t *testing.T) {
+	validator := RouteAnnotationSchema.Parameters["Cache"].Validator
+	if validator == nil {
+		t.Fatal("expected a validator for the Cache parameter")
+	}
+
+	tests := []struct {
+		value    string
+		errorMsg string
+	}{
+		{"30s", ""},
+		{"1h30m", ""},
+		{"soon", "not a valid duration"},
+		{"500ms", "must be at least 1s"},
+		{"-5s", "must be at least 1s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			err := validator(tt.value)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !contains(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error containing '%s', got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestRouteAnnotationSchema_ListQueryFields(t *testing.T) {
+	for _, parameter := range []string{"Sortable", "Filterable"} {
+		validator := RouteAnnotationSchema.Parameters[parameter].Validator
+		if validator == nil {
+			t.Fatalf("expected a validator for the %s parameter", parameter)
+		}
+
+		tests := []struct {
+			value    interface{}
+			errorMsg string
+		}{
+			{"name", ""},
+			{[]string{"created_at", "address.city"}, ""},
+			{"name,created-at", "not a valid field name"},
+			{[]string{"name", ""}, "not a valid field name"},
+			{"address.", "not a valid field name"},
+			{[]string{"name", "name"}, "listed twice"},
+		}
+
+		for _, tt := range tests {
+			err := validator(tt.value)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("%s %v: unexpected error: %v", parameter, tt.value, err)
+				}
+				continue
+			}
+			if err == nil || !contains(err.Error(), tt.errorMsg) {
+				t.Errorf("%s %v: expected error containing '%s', got %v", parameter, tt.value, tt.errorMsg, err)
+			}
+		}
+	}
+}
+
+func TestRouteAnnotationSchema_Fields(t *testing.T) {
+	validator := RouteAnnotationSchema.Parameters["Fields"].Validator
+	if validator == nil {
+		t.Fatal("expected a validator for the Fields parameter")
+	}
+
+	tests := []struct {
+		value    interface{}
+		errorMsg string
+	}{
+		{true, ""},
+		{"id", ""},
+		{[]string{"id", "address.city"}, ""},
+		{[]string{"id", "address..city"}, "not a valid field name"},
+		{[]string{"id", "id"}, "listed twice"},
+	}
+
+	for _, tt := range tests {
+		err := validator(tt.value)
+		if tt.errorMsg == "" {
+			if err != nil {
+				t.Errorf("Fields %v: unexpected error: %v", tt.value, err)
+			}
+			continue
+		}
+		if err == nil || !contains(err.Error(), tt.errorMsg) {
+			t.Errorf("Fields %v: expected error containing '%s', got %v", tt.value, tt.errorMsg, err)
+		}
+	}
+}
+
 func TestRouteParametersValidator(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -2,9 +2,12 @@ package annotations
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/toyz/axon/internal/utils"
+	"github.com/toyz/axon/pkg/axon"
 )
 
 // Common validation functions to eliminate duplication
@@ -118,6 +121,150 @@ func MiddlewareParameterSpec() ParameterSpec {
 	}
 }
 
+// RolesParameterSpec returns a standard Roles parameter specification
+func RolesParameterSpec() ParameterSpec {
+	return ParameterSpec{
+		Type:        StringSliceType,
+		Required:    false,
+		Description: "Comma-separated list of roles; the caller must hold at least one",
+	}
+}
+
+// PermissionsParameterSpec returns a standard Permissions parameter specification
+func PermissionsParameterSpec() ParameterSpec {
+	return ParameterSpec{
+		Type:        StringSliceType,
+		Required:    false,
+		Description: "Comma-separated list of permissions; the caller must hold all of them",
+	}
+}
+
+// NoCSRFParameterSpec returns a standard NoCSRF parameter specification
+func NoCSRFParameterSpec() ParameterSpec {
+	return ParameterSpec{
+		Type:         BoolType,
+		Required:     false,
+		DefaultValue: false,
+		Description:  "Whether to exempt the route from CSRF protection (e.g. for webhooks)",
+	}
+}
+
+// CSPParameterSpec returns a standard CSP parameter specification
+func CSPParameterSpec() ParameterSpec {
+	return ParameterSpec{
+		Type:        StringType,
+		Required:    false,
+		Description: "Name of the Content-Security-Policy (from SecurityHeadersConfig.CSPPolicies) to use instead of the default",
+	}
+}
+
+// CacheParameterSpec returns a standard Cache parameter specification
+func CacheParameterSpec() ParameterSpec {
+	return ParameterSpec{
+		Type:        StringType,
+		Required:    false,
+		Description: "How long GET responses are cached, as a Go duration (e.g., 30s, 5m)",
+		Validator:   ValidateCacheTTL,
+	}
+}
+
+// CacheVaryParameterSpec returns a standard CacheVary parameter specification
+func CacheVaryParameterSpec() ParameterSpec {
+	return ParameterSpec{
+		Type:        StringSliceType,
+		Required:    false,
+		Description: "Comma-separated request headers, or 'principal', that select separate cache entries",
+	}
+}
+
+// CoalesceParameterSpec returns a standard Coalesce parameter specification
+func CoalesceParameterSpec() ParameterSpec {
+	return ParameterSpec{
+		Type:         BoolType,
+		Required:     false,
+		DefaultValue: false,
+		Description:  "Whether identical concurrent GET requests share one handler execution",
+	}
+}
+
+// CoalesceKeyParameterSpec returns a standard CoalesceKey parameter specification
+func CoalesceKeyParameterSpec() ParameterSpec {
+	return ParameterSpec{
+		Type:        StringSliceType,
+		Required:    false,
+		Description: "Comma-separated request headers, or 'principal', added to the coalescing key",
+	}
+}
+
+// SortableParameterSpec returns a standard Sortable parameter specification
+func SortableParameterSpec() ParameterSpec {
+	return ParameterSpec{
+		Type:        StringSliceType,
+		Required:    false,
+		Description: "Comma-separated fields the route's axon.ListQuery can be sorted by",
+		Validator:   ValidateFieldList,
+	}
+}
+
+// FilterableParameterSpec returns a standard Filterable parameter specification
+func FilterableParameterSpec() ParameterSpec {
+	return ParameterSpec{
+		Type:        StringSliceType,
+		Required:    false,
+		Description: "Comma-separated fields the route's axon.ListQuery can be filtered by",
+		Validator:   ValidateFieldList,
+	}
+}
+
+// FieldsParameterSpec returns a standard Fields parameter specification
+func FieldsParameterSpec() ParameterSpec {
+	return ParameterSpec{
+		Type:        StringSliceType,
+		Required:    false,
+		Description: "Whether clients can select response fields with ?fields=; optionally the comma-separated JSON paths they can select",
+		Validator:   ValidateFieldsFlag,
+	}
+}
+
+// IdempotentParameterSpec returns a standard Idempotent parameter specification
+func IdempotentParameterSpec() ParameterSpec {
+	return ParameterSpec{
+		Type:         BoolType,
+		Required:     false,
+		DefaultValue: false,
+		Description:  "Whether retries with the same Idempotency-Key header replay the stored response",
+	}
+}
+
+// ETagParameterSpec returns a standard ETag parameter specification
+func ETagParameterSpec() ParameterSpec {
+	return ParameterSpec{
+		Type:        BoolType,
+		Required:    false,
+		Description: "Whether to set ETags and answer If-None-Match (304) and If-Match (412); -ETag=false opts out",
+	}
+}
+
+// CompressParameterSpec returns a standard Compress parameter specification
+func CompressParameterSpec() ParameterSpec {
+	return ParameterSpec{
+		Type:         BoolType,
+		Required:     false,
+		DefaultValue: true,
+		Description:  "Whether responses are compressed by axon.CompressionModule; -Compress=false opts out",
+	}
+}
+
+// MaxBodySizeParameterSpec returns a standard MaxBodySize parameter specification
+func MaxBodySizeParameterSpec() ParameterSpec {
+	return ParameterSpec{
+		Type:        StringType,
+		Required:    false,
+		Description: "Largest accepted request body (e.g., 512KB, 10MB); larger bodies get 413, 0 removes the limit",
+		Validator:   ValidateByteSize,
+	}
+}
+
 // PriorityParameterSpec returns a standard Priority parameter specification
 func PriorityParameterSpec() ParameterSpec {
 	return ParameterSpec{
@@ -166,6 +313,71 @@ func PrefixParameterSpec() ParameterSpec {
 	}
 }
 
+// ValidateCacheTTL validates the Cache duration
+func ValidateCacheTTL(v interface{}) error {
+	ttl, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("cache duration must be a string")
+	}
+	duration, err := time.ParseDuration(ttl)
+	if err != nil {
+		return fmt.Errorf("cache duration %q is not a valid duration (e.g., 30s, 5m)", ttl)
+	}
+	if duration < time.Second {
+		return fmt.Errorf("cache duration %q must be at least 1s", ttl)
+	}
+	return nil
+}
+
+// ValidateByteSize validates a size such as the MaxBodySize limit
+func ValidateByteSize(v interface{}) error {
+	size, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("size must be a string")
+	}
+	_, err := axon.ParseByteSize(size)
+	return err
+}
+
+// fieldNamePattern matches field names clients sort and filter by, such as
+// created_at or address.city
+var fieldNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// ValidateFieldList validates a comma-separated list of field names such as
+// the Sortable and Filterable fields
+func ValidateFieldList(v interface{}) error {
+	var fields []string
+	switch value := v.(type) {
+	case string:
+		fields = strings.Split(value, ",")
+	case []string:
+		fields = value
+	default:
+		return fmt.Errorf("fields must be a comma-separated list")
+	}
+
+	seen := make(map[string]bool)
+	for _, field := range fields {
+		if !fieldNamePattern.MatchString(field) {
+			return fmt.Errorf("%q is not a valid field name (e.g., name, created_at, address.city)", field)
+		}
+		if seen[field] {
+			return fmt.Errorf("field %q is listed twice", field)
+		}
+		seen[field] = true
+	}
+	return nil
+}
+
+// ValidateFieldsFlag validates the Fields flag: a bare flag, -Fields=false or
+// a list of dotted JSON paths
+func ValidateFieldsFlag(v interface{}) error {
+	if _, ok := v.(bool); ok {
+		return nil
+	}
+	return ValidateFieldList(v)
+}
+
 // ValidateConstructor validates constructor function names
 func ValidateConstructor(value interface{}) error {
 	constructor, ok := value.(string)
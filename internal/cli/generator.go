@@ -84,6 +84,16 @@ func (g *Generator) SetCustomModule(moduleName string) {
 	g.customModule = moduleName
 }
 
+// SetDefaultETag enables ETags on every data-returning route unless it opts out with -ETag=false
+func (g *Generator) SetDefaultETag(enabled bool) {
+	g.codeGenerator.SetDefaultETag(enabled)
+}
+
+// SetDefaultMaxBodySize limits request bodies of every route without -MaxBodySize
+func (g *Generator) SetDefaultMaxBodySize(size int64) {
+	g.codeGenerator.SetDefaultMaxBodySize(size)
+}
+
 // GetSummary returns the generation summary
 func (g *Generator) GetSummary() GenerationSummary {
 	return g.summary
@@ -311,6 +311,32 @@ func generateRouteValidationSuggestion(parameter, expected, actual string) strin
 		return "Middleware should be comma-separated names. Example: -Middleware=Auth,Logging"
 	case "PassContext":
 		return "PassContext is a boolean flag. Use: -PassContext (no value needed)"
+	case "NoCSRF":
+		return "NoCSRF is a boolean flag. Use: -NoCSRF (no value needed)"
+	case "CSP":
+		return "CSP should name a policy from SecurityHeadersConfig.CSPPolicies. Example: -CSP=admin"
+	case "Cache":
+		return "Cache should be a duration of at least 1s on a GET route. Example: -Cache=30s"
+	case "CacheVary":
+		return "CacheVary should be comma-separated header names or 'principal', used with -Cache. Example: -CacheVary=Accept-Language,principal"
+	case "ETag":
+		return "ETag is a boolean flag. Use: -ETag, or -ETag=false to opt out of a controller or global setting"
+	case "Coalesce":
+		return "Coalesce is a boolean flag for GET routes. Use: -Coalesce (no value needed)"
+	case "CoalesceKey":
+		return "CoalesceKey should be comma-separated header names or 'principal', used with -Coalesce. Example: -CoalesceKey=X-Tenant,principal"
+	case "Idempotent":
+		return "Idempotent is a boolean flag for POST, PUT, PATCH and DELETE routes. Use: -Idempotent (no value needed)"
+	case "Compress":
+		return "Compress is a boolean flag. Use: -Compress=false to exempt the route from response compression"
+	case "MaxBodySize":
+		return "MaxBodySize should be a size in bytes, KB, MB or GB, or 0 for no limit. Example: -MaxBodySize=10MB"
+	case "Sortable":
+		return "Sortable should be comma-separated field names, used with an axon.ListQuery parameter. Example: -Sortable=created_at,name"
+	case "Filterable":
+		return "Filterable should be comma-separated field names, used with an axon.ListQuery parameter. Example: -Filterable=status,age"
+	case "Fields":
+		return "Fields is a flag, optionally with the comma-separated JSON paths clients can select. Example: -Fields or -Fields=id,name,address.city"
 	case "Priority":
 		return "Priority should be an integer. Example: -Priority=10"
 	default:
@@ -327,6 +353,8 @@ func generateControllerValidationSuggestion(parameter, expected, actual string)
 		return "Middleware should be comma-separated names. Example: -Middleware=Auth,Logging"
 	case "Priority":
 		return "Priority should be an integer. Example: -Priority=10"
+	case "MaxBodySize":
+		return "MaxBodySize should be a size in bytes, KB, MB or GB, or 0 for no limit. Example: -MaxBodySize=10MB"
 	default:
 		return fmt.Sprintf("Controller annotation parameter '%s' should be %s, got '%s'", parameter, expected, actual)
 	}
@@ -368,9 +396,9 @@ func generateSchemaSuggestion(msg string, annotationType AnnotationType) string
 		case ServiceAnnotation:
 			return "Service annotation supports: Mode, Init, Manual, Constructor parameters"
 		case RouteAnnotation:
-			return "Route annotation supports: method, path, Middleware, PassContext, Priority parameters"
+			return "Route annotation supports: method, path, Middleware, PassContext, Priority, Roles, Permissions, NoCSRF, CSP, Cache, CacheVary, ETag, Coalesce, CoalesceKey, Idempotent, Compress, MaxBodySize, Sortable, Filterable, Fields parameters"
 		case ControllerAnnotation:
-			return "Controller annotation supports: Prefix, Middleware, Priority parameters"
+			return "Controller annotation supports: Prefix, Middleware, Priority, Roles, Permissions, ETag, MaxBodySize parameters"
 		case MiddlewareAnnotation:
 			return "Middleware annotation supports: Priority, Global parameters"
 		case InterfaceAnnotation:
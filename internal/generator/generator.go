@@ -5,8 +5,10 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/toyz/axon/internal/errors"
 	"github.com/toyz/axon/internal/models"
@@ -20,6 +22,8 @@ import (
 type Generator struct {
 	moduleResolver ModuleResolver
 	parserRegistry axon.ParserRegistryInterface
+	defaultETag    bool  // whether data-returning routes use ETags unless they opt out
+	defaultMaxBody int64 // request body limit in bytes for routes without -MaxBodySize, 0 for none
 }
 
 // ModuleResolver interface for resolving module paths
@@ -32,6 +36,7 @@ type ModuleResolver interface {
 func NewGenerator() *Generator {
 	return &Generator{
 		parserRegistry: registry.NewParserRegistry(),
+		defaultMaxBody: axon.DefaultMaxBodySize,
 	}
 }
 
@@ -40,9 +45,23 @@ func NewGeneratorWithResolver(resolver ModuleResolver) *Generator {
 	return &Generator{
 		moduleResolver: resolver,
 		parserRegistry: registry.NewParserRegistry(),
+		defaultMaxBody: axon.DefaultMaxBodySize,
 	}
 }
 
+// SetDefaultETag enables ETags on every route returning data or an *axon.Response.
+// Controllers and routes opt out with -ETag=false.
+func (g *Generator) SetDefaultETag(enabled bool) {
+	g.defaultETag = enabled
+}
+
+// SetDefaultMaxBodySize limits request bodies of every route to size bytes,
+// axon.DefaultMaxBodySize unless set. Controllers and routes override it with
+// -MaxBodySize; 0 disables the limit.
+func (g *Generator) SetDefaultMaxBodySize(size int64) {
+	g.defaultMaxBody = size
+}
+
 // GenerateModule generates a complete FX module file for a package with annotations
 func (g *Generator) GenerateModule(metadata *models.PackageMetadata) (*models.GeneratedModule, error) {
 	return g.GenerateModuleWithModule(metadata, "")
@@ -168,6 +187,9 @@ func (g *Generator) generateControllerModuleWithModule(metadata *models.PackageM
 	// Generate route wrapper functions
 	for _, controller := range metadata.Controllers {
 		for _, route := range controller.Routes {
+			// The wrapper sees the resolved ETag setting, to tag ETagger results
+			etag := g.resolveETag(route, controller)
+			route.ETag = &etag
 			wrapperCode, err := templates.GenerateRouteWrapper(route, controller.StructName, g.parserRegistry)
 			if err != nil {
 				return "", errors.WrapGenerateError("generate", "wrapper for route "+controller.Name+"."+route.HandlerName, err)
@@ -416,6 +438,21 @@ func (g *Generator) generateRouteRegistrationFunction(metadata *models.PackageMe
 			if err != nil {
 				return "", fmt.Errorf("failed to build route template data for %s: %w", route.HandlerName, err)
 			}
+			if routeData.HasAuthorization {
+				data.UsesAuthorizer = true
+			}
+			if routeData.UsesSession {
+				data.UsesSessions = true
+			}
+			if routeData.HasCache {
+				data.UsesCache = true
+			}
+			if routeData.Idempotent {
+				data.UsesIdempotency = true
+			}
+			if routeData.UsesPagination {
+				data.UsesPagination = true
+			}
 			controllerData.Routes = append(controllerData.Routes, routeData)
 		}
 
@@ -644,13 +681,15 @@ func handleAxonResponse(c axon.RequestContext, response *axon.Response) error {
 	// Set cookies
 	for _, cookie := range response.Cookies {
 		axonCookie := axon.AxonCookie{
-			Name:     cookie.Name,
-			Value:    cookie.Value,
-			Path:     cookie.Path,
-			Domain:   cookie.Domain,
-			MaxAge:   cookie.MaxAge,
-			Secure:   cookie.Secure,
-			HttpOnly: cookie.HttpOnly,
+			Name:      cookie.Name,
+			Value:     cookie.Value,
+			Path:      cookie.Path,
+			Domain:    cookie.Domain,
+			MaxAge:    cookie.MaxAge,
+			Secure:    cookie.Secure,
+			HttpOnly:  cookie.HttpOnly,
+			Signed:    cookie.Signed,
+			Encrypted: cookie.Encrypted,
 		}
 		if cookie.SameSite != "" {
 			switch cookie.SameSite {
@@ -682,7 +721,12 @@ func handleError(c axon.RequestContext, err error) error {
 	if httpErr, ok := err.(*axon.HttpError); ok {
 		return handleHttpError(c, httpErr)
 	}
-	return c.Response().JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	// HTTPErrors carry their own status and go to the adapter's error handling
+	if httpErr, ok := err.(*axon.HTTPError); ok {
+		return httpErr
+	}
+	// Other errors become a 500 the adapter renders and reports
+	return axon.NewHTTPError(http.StatusInternalServerError, err.Error(), err)
 }`
 }
 
@@ -733,6 +777,26 @@ func (g *Generator) buildRouteTemplateData(route models.RouteMetadata, controlle
 	echoPath := g.convertToEchoPath(routePath)
 	paramTypes := templates.ExtractParameterTypes(route.Path)
 
+	etag := g.resolveETag(route, controller)
+	etagCurrent := ""
+	if etag && route.Method != "GET" && route.Method != "HEAD" {
+		etagCurrent = g.etagCurrentHandler(route, controller)
+		if etagCurrent == "" && route.ETag != nil {
+			return templates.RouteTemplateData{}, fmt.Errorf("-ETag on %s %s needs a GET route with the same path on %s to check If-Match against", route.Method, route.Path, controller.StructName)
+		}
+	}
+
+	var cacheTTL time.Duration
+	if route.CacheTTL != "" {
+		ttl, err := time.ParseDuration(route.CacheTTL)
+		if err != nil {
+			return templates.RouteTemplateData{}, fmt.Errorf("invalid -Cache duration %q: %w", route.CacheTTL, err)
+		}
+		cacheTTL = ttl
+	}
+	roles, permissions := g.resolveAuthorization(route, controller)
+	guarded := len(roles) > 0 || len(permissions) > 0 || len(allMiddlewares) > 0
+
 	return templates.RouteTemplateData{
 		HandlerVar:               handlerVar,
 		WrapperFunc:              wrapperFunc,
@@ -750,5 +814,121 @@ func (g *Generator) buildRouteTemplateData(route models.RouteMetadata, controlle
 		MiddlewaresArray:         templates.BuildMiddlewaresArray(allMiddlewares),
 		MiddlewareInstancesArray: templates.BuildMiddlewareInstancesArray(allMiddlewares),
 		ParameterInstancesArray:  templates.BuildParameterInstancesArray(paramTypes),
+		HasAuthorization:         len(roles) > 0 || len(permissions) > 0,
+		RolesArray:               templates.BuildStringSliceLiteral(roles),
+		PermissionsArray:         templates.BuildStringSliceLiteral(permissions),
+		UsesSession:              hasSessionParameter(route.Parameters),
+		NoCSRF:                   route.NoCSRF,
+		CSPPolicy:                route.CSPPolicy,
+		HasCache:                 cacheTTL > 0,
+		CacheTTL:                 templates.BuildDurationLiteral(cacheTTL),
+		CacheVaryArray:           templates.BuildStringSliceLiteral(perPrincipal(route.CacheVary, guarded)),
+		HasETag:                  etag && (etagCurrent != "" || route.Method == "GET" || route.Method == "HEAD"),
+		ETagCurrent:              etagCurrent,
+		Coalesce:                 route.Coalesce,
+		CoalesceKeyArray:         templates.BuildStringSliceLiteral(perPrincipal(route.CoalesceKey, guarded)),
+		Idempotent:               route.Idempotent,
+		NoCompress:               route.NoCompress,
+		MaxBodySize:              g.resolveMaxBodySize(route, controller),
+		StreamsBody:              route.StreamsBody() || route.ReadsMultipart(),
+		UsesPagination:           templates.UsesPagination(route),
+		Fields:                   route.Fields,
+		FieldPathsArray:          templates.BuildStringSliceLiteral(route.FieldPaths),
+		FieldsPage:               route.ReturnType.Type == models.ReturnTypePage,
 	}, nil
 }
+
+// perPrincipal returns the cache vary or coalescing key of a route. Routes
+// behind authorization or middleware may answer callers differently, so their
+// key always includes the principal and one caller never receives another's
+// response.
+func perPrincipal(key []string, guarded bool) []string {
+	if !guarded {
+		return key
+	}
+	for _, name := range key {
+		if strings.EqualFold(name, axon.CacheVaryPrincipal) {
+			return key
+		}
+	}
+	return append(slices.Clone(key), axon.CacheVaryPrincipal)
+}
+
+// hasSessionParameter reports whether a handler takes an axon.Session parameter
+func hasSessionParameter(parameters []models.Parameter) bool {
+	for _, param := range parameters {
+		if param.Source == models.ParameterSourceSession {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveETag reports whether a route uses ETags. The route flag overrides the
+// controller flag, which overrides the generator default; the default only
+// covers routes returning data or an *axon.Response.
+func (g *Generator) resolveETag(route models.RouteMetadata, controller models.ControllerMetadata) bool {
+	if !route.SupportsETag() {
+		return false
+	}
+	if route.ETag != nil {
+		return *route.ETag
+	}
+	if controller.ETag != nil {
+		return *controller.ETag
+	}
+	return g.defaultETag && route.ReturnType.Type != models.ReturnTypeError
+}
+
+// resolveMaxBodySize returns the request body limit of a route. The route flag
+// overrides the controller flag, which overrides the generator default.
+func (g *Generator) resolveMaxBodySize(route models.RouteMetadata, controller models.ControllerMetadata) int64 {
+	if route.MaxBodySize != nil {
+		return *route.MaxBodySize
+	}
+	if controller.MaxBodySize != nil {
+		return *controller.MaxBodySize
+	}
+	return g.defaultMaxBody
+}
+
+// etagCurrentHandler returns an expression for the unwrapped GET handler with
+// the same path as a write route, which renders the representation If-Match is
+// checked against, or "" if the controller has none
+func (g *Generator) etagCurrentHandler(route models.RouteMetadata, controller models.ControllerMetadata) string {
+	controllerVar := strings.ToLower(controller.StructName)
+	for _, candidate := range controller.Routes {
+		if candidate.Method != "GET" || candidate.Path != route.Path {
+			continue
+		}
+		args := controllerVar
+		if hasSessionParameter(candidate.Parameters) {
+			args += ", sessions"
+		}
+		if templates.UsesPagination(candidate) {
+			args += ", pagination"
+		}
+		return fmt.Sprintf("wrap%s%s(%s)", controller.StructName, candidate.HandlerName, args)
+	}
+	return ""
+}
+
+// resolveAuthorization combines controller and route authorization requirements.
+// Route roles replace controller roles; permissions from both levels are all required.
+func (g *Generator) resolveAuthorization(route models.RouteMetadata, controller models.ControllerMetadata) ([]string, []string) {
+	roles := route.Roles
+	if len(roles) == 0 {
+		roles = controller.Roles
+	}
+
+	var permissions []string
+	seen := make(map[string]bool)
+	for _, permission := range append(append([]string{}, controller.Permissions...), route.Permissions...) {
+		if !seen[permission] {
+			seen[permission] = true
+			permissions = append(permissions, permission)
+		}
+	}
+
+	return roles, permissions
+}
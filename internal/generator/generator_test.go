@@ -139,7 +139,8 @@ func TestGenerateModule_Controllers(t *testing.T) {
 							},
 						},
 						ReturnType: models.ReturnTypeInfo{
-							Type: models.ReturnTypeDataError,
+							Type:     models.ReturnTypeDataError,
+							HasError: true,
 						},
 					},
 				},
@@ -186,6 +187,1068 @@ func TestGenerateModule_Controllers(t *testing.T) {
 	}
 }
 
+func TestGenerateModule_HandleError(t *testing.T) {
+	metadata := &models.PackageMetadata{
+		PackageName: "controllers",
+		PackagePath: "./controllers",
+		Controllers: []models.ControllerMetadata{
+			{
+				BaseMetadataTrait: models.BaseMetadataTrait{
+					Name:       "UserController",
+					StructName: "UserController",
+				},
+				Routes: []models.RouteMetadata{
+					{
+						Method:      "GET",
+						Path:        "/users",
+						HandlerName: "ListUsers",
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeDataError, HasError: true},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := NewGenerator().GenerateModule(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// *axon.HTTPError keeps its status and is rendered by the adapter, other
+	// errors become a 500 wrapping the original, so the adapter reports them
+	expected := []string{
+		"if httpErr, ok := err.(*axon.HTTPError); ok {\n\t\treturn httpErr\n\t}",
+		`return axon.NewHTTPError(http.StatusInternalServerError, err.Error(), err)`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected handleError to contain %q, got:\n%s", want, result.Content)
+		}
+	}
+	if strings.Contains(result.Content, `map[string]string{"error": err.Error()}`) {
+		t.Errorf("expected plain errors to reach the adapter instead of being written directly")
+	}
+	if strings.Index(result.Content, "err.(*axon.HTTPError)") > strings.Index(result.Content, "http.StatusInternalServerError, err.Error(), err") {
+		t.Errorf("expected *axon.HTTPError to pass through before the 500 fallback")
+	}
+}
+
+func TestGenerateModule_ControllerAuthorization(t *testing.T) {
+	generator := NewGenerator()
+
+	metadata := &models.PackageMetadata{
+		PackageName: "controllers",
+		PackagePath: "./controllers",
+		Controllers: []models.ControllerMetadata{
+			{
+				BaseMetadataTrait: models.BaseMetadataTrait{
+					Name:       "AdminController",
+					StructName: "AdminController",
+				},
+				AuthorizationTrait: models.AuthorizationTrait{
+					Roles:       []string{"admin"},
+					Permissions: []string{"admin:access"},
+				},
+				Routes: []models.RouteMetadata{
+					{
+						Method:      "GET",
+						Path:        "/stats",
+						HandlerName: "GetStats",
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeDataError, HasError: true},
+					},
+					{
+						Method:      "DELETE",
+						Path:        "/users/{id:int}",
+						HandlerName: "DeleteUser",
+						Parameters: []models.Parameter{
+							{Name: "id", Type: "int", Source: models.ParameterSourcePath},
+						},
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeError},
+						Roles:       []string{"ops"},
+						Permissions: []string{"users:write", "admin:access"},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := generator.GenerateModule(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(result.Content, "authorizer axon.Authorizer)") {
+		t.Errorf("expected RegisterRoutes to accept an axon.Authorizer")
+	}
+
+	// Controller requirements apply when the route declares none
+	if !strings.Contains(result.Content, `axon.AuthorizationRequirement{Roles: []string{"admin"}, Permissions: []string{"admin:access"}}`) {
+		t.Errorf("expected controller requirement on GetStats, got:\n%s", result.Content)
+	}
+
+	// Route roles replace controller roles, permissions are combined without duplicates
+	if !strings.Contains(result.Content, `axon.AuthorizationRequirement{Roles: []string{"ops"}, Permissions: []string{"admin:access", "users:write"}}`) {
+		t.Errorf("expected merged requirement on DeleteUser, got:\n%s", result.Content)
+	}
+
+	if !strings.Contains(result.Content, `Roles:               []string{"ops"},`) {
+		t.Errorf("expected roles in RouteInfo registration")
+	}
+}
+
+func TestGenerateModule_NoAuthorizer(t *testing.T) {
+	generator := NewGenerator()
+
+	metadata := &models.PackageMetadata{
+		PackageName: "controllers",
+		PackagePath: "./controllers",
+		Controllers: []models.ControllerMetadata{
+			{
+				BaseMetadataTrait: models.BaseMetadataTrait{Name: "HealthController", StructName: "HealthController"},
+				Routes: []models.RouteMetadata{
+					{Method: "GET", Path: "/health", HandlerName: "Health", ReturnType: models.ReturnTypeInfo{Type: models.ReturnTypeError}},
+				},
+			},
+		},
+	}
+
+	result, err := generator.GenerateModule(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Contains(result.Content, "axon.Authorizer") {
+		t.Errorf("did not expect an authorizer dependency without -Roles or -Permissions")
+	}
+}
+
+func TestGenerateModule_SessionParameter(t *testing.T) {
+	generator := NewGenerator()
+
+	metadata := &models.PackageMetadata{
+		PackageName: "controllers",
+		PackagePath: "./controllers",
+		Controllers: []models.ControllerMetadata{
+			{
+				BaseMetadataTrait: models.BaseMetadataTrait{
+					Name:       "CartController",
+					StructName: "CartController",
+				},
+				Routes: []models.RouteMetadata{
+					{
+						Method:      "POST",
+						Path:        "/cart/{id:int}",
+						HandlerName: "AddItem",
+						Parameters: []models.Parameter{
+							{Name: "id", Type: "int", Source: models.ParameterSourcePath, Position: 0},
+							{Name: "sess", Type: "axon.Session", Source: models.ParameterSourceSession, Position: 1},
+						},
+						ReturnType: models.ReturnTypeInfo{Type: models.ReturnTypeResponseError, HasError: true},
+					},
+					{
+						Method:      "GET",
+						Path:        "/cart",
+						HandlerName: "GetCart",
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeDataError, HasError: true},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := generator.GenerateModule(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"func wrapCartControllerAddItem(handler *CartController, sessions *axon.SessionManager) axon.HandlerFunc {",
+		"sess, err := sessions.Load(c)",
+		"response, err = handler.AddItem(id, sess)",
+		"if saveErr := sessions.Save(c, sess); saveErr != nil && err == nil {",
+		"func wrapCartControllerGetCart(handler *CartController) axon.HandlerFunc {",
+		"sessions *axon.SessionManager) {",
+		"wrapCartControllerAddItem(cartcontroller, sessions)",
+		"wrapCartControllerGetCart(cartcontroller)",
+	}
+	for _, want := range expected {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected generated code to contain %q, got:\n%s", want, result.Content)
+		}
+	}
+
+	// The session is saved before the response is written
+	if strings.Index(result.Content, "sessions.Save(c, sess)") > strings.Index(result.Content, "return handleAxonResponse(c, response)") {
+		t.Errorf("expected session to be saved before the response is written")
+	}
+}
+
+func TestGenerateModule_NoCSRF(t *testing.T) {
+	generator := NewGenerator()
+
+	metadata := &models.PackageMetadata{
+		PackageName: "controllers",
+		PackagePath: "./controllers",
+		Controllers: []models.ControllerMetadata{
+			{
+				BaseMetadataTrait: models.BaseMetadataTrait{
+					Name:       "WebhookController",
+					StructName: "WebhookController",
+				},
+				Routes: []models.RouteMetadata{
+					{
+						Method:      "POST",
+						Path:        "/webhooks/stripe",
+						HandlerName: "Stripe",
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeError},
+						NoCSRF:      true,
+					},
+					{
+						Method:      "POST",
+						Path:        "/webhooks/settings",
+						HandlerName: "Settings",
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeError},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := generator.GenerateModule(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if count := strings.Count(result.Content, "NoCSRF:              true,"); count != 1 {
+		t.Fatalf("expected exactly one NoCSRF registry entry, got %d:\n%s", count, result.Content)
+	}
+	// The exemption travels with the matched route, so only the Stripe route skips verification
+	if count := strings.Count(result.Content, ", NoCSRF: true})"); count != 1 {
+		t.Fatalf("expected exactly one exempt route match, got %d:\n%s", count, result.Content)
+	}
+	if !strings.Contains(result.Content, `axon.RouteMatch{Method: "POST", Path: "/webhooks/stripe", Controller: "WebhookController", Handler: "Stripe", NoCSRF: true}`) {
+		t.Errorf("expected the Stripe route match to be exempt, got:\n%s", result.Content)
+	}
+	stripe := strings.Index(result.Content, `HandlerName:         "Stripe"`)
+	settings := strings.Index(result.Content, `HandlerName:         "Settings"`)
+	noCSRF := strings.Index(result.Content, "NoCSRF:              true,")
+	if stripe == -1 || settings == -1 || noCSRF < stripe || noCSRF > settings {
+		t.Errorf("expected NoCSRF to be set on the Stripe route only, got:\n%s", result.Content)
+	}
+}
+
+func TestGenerateModule_CSPPolicy(t *testing.T) {
+	generator := NewGenerator()
+
+	metadata := &models.PackageMetadata{
+		PackageName: "controllers",
+		PackagePath: "./controllers",
+		Controllers: []models.ControllerMetadata{
+			{
+				BaseMetadataTrait: models.BaseMetadataTrait{
+					Name:       "AdminController",
+					StructName: "AdminController",
+				},
+				Routes: []models.RouteMetadata{
+					{
+						Method:      "GET",
+						Path:        "/admin",
+						HandlerName: "Dashboard",
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeError},
+						CSPPolicy:   "admin",
+					},
+					{
+						Method:      "GET",
+						Path:        "/admin/users",
+						HandlerName: "Users",
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeError},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := generator.GenerateModule(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		`handler_admincontrollerdashboard = axon.WithCSPPolicy("admin")(handler_admincontrollerdashboard)`,
+		`CSPPolicy:           "admin",`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected generated code to contain %q, got:\n%s", want, result.Content)
+		}
+	}
+	if count := strings.Count(result.Content, "axon.WithCSPPolicy("); count != 1 {
+		t.Errorf("expected exactly one CSP override, got %d", count)
+	}
+}
+
+func TestGenerateModule_Cache(t *testing.T) {
+	generator := NewGenerator()
+
+	metadata := &models.PackageMetadata{
+		PackageName: "controllers",
+		PackagePath: "./controllers",
+		Controllers: []models.ControllerMetadata{
+			{
+				BaseMetadataTrait: models.BaseMetadataTrait{
+					Name:       "ProductController",
+					StructName: "ProductController",
+				},
+				Routes: []models.RouteMetadata{
+					{
+						Method:      "GET",
+						Path:        "/products/{id:int}",
+						HandlerName: "GetProduct",
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeError},
+						Roles:       []string{"staff"},
+						CacheTTL:    "90s",
+						CacheVary:   []string{"Accept-Language", "principal"},
+					},
+					{
+						Method:      "GET",
+						Path:        "/products",
+						HandlerName: "ListProducts",
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeError},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := generator.GenerateModule(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		`cache *axon.ResponseCache)`,
+		`handler_productcontrollergetproduct = axon.WithCache(cache, axon.CachePolicy{Route: "ProductController.GetProduct", Path: "/products/{id:int}", TTL: 90 * time.Second, Vary: []string{"Accept-Language", "principal"}})(handler_productcontrollergetproduct)`,
+		`CacheTTL:            90 * time.Second,`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected generated code to contain %q, got:\n%s", want, result.Content)
+		}
+	}
+	if count := strings.Count(result.Content, "axon.WithCache("); count != 1 {
+		t.Errorf("expected exactly one cached route, got %d", count)
+	}
+
+	// Authorization wraps the cache, so it is checked before a cached response is served
+	cacheAt := strings.Index(result.Content, "axon.WithCache(")
+	authAt := strings.Index(result.Content, "axon.RequireAuthorization(")
+	if cacheAt < 0 || authAt < cacheAt {
+		t.Errorf("expected the cache decorator to be applied before authorization")
+	}
+}
+
+func TestGenerateModule_CacheGuarded(t *testing.T) {
+	route := func(handler string, roles, middlewares, vary []string) models.RouteMetadata {
+		return models.RouteMetadata{
+			Method:      "GET",
+			Path:        "/orders/" + strings.ToLower(handler),
+			HandlerName: handler,
+			ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeError},
+			Roles:       roles,
+			Middlewares: middlewares,
+			CacheTTL:    "30s",
+			CacheVary:   vary,
+		}
+	}
+	metadata := &models.PackageMetadata{
+		PackageName: "controllers",
+		PackagePath: "./controllers",
+		Controllers: []models.ControllerMetadata{
+			{
+				BaseMetadataTrait: models.BaseMetadataTrait{
+					Name:       "OrderController",
+					StructName: "OrderController",
+				},
+				Routes: []models.RouteMetadata{
+					route("Public", nil, nil, []string{"Accept-Language"}),
+					route("Admin", []string{"admin"}, nil, nil),
+					route("Mine", nil, []string{"AuthMiddleware"}, []string{"Accept-Language"}),
+					route("Listed", nil, []string{"AuthMiddleware"}, []string{"Principal"}),
+				},
+			},
+		},
+	}
+
+	result, err := NewGenerator().GenerateModule(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Routes behind authorization or middleware are always cached per principal
+	expected := []string{
+		`axon.CachePolicy{Route: "OrderController.Public", Path: "/orders/public", TTL: 30 * time.Second, Vary: []string{"Accept-Language"}}`,
+		`axon.CachePolicy{Route: "OrderController.Admin", Path: "/orders/admin", TTL: 30 * time.Second, Vary: []string{"principal"}}`,
+		`axon.CachePolicy{Route: "OrderController.Mine", Path: "/orders/mine", TTL: 30 * time.Second, Vary: []string{"Accept-Language", "principal"}}`,
+		`axon.CachePolicy{Route: "OrderController.Listed", Path: "/orders/listed", TTL: 30 * time.Second, Vary: []string{"Principal"}}`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected generated code to contain %q, got:\n%s", want, result.Content)
+		}
+	}
+}
+
+func TestGenerateModule_ETag(t *testing.T) {
+	enabled, disabled := true, false
+	userRoutes := func(putETag *bool) []models.RouteMetadata {
+		return []models.RouteMetadata{
+			{
+				Method:      "GET",
+				Path:        "/users/{id:int}",
+				HandlerName: "GetUser",
+				ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeDataError, HasError: true},
+			},
+			{
+				Method:      "PUT",
+				Path:        "/users/{id:int}",
+				HandlerName: "UpdateUser",
+				ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeResponseError, HasError: true},
+				ETag:        putETag,
+			},
+			{
+				Method:      "POST",
+				Path:        "/users",
+				HandlerName: "CreateUser",
+				ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeDataError, HasError: true},
+			},
+		}
+	}
+	generate := func(t *testing.T, controllerETag *bool, defaultETag bool, routes []models.RouteMetadata) string {
+		t.Helper()
+		generator := NewGenerator()
+		generator.SetDefaultETag(defaultETag)
+		metadata := &models.PackageMetadata{
+			PackageName: "controllers",
+			PackagePath: "./controllers",
+			Controllers: []models.ControllerMetadata{
+				{
+					BaseMetadataTrait: models.BaseMetadataTrait{Name: "UserController", StructName: "UserController"},
+					Routes:            routes,
+					ETag:              controllerETag,
+				},
+			},
+		}
+		result, err := generator.GenerateModule(metadata)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result.Content
+	}
+
+	t.Run("controller", func(t *testing.T) {
+		content := generate(t, &enabled, false, userRoutes(nil))
+		expected := []string{
+			`handler_usercontrollergetuser = axon.WithETag(axon.ETagPolicy{})(handler_usercontrollergetuser)`,
+			`handler_usercontrollerupdateuser = axon.WithETag(axon.ETagPolicy{Current: wrapUserControllerGetUser(usercontroller)})(handler_usercontrollerupdateuser)`,
+			`axon.SetETagFrom(c, data)`,
+			`axon.SetETagFrom(c, response.Body)`,
+			`ETag:                true,`,
+		}
+		for _, want := range expected {
+			if !strings.Contains(content, want) {
+				t.Errorf("expected generated code to contain %q, got:\n%s", want, content)
+			}
+		}
+		if count := strings.Count(content, "axon.WithETag("); count != 2 {
+			t.Errorf("expected ETags on the GET and PUT routes only, got %d", count)
+		}
+	})
+
+	t.Run("route opt-out", func(t *testing.T) {
+		content := generate(t, nil, true, userRoutes(&disabled))
+		if count := strings.Count(content, "axon.WithETag("); count != 1 {
+			t.Errorf("expected ETags on the GET route only, got %d", count)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		content := generate(t, nil, false, userRoutes(nil))
+		if strings.Contains(content, "ETag") {
+			t.Errorf("expected no ETag handling by default, got:\n%s", content)
+		}
+	})
+
+	t.Run("write route without GET", func(t *testing.T) {
+		generator := NewGenerator()
+		metadata := &models.PackageMetadata{
+			PackageName: "controllers",
+			Controllers: []models.ControllerMetadata{
+				{
+					BaseMetadataTrait: models.BaseMetadataTrait{Name: "UserController", StructName: "UserController"},
+					Routes:            userRoutes(&enabled)[1:],
+				},
+			},
+		}
+		_, err := generator.GenerateModule(metadata)
+		if err == nil || !strings.Contains(err.Error(), "needs a GET route with the same path") {
+			t.Errorf("expected an error about the missing GET route, got %v", err)
+		}
+	})
+}
+
+func TestGenerateModule_Coalesce(t *testing.T) {
+	generator := NewGenerator()
+
+	metadata := &models.PackageMetadata{
+		PackageName: "controllers",
+		PackagePath: "./controllers",
+		Controllers: []models.ControllerMetadata{
+			{
+				BaseMetadataTrait: models.BaseMetadataTrait{
+					Name:       "ReportController",
+					StructName: "ReportController",
+				},
+				Routes: []models.RouteMetadata{
+					{
+						Method:      "GET",
+						Path:        "/reports/{id:int}",
+						HandlerName: "GetReport",
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeError},
+						CacheTTL:    "1m",
+						Coalesce:    true,
+						CoalesceKey: []string{"X-Tenant", "principal"},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := generator.GenerateModule(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		`handler_reportcontrollergetreport = axon.WithCoalescing(axon.CoalescePolicy{Route: "ReportController.GetReport", Path: "/reports/{id:int}", Key: []string{"X-Tenant", "principal"}})(handler_reportcontrollergetreport)`,
+		`Coalesce:            true,`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected generated code to contain %q, got:\n%s", want, result.Content)
+		}
+	}
+
+	// Coalescing sits inside the cache, so only cache misses are coalesced
+	coalesceAt := strings.Index(result.Content, "axon.WithCoalescing(")
+	cacheAt := strings.Index(result.Content, "axon.WithCache(")
+	if coalesceAt < 0 || cacheAt < coalesceAt {
+		t.Errorf("expected the coalescing decorator to be applied before the cache")
+	}
+}
+
+func TestGenerateModule_CoalesceGuarded(t *testing.T) {
+	route := func(handler string, roles, middlewares, key []string) models.RouteMetadata {
+		return models.RouteMetadata{
+			Method:      "GET",
+			Path:        "/reports/" + strings.ToLower(handler),
+			HandlerName: handler,
+			ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeError},
+			Roles:       roles,
+			Middlewares: middlewares,
+			Coalesce:    true,
+			CoalesceKey: key,
+		}
+	}
+	metadata := &models.PackageMetadata{
+		PackageName: "controllers",
+		PackagePath: "./controllers",
+		Controllers: []models.ControllerMetadata{
+			{
+				BaseMetadataTrait: models.BaseMetadataTrait{
+					Name:       "ReportController",
+					StructName: "ReportController",
+				},
+				Routes: []models.RouteMetadata{
+					route("Public", nil, nil, []string{"X-Tenant"}),
+					route("Admin", []string{"admin"}, nil, nil),
+					route("Mine", nil, []string{"AuthMiddleware"}, []string{"X-Tenant"}),
+					route("Listed", []string{"admin"}, nil, []string{"Principal"}),
+				},
+			},
+		},
+	}
+
+	result, err := NewGenerator().GenerateModule(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Routes behind authorization or middleware always coalesce per principal
+	expected := []string{
+		`axon.CoalescePolicy{Route: "ReportController.Public", Path: "/reports/public", Key: []string{"X-Tenant"}}`,
+		`axon.CoalescePolicy{Route: "ReportController.Admin", Path: "/reports/admin", Key: []string{"principal"}}`,
+		`axon.CoalescePolicy{Route: "ReportController.Mine", Path: "/reports/mine", Key: []string{"X-Tenant", "principal"}}`,
+		`axon.CoalescePolicy{Route: "ReportController.Listed", Path: "/reports/listed", Key: []string{"Principal"}}`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected generated code to contain %q, got:\n%s", want, result.Content)
+		}
+	}
+}
+
+func TestGenerateModule_Idempotent(t *testing.T) {
+	generator := NewGenerator()
+
+	metadata := &models.PackageMetadata{
+		PackageName: "controllers",
+		PackagePath: "./controllers",
+		Controllers: []models.ControllerMetadata{
+			{
+				BaseMetadataTrait: models.BaseMetadataTrait{
+					Name:       "OrderController",
+					StructName: "OrderController",
+				},
+				Routes: []models.RouteMetadata{
+					{
+						Method:      "POST",
+						Path:        "/orders",
+						HandlerName: "CreateOrder",
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeError},
+						Roles:       []string{"customer"},
+						Idempotent:  true,
+					},
+				},
+			},
+		},
+	}
+
+	result, err := generator.GenerateModule(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		`idempotency *axon.Idempotency) {`,
+		`handler_ordercontrollercreateorder = axon.WithIdempotency(idempotency, axon.IdempotencyPolicy{Route: "OrderController.CreateOrder", Path: "/orders"})(handler_ordercontrollercreateorder)`,
+		`Idempotent:          true,`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected generated code to contain %q, got:\n%s", want, result.Content)
+		}
+	}
+
+	// Keys are scoped to the principal, so authorization runs first
+	idempotencyAt := strings.Index(result.Content, "axon.WithIdempotency(")
+	authorizationAt := strings.Index(result.Content, "axon.RequireAuthorization(")
+	if idempotencyAt < 0 || authorizationAt < idempotencyAt {
+		t.Errorf("expected the idempotency decorator to be applied inside authorization")
+	}
+}
+
+func TestGenerateModule_Pagination(t *testing.T) {
+	generator := NewGenerator()
+	etag := true
+
+	metadata := &models.PackageMetadata{
+		PackageName: "controllers",
+		PackagePath: "./controllers",
+		Controllers: []models.ControllerMetadata{
+			{
+				BaseMetadataTrait: models.BaseMetadataTrait{
+					Name:       "OrderController",
+					StructName: "OrderController",
+				},
+				Routes: []models.RouteMetadata{
+					{
+						Method:      "GET",
+						Path:        "/orders",
+						HandlerName: "ListOrders",
+						Parameters: []models.Parameter{
+							{Name: "page", Type: "axon.PageRequest", Source: models.ParameterSourcePage},
+						},
+						ReturnType: models.ReturnTypeInfo{Type: models.ReturnTypePage, DataType: "Order", HasError: true},
+					},
+					{
+						Method:      "PUT",
+						Path:        "/orders",
+						HandlerName: "ReplaceOrders",
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeError, HasError: true},
+						ETag:        &etag,
+					},
+				},
+			},
+		},
+	}
+
+	result, err := generator.GenerateModule(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		`pagination *axon.Pagination) {`,
+		`func wrapOrderControllerListOrders(handler *OrderController, pagination *axon.Pagination) axon.HandlerFunc {`,
+		`handler_ordercontrollerlistorders := wrapOrderControllerListOrders(ordercontroller, pagination)`,
+		`handler_ordercontrollerreplaceorders := wrapOrderControllerReplaceOrders(ordercontroller)`,
+		// If-Match is checked against the paginated GET handler
+		`axon.ETagPolicy{Current: wrapOrderControllerListOrders(ordercontroller, pagination)}`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected generated code to contain %q, got:\n%s", want, result.Content)
+		}
+	}
+}
+
+func TestGenerateModule_Fields(t *testing.T) {
+	generator := NewGenerator()
+
+	metadata := &models.PackageMetadata{
+		PackageName: "controllers",
+		PackagePath: "./controllers",
+		Controllers: []models.ControllerMetadata{
+			{
+				BaseMetadataTrait: models.BaseMetadataTrait{
+					Name:       "OrderController",
+					StructName: "OrderController",
+				},
+				Routes: []models.RouteMetadata{
+					{
+						Method:      "GET",
+						Path:        "/orders",
+						HandlerName: "ListOrders",
+						Parameters: []models.Parameter{
+							{Name: "page", Type: "axon.PageRequest", Source: models.ParameterSourcePage},
+						},
+						ReturnType: models.ReturnTypeInfo{Type: models.ReturnTypePage, DataType: "Order", HasError: true},
+						Fields:     true,
+						FieldPaths: []string{"id", "total"},
+						Coalesce:   true,
+					},
+					{
+						Method:      "GET",
+						Path:        "/stats",
+						HandlerName: "Stats",
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeDataError, DataType: "map[string]int", HasError: true},
+						Fields:      true,
+					},
+				},
+			},
+		},
+	}
+
+	result, err := generator.GenerateModule(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		`handler_ordercontrollerlistorders = axon.WithFields(axon.FieldsPolicy{Paths: []string{"id", "total"}, Page: true})(handler_ordercontrollerlistorders)`,
+		`handler_ordercontrollerstats = axon.WithFields(axon.FieldsPolicy{Paths: nil})(handler_ordercontrollerstats)`,
+		`Fields:              true,`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected generated code to contain %q, got:\n%s", want, result.Content)
+		}
+	}
+
+	// Responses are projected before they are shared with coalesced requests
+	fields := strings.Index(result.Content, "axon.WithFields(axon.FieldsPolicy{Paths: []string")
+	coalescing := strings.Index(result.Content, "axon.WithCoalescing(")
+	if fields < 0 || coalescing < 0 || fields > coalescing {
+		t.Errorf("expected WithFields to wrap the handler before WithCoalescing, got:\n%s", result.Content)
+	}
+}
+
+func TestGenerateModule_NoCompress(t *testing.T) {
+	generator := NewGenerator()
+
+	metadata := &models.PackageMetadata{
+		PackageName: "controllers",
+		PackagePath: "./controllers",
+		Controllers: []models.ControllerMetadata{
+			{
+				BaseMetadataTrait: models.BaseMetadataTrait{
+					Name:       "TokenController",
+					StructName: "TokenController",
+				},
+				Routes: []models.RouteMetadata{
+					{
+						Method:      "GET",
+						Path:        "/token",
+						HandlerName: "GetToken",
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeError},
+						NoCompress:  true,
+					},
+					{
+						Method:      "GET",
+						Path:        "/info",
+						HandlerName: "GetInfo",
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeError},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := generator.GenerateModule(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		`handler_tokencontrollergettoken = axon.WithoutCompression()(handler_tokencontrollergettoken)`,
+		`NoCompress:          true,`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected generated code to contain %q, got:\n%s", want, result.Content)
+		}
+	}
+	if strings.Count(result.Content, "axon.WithoutCompression()") != 1 {
+		t.Errorf("expected only the opted-out route to skip compression")
+	}
+}
+
+func TestGenerateModule_MaxBodySize(t *testing.T) {
+	generator := NewGenerator()
+	generator.SetDefaultMaxBodySize(2 << 20)
+
+	controllerLimit := int64(1 << 20)
+	routeLimit := int64(1 << 30)
+	noLimit := int64(0)
+
+	metadata := &models.PackageMetadata{
+		PackageName: "controllers",
+		PackagePath: "./controllers",
+		Controllers: []models.ControllerMetadata{
+			{
+				BaseMetadataTrait: models.BaseMetadataTrait{
+					Name:       "FileController",
+					StructName: "FileController",
+				},
+				MaxBodySize: &controllerLimit,
+				Routes: []models.RouteMetadata{
+					{
+						Method:      "PUT",
+						Path:        "/files/{name}",
+						HandlerName: "Upload",
+						Parameters: []models.Parameter{
+							{Name: "name", Type: "string", Source: models.ParameterSourcePath, Position: 0},
+							{Name: "body", Type: "axon.BodyStream", Source: models.ParameterSourceBodyStream, Position: 1},
+						},
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeError},
+						MaxBodySize: &routeLimit,
+					},
+					{
+						Method:      "POST",
+						Path:        "/files",
+						HandlerName: "Create",
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeError},
+					},
+					{
+						Method:      "POST",
+						Path:        "/files/raw",
+						HandlerName: "CreateRaw",
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeError},
+						MaxBodySize: &noLimit,
+					},
+				},
+			},
+			{
+				BaseMetadataTrait: models.BaseMetadataTrait{
+					Name:       "NoteController",
+					StructName: "NoteController",
+				},
+				Routes: []models.RouteMetadata{
+					{
+						Method:      "POST",
+						Path:        "/notes",
+						HandlerName: "Create",
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeError},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := generator.GenerateModule(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"body := axon.GetBodyStream(c)",
+		"handler.Upload(name, body)",
+		`handler_filecontrollerupload = axon.WithBodyLimit(axon.BodyLimitPolicy{MaxSize: 1073741824, Stream: true})(handler_filecontrollerupload)`,
+		`handler_filecontrollercreate = axon.WithBodyLimit(axon.BodyLimitPolicy{MaxSize: 1048576})(handler_filecontrollercreate)`,
+		`handler_notecontrollercreate = axon.WithBodyLimit(axon.BodyLimitPolicy{MaxSize: 2097152})(handler_notecontrollercreate)`,
+		`MaxBodySize:         1073741824,`,
+		"StreamsBody:         true,",
+	}
+	for _, want := range expected {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected generated code to contain %q, got:\n%s", want, result.Content)
+		}
+	}
+	if strings.Contains(result.Content, "axon.WithBodyLimit(axon.BodyLimitPolicy{MaxSize: 0") || strings.Count(result.Content, "axon.WithBodyLimit(") != 3 {
+		t.Errorf("expected -MaxBodySize=0 to remove the limit")
+	}
+	if strings.Contains(result.Content, "c.Bind(&body)") {
+		t.Errorf("expected streamed bodies not to be bound")
+	}
+	if count := strings.Count(result.Content, "StreamsBody:         true,"); count != 1 {
+		t.Errorf("expected only the streaming route to be registered as StreamsBody, got %d", count)
+	}
+}
+
+func TestGenerateModule_DefaultMaxBodySize(t *testing.T) {
+	metadata := &models.PackageMetadata{
+		PackageName: "controllers",
+		PackagePath: "./controllers",
+		Controllers: []models.ControllerMetadata{
+			{
+				BaseMetadataTrait: models.BaseMetadataTrait{
+					Name:       "NoteController",
+					StructName: "NoteController",
+				},
+				Routes: []models.RouteMetadata{
+					{
+						Method:      "POST",
+						Path:        "/notes",
+						HandlerName: "Create",
+						ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeError},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := NewGenerator().GenerateModule(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `handler_notecontrollercreate = axon.WithBodyLimit(axon.BodyLimitPolicy{MaxSize: 4194304})(handler_notecontrollercreate)`
+	if !strings.Contains(result.Content, want) {
+		t.Errorf("expected routes without -MaxBodySize to be limited to 4MB, got:\n%s", result.Content)
+	}
+
+	generator := NewGenerator()
+	generator.SetDefaultMaxBodySize(0)
+	result, err = generator.GenerateModule(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(result.Content, "axon.WithBodyLimit(") {
+		t.Errorf("expected -max-body-size=0 to remove the default limit")
+	}
+}
+
+func TestGenerateModule_Uploads(t *testing.T) {
+	generator := NewGenerator()
+	generator.SetDefaultMaxBodySize(10 << 20)
+
+	metadata := &models.PackageMetadata{
+		PackageName: "controllers",
+		PackagePath: "./controllers",
+		Controllers: []models.ControllerMetadata{
+			{
+				BaseMetadataTrait: models.BaseMetadataTrait{
+					Name:       "ProfileController",
+					StructName: "ProfileController",
+				},
+				Routes: []models.RouteMetadata{
+					{
+						Method:      "POST",
+						Path:        "/profile/avatar",
+						HandlerName: "UploadAvatar",
+						Parameters: []models.Parameter{
+							{Name: "upload", Type: "AvatarUpload", Source: models.ParameterSourceBody, Position: 0, Uploads: []models.UploadField{
+								{FieldName: "Avatar", FormName: "avatar", MaxCount: 1, MaxSize: 5 << 20, Accept: []string{"image/png"}, Required: true},
+							}},
+						},
+						ReturnType: models.ReturnTypeInfo{Type: models.ReturnTypeError},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := generator.GenerateModule(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		`uploadForm, err := axon.ReadMultipartForm(c,`,
+		`axon.UploadRule{Field: "avatar", MaxCount: 1, MaxSize: 5242880, Accept: []string{"image/png"}, Required: true},`,
+		"defer uploadForm.RemoveAll()",
+		"uploadForm.Bind(&body)",
+		"handler.UploadAvatar(body)",
+		// Multipart bodies are read as they stream in, not buffered up front
+		`axon.WithBodyLimit(axon.BodyLimitPolicy{MaxSize: 10485760, Stream: true})`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected generated code to contain %q, got:\n%s", want, result.Content)
+		}
+	}
+	if strings.Contains(result.Content, "c.Bind(&body)") {
+		t.Errorf("expected multipart bodies not to be bound with Bind")
+	}
+}
+
+func TestGenerateModule_LoggerParameter(t *testing.T) {
+	generator := NewGenerator()
+
+	metadata := &models.PackageMetadata{
+		PackageName: "controllers",
+		PackagePath: "./controllers",
+		Controllers: []models.ControllerMetadata{
+			{
+				BaseMetadataTrait: models.BaseMetadataTrait{
+					Name:       "OrderController",
+					StructName: "OrderController",
+				},
+				Routes: []models.RouteMetadata{
+					{
+						Method:      "GET",
+						Path:        "/orders/{id:int}",
+						HandlerName: "GetOrder",
+						Parameters: []models.Parameter{
+							{Name: "id", Type: "int", Source: models.ParameterSourcePath, Position: 0},
+							{Name: "log", Type: "*slog.Logger", Source: models.ParameterSourceLogger, Position: 1},
+						},
+						ReturnType: models.ReturnTypeInfo{Type: models.ReturnTypeError},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := generator.GenerateModule(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"log := axon.GetLogger(c)",
+		"handler.GetOrder(id, log)",
+		`axon.WithRouteMatch(axon.RouteMatch{Method: "GET", Path: "/orders/{id:int}", Controller: "OrderController", Handler: "GetOrder"})`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected generated code to contain %q, got:\n%s", want, result.Content)
+		}
+	}
+	if strings.Contains(result.Content, "\"log/slog\"") {
+		t.Errorf("expected log/slog not to be imported by the generated module")
+	}
+}
+
 func TestGenerateControllerProvider(t *testing.T) {
 	generator := NewGenerator()
 
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/toyz/axon/internal/models"
+	"golang.org/x/tools/imports"
 )
 
 // TestGeneratedCodeCompilation tests that generated code compiles successfully
@@ -253,3 +254,123 @@ var AutogenModule = fx.Module("services")
 		t.Fatalf("generated modules failed to compile: %v\nOutput: %s", err, output)
 	}
 }
+
+// TestGeneratedUploadModuleCompilation tests that a controller module reading
+// an upload body compiles against the axon package of this repository
+func TestGeneratedUploadModuleCompilation(t *testing.T) {
+	repoRoot, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatalf("failed to resolve repository root: %v", err)
+	}
+
+	// Create temporary directory for test
+	tempDir, err := os.MkdirTemp("", "generator_upload_integration_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Use this repository's axon package and its module checksums
+	goMod := "module testapp\n\ngo 1.25\n\nrequire github.com/toyz/axon v0.0.0\n\nreplace github.com/toyz/axon => " + repoRoot + "\n"
+	err = os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte(goMod), 0644)
+	if err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+	goSum, err := os.ReadFile(filepath.Join(repoRoot, "go.sum"))
+	if err != nil {
+		t.Fatalf("failed to read go.sum: %v", err)
+	}
+	err = os.WriteFile(filepath.Join(tempDir, "go.sum"), goSum, 0644)
+	if err != nil {
+		t.Fatalf("failed to write go.sum: %v", err)
+	}
+
+	controllersDir := filepath.Join(tempDir, "controllers")
+	err = os.MkdirAll(controllersDir, 0755)
+	if err != nil {
+		t.Fatalf("failed to create controllers dir: %v", err)
+	}
+
+	controllerCode := `package controllers
+
+import "github.com/toyz/axon/pkg/axon"
+
+// UploadReq is an upload body with a single file
+type UploadReq struct {
+	File *axon.UploadedFile ` + "`form:\"file\"`" + `
+}
+
+// UploadController accepts uploads
+type UploadController struct{}
+
+// Upload stores an uploaded file
+func (c *UploadController) Upload(req UploadReq) error {
+	return nil
+}
+`
+	err = os.WriteFile(filepath.Join(controllersDir, "upload_controller.go"), []byte(controllerCode), 0644)
+	if err != nil {
+		t.Fatalf("failed to write controller file: %v", err)
+	}
+
+	generator := NewGeneratorWithResolver(axonModuleResolver{})
+	metadata := &models.PackageMetadata{
+		PackageName: "controllers",
+		PackagePath: controllersDir,
+		Controllers: []models.ControllerMetadata{
+			{
+				BaseMetadataTrait: models.BaseMetadataTrait{
+					Name:       "UploadController",
+					StructName: "UploadController",
+				},
+				Routes: []models.RouteMetadata{
+					{
+						Method:      "POST",
+						Path:        "/upload",
+						HandlerName: "Upload",
+						Parameters: []models.Parameter{
+							{Name: "req", Type: "UploadReq", Source: models.ParameterSourceBody, Position: 0, Uploads: []models.UploadField{
+								{FieldName: "File", FormName: "file", MaxCount: 1},
+							}},
+						},
+						ReturnType: models.ReturnTypeInfo{Type: models.ReturnTypeError, HasError: true},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := generator.GenerateModule(metadata)
+	if err != nil {
+		t.Fatalf("failed to generate module: %v", err)
+	}
+
+	// Fix up the imports as the CLI does
+	content, err := imports.Process(result.FilePath, []byte(result.Content), nil)
+	if err != nil {
+		t.Fatalf("failed to process generated imports: %v\nGenerated code:\n%s", err, result.Content)
+	}
+	err = os.WriteFile(result.FilePath, content, 0644)
+	if err != nil {
+		t.Fatalf("failed to write generated module file: %v", err)
+	}
+
+	// Try to compile the generated code
+	cmd := exec.Command("go", "build", "-mod=mod", "./...")
+	cmd.Dir = tempDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("generated code failed to compile: %v\nOutput: %s\nGenerated code:\n%s", err, output, result.Content)
+	}
+}
+
+// axonModuleResolver resolves generated imports to the axon module
+type axonModuleResolver struct{}
+
+func (axonModuleResolver) ResolveModuleName(string) (string, error) {
+	return "github.com/toyz/axon", nil
+}
+
+func (axonModuleResolver) BuildPackagePath(moduleName, packageDir string) (string, error) {
+	return moduleName + "/" + packageDir, nil
+}
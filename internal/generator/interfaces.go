@@ -12,6 +12,8 @@ type CodeGenerator interface {
 	GenerateModuleWithPackagePaths(metadata *models.PackageMetadata, moduleName string, packagePaths map[string]string) (*models.GeneratedModule, error)
 	GenerateModuleWithRequiredPackages(metadata *models.PackageMetadata, moduleName string, packagePaths map[string]string, requiredPackages []string) (*models.GeneratedModule, error)
 	GetParserRegistry() axon.ParserRegistryInterface
+	SetDefaultETag(enabled bool)
+	SetDefaultMaxBodySize(size int64)
 }
 
 // Note: RouteGenerator interface was removed as it was unused.
@@ -1,5 +1,7 @@
 package models
 
+import "strings"
+
 // Backward compatibility aliases for external packages
 type BaseMetadata = BaseMetadataTrait
 type LifecycleMetadata = LifecycleTrait
@@ -9,8 +11,11 @@ type ControllerMetadata struct {
 	BaseMetadataTrait
 	PriorityTrait
 	MiddlewareTrait
-	Prefix string          // URL prefix for all routes in this controller
-	Routes []RouteMetadata // all routes defined on this controller
+	AuthorizationTrait
+	Prefix      string          // URL prefix for all routes in this controller
+	Routes      []RouteMetadata // all routes defined on this controller
+	ETag        *bool           // ETag setting for all routes; nil uses the generator default
+	MaxBodySize *int64          // request body limit in bytes for all routes; nil uses the generator default
 }
 
 // RouteMetadata represents an HTTP route handler
@@ -23,6 +28,57 @@ type RouteMetadata struct {
 	Middlewares []string       // middleware names to apply
 	Flags       []string       // flags like -PassContext
 	Priority    int            // route registration priority (lower = first, higher = last)
+	Roles       []string       // roles the caller must hold at least one of
+	Permissions []string       // permissions the caller must hold all of
+	NoCSRF      bool           // whether the route is exempt from CSRF protection
+	CSPPolicy   string         // named Content-Security-Policy replacing the default
+	CacheTTL    string         // how long responses are cached, as a Go duration
+	CacheVary   []string       // request headers or "principal" that select cache entries
+	ETag        *bool          // ETag setting; nil uses the controller setting
+	Coalesce    bool           // whether identical concurrent GETs share one execution
+	CoalesceKey []string       // request headers or "principal" added to the coalescing key
+	Idempotent  bool           // whether retries with the same Idempotency-Key are replayed
+	NoCompress  bool           // whether -Compress=false exempts the route from response compression
+	MaxBodySize *int64         // request body limit in bytes, 0 for none; nil uses the controller setting
+	Fields      bool           // whether clients can select response fields with ?fields=
+	FieldPaths  []string       // JSON paths clients can select; nil when the response type is not known
+}
+
+// SupportsETag reports whether -ETag applies to the route's method: conditional
+// GETs on reads and If-Match preconditions on writes
+func (r RouteMetadata) SupportsETag() bool {
+	switch r.Method {
+	case "GET", "HEAD", "PUT", "PATCH", "DELETE":
+		return true
+	}
+	return false
+}
+
+// StreamsBody reports whether the handler takes the request body as a stream
+func (r RouteMetadata) StreamsBody() bool {
+	for _, param := range r.Parameters {
+		if param.Source == ParameterSourceBodyStream {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadsMultipart reports whether the handler's body struct has upload fields;
+// the multipart body is then read as it streams in
+func (r RouteMetadata) ReadsMultipart() bool {
+	for _, param := range r.Parameters {
+		if param.Source == ParameterSourceBody && len(param.Uploads) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPatch reports whether the parameter is an axon.Patch[T] body, read from a
+// JSON Merge Patch or JSON Patch document
+func (p Parameter) IsPatch() bool {
+	return p.Source == ParameterSourceBody && strings.HasPrefix(p.Type, "axon.Patch[")
 }
 
 // Parameter represents a route parameter
@@ -34,6 +90,21 @@ type Parameter struct {
 	Position     int             // position in handler signature (for context parameters)
 	IsCustomType bool            // whether this parameter uses a custom parser
 	ParserFunc   string          // function name for custom parsers
+	Uploads      []UploadField   // file fields of a multipart body struct
+	Sortable     []string        // fields an axon.ListQuery can be sorted by, from -Sortable
+	Filterable   []string        // fields an axon.ListQuery can be filtered by, from -Filterable
+}
+
+// UploadField describes an *axon.UploadedFile or []*axon.UploadedFile field
+// of a body struct
+type UploadField struct {
+	FieldName string   // struct field name
+	FormName  string   // form field name from the form tag
+	Multiple  bool     // whether the field takes several files
+	MaxCount  int      // largest number of files; 1 for single files, 0 for no limit
+	MaxSize   int64    // largest file in bytes from the maxSize tag, 0 for no limit
+	Accept    []string // allowed media types from the accept tag
+	Required  bool     // whether the required tag is set
 }
 
 // ReturnTypeInfo describes handler return signature
@@ -41,5 +112,7 @@ type ReturnTypeInfo struct {
 	Type         ReturnType // type of return signature
 	DataType     string     // type of data returned (if applicable)
 	HasError     bool       // whether error is returned
+	HasStatus    bool       // whether a status code is returned after the data, as in (T, int, error)
 	UsesResponse bool       // whether custom Response struct is used
+	Stream       StreamKind // sequence kind of streaming handlers
 }
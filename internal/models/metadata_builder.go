@@ -7,6 +7,7 @@ type MetadataBuilder struct {
 	priority   *PriorityTrait
 	manual     *ManualModuleTrait
 	middleware *MiddlewareTrait
+	authz      *AuthorizationTrait
 	path       *PathTrait
 	service    *ServiceModeTrait
 	constructor *ConstructorTrait
@@ -73,6 +74,15 @@ func (b *MetadataBuilder) WithMiddlewares(middlewares ...string) *MetadataBuilde
 	return b
 }
 
+// WithAuthorization sets the required roles and permissions
+func (b *MetadataBuilder) WithAuthorization(roles, permissions []string) *MetadataBuilder {
+	b.authz = &AuthorizationTrait{
+		Roles:       roles,
+		Permissions: permissions,
+	}
+	return b
+}
+
 // WithPackagePath sets the package path
 func (b *MetadataBuilder) WithPackagePath(path string) *MetadataBuilder {
 	b.path = &PathTrait{PackagePath: path}
@@ -107,6 +117,10 @@ func (b *MetadataBuilder) BuildController(prefix string, routes []RouteMetadata)
 		controller.MiddlewareTrait = *b.middleware
 	}
 
+	if b.authz != nil {
+		controller.AuthorizationTrait = *b.authz
+	}
+
 	return controller
 }
 
@@ -93,6 +93,22 @@ func (m *MiddlewareTrait) GetMiddlewares() []string {
 	return m.Middlewares
 }
 
+// AuthorizationTrait provides role and permission requirements
+type AuthorizationTrait struct {
+	Roles       []string // roles the caller must hold at least one of
+	Permissions []string // permissions the caller must hold all of
+}
+
+// GetRoles returns the required roles
+func (a *AuthorizationTrait) GetRoles() []string {
+	return a.Roles
+}
+
+// GetPermissions returns the required permissions
+func (a *AuthorizationTrait) GetPermissions() []string {
+	return a.Permissions
+}
+
 // PathTrait provides package path functionality
 type PathTrait struct {
 	PackagePath string // package where component is defined
@@ -27,6 +27,11 @@ const (
 	ParameterSourceBody
 	ParameterSourceContext
 	ParameterSourceQuery
+	ParameterSourceSession
+	ParameterSourceLogger
+	ParameterSourceBodyStream
+	ParameterSourcePage
+	ParameterSourceListQuery
 )
 
 // ReturnType represents the type of return signature for handlers
@@ -36,6 +41,18 @@ const (
 	ReturnTypeDataError ReturnType = iota
 	ReturnTypeResponseError
 	ReturnTypeError
+	ReturnTypeStream
+	ReturnTypePage
+)
+
+// StreamKind is the kind of sequence a streaming handler returns
+type StreamKind int
+
+const (
+	StreamKindNone StreamKind = iota
+	StreamKindSeq             // iter.Seq[T]
+	StreamKindSeq2            // iter.Seq2[T, error]
+	StreamKindChan            // <-chan T
 )
 
 // ErrorType represents different types of generator errors
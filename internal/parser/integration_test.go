@@ -3,6 +3,8 @@ package parser
 import (
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/toyz/axon/internal/models"
@@ -581,3 +583,1320 @@ func containsAnySubstring(str, substr string) bool {
 	}
 	return false
 }
+
+func TestParser_AuthorizationFlags_Integration(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "axon_authorization_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	testFile := `package controllers
+
+//axon::controller -Prefix=/admin -Roles=admin,ops
+type AdminController struct{}
+
+//axon::route DELETE /users/{id:int} -Permissions=users:write,users:delete
+func (c *AdminController) DeleteUser(id int) error {
+	return nil
+}
+`
+
+	testFilePath := filepath.Join(tempDir, "admin.go")
+	if err := os.WriteFile(testFilePath, []byte(testFile), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	parser := NewParser()
+	metadata, err := parser.ParseDirectory(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(metadata.Controllers) != 1 {
+		t.Fatalf("expected 1 controller, got %d", len(metadata.Controllers))
+	}
+
+	controller := metadata.Controllers[0]
+	if strings.Join(controller.Roles, ",") != "admin,ops" {
+		t.Errorf("expected controller roles admin,ops, got %v", controller.Roles)
+	}
+
+	if len(controller.Routes) != 1 {
+		t.Fatalf("expected 1 route, got %d", len(controller.Routes))
+	}
+
+	route := controller.Routes[0]
+	if strings.Join(route.Permissions, ",") != "users:write,users:delete" {
+		t.Errorf("expected route permissions users:write,users:delete, got %v", route.Permissions)
+	}
+	if len(route.Roles) != 0 {
+		t.Errorf("expected no route-level roles, got %v", route.Roles)
+	}
+}
+
+func TestParser_SessionParameter_Integration(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "axon_session_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	testFile := `package controllers
+
+import "github.com/toyz/axon/pkg/axon"
+
+//axon::controller
+type CartController struct{}
+
+//axon::route POST /cart/{id:int}
+func (c *CartController) AddItem(id int, session axon.Session) error {
+	return nil
+}
+`
+
+	testFilePath := filepath.Join(tempDir, "cart.go")
+	if err := os.WriteFile(testFilePath, []byte(testFile), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	parser := NewParser()
+	metadata, err := parser.ParseDirectory(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(metadata.Controllers) != 1 || len(metadata.Controllers[0].Routes) != 1 {
+		t.Fatalf("expected 1 controller with 1 route")
+	}
+
+	var session *models.Parameter
+	for i, param := range metadata.Controllers[0].Routes[0].Parameters {
+		if param.Name == "session" {
+			session = &metadata.Controllers[0].Routes[0].Parameters[i]
+		}
+	}
+	if session == nil {
+		t.Fatalf("expected session parameter to be detected")
+	}
+	if session.Source != models.ParameterSourceSession {
+		t.Errorf("expected session source, got %v", session.Source)
+	}
+	if session.Position != 1 {
+		t.Errorf("expected session at position 1, got %d", session.Position)
+	}
+}
+
+func TestParser_NoCSRF_Integration(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "axon_nocsrf_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	testFile := `package controllers
+
+//axon::controller -Prefix=/webhooks
+type WebhookController struct{}
+
+//axon::route POST /stripe -NoCSRF
+func (c *WebhookController) Stripe() error {
+	return nil
+}
+
+//axon::route POST /settings
+func (c *WebhookController) Settings() error {
+	return nil
+}
+`
+
+	testFilePath := filepath.Join(tempDir, "webhooks.go")
+	if err := os.WriteFile(testFilePath, []byte(testFile), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	parser := NewParser()
+	metadata, err := parser.ParseDirectory(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(metadata.Controllers) != 1 || len(metadata.Controllers[0].Routes) != 2 {
+		t.Fatalf("expected 1 controller with 2 routes")
+	}
+
+	for _, route := range metadata.Controllers[0].Routes {
+		want := route.Path == "/webhooks/stripe"
+		if route.NoCSRF != want {
+			t.Errorf("route %s: expected NoCSRF=%v, got %v", route.Path, want, route.NoCSRF)
+		}
+	}
+}
+
+func TestParser_CSPPolicy_Integration(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "axon_csp_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	testFile := `package controllers
+
+//axon::controller
+type AdminController struct{}
+
+//axon::route GET /admin -CSP=admin
+func (c *AdminController) Dashboard() error {
+	return nil
+}
+`
+
+	testFilePath := filepath.Join(tempDir, "admin.go")
+	if err := os.WriteFile(testFilePath, []byte(testFile), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	parser := NewParser()
+	metadata, err := parser.ParseDirectory(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(metadata.Controllers) != 1 || len(metadata.Controllers[0].Routes) != 1 {
+		t.Fatalf("expected 1 controller with 1 route")
+	}
+	if policy := metadata.Controllers[0].Routes[0].CSPPolicy; policy != "admin" {
+		t.Errorf("expected CSP policy 'admin', got %q", policy)
+	}
+}
+
+func TestParser_Cache_Integration(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "axon_cache_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	testFile := `package controllers
+
+//axon::controller
+type ProductController struct{}
+
+//axon::route GET /products -Cache=30s -CacheVary=Accept-Language,principal -Coalesce -CoalesceKey=X-Tenant
+func (c *ProductController) ListProducts() error {
+	return nil
+}
+`
+
+	testFilePath := filepath.Join(tempDir, "products.go")
+	if err := os.WriteFile(testFilePath, []byte(testFile), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	parser := NewParser()
+	metadata, err := parser.ParseDirectory(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(metadata.Controllers) != 1 || len(metadata.Controllers[0].Routes) != 1 {
+		t.Fatalf("expected 1 controller with 1 route")
+	}
+	route := metadata.Controllers[0].Routes[0]
+	if route.CacheTTL != "30s" {
+		t.Errorf("expected cache TTL '30s', got %q", route.CacheTTL)
+	}
+	if len(route.CacheVary) != 2 || route.CacheVary[0] != "Accept-Language" || route.CacheVary[1] != "principal" {
+		t.Errorf("expected cache vary [Accept-Language principal], got %v", route.CacheVary)
+	}
+	if !route.Coalesce || len(route.CoalesceKey) != 1 || route.CoalesceKey[0] != "X-Tenant" {
+		t.Errorf("expected coalescing keyed by X-Tenant, got %v %v", route.Coalesce, route.CoalesceKey)
+	}
+}
+
+func TestParser_Idempotent_Integration(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "axon_idempotent_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	testFile := `package controllers
+
+//axon::controller
+type OrderController struct{}
+
+//axon::route POST /orders -Idempotent
+func (c *OrderController) CreateOrder() error {
+	return nil
+}
+
+//axon::route DELETE /orders/{id:int}
+func (c *OrderController) CancelOrder(id int) error {
+	return nil
+}
+`
+
+	testFilePath := filepath.Join(tempDir, "orders.go")
+	if err := os.WriteFile(testFilePath, []byte(testFile), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	parser := NewParser()
+	metadata, err := parser.ParseDirectory(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(metadata.Controllers) != 1 || len(metadata.Controllers[0].Routes) != 2 {
+		t.Fatalf("expected 1 controller with 2 routes")
+	}
+	for _, route := range metadata.Controllers[0].Routes {
+		if want := route.HandlerName == "CreateOrder"; route.Idempotent != want {
+			t.Errorf("expected %s to have Idempotent=%v, got %v", route.HandlerName, want, route.Idempotent)
+		}
+	}
+
+	// Streamed and upload bodies would be buffered to fingerprint them
+	bodies := []struct {
+		name      string
+		signature string
+	}{
+		{"body stream", "Import(body io.Reader) error"},
+		{"upload body", "Upload(req UploadRequest) error"},
+	}
+	for _, tt := range bodies {
+		t.Run(tt.name, func(t *testing.T) {
+			bodyDir := t.TempDir()
+			bodyFile := `package controllers
+
+import (
+	"io"
+
+	"github.com/toyz/axon/pkg/axon"
+)
+
+var _ io.Reader
+
+type UploadRequest struct {
+	File *axon.UploadedFile ` + "`form:\"file\"`" + `
+}
+
+//axon::controller
+type ImportController struct{}
+
+//axon::route POST /imports -Idempotent
+func (c *ImportController) ` + tt.signature + ` {
+	return nil
+}
+`
+			if err := os.WriteFile(filepath.Join(bodyDir, "imports.go"), []byte(bodyFile), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+			_, err := NewParser().ParseDirectory(bodyDir)
+			if err == nil || !strings.Contains(err.Error(), "-Idempotent with a streamed or upload body") {
+				t.Errorf("expected error for an idempotent %s, got %v", tt.name, err)
+			}
+		})
+	}
+}
+
+func TestParser_Compress_Integration(t *testing.T) {
+	tempDir := t.TempDir()
+
+	testFile := `package controllers
+
+//axon::controller
+type TokenController struct{}
+
+//axon::route GET /token -Compress=false
+func (c *TokenController) GetToken() (string, error) {
+	return "", nil
+}
+
+//axon::route GET /info -Compress
+func (c *TokenController) GetInfo() (string, error) {
+	return "", nil
+}
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "token.go"), []byte(testFile), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	metadata, err := NewParser().ParseDirectory(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metadata.Controllers) != 1 || len(metadata.Controllers[0].Routes) != 2 {
+		t.Fatalf("expected 1 controller with 2 routes")
+	}
+	for _, route := range metadata.Controllers[0].Routes {
+		if want := route.HandlerName == "GetToken"; route.NoCompress != want {
+			t.Errorf("expected %s to have NoCompress=%v, got %v", route.HandlerName, want, route.NoCompress)
+		}
+	}
+}
+
+func TestParser_MaxBodySize_Integration(t *testing.T) {
+	tempDir := t.TempDir()
+
+	testFile := `package controllers
+
+import (
+	"io"
+
+	"github.com/toyz/axon/pkg/axon"
+)
+
+//axon::controller -MaxBodySize=1MB
+type FileController struct{}
+
+//axon::route PUT /files/{name} -MaxBodySize=1GB
+func (c *FileController) Upload(name string, body axon.BodyStream) error {
+	return nil
+}
+
+//axon::route POST /files/raw -MaxBodySize=0
+func (c *FileController) UploadRaw(body io.Reader) error {
+	return nil
+}
+
+//axon::route POST /files
+func (c *FileController) Create() error {
+	return nil
+}
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "files.go"), []byte(testFile), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	metadata, err := NewParser().ParseDirectory(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metadata.Controllers) != 1 || len(metadata.Controllers[0].Routes) != 3 {
+		t.Fatalf("expected 1 controller with 3 routes")
+	}
+	controller := metadata.Controllers[0]
+	if controller.MaxBodySize == nil || *controller.MaxBodySize != 1<<20 {
+		t.Errorf("expected controller MaxBodySize of 1MB, got %v", controller.MaxBodySize)
+	}
+
+	for _, route := range controller.Routes {
+		switch route.HandlerName {
+		case "Upload":
+			if route.MaxBodySize == nil || *route.MaxBodySize != 1<<30 {
+				t.Errorf("expected Upload MaxBodySize of 1GB, got %v", route.MaxBodySize)
+			}
+			if !route.StreamsBody() {
+				t.Errorf("expected axon.BodyStream parameter to stream the body")
+			}
+		case "UploadRaw":
+			if route.MaxBodySize == nil || *route.MaxBodySize != 0 {
+				t.Errorf("expected UploadRaw MaxBodySize of 0, got %v", route.MaxBodySize)
+			}
+			if !route.StreamsBody() {
+				t.Errorf("expected io.Reader parameter to stream the body")
+			}
+		case "Create":
+			if route.MaxBodySize != nil {
+				t.Errorf("expected Create to inherit the controller MaxBodySize, got %d", *route.MaxBodySize)
+			}
+		}
+	}
+
+	// The body can be bound or streamed, not both
+	bothDir := t.TempDir()
+	bothFile := `package controllers
+
+import "io"
+
+type CreateFileRequest struct{}
+
+//axon::controller
+type FileController struct{}
+
+//axon::route POST /files
+func (c *FileController) Create(req CreateFileRequest, body io.Reader) error {
+	return nil
+}
+`
+	if err := os.WriteFile(filepath.Join(bothDir, "files.go"), []byte(bothFile), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	_, err = NewParser().ParseDirectory(bothDir)
+	if err == nil || !strings.Contains(err.Error(), "both a request body (CreateFileRequest) and a body stream") {
+		t.Errorf("expected error for a bound and streamed body, got %v", err)
+	}
+}
+
+func TestParser_CacheErrors_Integration(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		errorMsg   string
+	}{
+		{"non-GET route", "//axon::route POST /products -Cache=30s", "only GET responses can be cached"},
+		{"vary without cache", "//axon::route GET /products -CacheVary=principal", "-CacheVary without -Cache"},
+		{"coalesce on POST", "//axon::route POST /products -Coalesce", "only GET requests can be coalesced"},
+		{"key without coalesce", "//axon::route GET /products -CoalesceKey=X-Tenant", "-CoalesceKey without -Coalesce"},
+		{"idempotent GET", "//axon::route GET /products -Idempotent", "only POST, PUT, PATCH and DELETE requests take an Idempotency-Key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			testFile := "package controllers\n\n//axon::controller\ntype ProductController struct{}\n\n" +
+				tt.annotation + "\nfunc (c *ProductController) Products() error {\n\treturn nil\n}\n"
+			if err := os.WriteFile(filepath.Join(tempDir, "products.go"), []byte(testFile), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			_, err := NewParser().ParseDirectory(tempDir)
+			if err == nil {
+				t.Fatalf("expected error for %q", tt.annotation)
+			}
+			if !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error to contain %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestParser_ETag_Integration(t *testing.T) {
+	tempDir := t.TempDir()
+
+	testFile := `package controllers
+
+//axon::controller -ETag
+type UserController struct{}
+
+//axon::route GET /users/{id:int}
+func (c *UserController) GetUser(id int) (string, error) {
+	return "", nil
+}
+
+//axon::route DELETE /users/{id:int} -ETag=false
+func (c *UserController) DeleteUser(id int) error {
+	return nil
+}
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "users.go"), []byte(testFile), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	metadata, err := NewParser().ParseDirectory(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metadata.Controllers) != 1 || len(metadata.Controllers[0].Routes) != 2 {
+		t.Fatalf("expected 1 controller with 2 routes")
+	}
+
+	controller := metadata.Controllers[0]
+	if controller.ETag == nil || !*controller.ETag {
+		t.Errorf("expected the controller to enable ETags, got %v", controller.ETag)
+	}
+	for _, route := range controller.Routes {
+		switch route.HandlerName {
+		case "GetUser":
+			if route.ETag != nil {
+				t.Errorf("expected GetUser to inherit the controller setting, got %v", *route.ETag)
+			}
+		case "DeleteUser":
+			if route.ETag == nil || *route.ETag {
+				t.Errorf("expected DeleteUser to opt out, got %v", route.ETag)
+			}
+		}
+	}
+
+	invalid := strings.Replace(testFile, "//axon::route DELETE /users/{id:int} -ETag=false", "//axon::route POST /users/{id:int} -ETag", 1)
+	if err := os.WriteFile(filepath.Join(tempDir, "users.go"), []byte(invalid), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	if _, err := NewParser().ParseDirectory(tempDir); err == nil || !strings.Contains(err.Error(), "ETags apply to GET, HEAD, PUT, PATCH and DELETE") {
+		t.Errorf("expected an error for -ETag on POST, got %v", err)
+	}
+}
+
+func TestParser_LoggerParameter_Integration(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "axon_logger_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	testFile := `package controllers
+
+import "log/slog"
+
+//axon::controller
+type OrderController struct{}
+
+//axon::route GET /orders/{id:int}
+func (c *OrderController) GetOrder(id int, log *slog.Logger) error {
+	return nil
+}
+`
+
+	testFilePath := filepath.Join(tempDir, "orders.go")
+	if err := os.WriteFile(testFilePath, []byte(testFile), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	parser := NewParser()
+	metadata, err := parser.ParseDirectory(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(metadata.Controllers) != 1 || len(metadata.Controllers[0].Routes) != 1 {
+		t.Fatalf("expected 1 controller with 1 route")
+	}
+
+	var logger *models.Parameter
+	for i, param := range metadata.Controllers[0].Routes[0].Parameters {
+		if param.Name == "log" {
+			logger = &metadata.Controllers[0].Routes[0].Parameters[i]
+		}
+	}
+	if logger == nil {
+		t.Fatalf("expected logger parameter to be detected")
+	}
+	if logger.Source != models.ParameterSourceLogger {
+		t.Errorf("expected logger source, got %v", logger.Source)
+	}
+	if logger.Position != 1 {
+		t.Errorf("expected logger at position 1, got %d", logger.Position)
+	}
+}
+
+func TestParser_Uploads_Integration(t *testing.T) {
+	moduleDir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.25\n",
+		"uploads/uploads.go": `package uploads
+
+import "github.com/toyz/axon/pkg/axon"
+
+type GalleryUpload struct {
+	Title  string               ` + "`form:\"title\"`" + `
+	Photos []*axon.UploadedFile ` + "`form:\"photos\" maxCount:\"4\" maxSize:\"2MB\" accept:\"image/*\"`" + `
+}
+`,
+		"controllers/profile.go": `package controllers
+
+import (
+	"example.com/app/uploads"
+
+	"github.com/toyz/axon/pkg/axon"
+)
+
+type AvatarUpload struct {
+	Caption string             ` + "`form:\"caption\"`" + `
+	Avatar  *axon.UploadedFile ` + "`form:\"avatar\" maxSize:\"5MB\" accept:\"image/png, image/jpeg\" required:\"true\"`" + `
+}
+
+type ProfileUpdate struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+//axon::controller
+type ProfileController struct{}
+
+//axon::route POST /profile/avatar
+func (c *ProfileController) UploadAvatar(upload AvatarUpload) error {
+	return nil
+}
+
+//axon::route POST /profile/gallery
+func (c *ProfileController) UploadGallery(upload uploads.GalleryUpload) error {
+	return nil
+}
+
+//axon::route PUT /profile
+func (c *ProfileController) Update(update ProfileUpdate) error {
+	return nil
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(moduleDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	metadata, err := NewParser().ParseDirectory(filepath.Join(moduleDir, "controllers"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metadata.Controllers) != 1 || len(metadata.Controllers[0].Routes) != 3 {
+		t.Fatalf("expected 1 controller with 3 routes")
+	}
+
+	uploadsOf := func(route models.RouteMetadata) []models.UploadField {
+		for _, param := range route.Parameters {
+			if param.Source == models.ParameterSourceBody {
+				return param.Uploads
+			}
+		}
+		return nil
+	}
+
+	for _, route := range metadata.Controllers[0].Routes {
+		uploads := uploadsOf(route)
+		switch route.HandlerName {
+		case "UploadAvatar":
+			if len(uploads) != 1 {
+				t.Fatalf("expected 1 upload field, got %+v", uploads)
+			}
+			avatar := uploads[0]
+			if avatar.FieldName != "Avatar" || avatar.FormName != "avatar" || avatar.Multiple || avatar.MaxCount != 1 ||
+				avatar.MaxSize != 5<<20 || !avatar.Required || strings.Join(avatar.Accept, ",") != "image/png,image/jpeg" {
+				t.Errorf("unexpected avatar field: %+v", avatar)
+			}
+			if !route.ReadsMultipart() {
+				t.Errorf("expected UploadAvatar to read a multipart form")
+			}
+		case "UploadGallery":
+			if len(uploads) != 1 {
+				t.Fatalf("expected 1 upload field from the uploads package, got %+v", uploads)
+			}
+			photos := uploads[0]
+			if photos.FormName != "photos" || !photos.Multiple || photos.MaxCount != 4 || photos.MaxSize != 2<<20 || photos.Required {
+				t.Errorf("unexpected photos field: %+v", photos)
+			}
+		case "Update":
+			if len(uploads) != 0 || route.ReadsMultipart() {
+				t.Errorf("expected no upload fields for a JSON body, got %+v", uploads)
+			}
+		}
+	}
+}
+
+func TestParser_UploadErrors_Integration(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    string
+		errorMsg string
+	}{
+		{"missing form tag", "File *axon.UploadedFile", "missing form tag"},
+		{"invalid size", "File *axon.UploadedFile `form:\"file\" maxSize:\"5XB\"`", "invalid maxSize"},
+		{"count on single file", "File *axon.UploadedFile `form:\"file\" maxCount:\"2\"`", "maxCount only applies to []*axon.UploadedFile fields"},
+		{"invalid count", "Files []*axon.UploadedFile `form:\"files\" maxCount:\"0\"`", "invalid maxCount"},
+		{"invalid media type", "File *axon.UploadedFile `form:\"file\" accept:\"png\"`", "invalid media type \"png\""},
+		{"invalid required", "File *axon.UploadedFile `form:\"file\" required:\"yes\"`", "invalid required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			testFile := "package controllers\n\nimport \"github.com/toyz/axon/pkg/axon\"\n\n" +
+				"type FileUpload struct {\n\t" + tt.field + "\n}\n\n" +
+				"//axon::controller\ntype FileController struct{}\n\n" +
+				"//axon::route POST /files\nfunc (c *FileController) Upload(upload FileUpload) error {\n\treturn nil\n}\n"
+			if err := os.WriteFile(filepath.Join(tempDir, "files.go"), []byte(testFile), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			_, err := NewParser().ParseDirectory(tempDir)
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestParser_StreamReturnTypes_Integration(t *testing.T) {
+	tempDir := t.TempDir()
+
+	testFile := `package controllers
+
+import (
+	"iter"
+
+	"github.com/toyz/axon/pkg/axon"
+)
+
+type Event struct{}
+
+//axon::controller
+type EventController struct{}
+
+//axon::route GET /seq
+func (c *EventController) Seq() iter.Seq[Event] {
+	return nil
+}
+
+//axon::route GET /seq2
+func (c *EventController) Seq2() (iter.Seq2[*Event, error], error) {
+	return nil, nil
+}
+
+//axon::route GET /chan
+func (c *EventController) Chan(ctx axon.RequestContext) (<-chan []Event, error) {
+	return nil, nil
+}
+
+//axon::route GET /data
+func (c *EventController) Data() (Event, error) {
+	return Event{}, nil
+}
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "events.go"), []byte(testFile), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	metadata, err := NewParser().ParseDirectory(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metadata.Controllers) != 1 {
+		t.Fatalf("expected 1 controller, got %d", len(metadata.Controllers))
+	}
+
+	expected := map[string]models.ReturnTypeInfo{
+		"Seq":  {Type: models.ReturnTypeStream, DataType: "Event", Stream: models.StreamKindSeq},
+		"Seq2": {Type: models.ReturnTypeStream, DataType: "*Event", HasError: true, Stream: models.StreamKindSeq2},
+		"Chan": {Type: models.ReturnTypeStream, DataType: "[]Event", HasError: true, Stream: models.StreamKindChan},
+		"Data": {Type: models.ReturnTypeDataError, DataType: "Event", HasError: true},
+	}
+	if len(metadata.Controllers[0].Routes) != len(expected) {
+		t.Fatalf("expected %d routes, got %d", len(expected), len(metadata.Controllers[0].Routes))
+	}
+	for _, route := range metadata.Controllers[0].Routes {
+		want, ok := expected[route.HandlerName]
+		if !ok {
+			t.Errorf("unexpected route %s", route.HandlerName)
+			continue
+		}
+		if route.ReturnType != want {
+			t.Errorf("route %s: expected return type %+v, got %+v", route.HandlerName, want, route.ReturnType)
+		}
+	}
+}
+
+func TestParser_StreamReturnTypeErrors_Integration(t *testing.T) {
+	tests := []struct {
+		name       string
+		returnType string
+		errorMsg   string
+	}{
+		{"seq2 without error", "iter.Seq2[int, string]", "iter.Seq2 results must yield an error as their second value"},
+		{"send-only channel", "chan<- int", "channel results must be receivable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			testFile := "package controllers\n\nimport \"iter\"\n\nvar _ iter.Seq[int]\n\n" +
+				"//axon::controller\ntype EventController struct{}\n\n" +
+				"//axon::route GET /events\nfunc (c *EventController) Events() " + tt.returnType + " {\n\treturn nil\n}\n"
+			if err := os.WriteFile(filepath.Join(tempDir, "events.go"), []byte(testFile), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			_, err := NewParser().ParseDirectory(tempDir)
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error containing %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestParser_PatchParameter_Integration(t *testing.T) {
+	tempDir := t.TempDir()
+
+	testFile := `package controllers
+
+import "github.com/toyz/axon/pkg/axon"
+
+type User struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+//axon::controller
+type UserController struct{}
+
+//axon::route PATCH /users/{id:int}
+func (c *UserController) PatchUser(id int, patch axon.Patch[User]) (*User, error) {
+	return nil, nil
+}
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "users.go"), []byte(testFile), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	metadata, err := NewParser().ParseDirectory(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metadata.Controllers) != 1 || len(metadata.Controllers[0].Routes) != 1 {
+		t.Fatalf("expected 1 controller with 1 route")
+	}
+
+	var patch *models.Parameter
+	for i, param := range metadata.Controllers[0].Routes[0].Parameters {
+		if param.Name == "patch" {
+			patch = &metadata.Controllers[0].Routes[0].Parameters[i]
+		}
+	}
+	if patch == nil {
+		t.Fatalf("expected patch parameter to be detected")
+	}
+	if patch.Type != "axon.Patch[User]" {
+		t.Errorf("expected type axon.Patch[User], got %s", patch.Type)
+	}
+	if !patch.IsPatch() {
+		t.Errorf("expected patch parameter to be bound from a patch document")
+	}
+}
+
+func TestParser_Pagination_Integration(t *testing.T) {
+	tempDir := t.TempDir()
+
+	testFile := `package controllers
+
+import "github.com/toyz/axon/pkg/axon"
+
+type User struct{}
+
+//axon::controller
+type UserController struct{}
+
+//axon::route GET /users
+func (c *UserController) ListUsers(page axon.PageRequest) (axon.Page[*User], error) {
+	return axon.Page[*User]{}, nil
+}
+
+//axon::route GET /admins
+func (c *UserController) ListAdmins(page axon.PageRequest) *axon.Page[User] {
+	return nil
+}
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "users.go"), []byte(testFile), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	metadata, err := NewParser().ParseDirectory(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metadata.Controllers) != 1 || len(metadata.Controllers[0].Routes) != 2 {
+		t.Fatalf("expected 1 controller with 2 routes")
+	}
+
+	tests := map[string]struct {
+		dataType string
+		hasError bool
+	}{
+		"ListUsers":  {"*User", true},
+		"ListAdmins": {"User", false},
+	}
+	for _, route := range metadata.Controllers[0].Routes {
+		want := tests[route.HandlerName]
+		if route.ReturnType.Type != models.ReturnTypePage {
+			t.Errorf("%s: expected page return type, got %v", route.HandlerName, route.ReturnType.Type)
+		}
+		if route.ReturnType.DataType != want.dataType || route.ReturnType.HasError != want.hasError {
+			t.Errorf("%s: expected item type %s with error %v, got %s with error %v", route.HandlerName,
+				want.dataType, want.hasError, route.ReturnType.DataType, route.ReturnType.HasError)
+		}
+		if len(route.Parameters) != 1 || route.Parameters[0].Source != models.ParameterSourcePage {
+			t.Errorf("%s: expected a page request parameter, got %+v", route.HandlerName, route.Parameters)
+		}
+	}
+}
+
+func TestParser_ListQuery_Integration(t *testing.T) {
+	tempDir := t.TempDir()
+
+	testFile := `package controllers
+
+import "github.com/toyz/axon/pkg/axon"
+
+//axon::controller
+type UserController struct{}
+
+//axon::route GET /users -Sortable=created_at,name -Filterable=status,address.city
+func (c *UserController) ListUsers(query axon.ListQuery) ([]string, error) {
+	return nil, nil
+}
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "users.go"), []byte(testFile), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	metadata, err := NewParser().ParseDirectory(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metadata.Controllers) != 1 || len(metadata.Controllers[0].Routes) != 1 {
+		t.Fatalf("expected 1 controller with 1 route")
+	}
+	params := metadata.Controllers[0].Routes[0].Parameters
+	if len(params) != 1 || params[0].Source != models.ParameterSourceListQuery {
+		t.Fatalf("expected a list query parameter, got %+v", params)
+	}
+	if !slices.Equal(params[0].Sortable, []string{"created_at", "name"}) {
+		t.Errorf("expected sortable fields created_at, name, got %v", params[0].Sortable)
+	}
+	if !slices.Equal(params[0].Filterable, []string{"status", "address.city"}) {
+		t.Errorf("expected filterable fields status, address.city, got %v", params[0].Filterable)
+	}
+}
+
+func TestParser_ListQueryErrors_Integration(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		params     string
+		errorMsg   string
+	}{
+		{"no allow-list", "//axon::route GET /users", "query axon.ListQuery", "declares no fields to sort or filter by"},
+		{"sortable without list query", "//axon::route GET /users -Sortable=name", "", "-Sortable without an axon.ListQuery parameter"},
+		{"filterable without list query", "//axon::route GET /users -Filterable=status", "", "-Filterable without an axon.ListQuery parameter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			testFile := "package controllers\n\nimport \"github.com/toyz/axon/pkg/axon\"\n\nvar _ axon.ListQuery\n\n//axon::controller\ntype UserController struct{}\n\n" +
+				tt.annotation + "\nfunc (c *UserController) ListUsers(" + tt.params + ") error {\n\treturn nil\n}\n"
+			if err := os.WriteFile(filepath.Join(tempDir, "users.go"), []byte(testFile), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			_, err := NewParser().ParseDirectory(tempDir)
+			if err == nil {
+				t.Fatalf("expected error for %q", tt.annotation)
+			}
+			if !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error to contain %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestParser_Fields_Integration(t *testing.T) {
+	tempDir := t.TempDir()
+
+	testFile := `package controllers
+
+import (
+	"time"
+
+	"github.com/toyz/axon/pkg/axon"
+)
+
+type Role string
+
+type Audit struct {
+	CreatedAt time.Time ` + "`json:\"created_at\"`" + `
+}
+
+type Address struct {
+	City    string ` + "`json:\"city\"`" + `
+	Country string
+}
+
+type User struct {
+	Audit
+	ID       int               ` + "`json:\"id\"`" + `
+	Name     string            ` + "`json:\"name,omitempty\"`" + `
+	Password string            ` + "`json:\"-\"`" + `
+	secret   string
+	Role     Role              ` + "`json:\"role\"`" + `
+	Address  *Address          ` + "`json:\"address\"`" + `
+	Friends  []*User           ` + "`json:\"friends\"`" + `
+	Labels   map[string]string ` + "`json:\"labels\"`" + `
+}
+
+//axon::controller
+type UserController struct{}
+
+//axon::route GET /users/{id:int} -Fields
+func (c *UserController) GetUser(id int) (*User, error) {
+	return nil, nil
+}
+
+//axon::route GET /users -Fields=id,name,address.city
+func (c *UserController) ListUsers(page axon.PageRequest) (axon.Page[*User], error) {
+	return axon.Page[*User]{}, nil
+}
+
+//axon::route GET /stats -Fields
+func (c *UserController) Stats() (map[string]int, error) {
+	return nil, nil
+}
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "users.go"), []byte(testFile), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	metadata, err := NewParser().ParseDirectory(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metadata.Controllers) != 1 || len(metadata.Controllers[0].Routes) != 3 {
+		t.Fatalf("expected 1 controller with 3 routes")
+	}
+
+	expected := map[string][]string{
+		"GetUser": {
+			"created_at", "id", "name", "role",
+			"address", "address.city", "address.Country",
+			"friends", "friends.*",
+			"labels", "labels.*",
+		},
+		"ListUsers": {"id", "name", "address.city"},
+		"Stats":     nil,
+	}
+	for _, route := range metadata.Controllers[0].Routes {
+		if !route.Fields {
+			t.Errorf("route %s: expected -Fields to be set", route.HandlerName)
+		}
+		if !slices.Equal(route.FieldPaths, expected[route.HandlerName]) || (route.FieldPaths == nil) != (expected[route.HandlerName] == nil) {
+			t.Errorf("route %s: expected field paths %v, got %v", route.HandlerName, expected[route.HandlerName], route.FieldPaths)
+		}
+	}
+}
+
+func TestParser_FieldsErrors_Integration(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		results    string
+		errorMsg   string
+	}{
+		{"unknown path", "//axon::route GET /users -Fields=id,email", "(*User, error)", `-Fields path "email" is not a JSON field of *User`},
+		{"unknown nested path", "//axon::route GET /users -Fields=address.zip", "([]User, error)", `-Fields path "address.zip" is not a JSON field of []User`},
+		{"error only", "//axon::route GET /users -Fields", "error", "the handler only returns an error"},
+		{"stream", "//axon::route GET /users -Fields", "iter.Seq[User]", "-Fields cannot project streamed responses"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			testFile := "package controllers\n\nimport \"iter\"\n\nvar _ iter.Seq[int]\n\ntype Address struct {\n\tCity string `json:\"city\"`\n}\n\ntype User struct {\n\tID int `json:\"id\"`\n\tAddress Address `json:\"address\"`\n}\n\n//axon::controller\ntype UserController struct{}\n\n" +
+				tt.annotation + "\nfunc (c *UserController) ListUsers() " + tt.results + " {\n\tpanic(\"unused\")\n}\n"
+			if err := os.WriteFile(filepath.Join(tempDir, "users.go"), []byte(testFile), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			_, err := NewParser().ParseDirectory(tempDir)
+			if err == nil {
+				t.Fatalf("expected error for %q", tt.annotation)
+			}
+			if !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error to contain %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestParser_TypedResponse_Integration(t *testing.T) {
+	tempDir := t.TempDir()
+
+	testFile := `package controllers
+
+import "github.com/toyz/axon/pkg/axon"
+
+type User struct {
+	ID   int    ` + "`json:\"id\"`" + `
+	Name string ` + "`json:\"name\"`" + `
+}
+
+//axon::controller
+type UserController struct{}
+
+//axon::route POST /users -Fields
+func (c *UserController) CreateUser(user User) (*axon.TypedResponse[*User], error) {
+	return axon.TypedCreated(&user), nil
+}
+
+//axon::route GET /users
+func (c *UserController) ListUsers() (*axon.TypedResponse[map[string][]User], error) {
+	return nil, nil
+}
+
+//axon::route DELETE /users
+func (c *UserController) DeleteUsers() (*axon.Response, error) {
+	return axon.NoContent(), nil
+}
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "users.go"), []byte(testFile), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	metadata, err := NewParser().ParseDirectory(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metadata.Controllers) != 1 {
+		t.Fatalf("expected 1 controller, got %d", len(metadata.Controllers))
+	}
+
+	expected := map[string]models.ReturnTypeInfo{
+		"CreateUser":  {Type: models.ReturnTypeResponseError, DataType: "*User", HasError: true, UsesResponse: true},
+		"ListUsers":   {Type: models.ReturnTypeResponseError, DataType: "map[string][]User", HasError: true, UsesResponse: true},
+		"DeleteUsers": {Type: models.ReturnTypeResponseError, HasError: true, UsesResponse: true},
+	}
+	if len(metadata.Controllers[0].Routes) != len(expected) {
+		t.Fatalf("expected %d routes, got %d", len(expected), len(metadata.Controllers[0].Routes))
+	}
+	for _, route := range metadata.Controllers[0].Routes {
+		if want := expected[route.HandlerName]; route.ReturnType != want {
+			t.Errorf("route %s: expected return type %+v, got %+v", route.HandlerName, want, route.ReturnType)
+		}
+		if route.HandlerName == "CreateUser" && !slices.Equal(route.FieldPaths, []string{"id", "name"}) {
+			t.Errorf("expected the fields of the typed body, got %v", route.FieldPaths)
+		}
+	}
+}
+
+func TestParser_TypedResponseByValue_Integration(t *testing.T) {
+	tempDir := t.TempDir()
+
+	testFile := `package controllers
+
+import "github.com/toyz/axon/pkg/axon"
+
+//axon::controller
+type UserController struct{}
+
+//axon::route GET /users
+func (c *UserController) ListUsers() (axon.TypedResponse[[]string], error) {
+	return axon.TypedResponse[[]string]{}, nil
+}
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "users.go"), []byte(testFile), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	_, err := NewParser().ParseDirectory(tempDir)
+	if err == nil || !strings.Contains(err.Error(), "use *axon.TypedResponse[[]string]") {
+		t.Errorf("expected an error asking for a pointer, got %v", err)
+	}
+}
+
+func TestParser_ReturnSignatures_Integration(t *testing.T) {
+	tempDir := t.TempDir()
+
+	testFile := `package controllers
+
+import "github.com/toyz/axon/pkg/axon"
+
+type Order struct{}
+
+//axon::controller
+type OrderController struct{}
+
+//axon::route POST /orders
+func (c *OrderController) CreateOrder() (*Order, int, error) {
+	return nil, 201, nil
+}
+
+//axon::route GET /orders/latest
+func (c *OrderController) LatestOrder() Order {
+	return Order{}
+}
+
+//axon::route GET /orders/summary
+func (c *OrderController) Summary() (summary, status string, err error) {
+	return "", "", nil
+}
+
+//axon::route GET /orders/export
+func (c *OrderController) ExportOrders() *axon.Response {
+	return nil
+}
+
+//axon::route GET /orders/typed
+func (c *OrderController) TypedOrders() *axon.TypedResponse[[]Order] {
+	return nil
+}
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "orders.go"), []byte(testFile), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	_, err := NewParser().ParseDirectory(tempDir)
+	if err == nil || !strings.Contains(err.Error(), "unsupported return signature (string, string, error)") {
+		t.Fatalf("expected the named results of Summary to be rejected, got %v", err)
+	}
+
+	testFile = strings.Replace(testFile, "(summary, status string, err error)", "(summary string, status int, err error)", 1)
+	if err := os.WriteFile(filepath.Join(tempDir, "orders.go"), []byte(testFile), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	metadata, err := NewParser().ParseDirectory(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]models.ReturnTypeInfo{
+		"CreateOrder":  {Type: models.ReturnTypeDataError, DataType: "*Order", HasError: true, HasStatus: true},
+		"LatestOrder":  {Type: models.ReturnTypeDataError, DataType: "Order"},
+		"Summary":      {Type: models.ReturnTypeDataError, DataType: "string", HasError: true, HasStatus: true},
+		"ExportOrders": {Type: models.ReturnTypeResponseError, UsesResponse: true},
+		"TypedOrders":  {Type: models.ReturnTypeResponseError, DataType: "[]Order", UsesResponse: true},
+	}
+	if len(metadata.Controllers) != 1 || len(metadata.Controllers[0].Routes) != len(expected) {
+		t.Fatalf("expected 1 controller with %d routes", len(expected))
+	}
+	for _, route := range metadata.Controllers[0].Routes {
+		if want := expected[route.HandlerName]; route.ReturnType != want {
+			t.Errorf("route %s: expected return type %+v, got %+v", route.HandlerName, want, route.ReturnType)
+		}
+	}
+}
+
+func TestParser_UnsupportedReturnSignatures_Integration(t *testing.T) {
+	tests := []struct {
+		name     string
+		results  string
+		errorMsg string
+	}{
+		{"no results", "", "unsupported return signature ()"},
+		{"second result not an error", "(string, int)", "unsupported return signature (string, int)"},
+		{"error first", "(error, string)", "unsupported return signature (error, string)"},
+		{"two errors", "(error, error)", "the error is the last result"},
+		{"status not an int", "(string, string, error)", "unsupported return signature (string, string, error)"},
+		{"status with a response", "(*axon.Response, int, error)", "responses carry their own status code"},
+		{"status with a typed response", "(*axon.TypedResponse[string], int, error)", "responses carry their own status code"},
+		{"status with a page", "(axon.Page[string], int, error)", "pages and streams are always sent with 200"},
+		{"status with a page pointer", "(*axon.Page[string], int, error)", "pages and streams are always sent with 200"},
+		{"status with a sequence", "(iter.Seq[string], int, error)", "pages and streams are always sent with 200"},
+		{"status with a channel", "(<-chan string, int, error)", "pages and streams are always sent with 200"},
+		{"status with a pair sequence", "(iter.Seq2[string, error], int, error)", "pages and streams are always sent with 200"},
+		{"response by value", "(axon.Response, error)", "use *axon.Response"},
+		{"four results", "(string, int, bool, error)", "unsupported return signature (string, int, bool, error)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			testFile := "package controllers\n\nimport \"github.com/toyz/axon/pkg/axon\"\n\nvar _ axon.Response\n\n//axon::controller\ntype OrderController struct{}\n\n//axon::route GET /orders\nfunc (c *OrderController) ListOrders() " +
+				tt.results + " {\n\tpanic(\"unused\")\n}\n"
+			if err := os.WriteFile(filepath.Join(tempDir, "orders.go"), []byte(testFile), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			_, err := NewParser().ParseDirectory(tempDir)
+			if err == nil {
+				t.Fatalf("expected error for %s", tt.results)
+			}
+			if !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("expected error to contain %q, got %v", tt.errorMsg, err)
+			}
+			if !strings.Contains(err.Error(), "ListOrders") {
+				t.Errorf("expected the error to name the handler, got %v", err)
+			}
+		})
+	}
+}
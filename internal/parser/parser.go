@@ -354,7 +354,14 @@ func (p *Parser) processAnnotations(annotations []models.Annotation, metadata *m
 				WithDependencies(annotation.Dependencies...).
 				WithPriority(annotation.GetInt("Priority", 100)).
 				WithMiddlewares(annotation.GetStringSlice("Middleware")...).
+				WithAuthorization(annotation.GetStringSlice("Roles"), annotation.GetStringSlice("Permissions")).
 				BuildController(annotation.GetString("Prefix", ""), []models.RouteMetadata{})
+			controller.ETag = optionalBool(annotation, "ETag")
+			maxBodySize, err := optionalByteSize(annotation, "MaxBodySize")
+			if err != nil {
+				return fmt.Errorf("controller %s: %w", annotation.Target, err)
+			}
+			controller.MaxBodySize = maxBodySize
 			metadata.Controllers = append(metadata.Controllers, controller)
 
 			// If this controller also has an interface annotation, generate interface
@@ -392,6 +399,42 @@ func (p *Parser) processAnnotations(annotations []models.Annotation, metadata *m
 				Path:        annotation.GetString("path"),
 				HandlerName: annotation.Target,                  // Keep full target for now, will be processed later
 				Priority:    annotation.GetInt("Priority", 100), // Default priority 100
+				Roles:       annotation.GetStringSlice("Roles"),
+				Permissions: annotation.GetStringSlice("Permissions"),
+				NoCSRF:      annotation.GetBool("NoCSRF", false),
+				CSPPolicy:   annotation.GetString("CSP"),
+				CacheTTL:    annotation.GetString("Cache"),
+				CacheVary:   annotation.GetStringSlice("CacheVary"),
+				ETag:        optionalBool(annotation, "ETag"),
+				Coalesce:    annotation.GetBool("Coalesce", false),
+				CoalesceKey: annotation.GetStringSlice("CoalesceKey"),
+				Idempotent:  annotation.GetBool("Idempotent", false),
+				NoCompress:  !annotation.GetBool("Compress", true),
+			}
+
+			maxBodySize, err := optionalByteSize(annotation, "MaxBodySize")
+			if err != nil {
+				return fmt.Errorf("route %s: %w", annotation.Target, err)
+			}
+			route.MaxBodySize = maxBodySize
+
+			if route.CacheTTL != "" && route.Method != "GET" {
+				return fmt.Errorf("route %s uses -Cache on a %s route; only GET responses can be cached", annotation.Target, route.Method)
+			}
+			if len(route.CacheVary) > 0 && route.CacheTTL == "" {
+				return fmt.Errorf("route %s uses -CacheVary without -Cache", annotation.Target)
+			}
+			if route.Coalesce && route.Method != "GET" {
+				return fmt.Errorf("route %s uses -Coalesce on a %s route; only GET requests can be coalesced", annotation.Target, route.Method)
+			}
+			if len(route.CoalesceKey) > 0 && !route.Coalesce {
+				return fmt.Errorf("route %s uses -CoalesceKey without -Coalesce", annotation.Target)
+			}
+			if route.Idempotent && route.Method != "POST" && route.Method != "PUT" && route.Method != "PATCH" && route.Method != "DELETE" {
+				return fmt.Errorf("route %s uses -Idempotent on a %s route; only POST, PUT, PATCH and DELETE requests take an Idempotency-Key", annotation.Target, route.Method)
+			}
+			if route.ETag != nil && *route.ETag && !route.SupportsETag() {
+				return fmt.Errorf("route %s uses -ETag on a %s route; ETags apply to GET, HEAD, PUT, PATCH and DELETE", annotation.Target, route.Method)
 			}
 
 			// Parse path parameters from the route path
@@ -408,6 +451,20 @@ func (p *Parser) processAnnotations(annotations []models.Annotation, metadata *m
 					return fmt.Errorf("failed to analyze handler signature for %s: %w", annotation.Target, err)
 				}
 
+				// Body structs with upload fields are read as multipart forms
+				for i, param := range signatureParams {
+					if param.Source != models.ParameterSourceBody {
+						continue
+					}
+					if structType := p.resolveStructType(param.Type, file, fileMap, metadata); structType != nil {
+						uploads, err := p.analyzeUploadFields(structType)
+						if err != nil {
+							return fmt.Errorf("route %s body %s: %w", annotation.Target, param.Type, err)
+						}
+						signatureParams[i].Uploads = uploads
+					}
+				}
+
 				// Merge path parameters with signature parameters
 				allParams = p.mergeParameters(pathParams, signatureParams)
 			} else {
@@ -415,7 +472,38 @@ func (p *Parser) processAnnotations(annotations []models.Annotation, metadata *m
 				allParams = pathParams
 			}
 
+			// List queries are checked against the route's allow-lists
+			sortable, filterable := annotation.GetStringSlice("Sortable"), annotation.GetStringSlice("Filterable")
+			takesListQuery := false
+			for i, param := range allParams {
+				if param.Source != models.ParameterSourceListQuery {
+					continue
+				}
+				if len(sortable) == 0 && len(filterable) == 0 {
+					return fmt.Errorf("route %s takes an axon.ListQuery but declares no fields to sort or filter by; add -Sortable and/or -Filterable", annotation.Target)
+				}
+				allParams[i].Sortable, allParams[i].Filterable = sortable, filterable
+				takesListQuery = true
+			}
+			if !takesListQuery && len(sortable) > 0 {
+				return fmt.Errorf("route %s uses -Sortable without an axon.ListQuery parameter", annotation.Target)
+			}
+			if !takesListQuery && len(filterable) > 0 {
+				return fmt.Errorf("route %s uses -Filterable without an axon.ListQuery parameter", annotation.Target)
+			}
+
 			route.Parameters = allParams
+			if route.StreamsBody() {
+				for _, param := range allParams {
+					if param.Source == models.ParameterSourceBody {
+						return fmt.Errorf("route %s takes both a request body (%s) and a body stream; the body can only be read once", annotation.Target, param.Type)
+					}
+				}
+			}
+			// Idempotency fingerprints hash the whole body before the handler runs
+			if route.Idempotent && (route.StreamsBody() || route.ReadsMultipart()) {
+				return fmt.Errorf("route %s uses -Idempotent with a streamed or upload body; the body is hashed to tell requests apart, which would buffer it in memory", annotation.Target)
+			}
 
 			// Analyze return type
 			if file := fileMap[annotation.FileName]; file != nil {
@@ -423,21 +511,17 @@ func (p *Parser) processAnnotations(annotations []models.Annotation, metadata *m
 				if err != nil {
 					return fmt.Errorf("failed to analyze return type for %s: %w", annotation.Target, err)
 				}
+				route.ReturnType = returnType
+			}
 
-				// Map string return type to enum
-				var returnTypeEnum models.ReturnType
-				switch returnType {
-				case "error":
-					returnTypeEnum = models.ReturnTypeError
-				case "response-error":
-					returnTypeEnum = models.ReturnTypeResponseError
-				case "data-error":
-					returnTypeEnum = models.ReturnTypeDataError
-				default:
-					returnTypeEnum = models.ReturnTypeDataError // Default assumption
+			// Sparse fieldsets are checked against the JSON fields of the response type
+			if annotation.HasParameter("Fields") && annotation.GetBool("Fields", true) {
+				fieldPaths, err := p.responseFieldPaths(route, annotation.GetStringSlice("Fields"), fileMap[annotation.FileName], fileMap, metadata)
+				if err != nil {
+					return fmt.Errorf("route %s: %w", annotation.Target, err)
 				}
-
-				route.ReturnType = models.ReturnTypeInfo{Type: returnTypeEnum}
+				route.Fields = true
+				route.FieldPaths = fieldPaths
 			}
 
 			// Parse middleware and validate
@@ -838,7 +922,21 @@ func (p *Parser) getTypeString(expr ast.Expr) string {
 	case *ast.InterfaceType:
 		return "interface{}"
 	case *ast.ChanType:
+		switch t.Dir {
+		case ast.RECV:
+			return "<-chan " + p.getTypeString(t.Value)
+		case ast.SEND:
+			return "chan<- " + p.getTypeString(t.Value)
+		}
 		return "chan " + p.getTypeString(t.Value)
+	case *ast.IndexExpr:
+		return p.getTypeString(t.X) + "[" + p.getTypeString(t.Index) + "]"
+	case *ast.IndexListExpr:
+		var indices []string
+		for _, index := range t.Indices {
+			indices = append(indices, p.getTypeString(index))
+		}
+		return p.getTypeString(t.X) + "[" + strings.Join(indices, ", ") + "]"
 	case *ast.FuncType:
 		return p.getFuncTypeString(t)
 	case *ast.Ellipsis:
@@ -889,6 +987,28 @@ func (p *Parser) getFuncTypeString(funcType *ast.FuncType) string {
 	return result
 }
 
+// optionalBool returns a boolean flag's value, or nil if the annotation does not set it
+func optionalBool(annotation models.Annotation, name string) *bool {
+	if !annotation.HasParameter(name) {
+		return nil
+	}
+	value := annotation.GetBool(name)
+	return &value
+}
+
+// optionalByteSize returns the size in bytes of a size flag such as
+// -MaxBodySize=10MB, or nil if the annotation does not set it
+func optionalByteSize(annotation models.Annotation, name string) (*int64, error) {
+	if !annotation.HasParameter(name) {
+		return nil, nil
+	}
+	size, err := axon.ParseByteSize(annotation.GetString(name))
+	if err != nil {
+		return nil, fmt.Errorf("-%s: %w", name, err)
+	}
+	return &size, nil
+}
+
 // parsePathParameters extracts path parameters from a route path
 func (p *Parser) parsePathParameters(path string) ([]models.Parameter, error) {
 	var parameters []models.Parameter
@@ -1062,6 +1182,16 @@ func (p *Parser) analyzeHandlerSignature(file *ast.File, controllerName, methodN
 												source = models.ParameterSourceContext
 											} else if paramType == "axon.QueryMap" {
 												source = models.ParameterSourceQuery
+											} else if paramType == "axon.Session" {
+												source = models.ParameterSourceSession
+											} else if paramType == "*slog.Logger" {
+												source = models.ParameterSourceLogger
+											} else if paramType == "axon.BodyStream" || paramType == "io.Reader" {
+												source = models.ParameterSourceBodyStream
+											} else if paramType == "axon.PageRequest" {
+												source = models.ParameterSourcePage
+											} else if paramType == "axon.ListQuery" {
+												source = models.ParameterSourceListQuery
 											}
 
 											p := models.Parameter{
@@ -1088,8 +1218,8 @@ func (p *Parser) analyzeHandlerSignature(file *ast.File, controllerName, methodN
 }
 
 // analyzeReturnType analyzes a handler method's return type
-func (p *Parser) analyzeReturnType(file *ast.File, controllerName, methodName string) (string, error) {
-	var returnType string
+func (p *Parser) analyzeReturnType(file *ast.File, controllerName, methodName string) (models.ReturnTypeInfo, error) {
+	var results []*ast.Field
 
 	// Find the method in the AST
 	ast.Inspect(file, func(n ast.Node) bool {
@@ -1101,27 +1231,8 @@ func (p *Parser) analyzeReturnType(file *ast.File, controllerName, methodName st
 					if starExpr, ok := funcDecl.Recv.List[0].Type.(*ast.StarExpr); ok {
 						if ident, ok := starExpr.X.(*ast.Ident); ok && ident.Name == controllerName {
 							// This is our method - analyze return types
-							if funcDecl.Type.Results != nil && len(funcDecl.Type.Results.List) > 0 {
-								results := funcDecl.Type.Results.List
-								
-								if len(results) == 1 {
-									// Single return type
-									returnType = p.getTypeString(results[0].Type)
-								} else if len(results) == 2 {
-									// Two return types - check for (*axon.Response, error) pattern
-									firstType := p.getTypeString(results[0].Type)
-									secondType := p.getTypeString(results[1].Type)
-									
-									if (firstType == "*axon.Response" || firstType == "axon.Response") && secondType == "error" {
-										returnType = "response-error"
-									} else {
-										// Default to data-error pattern for (data, error)
-										returnType = "data-error"
-									}
-								} else {
-									// More than 2 return types - use first one
-									returnType = p.getTypeString(results[0].Type)
-								}
+							if funcDecl.Type.Results != nil {
+								results = funcDecl.Type.Results.List
 							}
 							return false // Stop searching
 						}
@@ -1132,11 +1243,123 @@ func (p *Parser) analyzeReturnType(file *ast.File, controllerName, methodName st
 		return true
 	})
 
-	if returnType == "" {
-		return "void", nil
+	// Results declared together, as in (a, b int), are one field
+	var types []string
+	var exprs []ast.Expr
+	for _, result := range results {
+		for range max(len(result.Names), 1) {
+			types = append(types, p.getTypeString(result.Type))
+			exprs = append(exprs, result.Type)
+		}
+	}
+	withError := len(types) == 1 || (len(types) == 2 && types[1] == "error")
+
+	// Handlers returning a sequence, optionally with an error, stream it
+	if withError {
+		kind, elemType, err := p.analyzeStreamType(exprs[0])
+		if err != nil {
+			return models.ReturnTypeInfo{}, err
+		}
+		if kind != models.StreamKindNone {
+			return models.ReturnTypeInfo{
+				Type:     models.ReturnTypeStream,
+				DataType: elemType,
+				HasError: len(types) == 2,
+				Stream:   kind,
+			}, nil
+		}
+	}
+
+	// Handlers returning an axon.Page, optionally with an error, are paginated
+	if withError {
+		if itemType, ok := strings.CutPrefix(strings.TrimPrefix(types[0], "*"), "axon.Page["); ok {
+			return models.ReturnTypeInfo{
+				Type:     models.ReturnTypePage,
+				DataType: strings.TrimSuffix(itemType, "]"),
+				HasError: len(types) == 2,
+			}, nil
+		}
+	}
+
+	switch {
+	case len(types) == 1 && types[0] == "error":
+		return models.ReturnTypeInfo{Type: models.ReturnTypeError, HasError: true}, nil
+	case withError:
+		return dataReturnType(types)
+	case len(types) == 3 && types[1] == "int" && types[2] == "error":
+		// (T, int, error) returns the status code of the data, which cannot
+		// be a page or a stream
+		if kind, _, err := p.analyzeStreamType(exprs[0]); err != nil || kind != models.StreamKindNone || isPageType(types[0]) {
+			return models.ReturnTypeInfo{}, fmt.Errorf("unsupported return signature %s: pages and streams are always sent with 200, so return (%s, error)", formatResults(types), types[0])
+		}
+		returnType, err := dataReturnType(types[:1])
+		if err != nil {
+			return models.ReturnTypeInfo{}, err
+		}
+		if returnType.UsesResponse {
+			return models.ReturnTypeInfo{}, fmt.Errorf("unsupported return signature %s: responses carry their own status code, so return (%s, error)", formatResults(types), types[0])
+		}
+		returnType.HasError, returnType.HasStatus = true, true
+		return returnType, nil
+	}
+	return models.ReturnTypeInfo{}, fmt.Errorf("unsupported return signature %s: handlers return error, T, (T, error), (T, int, error), (*axon.Response, error), (*axon.TypedResponse[T], error), (axon.Page[T], error) or a stream", formatResults(types))
+}
+
+// dataReturnType describes a handler returning data, a response or a typed
+// response, optionally with an error: the first of types, and error if
+// there are two
+func dataReturnType(types []string) (models.ReturnTypeInfo, error) {
+	hasError := len(types) == 2
+	switch result := types[0]; {
+	case result == "error":
+		return models.ReturnTypeInfo{}, fmt.Errorf("unsupported return signature %s: the error is the last result", formatResults(types))
+	case result == "*axon.Response":
+		return models.ReturnTypeInfo{Type: models.ReturnTypeResponseError, HasError: hasError, UsesResponse: true}, nil
+	case strings.HasPrefix(result, "*axon.TypedResponse["):
+		// Typed responses are rendered like responses, and record their body type
+		bodyType := strings.TrimSuffix(strings.TrimPrefix(result, "*axon.TypedResponse["), "]")
+		return models.ReturnTypeInfo{Type: models.ReturnTypeResponseError, DataType: bodyType, HasError: hasError, UsesResponse: true}, nil
+	case result == "axon.Response" || strings.HasPrefix(result, "axon.TypedResponse["):
+		return models.ReturnTypeInfo{}, fmt.Errorf("unsupported return signature %s: handlers return responses by pointer, use *%s", formatResults(types), result)
+	}
+	return models.ReturnTypeInfo{Type: models.ReturnTypeDataError, DataType: types[0], HasError: hasError}, nil
+}
+
+// isPageType reports whether a result type is an axon.Page or a pointer to one
+func isPageType(result string) bool {
+	return strings.HasPrefix(strings.TrimPrefix(result, "*"), "axon.Page[")
+}
+
+// formatResults formats result types as they are written in a signature
+func formatResults(types []string) string {
+	if len(types) == 1 {
+		return types[0]
 	}
+	return "(" + strings.Join(types, ", ") + ")"
+}
 
-	return returnType, nil
+// analyzeStreamType returns the sequence kind and element type of a handler
+// result streamed to the client: iter.Seq[T], iter.Seq2[T, error] or <-chan T
+func (p *Parser) analyzeStreamType(expr ast.Expr) (models.StreamKind, string, error) {
+	switch t := expr.(type) {
+	case *ast.IndexExpr:
+		if p.getTypeString(t.X) == "iter.Seq" {
+			return models.StreamKindSeq, p.getTypeString(t.Index), nil
+		}
+	case *ast.IndexListExpr:
+		if p.getTypeString(t.X) == "iter.Seq2" {
+			if len(t.Indices) != 2 || p.getTypeString(t.Indices[1]) != "error" {
+				return models.StreamKindNone, "", fmt.Errorf("cannot stream %s: iter.Seq2 results must yield an error as their second value (iter.Seq2[T, error])", p.getTypeString(expr))
+			}
+			return models.StreamKindSeq2, p.getTypeString(t.Indices[0]), nil
+		}
+	case *ast.ChanType:
+		if t.Dir == ast.SEND {
+			return models.StreamKindNone, "", fmt.Errorf("cannot stream %s: channel results must be receivable (<-chan T)", p.getTypeString(expr))
+		}
+		return models.StreamKindChan, p.getTypeString(t.Value), nil
+	}
+	return models.StreamKindNone, "", nil
 }
 
 // mergeParameters merges path parameters with signature parameters
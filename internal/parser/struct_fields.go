@@ -0,0 +1,359 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/toyz/axon/internal/models"
+	"github.com/toyz/axon/pkg/axon"
+)
+
+// resolveStructType finds the struct declaration of a type used in file, such
+// as a handler's body type. Types of the package are looked up in fileMap,
+// qualified types (pkg.Type) in the package they are imported from when it
+// belongs to the module. Returns nil if the type is not a struct declared in
+// the module.
+func (p *Parser) resolveStructType(typeName string, file *ast.File, fileMap map[string]*ast.File, metadata *models.PackageMetadata) *ast.StructType {
+	structType, _, _ := p.resolveStructDecl(typeName, file, fileMap, metadata)
+	return structType
+}
+
+// resolveStructDecl is resolveStructType that also returns the file declaring
+// the struct and the files of its package, which its field types resolve in
+func (p *Parser) resolveStructDecl(typeName string, file *ast.File, fileMap map[string]*ast.File, metadata *models.PackageMetadata) (*ast.StructType, *ast.File, map[string]*ast.File) {
+	typeName = strings.TrimPrefix(typeName, "*")
+
+	qualifier, name, qualified := strings.Cut(typeName, ".")
+	if !qualified {
+		structType, declFile := findStructDecl(fileMap, typeName)
+		return structType, declFile, fileMap
+	}
+
+	dir := p.importDirectory(file, qualifier, metadata)
+	if dir == "" {
+		return nil, nil, nil
+	}
+	files, _, err := p.parseDirectoryFiles(dir)
+	if err != nil {
+		p.reporter.Debug("Warning: failed to parse package %s for type %s: %v", dir, typeName, err)
+		return nil, nil, nil
+	}
+	structType, declFile := findStructDecl(files, name)
+	return structType, declFile, files
+}
+
+// importDirectory returns the directory of the package file imports as
+// qualifier, or "" if it is not part of the module
+func (p *Parser) importDirectory(file *ast.File, qualifier string, metadata *models.PackageMetadata) string {
+	if metadata.ModulePath == "" || metadata.ModuleRoot == "" {
+		return ""
+	}
+	for _, imp := range p.ExtractImports(file) {
+		name := imp.Alias
+		if name == "" {
+			name = filepath.Base(imp.Path)
+		}
+		if name != qualifier {
+			continue
+		}
+		if imp.Path == metadata.ModulePath {
+			return metadata.ModuleRoot
+		}
+		if rel, ok := strings.CutPrefix(imp.Path, metadata.ModulePath+"/"); ok {
+			return filepath.Join(metadata.ModuleRoot, filepath.FromSlash(rel))
+		}
+		return ""
+	}
+	return ""
+}
+
+// isStandardLibraryImport reports whether qualifier names a standard library
+// package imported by file
+func (p *Parser) isStandardLibraryImport(file *ast.File, qualifier string) bool {
+	for _, imp := range p.ExtractImports(file) {
+		name := imp.Alias
+		if name == "" {
+			name = filepath.Base(imp.Path)
+		}
+		if name == qualifier {
+			first, _, _ := strings.Cut(imp.Path, "/")
+			return !strings.Contains(first, ".")
+		}
+	}
+	return false
+}
+
+// findStructType returns the struct type declared as name in files
+func findStructType(files map[string]*ast.File, name string) *ast.StructType {
+	structType, _ := findStructDecl(files, name)
+	return structType
+}
+
+// findStructDecl returns the struct type declared as name in files and the
+// file declaring it
+func findStructDecl(files map[string]*ast.File, name string) (*ast.StructType, *ast.File) {
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec, ok := spec.(*ast.TypeSpec)
+				if !ok || typeSpec.Name.Name != name {
+					continue
+				}
+				structType, _ := typeSpec.Type.(*ast.StructType)
+				return structType, file
+			}
+		}
+	}
+	return nil, nil
+}
+
+// structTag returns the tag of a struct field
+func structTag(field *ast.Field) reflect.StructTag {
+	if field.Tag == nil {
+		return ""
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return ""
+	}
+	return reflect.StructTag(tag)
+}
+
+// analyzeUploadFields returns the *axon.UploadedFile and []*axon.UploadedFile
+// fields of a body struct, validating their form, maxCount, maxSize, accept
+// and required tags
+func (p *Parser) analyzeUploadFields(structType *ast.StructType) ([]models.UploadField, error) {
+	var uploads []models.UploadField
+	for _, field := range structType.Fields.List {
+		fieldType := p.getTypeString(field.Type)
+		if fieldType != "*axon.UploadedFile" && fieldType != "[]*axon.UploadedFile" {
+			continue
+		}
+		for _, name := range field.Names {
+			upload, err := parseUploadField(name.Name, fieldType == "[]*axon.UploadedFile", structTag(field))
+			if err != nil {
+				return nil, fmt.Errorf("upload field %s: %w", name.Name, err)
+			}
+			uploads = append(uploads, upload)
+		}
+	}
+	return uploads, nil
+}
+
+// parseUploadField builds an upload field from its struct tag
+func parseUploadField(fieldName string, multiple bool, tag reflect.StructTag) (models.UploadField, error) {
+	upload := models.UploadField{
+		FieldName: fieldName,
+		Multiple:  multiple,
+	}
+
+	formName, _, _ := strings.Cut(tag.Get("form"), ",")
+	if formName == "" || formName == "-" {
+		return upload, fmt.Errorf(`missing form tag (e.g., form:"avatar")`)
+	}
+	upload.FormName = formName
+
+	upload.MaxCount = 1
+	if multiple {
+		upload.MaxCount = 0
+	}
+	if value, ok := tag.Lookup("maxCount"); ok {
+		if !multiple {
+			return upload, fmt.Errorf("maxCount only applies to []*axon.UploadedFile fields")
+		}
+		count, err := strconv.Atoi(value)
+		if err != nil || count <= 0 {
+			return upload, fmt.Errorf("invalid maxCount %q: must be a positive number", value)
+		}
+		upload.MaxCount = count
+	}
+
+	if value, ok := tag.Lookup("maxSize"); ok {
+		size, err := axon.ParseByteSize(value)
+		if err != nil {
+			return upload, fmt.Errorf("invalid maxSize: %w", err)
+		}
+		upload.MaxSize = size
+	}
+
+	if value, ok := tag.Lookup("accept"); ok {
+		for _, mediaType := range strings.Split(value, ",") {
+			mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+			major, minor, ok := strings.Cut(mediaType, "/")
+			if !ok || major == "" || minor == "" || strings.ContainsAny(mediaType, " ;") {
+				return upload, fmt.Errorf("invalid media type %q in accept (e.g., image/png, image/*)", mediaType)
+			}
+			upload.Accept = append(upload.Accept, mediaType)
+		}
+	}
+
+	if value, ok := tag.Lookup("required"); ok {
+		required, err := strconv.ParseBool(value)
+		if err != nil {
+			return upload, fmt.Errorf("invalid required %q: must be true or false", value)
+		}
+		upload.Required = required
+	}
+
+	return upload, nil
+}
+
+// jsonFieldPaths returns the dotted JSON paths of a response type, as
+// clients select them with -Fields. Slices are looked through, as their items
+// are projected one by one. A path ending in .* stands for any path below a
+// member whose members are not known: maps, interfaces, recursive types and
+// types from other modules. Returns nil if the type is not a struct
+// declared in the module.
+func (p *Parser) jsonFieldPaths(typeName string, file *ast.File, fileMap map[string]*ast.File, metadata *models.PackageMetadata) []string {
+	for strings.HasPrefix(typeName, "*") || strings.HasPrefix(typeName, "[]") {
+		typeName = strings.TrimPrefix(strings.TrimPrefix(typeName, "*"), "[]")
+	}
+	structType, declFile, files := p.resolveStructDecl(typeName, file, fileMap, metadata)
+	if structType == nil {
+		return nil
+	}
+	paths := []string{}
+	p.collectJSONFieldPaths(structType, declFile, files, metadata, "", map[*ast.StructType]bool{}, &paths)
+	return paths
+}
+
+// collectJSONFieldPaths adds the JSON paths of the members of a struct,
+// prefixed with prefix, following the naming rules of encoding/json
+func (p *Parser) collectJSONFieldPaths(structType *ast.StructType, file *ast.File, files map[string]*ast.File, metadata *models.PackageMetadata, prefix string, visiting map[*ast.StructType]bool, paths *[]string) {
+	visiting[structType] = true
+	defer delete(visiting, structType)
+
+	for _, field := range structType.Fields.List {
+		name, _, _ := strings.Cut(structTag(field).Get("json"), ",")
+		if name == "-" && !strings.HasPrefix(structTag(field).Get("json"), "-,") {
+			continue
+		}
+
+		names := make([]string, 0, len(field.Names))
+		for _, ident := range field.Names {
+			names = append(names, ident.Name)
+		}
+		if len(field.Names) == 0 {
+			// Untagged embedded structs have their members promoted
+			embedded := field.Type
+			if star, ok := embedded.(*ast.StarExpr); ok {
+				embedded = star.X
+			}
+			if name == "" {
+				if nested, nestedFile, nestedFiles := p.resolveStructDecl(p.getTypeString(embedded), file, files, metadata); nested != nil && !visiting[nested] {
+					p.collectJSONFieldPaths(nested, nestedFile, nestedFiles, metadata, prefix, visiting, paths)
+					continue
+				}
+			}
+			typeName := p.getTypeString(embedded)
+			names = append(names, typeName[strings.LastIndex(typeName, ".")+1:])
+		}
+
+		for _, fieldName := range names {
+			if !ast.IsExported(fieldName) {
+				continue
+			}
+			path := prefix + fieldName
+			if name != "" {
+				path = prefix + name
+			}
+			*paths = append(*paths, path)
+			p.collectNestedJSONFieldPaths(field.Type, file, files, metadata, path, visiting, paths)
+		}
+	}
+}
+
+// collectNestedJSONFieldPaths adds the JSON paths below a member of type expr
+func (p *Parser) collectNestedJSONFieldPaths(expr ast.Expr, file *ast.File, files map[string]*ast.File, metadata *models.PackageMetadata, path string, visiting map[*ast.StructType]bool, paths *[]string) {
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+			continue
+		case *ast.ArrayType:
+			expr = t.Elt
+			continue
+		}
+		break
+	}
+
+	open := func() { *paths = append(*paths, path+".*") }
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if types.Universe.Lookup(t.Name) != nil {
+			if t.Name == "any" {
+				open()
+			}
+			return
+		}
+	case *ast.SelectorExpr:
+		qualifier, ok := t.X.(*ast.Ident)
+		if ok && p.importDirectory(file, qualifier.Name, metadata) != "" {
+			break
+		}
+		// Standard library types such as time.Time marshal as single values
+		if ok && p.isStandardLibraryImport(file, qualifier.Name) && p.getTypeString(t) != "json.RawMessage" {
+			return
+		}
+		open()
+		return
+	case *ast.StructType:
+		p.collectJSONFieldPaths(t, file, files, metadata, path+".", visiting, paths)
+		return
+	default:
+		// maps, interfaces and generic types
+		open()
+		return
+	}
+
+	nested, nestedFile, nestedFiles := p.resolveStructDecl(p.getTypeString(expr), file, files, metadata)
+	switch {
+	case nested == nil:
+		// named types of the module that are not structs, such as enums
+	case visiting[nested]:
+		open()
+	default:
+		p.collectJSONFieldPaths(nested, nestedFile, nestedFiles, metadata, path+".", visiting, paths)
+	}
+}
+
+// responseFieldPaths returns the JSON paths clients can select from the
+// response of a route with -Fields: the listed paths, or all the paths of
+// the response type when none are listed. Listed paths must be paths of the
+// response type when it is known. Returns nil paths when the response type
+// is not known.
+func (p *Parser) responseFieldPaths(route models.RouteMetadata, listed []string, file *ast.File, fileMap map[string]*ast.File, metadata *models.PackageMetadata) ([]string, error) {
+	switch route.ReturnType.Type {
+	case models.ReturnTypeStream:
+		return nil, fmt.Errorf("-Fields cannot project streamed responses")
+	case models.ReturnTypeError:
+		return nil, fmt.Errorf("-Fields needs a handler returning data; the handler only returns an error")
+	}
+
+	var known []string
+	if route.ReturnType.DataType != "" && file != nil {
+		known = p.jsonFieldPaths(route.ReturnType.DataType, file, fileMap, metadata)
+	}
+	if len(listed) == 0 {
+		return known, nil
+	}
+
+	if known != nil {
+		policy := axon.FieldsPolicy{Paths: known}
+		for _, path := range listed {
+			if !policy.Allows(path) {
+				return nil, fmt.Errorf("-Fields path %q is not a JSON field of %s", path, route.ReturnType.DataType)
+			}
+		}
+	}
+	return listed, nil
+}
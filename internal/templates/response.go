@@ -14,12 +14,20 @@ import (
 type ResponseHandlerData struct {
 	HandlerCall        string
 	ErrAlreadyDeclared bool
+	SessionVar         string // session variable to save after the handler returns, if any
+	SetETag            bool   // whether to set the ETag of ETagger results
+	HasError           bool   // whether the handler also returns an error
+	HasStatus          bool   // whether the handler returns a status code after the data
+	StreamFunc         string // axon function streaming the handler's sequence
+	TypedResponse      bool   // whether the handler returns an *axon.TypedResponse rather than an *axon.Response
 }
 
 // RouteWrapperData represents data needed for route wrapper template
 type RouteWrapperData struct {
 	WrapperName          string
 	ControllerName       string
+	UsesSession          bool
+	UsesPagination       bool
 	ParameterBindingCode string
 	BodyBindingCode      string
 	ResponseHandlingCode string
@@ -28,22 +36,34 @@ type RouteWrapperData struct {
 // BodyBindingData represents data needed for body binding template
 type BodyBindingData struct {
 	BodyType string
+	Uploads  []models.UploadField // file fields; the body is then read as a multipart form
 }
 
 // GenerateResponseHandling generates response handling code based on handler return type
 func GenerateResponseHandling(route models.RouteMetadata, controllerName string) (string, error) {
 	handlerCall := generateHandlerCall(route, controllerName)
 
-	// Check if err variable is already declared by parameter binding
-	errAlreadyDeclared := hasPathParameters(route.Parameters)
+	// Check if err variable is already declared by parameter binding, or by
+	// reading an upload body as a multipart form
+	sessionVar := sessionParameterName(route.Parameters)
+	errAlreadyDeclared := hasPathParameters(route.Parameters) || sessionVar != "" ||
+		hasParameterSource(route.Parameters, models.ParameterSourcePage) ||
+		hasParameterSource(route.Parameters, models.ParameterSourceListQuery) ||
+		(route.ReadsMultipart() && route.Method != "GET")
+
+	setETag := route.ETag != nil && *route.ETag
 
 	switch route.ReturnType.Type {
 	case models.ReturnTypeDataError:
-		return generateDataErrorResponse(handlerCall, errAlreadyDeclared), nil
+		return generateDataErrorResponse(handlerCall, route.ReturnType, errAlreadyDeclared, sessionVar, setETag), nil
 	case models.ReturnTypeResponseError:
-		return generateResponseErrorResponse(handlerCall, errAlreadyDeclared), nil
+		return generateResponseErrorResponse(handlerCall, route.ReturnType, errAlreadyDeclared, sessionVar, setETag), nil
 	case models.ReturnTypeError:
-		return generateErrorResponse(handlerCall, errAlreadyDeclared), nil
+		return generateErrorResponse(handlerCall, errAlreadyDeclared, sessionVar), nil
+	case models.ReturnTypeStream:
+		return generateStreamResponse(handlerCall, route.ReturnType, sessionVar)
+	case models.ReturnTypePage:
+		return generatePageResponse(handlerCall, route.ReturnType, sessionVar)
 	default:
 		return "", fmt.Errorf("unsupported return type: %v", route.ReturnType.Type)
 	}
@@ -59,6 +79,32 @@ func hasPathParameters(parameters []models.Parameter) bool {
 	return false
 }
 
+// sessionParameterName returns the name of the axon.Session parameter, or "" if the route has none
+func sessionParameterName(parameters []models.Parameter) string {
+	for _, param := range parameters {
+		if param.Source == models.ParameterSourceSession {
+			return param.Name
+		}
+	}
+	return ""
+}
+
+// hasParameterSource reports whether the route takes a parameter from source
+func hasParameterSource(parameters []models.Parameter, source models.ParameterSource) bool {
+	for _, param := range parameters {
+		if param.Source == source {
+			return true
+		}
+	}
+	return false
+}
+
+// UsesPagination reports whether a route needs the *axon.Pagination passed
+// to its wrapper: it takes an axon.PageRequest or returns an axon.Page
+func UsesPagination(route models.RouteMetadata) bool {
+	return hasParameterSource(route.Parameters, models.ParameterSourcePage) || route.ReturnType.Type == models.ReturnTypePage
+}
+
 // generateHandlerCall creates the handler method call with appropriate parameters
 func generateHandlerCall(route models.RouteMetadata, controllerName string) string {
 	// Create a slice to hold parameters in the correct order
@@ -96,8 +142,8 @@ func generateHandlerCall(route models.RouteMetadata, controllerName string) stri
 				position: param.Position,
 				source:   param.Source,
 			})
-		case models.ParameterSourceQuery:
-			// For query parameters (like axon.QueryMap), use the parameter name
+		case models.ParameterSourceQuery, models.ParameterSourceSession, models.ParameterSourceLogger, models.ParameterSourceBodyStream, models.ParameterSourcePage, models.ParameterSourceListQuery:
+			// For query parameters (like axon.QueryMap), sessions, loggers, body streams, page and list queries, use the parameter name
 			orderedParams = append(orderedParams, paramWithPosition{
 				name:     param.Name,
 				position: param.Position,
@@ -150,10 +196,14 @@ func generateHandlerCall(route models.RouteMetadata, controllerName string) stri
 }
 
 // generateDataErrorResponse generates response handling for (data, error) return type
-func generateDataErrorResponse(handlerCall string, errAlreadyDeclared bool) string {
+func generateDataErrorResponse(handlerCall string, returnType models.ReturnTypeInfo, errAlreadyDeclared bool, sessionVar string, setETag bool) string {
 	data := ResponseHandlerData{
 		HandlerCall:        handlerCall,
 		ErrAlreadyDeclared: errAlreadyDeclared,
+		SessionVar:         sessionVar,
+		SetETag:            setETag,
+		HasError:           returnType.HasError,
+		HasStatus:          returnType.HasStatus,
 	}
 
 	result, err := executeRegistryTemplate("data-error-response", data)
@@ -178,10 +228,14 @@ func generateDataErrorResponse(handlerCall string, errAlreadyDeclared bool) stri
 }
 
 // generateResponseErrorResponse generates response handling for (*Response, error) return type
-func generateResponseErrorResponse(handlerCall string, errAlreadyDeclared bool) string {
+func generateResponseErrorResponse(handlerCall string, returnType models.ReturnTypeInfo, errAlreadyDeclared bool, sessionVar string, setETag bool) string {
 	data := ResponseHandlerData{
 		HandlerCall:        handlerCall,
 		ErrAlreadyDeclared: errAlreadyDeclared,
+		SessionVar:         sessionVar,
+		SetETag:            setETag,
+		HasError:           returnType.HasError,
+		TypedResponse:      returnType.DataType != "", // typed responses record their body type
 	}
 
 	result, err := executeRegistryTemplate("response-error-response", data)
@@ -213,10 +267,11 @@ func generateResponseErrorResponse(handlerCall string, errAlreadyDeclared bool)
 }
 
 // generateErrorResponse generates response handling for error return type
-func generateErrorResponse(handlerCall string, errAlreadyDeclared bool) string {
+func generateErrorResponse(handlerCall string, errAlreadyDeclared bool, sessionVar string) string {
 	data := ResponseHandlerData{
 		HandlerCall:        handlerCall,
 		ErrAlreadyDeclared: errAlreadyDeclared,
+		SessionVar:         sessionVar,
 	}
 
 	result, err := executeRegistryTemplate("error-response", data)
@@ -254,7 +309,10 @@ func GenerateRouteWrapper(route models.RouteMetadata, controllerName string, par
 	}
 
 	// Generate body binding code if needed
-	bodyBindingCode := generateBodyBindingCode(route.Parameters, route.Method)
+	bodyBindingCode, err := generateBodyBindingCode(route.Parameters, route.Method)
+	if err != nil {
+		return "", errors.WrapGenerateError("body", "binding", err)
+	}
 
 	// Generate response handling code
 	responseHandlingCode, err := GenerateResponseHandling(route, controllerName)
@@ -266,6 +324,8 @@ func GenerateRouteWrapper(route models.RouteMetadata, controllerName string, par
 	data := RouteWrapperData{
 		WrapperName:          wrapperName,
 		ControllerName:       controllerName,
+		UsesSession:          sessionParameterName(route.Parameters) != "",
+		UsesPagination:       UsesPagination(route),
 		ParameterBindingCode: paramBindingCode,
 		BodyBindingCode:      bodyBindingCode,
 		ResponseHandlingCode: responseHandlingCode,
@@ -292,17 +352,27 @@ func GenerateRouteWrapper(route models.RouteMetadata, controllerName string, par
 	return result, nil
 }
 
-// generateBodyBindingCode generates body parameter binding code
-func generateBodyBindingCode(parameters []models.Parameter, method string) string {
+// generateBodyBindingCode generates body parameter binding code. Body structs
+// with upload fields are read as multipart forms, and axon.Patch bodies as
+// patch documents.
+func generateBodyBindingCode(parameters []models.Parameter, method string) (string, error) {
 	// Don't generate body binding for GET requests
 	if method == "GET" {
-		return ""
+		return "", nil
 	}
 
 	for _, param := range parameters {
 		if param.Source == models.ParameterSourceBody {
 			data := BodyBindingData{
 				BodyType: param.Type,
+				Uploads:  param.Uploads,
+			}
+
+			if len(param.Uploads) > 0 {
+				return executeRegistryTemplate("upload-binding", data)
+			}
+			if param.IsPatch() {
+				return executeRegistryTemplate("patch-binding", data)
 			}
 
 			result, err := executeRegistryTemplate("body-binding", data)
@@ -312,12 +382,12 @@ func generateBodyBindingCode(parameters []models.Parameter, method string) strin
 		if err := c.Bind(&body); err != nil {
 			return axon.NewHTTPError(http.StatusBadRequest, err.Error())
 		}
-`, param.Type)
+`, param.Type), nil
 			}
-			return result
+			return result, nil
 		}
 	}
-	return ""
+	return "", nil
 }
 
 // generateMiddlewareParameters generates the parameter list for middleware dependencies
@@ -390,3 +460,38 @@ func convertAxonPathToEcho(axonPath string) string {
 
 	return result
 }
+
+// streamFuncs maps sequence kinds to the axon functions streaming them
+var streamFuncs = map[models.StreamKind]string{
+	models.StreamKindSeq:  "StreamSeq",
+	models.StreamKindSeq2: "StreamSeq2",
+	models.StreamKindChan: "StreamChan",
+}
+
+// generateStreamResponse generates response handling for handlers returning an
+// iter.Seq, iter.Seq2 or channel, optionally with an error
+func generateStreamResponse(handlerCall string, returnType models.ReturnTypeInfo, sessionVar string) (string, error) {
+	streamFunc, ok := streamFuncs[returnType.Stream]
+	if !ok {
+		return "", fmt.Errorf("unsupported stream kind: %v", returnType.Stream)
+	}
+
+	data := ResponseHandlerData{
+		HandlerCall: handlerCall,
+		SessionVar:  sessionVar,
+		HasError:    returnType.HasError,
+		StreamFunc:  streamFunc,
+	}
+	return executeRegistryTemplate("stream-response", data)
+}
+
+// generatePageResponse generates response handling for handlers returning an
+// axon.Page, optionally with an error
+func generatePageResponse(handlerCall string, returnType models.ReturnTypeInfo, sessionVar string) (string, error) {
+	data := ResponseHandlerData{
+		HandlerCall: handlerCall,
+		SessionVar:  sessionVar,
+		HasError:    returnType.HasError,
+	}
+	return executeRegistryTemplate("page-response", data)
+}
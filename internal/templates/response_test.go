@@ -8,6 +8,7 @@ import (
 )
 
 func TestGenerateResponseHandling(t *testing.T) {
+	setETag := true
 	tests := []struct {
 		name           string
 		route          models.RouteMetadata
@@ -34,7 +35,7 @@ func TestGenerateResponseHandling(t *testing.T) {
 		if err != nil {
 			return handleError(c, err)
 		}
-		return c.Response().JSON(http.StatusOK, data)`,
+		return axon.WriteData(c, data)`,
 		},
 		{
 			name: "response error return type",
@@ -59,6 +60,33 @@ func TestGenerateResponseHandling(t *testing.T) {
 		}
 		return handleAxonResponse(c, response)`,
 		},
+		{
+			name: "typed response with path parameters and ETag",
+			route: models.RouteMetadata{
+				HandlerName: "UpdateUser",
+				ReturnType: models.ReturnTypeInfo{
+					Type:         models.ReturnTypeResponseError,
+					DataType:     "*User",
+					UsesResponse: true,
+					HasError:     true,
+				},
+				Parameters: []models.Parameter{
+					{Name: "id", Type: "int", Source: models.ParameterSourcePath},
+				},
+				ETag: &setETag,
+			},
+			controllerName: "UserController",
+			expected: `		typedResponse, err := handler.UpdateUser(id)
+		if err != nil {
+			return handleError(c, err)
+		}
+		response := typedResponse.Response()
+		if response == nil {
+			return axon.NewHTTPError(http.StatusInternalServerError, "handler returned nil response")
+		}
+		axon.SetETagFrom(c, response.Body)
+		return handleAxonResponse(c, response)`,
+		},
 		{
 			name: "error only return type",
 			route: models.RouteMetadata{
@@ -80,6 +108,153 @@ func TestGenerateResponseHandling(t *testing.T) {
 		}
 		return nil`,
 		},
+		{
+			name: "streamed iter.Seq2 with error",
+			route: models.RouteMetadata{
+				HandlerName: "ExportUsers",
+				ReturnType: models.ReturnTypeInfo{
+					Type:     models.ReturnTypeStream,
+					DataType: "*User",
+					HasError: true,
+					Stream:   models.StreamKindSeq2,
+				},
+			},
+			controllerName: "UserController",
+			expected: `		stream, err := handler.ExportUsers()
+		if err != nil {
+			return handleError(c, err)
+		}
+		return axon.StreamSeq2(c, stream)`,
+		},
+		{
+			name: "streamed channel",
+			route: models.RouteMetadata{
+				HandlerName: "Events",
+				ReturnType: models.ReturnTypeInfo{
+					Type:     models.ReturnTypeStream,
+					DataType: "Event",
+					Stream:   models.StreamKindChan,
+				},
+				Parameters: []models.Parameter{
+					{Name: "c", Type: "axon.RequestContext", Source: models.ParameterSourceContext, Position: 0},
+				},
+			},
+			controllerName: "EventController",
+			expected: `		stream := handler.Events(c)
+		return axon.StreamChan(c, stream)`,
+		},
+		{
+			name: "page with session",
+			route: models.RouteMetadata{
+				HandlerName: "ListUsers",
+				ReturnType: models.ReturnTypeInfo{
+					Type:     models.ReturnTypePage,
+					DataType: "*User",
+					HasError: true,
+				},
+				Parameters: []models.Parameter{
+					{Name: "sess", Type: "axon.Session", Source: models.ParameterSourceSession, Position: 0},
+					{Name: "page", Type: "axon.PageRequest", Source: models.ParameterSourcePage, Position: 1},
+				},
+			},
+			controllerName: "UserController",
+			expected: `		pageResult, err := handler.ListUsers(sess, page)
+		if saveErr := sessions.Save(c, sess); saveErr != nil && err == nil {
+			err = saveErr
+		}
+		if err != nil {
+			return handleError(c, err)
+		}
+		return pagination.WritePage(c, pageResult)`,
+		},
+		{
+			name: "error with session",
+			route: models.RouteMetadata{
+				HandlerName: "Login",
+				ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeError},
+				Parameters: []models.Parameter{
+					{Name: "c", Type: "axon.RequestContext", Source: models.ParameterSourceContext, Position: 0},
+					{Name: "sess", Type: "axon.Session", Source: models.ParameterSourceSession, Position: 1},
+				},
+			},
+			controllerName: "AuthController",
+			expected: `		c = sessions.SaveOnWrite(c, sess)
+		err = handler.Login(c, sess)
+		if saveErr := sessions.Save(c, sess); saveErr != nil && err == nil {
+			err = saveErr
+		}
+		if err != nil {
+			return err
+		}
+		return nil`,
+		},
+		{
+			name: "data with status and path parameters",
+			route: models.RouteMetadata{
+				HandlerName: "CreateOrder",
+				ReturnType: models.ReturnTypeInfo{
+					Type:      models.ReturnTypeDataError,
+					DataType:  "*Order",
+					HasError:  true,
+					HasStatus: true,
+				},
+				Parameters: []models.Parameter{
+					{Name: "id", Type: "int", Source: models.ParameterSourcePath},
+				},
+			},
+			controllerName: "OrderController",
+			expected: `		data, status, err := handler.CreateOrder(id)
+		if err != nil {
+			return handleError(c, err)
+		}
+		return axon.WriteDataStatus(c, status, data)`,
+		},
+		{
+			name: "data without error with session",
+			route: models.RouteMetadata{
+				HandlerName: "LatestOrder",
+				ReturnType: models.ReturnTypeInfo{
+					Type:     models.ReturnTypeDataError,
+					DataType: "Order",
+				},
+				Parameters: []models.Parameter{
+					{Name: "sess", Type: "axon.Session", Source: models.ParameterSourceSession},
+				},
+			},
+			controllerName: "OrderController",
+			expected: `		data := handler.LatestOrder(sess)
+		if err := sessions.Save(c, sess); err != nil {
+			return handleError(c, err)
+		}
+		return axon.WriteData(c, data)`,
+		},
+		{
+			name: "typed response without error",
+			route: models.RouteMetadata{
+				HandlerName: "ExportOrders",
+				ReturnType: models.ReturnTypeInfo{
+					Type:         models.ReturnTypeResponseError,
+					DataType:     "[]Order",
+					UsesResponse: true,
+				},
+			},
+			controllerName: "OrderController",
+			expected: `		typedResponse := handler.ExportOrders()
+		response := typedResponse.Response()
+		if response == nil {
+			return axon.NewHTTPError(http.StatusInternalServerError, "handler returned nil response")
+		}
+		return handleAxonResponse(c, response)`,
+		},
+		{
+			name: "stream without kind",
+			route: models.RouteMetadata{
+				HandlerName: "Events",
+				ReturnType:  models.ReturnTypeInfo{Type: models.ReturnTypeStream},
+			},
+			controllerName: "EventController",
+			expectError:    true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -201,7 +376,7 @@ func TestGenerateRouteWrapper(t *testing.T) {
 				"id, err := axon.ParseInt(c, c.Param(\"id\"))",
 				"var data interface{}",
 				"data, err = handler.GetUser(id)",
-				"return c.Response().JSON(http.StatusOK, data)",
+				"return axon.WriteData(c, data)",
 			},
 		},
 		{
@@ -278,6 +453,28 @@ func TestGenerateRouteWrapper(t *testing.T) {
 				"data, err := handler.CreateUser(body)",
 			},
 		},
+		{
+			name: "wrapper with page request and page return",
+			route: models.RouteMetadata{
+				Method:      "GET",
+				Path:        "/users",
+				HandlerName: "ListUsers",
+				Parameters: []models.Parameter{
+					{Name: "page", Type: "axon.PageRequest", Source: models.ParameterSourcePage},
+				},
+				ReturnType: models.ReturnTypeInfo{
+					Type:     models.ReturnTypePage,
+					DataType: "User",
+				},
+			},
+			controllerName: "UserController",
+			shouldContain: []string{
+				"func wrapUserControllerListUsers(handler *UserController, pagination *axon.Pagination) axon.HandlerFunc",
+				"page, err := pagination.Bind(c)",
+				"pageResult := handler.ListUsers(page)",
+				"return pagination.WritePage(c, pageResult)",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -362,6 +559,42 @@ func TestGenerateBodyBindingCode(t *testing.T) {
 		if err := c.Bind(&body); err != nil {
 			return axon.NewHTTPError(http.StatusBadRequest, err.Error())
 		}
+`,
+		},
+		{
+			name: "with upload fields",
+			parameters: []models.Parameter{
+				{Name: "upload", Type: "AvatarUpload", Source: models.ParameterSourceBody, Uploads: []models.UploadField{
+					{FieldName: "Avatar", FormName: "avatar", MaxCount: 1, MaxSize: 5 << 20, Accept: []string{"image/png", "image/jpeg"}, Required: true},
+					{FieldName: "Photos", FormName: "photos", Multiple: true},
+				}},
+			},
+			method: "POST",
+			expected: `		var body AvatarUpload
+		uploadForm, err := axon.ReadMultipartForm(c,
+			axon.UploadRule{Field: "avatar", MaxCount: 1, MaxSize: 5242880, Accept: []string{"image/png", "image/jpeg"}, Required: true},
+			axon.UploadRule{Field: "photos"},
+		)
+		if err != nil {
+			return err
+		}
+		defer uploadForm.RemoveAll()
+		if err := uploadForm.Bind(&body); err != nil {
+			return axon.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+`,
+		},
+		{
+			name: "with patch parameter",
+			parameters: []models.Parameter{
+				{Name: "id", Type: "int", Source: models.ParameterSourcePath},
+				{Name: "patch", Type: "axon.Patch[models.User]", Source: models.ParameterSourceBody},
+			},
+			method: "PATCH",
+			expected: `		var body axon.Patch[models.User]
+		if err := axon.BindPatch(c, &body); err != nil {
+			return err
+		}
 `,
 		},
 		{
@@ -382,7 +615,10 @@ func TestGenerateBodyBindingCode(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := generateBodyBindingCode(tt.parameters, tt.method)
+			result, err := generateBodyBindingCode(tt.parameters, tt.method)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
 			if result != tt.expected {
 				t.Errorf("expected:\n%s\n\ngot:\n%s", tt.expected, result)
 			}
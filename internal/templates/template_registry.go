@@ -156,41 +156,103 @@ func New{{.StructName}}Factory({{range $i, $dep := .InjectedDeps}}{{if $i}}, {{e
 
 // registerResponseTemplates registers all response handling templates
 func (tr *TemplateRegistry) registerResponseTemplates() {
-	tr.templates["route-wrapper"] = `func {{.WrapperName}}(handler *{{.ControllerName}}) axon.HandlerFunc {
+	tr.templates["route-wrapper"] = `func {{.WrapperName}}(handler *{{.ControllerName}}{{if .UsesSession}}, sessions *axon.SessionManager{{end}}{{if .UsesPagination}}, pagination *axon.Pagination{{end}}) axon.HandlerFunc {
 	return func(c axon.RequestContext) error {
 {{.ParameterBindingCode}}{{.BodyBindingCode}}
 {{.ResponseHandlingCode}}
 	}
 }`
 
-	tr.templates["data-error-response"] = `		{{if .ErrAlreadyDeclared}}var data interface{}
-		data, err = {{.HandlerCall}}{{else}}data, err := {{.HandlerCall}}{{end}}
+	tr.templates["data-error-response"] = `		{{if not .HasError}}data := {{.HandlerCall}}{{else if .HasStatus}}data, status, err := {{.HandlerCall}}{{else if .ErrAlreadyDeclared}}var data interface{}
+		data, err = {{.HandlerCall}}{{else}}data, err := {{.HandlerCall}}{{end}}{{if .SessionVar}}{{if .HasError}}
+		if saveErr := sessions.Save(c, {{.SessionVar}}); saveErr != nil && err == nil {
+			err = saveErr
+		}{{else}}
+		if err := sessions.Save(c, {{.SessionVar}}); err != nil {
+			return handleError(c, err)
+		}{{end}}{{end}}{{if .HasError}}
 		if err != nil {
 			return handleError(c, err)
-		}
-		return c.Response().JSON(http.StatusOK, data)`
-
-	tr.templates["response-error-response"] = `		{{if .ErrAlreadyDeclared}}var response *axon.Response
-		response, err = {{.HandlerCall}}{{else}}response, err := {{.HandlerCall}}{{end}}
+		}{{end}}{{if .SetETag}}
+		axon.SetETagFrom(c, data){{end}}
+		return {{if .HasStatus}}axon.WriteDataStatus(c, status, data){{else}}axon.WriteData(c, data){{end}}`
+
+	tr.templates["response-error-response"] = `		{{if not .HasError}}{{if .TypedResponse}}typedResponse{{else}}response{{end}} := {{.HandlerCall}}{{else if .TypedResponse}}typedResponse, err := {{.HandlerCall}}{{else if .ErrAlreadyDeclared}}var response *axon.Response
+		response, err = {{.HandlerCall}}{{else}}response, err := {{.HandlerCall}}{{end}}{{if .SessionVar}}{{if .HasError}}
+		if saveErr := sessions.Save(c, {{.SessionVar}}); saveErr != nil && err == nil {
+			err = saveErr
+		}{{else}}
+		if err := sessions.Save(c, {{.SessionVar}}); err != nil {
+			return handleError(c, err)
+		}{{end}}{{end}}{{if .HasError}}
 		if err != nil {
 			return handleError(c, err)
-		}
+		}{{end}}{{if .TypedResponse}}
+		response := typedResponse.Response(){{end}}
 		if response == nil {
 			return axon.NewHTTPError(http.StatusInternalServerError, "handler returned nil response")
-		}
+		}{{if .SetETag}}
+		axon.SetETagFrom(c, response.Body){{end}}
 		return handleAxonResponse(c, response)`
 
-	tr.templates["error-response"] = `		{{if .ErrAlreadyDeclared}}err = {{.HandlerCall}}{{else}}err := {{.HandlerCall}}{{end}}
+	tr.templates["error-response"] = `{{if .SessionVar}}		c = sessions.SaveOnWrite(c, {{.SessionVar}})
+{{end}}		{{if .ErrAlreadyDeclared}}err = {{.HandlerCall}}{{else}}err := {{.HandlerCall}}{{end}}{{if .SessionVar}}
+		if saveErr := sessions.Save(c, {{.SessionVar}}); saveErr != nil && err == nil {
+			err = saveErr
+		}{{end}}
 		if err != nil {
 			return err
 		}
 		return nil`
 
+	tr.templates["stream-response"] = `		{{if .HasError}}stream, err := {{.HandlerCall}}{{else}}stream := {{.HandlerCall}}{{end}}{{if .SessionVar}}{{if .HasError}}
+		if saveErr := sessions.Save(c, {{.SessionVar}}); saveErr != nil && err == nil {
+			err = saveErr
+		}{{else}}
+		if err := sessions.Save(c, {{.SessionVar}}); err != nil {
+			return handleError(c, err)
+		}{{end}}{{end}}{{if .HasError}}
+		if err != nil {
+			return handleError(c, err)
+		}{{end}}
+		return axon.{{.StreamFunc}}(c, stream)`
+
+	tr.templates["page-response"] = `		{{if .HasError}}pageResult, err := {{.HandlerCall}}{{else}}pageResult := {{.HandlerCall}}{{end}}{{if .SessionVar}}{{if .HasError}}
+		if saveErr := sessions.Save(c, {{.SessionVar}}); saveErr != nil && err == nil {
+			err = saveErr
+		}{{else}}
+		if err := sessions.Save(c, {{.SessionVar}}); err != nil {
+			return handleError(c, err)
+		}{{end}}{{end}}{{if .HasError}}
+		if err != nil {
+			return handleError(c, err)
+		}{{end}}
+		return pagination.WritePage(c, pageResult)`
+
 	tr.templates["body-binding"] = `		var body {{.BodyType}}
 		if err := c.Bind(&body); err != nil {
 			return axon.NewHTTPError(http.StatusBadRequest, err.Error())
 		}
 `
+
+	tr.templates["patch-binding"] = `		var body {{.BodyType}}
+		if err := axon.BindPatch(c, &body); err != nil {
+			return err
+		}
+`
+
+	tr.templates["upload-binding"] = `		var body {{.BodyType}}
+		uploadForm, err := axon.ReadMultipartForm(c,{{range .Uploads}}
+			axon.UploadRule{Field: {{printf "%q" .FormName}}{{if .MaxCount}}, MaxCount: {{.MaxCount}}{{end}}{{if .MaxSize}}, MaxSize: {{.MaxSize}}{{end}}{{if .Accept}}, Accept: []string{ {{- range $i, $accept := .Accept}}{{if $i}}, {{end}}{{printf "%q" $accept}}{{end -}} }{{end}}{{if .Required}}, Required: true{{end}}},{{end}}
+		)
+		if err != nil {
+			return err
+		}
+		defer uploadForm.RemoveAll()
+		if err := uploadForm.Bind(&body); err != nil {
+			return axon.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+`
 }
 
 // registerInterfaceTemplates registers all interface-related templates
@@ -235,13 +297,15 @@ func handleAxonResponse(c axon.RequestContext, response *axon.Response) error {
 	// Set cookies
 	for _, cookie := range response.Cookies {
 		axonCookie := axon.AxonCookie{
-			Name:     cookie.Name,
-			Value:    cookie.Value,
-			Path:     cookie.Path,
-			Domain:   cookie.Domain,
-			MaxAge:   cookie.MaxAge,
-			Secure:   cookie.Secure,
-			HttpOnly: cookie.HttpOnly,
+			Name:      cookie.Name,
+			Value:     cookie.Value,
+			Path:      cookie.Path,
+			Domain:    cookie.Domain,
+			MaxAge:    cookie.MaxAge,
+			Secure:    cookie.Secure,
+			HttpOnly:  cookie.HttpOnly,
+			Signed:    cookie.Signed,
+			Encrypted: cookie.Encrypted,
 		}
 		if cookie.SameSite != "" {
 			switch cookie.SameSite {
@@ -273,21 +337,34 @@ func handleError(c axon.RequestContext, err error) error {
 	if httpErr, ok := err.(*axon.HttpError); ok {
 		return handleHttpError(c, httpErr)
 	}
-	return axon.NewHTTPError(http.StatusInternalServerError, err.Error())
+	// HTTPErrors carry their own status and go to the adapter's error handling
+	if httpErr, ok := err.(*axon.HTTPError); ok {
+		return httpErr
+	}
+	// Other errors become a 500 the adapter renders and reports
+	return axon.NewHTTPError(http.StatusInternalServerError, err.Error(), err)
 }`
 }
 
 // registerRouteTemplates registers all route-related templates
 func (tr *TemplateRegistry) registerRouteTemplates() {
 	tr.templates["route-registration-function"] = `// RegisterRoutes registers all HTTP routes with the web server
-func RegisterRoutes(server axon.WebServerInterface{{range .Controllers}}, {{.VarName}} *{{.StructName}}{{end}}{{range .MiddlewareDeps}}, {{.VarName}} *{{.PackageName}}.{{.Name}}{{end}}) {
+func RegisterRoutes(server axon.WebServerInterface{{range .Controllers}}, {{.VarName}} *{{.StructName}}{{end}}{{range .MiddlewareDeps}}, {{.VarName}} *{{.PackageName}}.{{.Name}}{{end}}{{if .UsesAuthorizer}}, authorizer axon.Authorizer{{end}}{{if .UsesSessions}}, sessions *axon.SessionManager{{end}}{{if .UsesCache}}, cache *axon.ResponseCache{{end}}{{if .UsesIdempotency}}, idempotency *axon.Idempotency{{end}}{{if .UsesPagination}}, pagination *axon.Pagination{{end}}) {
 {{range .Controllers}}{{if .Prefix}}	{{.VarName}}Group := server.RegisterGroup("{{.EchoPrefix}}")
 {{end}}{{range .Routes}}{{template "RouteRegistration" .}}{{end}}{{end}}}`
 
-	tr.templates["route-registration"] = `	{{.HandlerVar}} := {{.WrapperFunc}}({{.ControllerVar}})
-{{if .HasMiddleware}}	{{.GroupVar}}.RegisterRoute("{{.Method}}", axon.NewAxonPath("{{.RelativePath}}"), {{.HandlerVar}}, {{.MiddlewareList}})
-{{else}}	{{.GroupVar}}.RegisterRoute("{{.Method}}", axon.NewAxonPath("{{.RelativePath}}"), {{.HandlerVar}})
-{{end}}	axon.DefaultRouteRegistry.RegisterRoute(axon.RouteInfo{
+	tr.templates["route-registration"] = `	{{.HandlerVar}} := {{.WrapperFunc}}({{.ControllerVar}}{{if .UsesSession}}, sessions{{end}}{{if .UsesPagination}}, pagination{{end}})
+{{if .Fields}}	{{.HandlerVar}} = axon.WithFields(axon.FieldsPolicy{Paths: {{.FieldPathsArray}}{{if .FieldsPage}}, Page: true{{end}}})({{.HandlerVar}})
+{{end}}{{if .Coalesce}}	{{.HandlerVar}} = axon.WithCoalescing(axon.CoalescePolicy{Route: "{{.ControllerName}}.{{.HandlerName}}", Path: "{{.Path}}", Key: {{.CoalesceKeyArray}}})({{.HandlerVar}})
+{{end}}{{if .HasCache}}	{{.HandlerVar}} = axon.WithCache(cache, axon.CachePolicy{Route: "{{.ControllerName}}.{{.HandlerName}}", Path: "{{.Path}}", TTL: {{.CacheTTL}}, Vary: {{.CacheVaryArray}}})({{.HandlerVar}})
+{{end}}{{if .HasETag}}	{{.HandlerVar}} = axon.WithETag(axon.ETagPolicy{ {{- if .ETagCurrent}}Current: {{.ETagCurrent}}{{end -}} })({{.HandlerVar}})
+{{end}}{{if .Idempotent}}	{{.HandlerVar}} = axon.WithIdempotency(idempotency, axon.IdempotencyPolicy{Route: "{{.ControllerName}}.{{.HandlerName}}", Path: "{{.Path}}"})({{.HandlerVar}})
+{{end}}{{if .MaxBodySize}}	{{.HandlerVar}} = axon.WithBodyLimit(axon.BodyLimitPolicy{MaxSize: {{.MaxBodySize}}{{if .StreamsBody}}, Stream: true{{end}}})({{.HandlerVar}})
+{{end}}{{if .HasAuthorization}}	{{.HandlerVar}} = axon.RequireAuthorization(authorizer, axon.AuthorizationRequirement{Roles: {{.RolesArray}}, Permissions: {{.PermissionsArray}}})({{.HandlerVar}})
+{{end}}{{if .CSPPolicy}}	{{.HandlerVar}} = axon.WithCSPPolicy({{printf "%q" .CSPPolicy}})({{.HandlerVar}})
+{{end}}{{if .NoCompress}}	{{.HandlerVar}} = axon.WithoutCompression()({{.HandlerVar}})
+{{end}}	{{.GroupVar}}.RegisterRoute("{{.Method}}", axon.NewAxonPath("{{.RelativePath}}"), {{.HandlerVar}}, axon.WithRouteMatch(axon.RouteMatch{Method: "{{.Method}}", Path: "{{.Path}}", Controller: "{{.ControllerName}}", Handler: "{{.HandlerName}}"{{if .NoCSRF}}, NoCSRF: true{{end}}}){{if .HasMiddleware}}, {{.MiddlewareList}}{{end}})
+	axon.DefaultRouteRegistry.RegisterRoute(axon.RouteInfo{
 		Method:              "{{.Method}}",
 		Path:                "{{.Path}}",
 		EchoPath:            "{{.EchoPath}}",
@@ -297,7 +374,19 @@ func RegisterRoutes(server axon.WebServerInterface{{range .Controllers}}, {{.Var
 		Middlewares:         {{.MiddlewaresArray}},
 		MiddlewareInstances: {{.MiddlewareInstancesArray}},
 		ParameterInstances:  {{.ParameterInstancesArray}},
-		Handler:             {{.HandlerVar}},
+{{if .HasAuthorization}}		Roles:               {{.RolesArray}},
+		Permissions:         {{.PermissionsArray}},
+{{end}}{{if .NoCSRF}}		NoCSRF:              true,
+{{end}}{{if .CSPPolicy}}		CSPPolicy:           {{printf "%q" .CSPPolicy}},
+{{end}}{{if .HasCache}}		CacheTTL:            {{.CacheTTL}},
+{{end}}{{if .HasETag}}		ETag:                true,
+{{end}}{{if .Coalesce}}		Coalesce:            true,
+{{end}}{{if .Fields}}		Fields:              true,
+{{end}}{{if .Idempotent}}		Idempotent:          true,
+{{end}}{{if .NoCompress}}		NoCompress:          true,
+{{end}}{{if .MaxBodySize}}		MaxBodySize:         {{.MaxBodySize}},
+{{end}}{{if .StreamsBody}}		StreamsBody:         true,
+{{end}}		Handler:             {{.HandlerVar}},
 	})
 `
 
@@ -1,7 +1,9 @@
 package templates
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/toyz/axon/internal/models"
 )
@@ -199,5 +201,33 @@ func (tu *TemplateUtils) BuildMiddlewaresArray(middlewares []string) string {
 	return "[]string{" + quoted + "}"
 }
 
+// BuildStringSliceLiteral builds a []string literal, or nil when items is empty
+func (tu *TemplateUtils) BuildStringSliceLiteral(items []string) string {
+	if len(items) == 0 {
+		return "nil"
+	}
+
+	return "[]string{" + tu.JoinQuoted(items) + "}"
+}
+
+// BuildDurationLiteral builds a time.Duration expression in the largest unit that divides d
+func (tu *TemplateUtils) BuildDurationLiteral(d time.Duration) string {
+	units := []struct {
+		unit time.Duration
+		name string
+	}{
+		{time.Hour, "time.Hour"},
+		{time.Minute, "time.Minute"},
+		{time.Second, "time.Second"},
+		{time.Millisecond, "time.Millisecond"},
+	}
+	for _, u := range units {
+		if d%u.unit == 0 {
+			return fmt.Sprintf("%d * %s", d/u.unit, u.name)
+		}
+	}
+	return fmt.Sprintf("time.Duration(%d)", int64(d))
+}
+
 // DefaultTemplateUtils provides a global instance for convenience
 var DefaultTemplateUtils = NewTemplateUtils()
\ No newline at end of file
@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/toyz/axon/internal/errors"
 	"github.com/toyz/axon/internal/models"
@@ -20,8 +21,13 @@ import (
 
 // Route registration template data structures
 type RouteRegistrationData struct {
-	Controllers    []ControllerTemplateData
-	MiddlewareDeps []MiddlewareDependency
+	Controllers     []ControllerTemplateData
+	MiddlewareDeps  []MiddlewareDependency
+	UsesAuthorizer  bool // whether any route requires roles or permissions
+	UsesSessions    bool // whether any route takes an axon.Session parameter
+	UsesCache       bool // whether any route caches responses
+	UsesIdempotency bool // whether any route honors Idempotency-Key
+	UsesPagination  bool // whether any route takes an axon.PageRequest or returns an axon.Page
 }
 
 type ControllerTemplateData struct {
@@ -49,6 +55,27 @@ type RouteTemplateData struct {
 	MiddlewaresArray         string
 	MiddlewareInstancesArray string
 	ParameterInstancesArray  string
+	HasAuthorization         bool   // whether the route requires roles or permissions
+	RolesArray               string // []string literal of required roles
+	PermissionsArray         string // []string literal of required permissions
+	UsesSession              bool   // whether the wrapper needs the session manager
+	NoCSRF                   bool   // whether the route is exempt from CSRF protection
+	CSPPolicy                string // named Content-Security-Policy for the route
+	HasCache                 bool   // whether responses are cached
+	CacheTTL                 string // time.Duration literal of the cache TTL
+	CacheVaryArray           string // []string literal of the cache vary keys
+	HasETag                  bool   // whether the route sets ETags and checks preconditions
+	ETagCurrent              string // expression for the GET handler that If-Match is checked against
+	Coalesce                 bool   // whether identical concurrent requests share one execution
+	CoalesceKeyArray         string // []string literal of the extra coalescing key values
+	Idempotent               bool   // whether retries with the same Idempotency-Key are replayed
+	NoCompress               bool   // whether the route is exempt from response compression
+	MaxBodySize              int64  // request body limit in bytes, 0 for none
+	StreamsBody              bool   // whether the handler streams the body or reads it as a multipart form
+	UsesPagination           bool   // whether the wrapper needs the pagination handling
+	Fields                   bool   // whether clients can select response fields with ?fields=
+	FieldPathsArray          string // []string literal of the selectable JSON paths, nil for any
+	FieldsPage               bool   // whether fields select members of the items of an axon.Page
 }
 
 type MiddlewareDependency struct {
@@ -85,6 +112,15 @@ func BuildParameterInstancesArray(paramTypes map[string]string) string {
 	return DefaultTemplateUtils.BuildParameterInstancesArray(paramTypes)
 }
 
+func BuildStringSliceLiteral(items []string) string {
+	return DefaultTemplateUtils.BuildStringSliceLiteral(items)
+}
+
+// BuildDurationLiteral builds a time.Duration literal (e.g., 30 * time.Second)
+func BuildDurationLiteral(d time.Duration) string {
+	return DefaultTemplateUtils.BuildDurationLiteral(d)
+}
+
 func BuildMiddlewareList(middlewares []string) string {
 	return DefaultTemplateUtils.BuildMiddlewareList(middlewares)
 }
@@ -179,6 +215,33 @@ func GenerateParameterBindingCode(parameters []models.Parameter, parserRegistry
 			// Context parameters don't need binding code - they're passed directly
 			// The context is already available as 'c' in the wrapper function
 			continue
+		case models.ParameterSourceSession:
+			// Sessions are loaded from the manager passed to the wrapper and saved after the handler returns
+			bindingCode.WriteString(fmt.Sprintf(`		%s, err := sessions.Load(c)
+		if err != nil {
+			return err
+		}
+`, param.Name))
+		case models.ParameterSourceLogger:
+			// The request-scoped logger carries the request ID, route template and controller
+			bindingCode.WriteString(fmt.Sprintf("\t\t%s := axon.GetLogger(c)\n", param.Name))
+		case models.ParameterSourceBodyStream:
+			// The body is streamed unbuffered, limited to the route's -MaxBodySize
+			bindingCode.WriteString(fmt.Sprintf("\t\t%s := axon.GetBodyStream(c)\n", param.Name))
+		case models.ParameterSourceListQuery:
+			// List queries are parsed against the route's -Sortable and -Filterable fields
+			bindingCode.WriteString(fmt.Sprintf(`		%s, err := axon.BindListQuery(c, axon.ListQueryPolicy{Sortable: %s, Filterable: %s})
+		if err != nil {
+			return err
+		}
+`, param.Name, BuildStringSliceLiteral(param.Sortable), BuildStringSliceLiteral(param.Filterable)))
+		case models.ParameterSourcePage:
+			// Page requests are read from the limit, offset and cursor query parameters
+			bindingCode.WriteString(fmt.Sprintf(`		%s, err := pagination.Bind(c)
+		if err != nil {
+			return err
+		}
+`, param.Name))
 		}
 	}
 
@@ -194,6 +257,16 @@ func getParameterSourceString(source models.ParameterSource) string {
 		return "body"
 	case models.ParameterSourceContext:
 		return "context"
+	case models.ParameterSourceSession:
+		return "session"
+	case models.ParameterSourceLogger:
+		return "logger"
+	case models.ParameterSourceBodyStream:
+		return "body-stream"
+	case models.ParameterSourcePage:
+		return "page"
+	case models.ParameterSourceListQuery:
+		return "list-query"
 	default:
 		return "unknown"
 	}
@@ -105,6 +105,23 @@ func TestGenerateParameterBindingCode(t *testing.T) {
 		if err != nil {
 			return axon.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid id: %v", err))
 		}
+`,
+		},
+		{
+			name: "list query parameter",
+			parameters: []models.Parameter{
+				{
+					Name:       "query",
+					Type:       "axon.ListQuery",
+					Source:     models.ParameterSourceListQuery,
+					Sortable:   []string{"created_at", "name"},
+					Filterable: []string{"status"},
+				},
+			},
+			expected: `		query, err := axon.BindListQuery(c, axon.ListQueryPolicy{Sortable: []string{"created_at", "name"}, Filterable: []string{"status"}})
+		if err != nil {
+			return err
+		}
 `,
 		},
 	}
@@ -0,0 +1,292 @@
+package axon
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+// RedactedValue replaces redacted header, query and body values in access logs
+const RedactedValue = "[REDACTED]"
+
+// defaultMaxLoggedBody is the largest request body AccessLog decodes by default
+const defaultMaxLoggedBody = 16 << 10
+
+// Values that are always redacted, in addition to those configured
+var (
+	defaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-API-Key", "X-CSRF-Token"}
+	defaultRedactedQuery   = []string{"access_token", "api_key", "token", "password", "secret"}
+	defaultRedactedFields  = []string{"password", "secret", "token", "access_token", "refresh_token", "api_key", "client_secret"}
+)
+
+// AccessLogConfig configures the access log middleware
+type AccessLogConfig struct {
+	// Logger receives the access log records (default: slog.Default())
+	Logger *slog.Logger
+
+	// Message is the log message of each record (default: "request")
+	Message string
+
+	// Headers lists request headers to include in the log
+	Headers []string
+
+	// LogQuery includes the query string in the log
+	LogQuery bool
+
+	// LogBody includes JSON request bodies up to MaxBodySize in the log
+	LogBody bool
+
+	// MaxBodySize is the largest request body that is logged (default: 16KB)
+	MaxBodySize int64
+
+	// RedactHeaders, RedactQuery and RedactFields name headers, query parameters
+	// and JSON body fields (at any depth) whose values are replaced with
+	// RedactedValue. Names are case-insensitive. Credentials such as
+	// Authorization, Cookie, token and password are always redacted.
+	RedactHeaders []string
+	RedactQuery   []string
+	RedactFields  []string
+
+	// SuccessSampleRate is the fraction of 2xx responses that are logged, between
+	// 0 and 1. Zero logs every request; other responses are always logged.
+	SuccessSampleRate float64
+
+	// Skip excludes requests from the log (e.g. health checks)
+	Skip func(c RequestContext) bool
+}
+
+// AccessLog is a middleware that writes one structured slog record per request
+// with the method, route template, status, latency, response size, request ID
+// and principal, so the log format is the same on every adapter. Responses
+// with a 5xx status are logged at error level and 4xx at warn level.
+type AccessLog struct {
+	config        AccessLogConfig
+	redactHeaders map[string]bool
+	redactQuery   map[string]bool
+	redactFields  map[string]bool
+}
+
+// NewAccessLog creates an access log middleware
+func NewAccessLog(config AccessLogConfig) *AccessLog {
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+	if config.Message == "" {
+		config.Message = "request"
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = defaultMaxLoggedBody
+	}
+	return &AccessLog{
+		config:        config,
+		redactHeaders: lowerSet(defaultRedactedHeaders, config.RedactHeaders),
+		redactQuery:   lowerSet(defaultRedactedQuery, config.RedactQuery),
+		redactFields:  lowerSet(defaultRedactedFields, config.RedactFields),
+	}
+}
+
+// Handle implements the middleware
+func (l *AccessLog) Handle(next HandlerFunc) HandlerFunc {
+	return func(c RequestContext) error {
+		if l.config.Skip != nil && l.config.Skip(c) {
+			return next(c)
+		}
+
+		// The body has to be captured before the handler consumes it
+		var body any
+		if l.config.LogBody {
+			body = l.body(c)
+		}
+
+		start := time.Now()
+		err := next(c)
+		latency := time.Since(start)
+
+		status := responseStatus(c, err)
+		if status >= 200 && status < 300 && !l.sampled() {
+			return err
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+		}
+		if match, ok := GetRouteMatch(c); ok {
+			attrs = append(attrs, slog.String("route", match.Path))
+		}
+		attrs = append(attrs,
+			slog.Int("status", status),
+			slog.Duration("latency", latency),
+			slog.Int64("bytes", max(c.Response().Size(), 0)),
+			slog.String("ip", c.RealIP()),
+		)
+		if id := GetRequestID(c); id != "" {
+			attrs = append(attrs, slog.String("request_id", id))
+		}
+		if principal, ok := GetPrincipal(c); ok {
+			attrs = append(attrs, slog.String("principal", principal.GetSubject()))
+		}
+		if len(l.config.Headers) > 0 {
+			attrs = append(attrs, slog.Any("headers", l.headers(c)))
+		}
+		if l.config.LogQuery {
+			if query := l.query(c); query != "" {
+				attrs = append(attrs, slog.String("query", query))
+			}
+		}
+		if body != nil {
+			attrs = append(attrs, slog.Any("body", body))
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		l.config.Logger.LogAttrs(context.Background(), level, l.config.Message, attrs...)
+		return err
+	}
+}
+
+// sampled decides whether a 2xx response is logged
+func (l *AccessLog) sampled() bool {
+	rate := l.config.SuccessSampleRate
+	return rate <= 0 || rate >= 1 || rand.Float64() < rate
+}
+
+// headers returns the configured request headers with credentials redacted
+func (l *AccessLog) headers(c RequestContext) map[string]string {
+	headers := make(map[string]string, len(l.config.Headers))
+	for _, name := range l.config.Headers {
+		value := c.Request().Header(name)
+		if value == "" {
+			continue
+		}
+		if l.redactHeaders[strings.ToLower(name)] {
+			value = RedactedValue
+		}
+		headers[name] = value
+	}
+	return headers
+}
+
+// query returns the encoded query string with redacted parameter values
+func (l *AccessLog) query(c RequestContext) string {
+	query := url.Values(c.QueryParams())
+	redacted := make(url.Values, len(query))
+	for name, values := range query {
+		if l.redactQuery[strings.ToLower(name)] {
+			values = []string{RedactedValue}
+		}
+		redacted[name] = values
+	}
+	return redacted.Encode()
+}
+
+// body decodes a JSON request body and redacts its sensitive fields. Bodies
+// that are not JSON, too large or of unknown length are not logged.
+func (l *AccessLog) body(c RequestContext) any {
+	if !strings.HasPrefix(c.Request().ContentType(), "application/json") {
+		return nil
+	}
+	if size := c.Request().ContentLength(); size <= 0 || size > l.config.MaxBodySize {
+		return nil
+	}
+	var body any
+	if err := json.Unmarshal(c.Request().Body(), &body); err != nil {
+		return nil
+	}
+	return l.redact(body)
+}
+
+// redact replaces the values of sensitive fields in a decoded JSON document
+func (l *AccessLog) redact(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if l.redactFields[strings.ToLower(key)] {
+				v[key] = RedactedValue
+			} else {
+				v[key] = l.redact(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = l.redact(item)
+		}
+	}
+	return value
+}
+
+// responseStatus returns the status of the response to a request. Errors that
+// have not been written yet are answered after the middleware chain returns,
+// so their status is taken from the error.
+func responseStatus(c RequestContext, err error) int {
+	if err != nil && !c.Response().Written() {
+		return ErrorStatus(err)
+	}
+	if status := c.Response().Status(); status != 0 {
+		return status
+	}
+	return http.StatusOK
+}
+
+// lowerSet builds a case-insensitive set from lists of names
+func lowerSet(lists ...[]string) map[string]bool {
+	set := make(map[string]bool)
+	for _, list := range lists {
+		for _, name := range list {
+			set[strings.ToLower(name)] = true
+		}
+	}
+	return set
+}
+
+// AccessLogParams are the fx dependencies of the access log middleware.
+// Both are optional; an injected *slog.Logger is used unless the config sets one.
+type AccessLogParams struct {
+	fx.In
+
+	Config *AccessLogConfig `optional:"true"`
+	Logger *slog.Logger     `optional:"true"`
+}
+
+// NewAccessLogFromParams builds the middleware from fx-provided dependencies
+func NewAccessLogFromParams(p AccessLogParams) *AccessLog {
+	var config AccessLogConfig
+	if p.Config != nil {
+		config = *p.Config
+	}
+	if config.Logger == nil {
+		config.Logger = p.Logger
+	}
+	return NewAccessLog(config)
+}
+
+// AccessLogModule provides an *AccessLog and registers it as global middleware.
+// Include it before the generated modules so it wraps every route.
+//
+//	fx.New(
+//	    fx.Supply(&axon.AccessLogConfig{LogQuery: true, SuccessSampleRate: 0.1}),
+//	    axon.RequestIDModule,
+//	    axon.AccessLogModule,
+//	    controllers.AutogenModule,
+//	)
+var AccessLogModule = fx.Module("axon-access-log",
+	fx.Provide(NewAccessLogFromParams),
+	fx.Invoke(func(server WebServerInterface, accessLog *AccessLog) {
+		server.Use(accessLog.Handle)
+	}),
+)
@@ -0,0 +1,196 @@
+package axon
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestAccessLog returns an access log writing JSON records to buf
+func newTestAccessLog(buf *bytes.Buffer, config AccessLogConfig) *AccessLog {
+	config.Logger = slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	return NewAccessLog(config)
+}
+
+func TestAccessLog_Fields(t *testing.T) {
+	var buf bytes.Buffer
+	accessLog := newTestAccessLog(&buf, AccessLogConfig{})
+	route := WithRouteMatch(RouteMatch{Method: "GET", Path: "/users/{id:int}", Controller: "UserController", Handler: "GetUser"})
+
+	c := newValueRequestContext()
+	c.path = "/users/7"
+	c.Set(RequestIDContextKey, "req-1")
+	SetPrincipal(c, &BasicPrincipal{Subject: "alice"})
+
+	handler := accessLog.Handle(route(func(c RequestContext) error {
+		return c.Response().String(http.StatusOK, "hello")
+	}))
+	require.NoError(t, handler(c))
+
+	records := logRecords(t, &buf)
+	require.Len(t, records, 1)
+	record := records[0]
+	assert.Equal(t, "INFO", record["level"])
+	assert.Equal(t, "request", record["msg"])
+	assert.Equal(t, "GET", record["method"])
+	assert.Equal(t, "/users/7", record["path"])
+	assert.Equal(t, "/users/{id:int}", record["route"])
+	assert.EqualValues(t, 200, record["status"])
+	assert.EqualValues(t, 5, record["bytes"])
+	assert.Contains(t, record, "latency")
+	assert.Equal(t, "127.0.0.1", record["ip"])
+	assert.Equal(t, "req-1", record["request_id"])
+	assert.Equal(t, "alice", record["principal"])
+	assert.NotContains(t, record, "error")
+}
+
+func TestAccessLog_ErrorStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status float64
+		level  string
+	}{
+		{"http error", NewHTTPError(http.StatusNotFound, "user not found"), 404, "WARN"},
+		{"legacy http error", ErrConflict("already exists"), 409, "WARN"},
+		{"plain error", errors.New("database down"), 500, "ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			accessLog := newTestAccessLog(&buf, AccessLogConfig{})
+
+			err := accessLog.Handle(func(RequestContext) error { return tt.err })(newValueRequestContext())
+			assert.Equal(t, tt.err, err)
+
+			records := logRecords(t, &buf)
+			require.Len(t, records, 1)
+			assert.Equal(t, tt.status, records[0]["status"])
+			assert.Equal(t, tt.level, records[0]["level"])
+			assert.Equal(t, tt.err.Error(), records[0]["error"])
+		})
+	}
+}
+
+func TestAccessLog_Redaction(t *testing.T) {
+	var buf bytes.Buffer
+	accessLog := newTestAccessLog(&buf, AccessLogConfig{
+		Headers:      []string{"Authorization", "User-Agent", "X-Tenant"},
+		LogQuery:     true,
+		LogBody:      true,
+		RedactQuery:  []string{"email"},
+		RedactFields: []string{"ssn"},
+	})
+
+	c := newValueRequestContext().
+		withHeader("Authorization", "Bearer secret-token").
+		withHeader("User-Agent", "curl/8.0").
+		withHeader("Content-Type", "application/json")
+	c.method = http.MethodPost
+	c.query["q"] = "shoes"
+	c.query["token"] = "abc"
+	c.query["Email"] = "a@example.com"
+	c.request.body = []byte(`{"name":"alice","Password":"hunter2","profile":{"ssn":"123"},"keys":[{"api_key":"k1"}]}`)
+
+	var handlerBody []byte
+	err := accessLog.Handle(func(c RequestContext) error {
+		handlerBody = c.Request().Body()
+		return nil
+	})(c)
+	require.NoError(t, err)
+	assert.Contains(t, string(handlerBody), "hunter2", "the handler still sees the original body")
+
+	records := logRecords(t, &buf)
+	require.Len(t, records, 1)
+	record := records[0]
+
+	assert.Equal(t, map[string]interface{}{
+		"Authorization": RedactedValue,
+		"User-Agent":    "curl/8.0",
+	}, record["headers"])
+	assert.Equal(t, "Email=%5BREDACTED%5D&q=shoes&token=%5BREDACTED%5D", record["query"])
+	assert.Equal(t, map[string]interface{}{
+		"name":     "alice",
+		"Password": RedactedValue,
+		"profile":  map[string]interface{}{"ssn": RedactedValue},
+		"keys":     []interface{}{map[string]interface{}{"api_key": RedactedValue}},
+	}, record["body"])
+}
+
+func TestAccessLog_BodyLimits(t *testing.T) {
+	var buf bytes.Buffer
+	accessLog := newTestAccessLog(&buf, AccessLogConfig{LogBody: true, MaxBodySize: 16})
+	next := func(RequestContext) error { return nil }
+
+	large := newValueRequestContext().withHeader("Content-Type", "application/json")
+	large.request.body = []byte(`{"name":"a very long name"}`)
+	require.NoError(t, accessLog.Handle(next)(large))
+
+	form := newValueRequestContext().withHeader("Content-Type", "application/x-www-form-urlencoded")
+	form.request.body = []byte(`password=x`)
+	require.NoError(t, accessLog.Handle(next)(form))
+
+	records := logRecords(t, &buf)
+	require.Len(t, records, 2)
+	assert.NotContains(t, records[0], "body")
+	assert.NotContains(t, records[1], "body")
+}
+
+func TestAccessLog_Sampling(t *testing.T) {
+	var buf bytes.Buffer
+	accessLog := newTestAccessLog(&buf, AccessLogConfig{SuccessSampleRate: 1e-9})
+
+	ok := accessLog.Handle(func(c RequestContext) error {
+		return c.Response().String(http.StatusOK, "ok")
+	})
+	failing := accessLog.Handle(func(RequestContext) error {
+		return NewHTTPError(http.StatusBadRequest, "bad input")
+	})
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, ok(newValueRequestContext()))
+	}
+	require.Error(t, failing(newValueRequestContext()))
+
+	records := logRecords(t, &buf)
+	require.Len(t, records, 1, "2xx responses are sampled, errors are always logged")
+	assert.EqualValues(t, 400, records[0]["status"])
+}
+
+func TestAccessLog_Skip(t *testing.T) {
+	var buf bytes.Buffer
+	accessLog := newTestAccessLog(&buf, AccessLogConfig{
+		Skip: func(c RequestContext) bool { return c.Path() == "/health" },
+	})
+
+	c := newValueRequestContext()
+	c.path = "/health"
+	called := false
+	require.NoError(t, accessLog.Handle(func(RequestContext) error {
+		called = true
+		return nil
+	})(c))
+
+	assert.True(t, called)
+	assert.Empty(t, buf.String())
+}
+
+func TestNewAccessLogFromParams(t *testing.T) {
+	var buf bytes.Buffer
+	accessLog := NewAccessLogFromParams(AccessLogParams{
+		Config: &AccessLogConfig{Message: "http"},
+		Logger: slog.New(slog.NewJSONHandler(&buf, nil)),
+	})
+
+	require.NoError(t, accessLog.Handle(func(RequestContext) error { return nil })(newValueRequestContext()))
+
+	records := logRecords(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "http", records[0]["msg"])
+}
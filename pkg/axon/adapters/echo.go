@@ -2,6 +2,7 @@ package adapters
 
 import (
 	"context"
+	"io"
 	"mime/multipart"
 	"net/http"
 	"strings"
@@ -12,17 +13,18 @@ import (
 
 // EchoAdapter implements axon.WebServerInterface for Echo v4
 type EchoAdapter struct {
-	engine *echo.Echo
+	engine  *echo.Echo
+	options adapterOptions
 }
 
 // NewEchoAdapter creates a new Echo adapter
-func NewEchoAdapter(e *echo.Echo) *EchoAdapter {
-	return &EchoAdapter{engine: e}
+func NewEchoAdapter(e *echo.Echo, opts ...AdapterOption) *EchoAdapter {
+	return &EchoAdapter{engine: e, options: newAdapterOptions(opts)}
 }
 
 // NewDefaultEchoAdapter creates a new Echo adapter with default Echo instance
-func NewDefaultEchoAdapter() *EchoAdapter {
-	return &EchoAdapter{engine: echo.New()}
+func NewDefaultEchoAdapter(opts ...AdapterOption) *EchoAdapter {
+	return NewEchoAdapter(echo.New(), opts...)
 }
 
 // convertAxonPathToEcho converts AxonPath to Echo path format
@@ -132,12 +134,13 @@ func (ega *EchoGroupAdapter) Group(prefix string) axon.RouteGroup {
 // convertHandler converts axon.HandlerFunc to echo.HandlerFunc
 func (ea *EchoAdapter) convertHandler(handler axon.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		ctx := &EchoRequestContext{context: c}
-		err := handler(ctx)
+		ctx := &EchoRequestContext{context: c, keyring: ea.options.keyring, proxies: ea.options.proxies}
+		err := axon.Recover(handler)(ctx)
 		if err != nil {
+			axon.ReportError(ea.options.reporter, ctx, err)
 			// Convert axon.HTTPError to echo.HTTPError
 			if httpErr, ok := err.(*axon.HTTPError); ok {
-				return echo.NewHTTPError(httpErr.Code, httpErr.Message)
+				return echo.NewHTTPError(httpErr.Code, httpErr.Message).SetInternal(httpErr.Internal)
 			}
 			// For other errors, return as-is
 			return err
@@ -150,21 +153,33 @@ func (ea *EchoAdapter) convertHandler(handler axon.HandlerFunc) echo.HandlerFunc
 func (ea *EchoAdapter) convertMiddleware(middleware axon.MiddlewareFunc) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			// Convert echo.HandlerFunc to axon.HandlerFunc
-			axonNext := func(ctx axon.RequestContext) error {
-				return next(c)
+			ctx := &EchoRequestContext{context: c, keyring: ea.options.keyring, proxies: ea.options.proxies}
+
+			// Convert echo.HandlerFunc to axon.HandlerFunc. Echo errors are
+			// only written by the error handler once the chain returns, so
+			// they are passed up as axon.HTTPError for middleware to inspect.
+			// A response replaced by the middleware is used by the rest of the chain.
+			axonNext := func(nextCtx axon.RequestContext) error {
+				if nextCtx != ctx {
+					previous := c.Get(responseOverrideKey)
+					c.Set(responseOverrideKey, nextCtx.Response())
+					defer c.Set(responseOverrideKey, previous)
+				}
+				err := next(c)
+				if echoErr, ok := err.(*echo.HTTPError); ok {
+					return axon.NewHTTPError(echoErr.Code, echoErr.Message, echoErr.Internal)
+				}
+				return err
 			}
 
 			// Call axon middleware
-			axonHandler := middleware(axonNext)
-
-			// Convert back to echo context and call
-			ctx := &EchoRequestContext{context: c}
+			axonHandler := axon.Recover(middleware(axonNext))
 			err := axonHandler(ctx)
 			if err != nil {
+				axon.ReportError(ea.options.reporter, ctx, err)
 				// Convert axon.HTTPError to echo.HTTPError
 				if httpErr, ok := err.(*axon.HTTPError); ok {
-					return echo.NewHTTPError(httpErr.Code, httpErr.Message)
+					return echo.NewHTTPError(httpErr.Code, httpErr.Message).SetInternal(httpErr.Internal)
 				}
 				// For other errors, return as-is
 				return err
@@ -177,6 +192,8 @@ func (ea *EchoAdapter) convertMiddleware(middleware axon.MiddlewareFunc) echo.Mi
 // EchoRequestContext implements axon.RequestContext for Echo
 type EchoRequestContext struct {
 	context echo.Context
+	keyring *axon.Keyring
+	proxies *axon.TrustedProxies
 }
 
 // Method returns the HTTP method
@@ -189,9 +206,19 @@ func (erc *EchoRequestContext) Path() string {
 	return erc.context.Request().URL.Path
 }
 
-// RealIP returns the real IP address
+// RealIP returns the client IP address, resolved through trusted proxies
 func (erc *EchoRequestContext) RealIP() string {
-	return erc.context.RealIP()
+	return resolveHTTPClient(erc.proxies, erc.context.Request()).IP
+}
+
+// Scheme returns the client scheme, resolved through trusted proxies
+func (erc *EchoRequestContext) Scheme() string {
+	return resolveHTTPClient(erc.proxies, erc.context.Request()).Scheme
+}
+
+// Host returns the client host, resolved through trusted proxies
+func (erc *EchoRequestContext) Host() string {
+	return resolveHTTPClient(erc.proxies, erc.context.Request()).Host
 }
 
 // Param returns path parameter by name
@@ -234,12 +261,15 @@ func (erc *EchoRequestContext) QueryString() string {
 
 // Request returns the request interface
 func (erc *EchoRequestContext) Request() axon.RequestInterface {
-	return &EchoRequestInterface{request: erc.context.Request()}
+	return &EchoRequestInterface{request: erc.context.Request(), keyring: erc.keyring}
 }
 
 // Response returns the response interface
 func (erc *EchoRequestContext) Response() axon.ResponseInterface {
-	return &EchoResponseInterface{response: erc.context.Response(), context: erc.context}
+	if response, ok := erc.context.Get(responseOverrideKey).(axon.ResponseInterface); ok {
+		return response
+	}
+	return &EchoResponseInterface{response: erc.context.Response(), context: erc.context, keyring: erc.keyring}
 }
 
 // Bind binds request body to provided struct
@@ -293,11 +323,12 @@ func (erc *EchoRequestContext) MultipartForm() (axon.MultipartForm, error) {
 // EchoRequestInterface implements axon.RequestInterface for Echo requests
 type EchoRequestInterface struct {
 	request *http.Request
+	keyring *axon.Keyring
 }
 
 // Header returns request header value
 func (eri *EchoRequestInterface) Header(key string) string {
-	return eri.request.Header.Get(key)
+	return requestHeader(eri.request, key)
 }
 
 // SetHeader sets request header
@@ -307,9 +338,17 @@ func (eri *EchoRequestInterface) SetHeader(key, value string) {
 
 // Body returns request body
 func (eri *EchoRequestInterface) Body() []byte {
-	// Note: This is a simplified implementation
-	// In production, you'd want to handle this more carefully
-	return nil
+	return readBody(eri.request)
+}
+
+// SetBody replaces the request body
+func (eri *EchoRequestInterface) SetBody(body []byte) {
+	setBody(eri.request, body)
+}
+
+// BodyStream returns the request body without buffering it
+func (eri *EchoRequestInterface) BodyStream() io.ReadCloser {
+	return eri.request.Body
 }
 
 // ContentLength returns content length
@@ -327,17 +366,7 @@ func (eri *EchoRequestInterface) Cookies() []axon.AxonCookie {
 	cookies := eri.request.Cookies()
 	result := make([]axon.AxonCookie, len(cookies))
 	for i, c := range cookies {
-		result[i] = axon.AxonCookie{
-			Name:     c.Name,
-			Value:    c.Value,
-			Path:     c.Path,
-			Domain:   c.Domain,
-			Expires:  c.Expires,
-			MaxAge:   c.MaxAge,
-			Secure:   c.Secure,
-			HttpOnly: c.HttpOnly,
-			SameSite: axon.SameSiteMode(c.SameSite),
-		}
+		result[i] = fromHTTPCookie(c)
 	}
 	return result
 }
@@ -348,23 +377,26 @@ func (eri *EchoRequestInterface) Cookie(name string) (axon.AxonCookie, error) {
 	if err != nil {
 		return axon.AxonCookie{}, err
 	}
-	return axon.AxonCookie{
-		Name:     c.Name,
-		Value:    c.Value,
-		Path:     c.Path,
-		Domain:   c.Domain,
-		Expires:  c.Expires,
-		MaxAge:   c.MaxAge,
-		Secure:   c.Secure,
-		HttpOnly: c.HttpOnly,
-		SameSite: axon.SameSiteMode(c.SameSite),
-	}, nil
+	return fromHTTPCookie(c), nil
+}
+
+// SignedCookie returns the verified value of a signed cookie
+func (eri *EchoRequestInterface) SignedCookie(name string) (string, error) {
+	c, _ := eri.request.Cookie(name)
+	return readSignedCookie(eri.keyring, name, cookieValue(c))
+}
+
+// EncryptedCookie returns the decrypted value of an encrypted cookie
+func (eri *EchoRequestInterface) EncryptedCookie(name string) (string, error) {
+	c, _ := eri.request.Cookie(name)
+	return readEncryptedCookie(eri.keyring, name, cookieValue(c))
 }
 
 // EchoResponseInterface implements axon.ResponseInterface for Echo responses
 type EchoResponseInterface struct {
 	response *echo.Response
 	context  echo.Context
+	keyring  *axon.Keyring
 }
 
 // Status returns response status code
@@ -387,33 +419,56 @@ func (eri *EchoResponseInterface) SetHeader(key, value string) {
 	eri.response.Header().Set(key, value)
 }
 
+// AddHeader adds a response header value
+func (eri *EchoResponseInterface) AddHeader(key, value string) {
+	eri.response.Header().Add(key, value)
+}
+
 // JSON writes JSON response
 func (eri *EchoResponseInterface) JSON(code int, i interface{}) error {
+	if err := eri.cookieError(); err != nil {
+		return err
+	}
 	return eri.context.JSON(code, i)
 }
 
 // JSONPretty writes pretty JSON response
 func (eri *EchoResponseInterface) JSONPretty(code int, i interface{}, indent string) error {
+	if err := eri.cookieError(); err != nil {
+		return err
+	}
 	return eri.context.JSONPretty(code, i, indent)
 }
 
 // String writes string response
 func (eri *EchoResponseInterface) String(code int, s string) error {
+	if err := eri.cookieError(); err != nil {
+		return err
+	}
 	return eri.context.String(code, s)
 }
 
 // HTML writes HTML response
 func (eri *EchoResponseInterface) HTML(code int, html string) error {
+	if err := eri.cookieError(); err != nil {
+		return err
+	}
 	return eri.context.HTML(code, html)
 }
 
 // Blob writes blob response
 func (eri *EchoResponseInterface) Blob(code int, contentType string, b []byte) error {
+	if err := eri.cookieError(); err != nil {
+		return err
+	}
 	return eri.context.Blob(code, contentType, b)
 }
 
 // Stream writes streaming response
 func (eri *EchoResponseInterface) Stream(code int, contentType string, r interface{}) error {
+	if err := eri.cookieError(); err != nil {
+		return err
+	}
 	// Type assertion for io.Reader - this is framework-specific
 	if reader, ok := r.(interface{ Read([]byte) (int, error) }); ok {
 		return eri.context.Stream(code, contentType, reader)
@@ -421,20 +476,33 @@ func (eri *EchoResponseInterface) Stream(code int, contentType string, r interfa
 	return axon.NewHTTPError(500, "Invalid stream reader")
 }
 
-// SetCookie sets a cookie
+// WriteStream writes a streamed response, flushed by write
+func (eri *EchoResponseInterface) WriteStream(code int, contentType string, write func(axon.StreamWriter) error) error {
+	if err := eri.cookieError(); err != nil {
+		return err
+	}
+	return writeHTTPStream(eri.response, eri.context.Request(), code, contentType, write)
+}
+
+// SetCookie sets a cookie. A protected cookie that cannot be set is dropped
+// and fails the response when it is written.
 func (eri *EchoResponseInterface) SetCookie(cookie axon.AxonCookie) {
-	httpCookie := &http.Cookie{
-		Name:     cookie.Name,
-		Value:    cookie.Value,
-		Path:     cookie.Path,
-		Domain:   cookie.Domain,
-		Expires:  cookie.Expires,
-		MaxAge:   cookie.MaxAge,
-		Secure:   cookie.Secure,
-		HttpOnly: cookie.HttpOnly,
-		SameSite: http.SameSite(cookie.SameSite),
+	cookie, err := protectCookie(eri.keyring, cookie)
+	if err != nil {
+		eri.context.Set(cookieErrorKey, err)
+		return
+	}
+	eri.context.SetCookie(toHTTPCookie(cookie))
+}
+
+// cookieError returns the error of a protected cookie that could not be set.
+// It is returned once, so the error response itself can still be written.
+func (eri *EchoResponseInterface) cookieError() error {
+	err, _ := eri.context.Get(cookieErrorKey).(error)
+	if err != nil {
+		eri.context.Set(cookieErrorKey, nil)
 	}
-	eri.context.SetCookie(httpCookie)
+	return err
 }
 
 // Size returns response size
@@ -473,7 +541,7 @@ func (efh *EchoFileHeader) Size() int64 {
 }
 
 // Open opens the uploaded file
-func (efh *EchoFileHeader) Open() (interface{}, error) {
+func (efh *EchoFileHeader) Open() (multipart.File, error) {
 	return efh.header.Open()
 }
 
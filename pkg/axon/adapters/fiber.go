@@ -1,11 +1,14 @@
 package adapters
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/logger"
@@ -15,12 +18,21 @@ import (
 
 // FiberAdapter wraps a Fiber app to implement axon.WebServerInterface
 type FiberAdapter struct {
-	app *fiber.App
+	app     *fiber.App
+	options adapterOptions
+
+	bodyLimitOnce sync.Once
+	bodyLimit     int64
 }
 
 // NewFiberAdapter creates a new Fiber adapter instance
-func NewFiberAdapter() *FiberAdapter {
+func NewFiberAdapter(opts ...AdapterOption) *FiberAdapter {
 	app := fiber.New(fiber.Config{
+		// Bodies larger than BodyLimit are streamed instead of rejected, so
+		// routes enforce their own -MaxBodySize and can stream uploads;
+		// limitBody keeps a server-level limit
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			// Handle errors appropriately
 			code := fiber.StatusInternalServerError
@@ -31,15 +43,46 @@ func NewFiberAdapter() *FiberAdapter {
 		},
 	})
 
-	return &FiberAdapter{app: app}
+	adapter := &FiberAdapter{app: app, options: newAdapterOptions(opts)}
+	// Bodies are limited before any other middleware can read them
+	app.Use(adapter.convertAxonMiddlewareToFiber(adapter.limitBody))
+	return adapter
+}
+
+// limitBody rejects request bodies over the server-level limit with 413.
+// Fiber streams bodies over its BodyLimit to the handler, so without it global
+// middleware reading the body (forms, access logs) would buffer any size.
+// Route limits still apply inside it.
+func (fa *FiberAdapter) limitBody(next axon.HandlerFunc) axon.HandlerFunc {
+	return func(c axon.RequestContext) error {
+		return axon.WithBodyLimit(axon.BodyLimitPolicy{MaxSize: fa.maxBodySize()})(next)(c)
+	}
+}
+
+// maxBodySize returns the server-level body limit. It is resolved on the
+// first request, once the generated routes are registered.
+func (fa *FiberAdapter) maxBodySize() int64 {
+	fa.bodyLimitOnce.Do(func() {
+		if fa.options.maxBodySize != nil {
+			fa.bodyLimit = *fa.options.maxBodySize
+			return
+		}
+		fa.bodyLimit = axon.DefaultMaxBodySize
+		for _, route := range axon.DefaultRouteRegistry.GetAllRoutes() {
+			fa.bodyLimit = max(fa.bodyLimit, route.MaxBodySize)
+		}
+	})
+	return fa.bodyLimit
 }
 
 // NewDefaultFiberAdapter creates a new Fiber adapter with default middleware
-func NewDefaultFiberAdapter() *FiberAdapter {
-	adapter := NewFiberAdapter()
+func NewDefaultFiberAdapter(opts ...AdapterOption) *FiberAdapter {
+	adapter := NewFiberAdapter(opts...)
 
 	// Add default middleware
-	adapter.app.Use(logger.New())
+	if adapter.options.frameworkLogger {
+		adapter.app.Use(logger.New())
+	}
 	adapter.app.Use(recover.New())
 
 	return adapter
@@ -82,11 +125,11 @@ func (fa *FiberAdapter) RegisterRoute(method string, path axon.AxonPath, handler
 	// Convert middlewares to Fiber handlers
 	var fiberMiddlewares []fiber.Handler
 	for _, mw := range middlewares {
-		fiberMiddlewares = append(fiberMiddlewares, convertAxonMiddlewareToFiber(mw))
+		fiberMiddlewares = append(fiberMiddlewares, fa.convertAxonMiddlewareToFiber(mw))
 	}
 
 	// Convert main handler
-	fiberHandler := convertAxonHandlerToFiber(handler)
+	fiberHandler := fa.convertAxonHandlerToFiber(handler)
 
 	// Combine middlewares and handler
 	handlers := append(fiberMiddlewares, fiberHandler)
@@ -118,7 +161,7 @@ func (fa *FiberAdapter) RegisterGroup(prefix string) axon.RouteGroup {
 
 // Use adds middleware to the Fiber app
 func (fa *FiberAdapter) Use(middleware axon.MiddlewareFunc) {
-	fa.app.Use(convertAxonMiddlewareToFiber(middleware))
+	fa.app.Use(fa.convertAxonMiddlewareToFiber(middleware))
 }
 
 // Start starts the Fiber server
@@ -150,11 +193,11 @@ func (frg *FiberRouteGroup) RegisterRoute(method string, path axon.AxonPath, han
 	// Convert middlewares to Fiber handlers
 	var fiberMiddlewares []fiber.Handler
 	for _, mw := range middlewares {
-		fiberMiddlewares = append(fiberMiddlewares, convertAxonMiddlewareToFiber(mw))
+		fiberMiddlewares = append(fiberMiddlewares, frg.adapter.convertAxonMiddlewareToFiber(mw))
 	}
 
 	// Convert main handler
-	fiberHandler := convertAxonHandlerToFiber(handler)
+	fiberHandler := frg.adapter.convertAxonHandlerToFiber(handler)
 
 	// Combine middlewares and handler
 	handlers := append(fiberMiddlewares, fiberHandler)
@@ -180,7 +223,7 @@ func (frg *FiberRouteGroup) RegisterRoute(method string, path axon.AxonPath, han
 
 // Use adds middleware to this route group
 func (frg *FiberRouteGroup) Use(middleware axon.MiddlewareFunc) {
-	frg.group.Use(convertAxonMiddlewareToFiber(middleware))
+	frg.group.Use(frg.adapter.convertAxonMiddlewareToFiber(middleware))
 }
 
 // Group creates a sub-group with the given prefix
@@ -190,14 +233,15 @@ func (frg *FiberRouteGroup) Group(prefix string) axon.RouteGroup {
 }
 
 // convertAxonHandlerToFiber converts an Axon handler to a Fiber handler
-func convertAxonHandlerToFiber(handler axon.HandlerFunc) fiber.Handler {
+func (fa *FiberAdapter) convertAxonHandlerToFiber(handler axon.HandlerFunc) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Create Axon request context wrapper
-		axonCtx := &FiberRequestContext{ctx: c}
+		axonCtx := &FiberRequestContext{ctx: c, keyring: fa.options.keyring, proxies: fa.options.proxies}
 
 		// Call the Axon handler
-		err := handler(axonCtx)
+		err := axon.Recover(handler)(axonCtx)
 		if err != nil {
+			axon.ReportError(fa.options.reporter, axonCtx, err)
 			// Handle error - convert to appropriate Fiber response
 			if httpErr, ok := err.(*axon.HTTPError); ok {
 				return c.Status(httpErr.Code).JSON(httpErr)
@@ -210,18 +254,24 @@ func convertAxonHandlerToFiber(handler axon.HandlerFunc) fiber.Handler {
 }
 
 // convertAxonMiddlewareToFiber converts an Axon middleware to a Fiber middleware
-func convertAxonMiddlewareToFiber(middleware axon.MiddlewareFunc) fiber.Handler {
+func (fa *FiberAdapter) convertAxonMiddlewareToFiber(middleware axon.MiddlewareFunc) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Create Axon request context wrapper
-		axonCtx := &FiberRequestContext{ctx: c}
+		axonCtx := &FiberRequestContext{ctx: c, keyring: fa.options.keyring, proxies: fa.options.proxies}
 
 		// Call the Axon middleware
-		err := middleware(func(ctx axon.RequestContext) error {
+		err := axon.Recover(middleware(func(ctx axon.RequestContext) error {
+			if ctx != axonCtx {
+				previous := c.Locals(responseOverrideKey)
+				c.Locals(responseOverrideKey, ctx.Response())
+				defer c.Locals(responseOverrideKey, previous)
+			}
 			// Continue to next middleware/handler
 			return c.Next()
-		})(axonCtx)
+		}))(axonCtx)
 
 		if err != nil {
+			axon.ReportError(fa.options.reporter, axonCtx, err)
 			// Handle error - convert to appropriate Fiber response
 			if httpErr, ok := err.(*axon.HTTPError); ok {
 				return c.Status(httpErr.Code).JSON(httpErr)
@@ -235,7 +285,9 @@ func convertAxonMiddlewareToFiber(middleware axon.MiddlewareFunc) fiber.Handler
 
 // FiberRequestContext wraps fiber.Ctx to implement axon.RequestContext
 type FiberRequestContext struct {
-	ctx *fiber.Ctx
+	ctx     *fiber.Ctx
+	keyring *axon.Keyring
+	proxies *axon.TrustedProxies
 }
 
 // Request data methods
@@ -247,8 +299,36 @@ func (frc *FiberRequestContext) Path() string {
 	return frc.ctx.Path()
 }
 
+// RealIP returns the client IP address, resolved through trusted proxies
 func (frc *FiberRequestContext) RealIP() string {
-	return frc.ctx.IP()
+	return frc.client().IP
+}
+
+// Scheme returns the client scheme, resolved through trusted proxies
+func (frc *FiberRequestContext) Scheme() string {
+	return frc.client().Scheme
+}
+
+// Host returns the client host, resolved through trusted proxies
+func (frc *FiberRequestContext) Host() string {
+	return frc.client().Host
+}
+
+// client resolves the client side of the request from the fasthttp connection
+func (frc *FiberRequestContext) client() axon.ClientInfo {
+	request := frc.ctx.Request()
+	return frc.proxies.Resolve(axon.ProxyRequest{
+		RemoteAddr: frc.ctx.Context().RemoteAddr().String(),
+		TLS:        frc.ctx.Context().IsTLS(),
+		Host:       string(request.Host()),
+		Header: func(name string) []string {
+			var values []string
+			for _, value := range request.Header.PeekAll(name) {
+				values = append(values, string(value))
+			}
+			return values
+		},
+	})
 }
 
 // Parameter methods
@@ -296,11 +376,14 @@ func (frc *FiberRequestContext) QueryString() string {
 
 // Request and Response interfaces
 func (frc *FiberRequestContext) Request() axon.RequestInterface {
-	return &FiberRequest{ctx: frc.ctx}
+	return &FiberRequest{ctx: frc.ctx, keyring: frc.keyring}
 }
 
 func (frc *FiberRequestContext) Response() axon.ResponseInterface {
-	return &FiberResponse{ctx: frc.ctx}
+	if response, ok := frc.ctx.Locals(responseOverrideKey).(axon.ResponseInterface); ok {
+		return response
+	}
+	return &FiberResponse{ctx: frc.ctx, keyring: frc.keyring}
 }
 
 // Body handling
@@ -411,7 +494,7 @@ func (ffh *FiberFileHeader) Header() map[string][]string {
 	return ffh.header.Header
 }
 
-func (ffh *FiberFileHeader) Open() (interface{}, error) {
+func (ffh *FiberFileHeader) Open() (multipart.File, error) {
 	return ffh.header.Open()
 }
 
@@ -436,24 +519,51 @@ func (fmf *FiberMultipartForm) File() map[string][]axon.FileHeader {
 
 // FiberRequest wraps fiber.Ctx to implement axon.RequestInterface
 type FiberRequest struct {
-	ctx *fiber.Ctx
+	ctx     *fiber.Ctx
+	keyring *axon.Keyring
 }
 
 func (fr *FiberRequest) Header(key string) string {
+	// fasthttp keeps the Host header apart from the other headers
+	if http.CanonicalHeaderKey(key) == "Host" {
+		return string(fr.ctx.Request().Host())
+	}
 	return fr.ctx.Get(key)
 }
 
 func (fr *FiberRequest) SetHeader(key, value string) {
-	// Fiber doesn't allow modifying request headers after creation
-	// This is a no-op
+	fr.ctx.Request().Header.Set(key, value)
 }
 
+// Body returns the raw request body, as on the other adapters. Fiber's
+// Ctx.Body would decode a Content-Encoding without limiting its size.
 func (fr *FiberRequest) Body() []byte {
-	return fr.ctx.Body()
+	return fr.ctx.Request().Body()
 }
 
+func (fr *FiberRequest) SetBody(body []byte) {
+	fr.ctx.Request().SetBody(body)
+}
+
+// BodyStream returns the request body without buffering it. Bodies larger
+// than Fiber's BodyLimit are streamed from the connection.
+func (fr *FiberRequest) BodyStream() io.ReadCloser {
+	if stream := fr.ctx.Request().BodyStream(); stream != nil {
+		return io.NopCloser(stream)
+	}
+	return io.NopCloser(bytes.NewReader(fr.ctx.Request().Body()))
+}
+
+// ContentLength returns the declared body size, or -1 if unknown. It does
+// not read a streamed body.
 func (fr *FiberRequest) ContentLength() int64 {
-	return int64(len(fr.ctx.Body()))
+	if !fr.ctx.Request().IsBodyStream() {
+		return int64(len(fr.ctx.Request().Body()))
+	}
+	if length := fr.ctx.Request().Header.ContentLength(); length >= 0 {
+		return int64(length)
+	}
+	return -1
 }
 
 func (fr *FiberRequest) ContentType() string {
@@ -476,9 +586,20 @@ func (fr *FiberRequest) Cookie(name string) (axon.AxonCookie, error) {
 	}, nil
 }
 
+// SignedCookie returns the verified value of a signed cookie
+func (fr *FiberRequest) SignedCookie(name string) (string, error) {
+	return readSignedCookie(fr.keyring, name, fr.ctx.Cookies(name))
+}
+
+// EncryptedCookie returns the decrypted value of an encrypted cookie
+func (fr *FiberRequest) EncryptedCookie(name string) (string, error) {
+	return readEncryptedCookie(fr.keyring, name, fr.ctx.Cookies(name))
+}
+
 // FiberResponse wraps fiber.Ctx to implement axon.ResponseInterface
 type FiberResponse struct {
-	ctx *fiber.Ctx
+	ctx     *fiber.Ctx
+	keyring *axon.Keyring
 }
 
 // Status methods
@@ -499,30 +620,52 @@ func (fr *FiberResponse) SetHeader(name, value string) {
 	fr.ctx.Set(name, value)
 }
 
+func (fr *FiberResponse) AddHeader(name, value string) {
+	fr.ctx.Response().Header.Add(name, value)
+}
+
 // Content methods
 func (fr *FiberResponse) JSON(code int, data interface{}) error {
+	if err := fr.cookieError(); err != nil {
+		return err
+	}
 	return fr.ctx.Status(code).JSON(data)
 }
 
 func (fr *FiberResponse) JSONPretty(code int, data interface{}, indent string) error {
+	if err := fr.cookieError(); err != nil {
+		return err
+	}
 	return fr.ctx.Status(code).JSON(data) // Fiber doesn't support pretty JSON directly
 }
 
 func (fr *FiberResponse) String(code int, s string) error {
+	if err := fr.cookieError(); err != nil {
+		return err
+	}
 	return fr.ctx.Status(code).SendString(s)
 }
 
 func (fr *FiberResponse) HTML(code int, html string) error {
+	if err := fr.cookieError(); err != nil {
+		return err
+	}
 	fr.ctx.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
 	return fr.ctx.Status(code).SendString(html)
 }
 
 func (fr *FiberResponse) Blob(code int, contentType string, data []byte) error {
+	if err := fr.cookieError(); err != nil {
+		return err
+	}
 	fr.ctx.Set(fiber.HeaderContentType, contentType)
 	return fr.ctx.Status(code).Send(data)
 }
 
 func (fr *FiberResponse) Stream(code int, contentType string, r interface{}) error {
+	if err := fr.cookieError(); err != nil {
+		return err
+	}
 	fr.ctx.Set(fiber.HeaderContentType, contentType)
 	fr.ctx.Status(code)
 	if reader, ok := r.(io.Reader); ok {
@@ -535,13 +678,45 @@ func (fr *FiberResponse) Stream(code int, contentType string, r interface{}) err
 	return fr.ctx.SendString("unsupported stream type")
 }
 
-// Cookie methods
+// WriteStream writes a streamed response. fasthttp sends the body after the
+// handler returns, so write runs then, with chunked transfer encoding.
+func (fr *FiberResponse) WriteStream(code int, contentType string, write func(axon.StreamWriter) error) error {
+	if err := fr.cookieError(); err != nil {
+		return err
+	}
+	fr.ctx.Set(fiber.HeaderContentType, contentType)
+	fr.ctx.Status(code)
+	fr.ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		_ = write(fiberStreamWriter{w})
+		_ = w.Flush()
+	})
+	return nil
+}
+
+// fiberStreamWriter is the axon.StreamWriter of a fasthttp body stream. A
+// disconnect shows as a failed write or flush.
+type fiberStreamWriter struct {
+	*bufio.Writer
+}
+
+func (fiberStreamWriter) Done() <-chan struct{} {
+	return nil
+}
+
+// SetCookie sets a response cookie. A protected cookie that cannot be set is
+// dropped and fails the response when it is written.
 func (fr *FiberResponse) SetCookie(cookie axon.AxonCookie) {
+	cookie, err := protectCookie(fr.keyring, cookie)
+	if err != nil {
+		fr.ctx.Locals(cookieErrorKey, err)
+		return
+	}
 	fiberCookie := &fiber.Cookie{
 		Name:     cookie.Name,
 		Value:    cookie.Value,
 		Path:     cookie.Path,
 		Domain:   cookie.Domain,
+		Expires:  cookie.Expires,
 		MaxAge:   cookie.MaxAge,
 		Secure:   cookie.Secure,
 		HTTPOnly: cookie.HttpOnly,
@@ -556,14 +731,30 @@ func (fr *FiberResponse) SetCookie(cookie axon.AxonCookie) {
 	case axon.SameSiteNoneMode:
 		fiberCookie.SameSite = "None"
 	default:
-		fiberCookie.SameSite = "Lax" // Default
+		// Omit the attribute like net/http does for SameSiteDefaultMode
+		fiberCookie.SameSite = fiber.CookieSameSiteDisabled
 	}
 
 	fr.ctx.Cookie(fiberCookie)
 }
 
+// cookieError returns the error of a protected cookie that could not be set.
+// It is returned once, so the error response itself can still be written.
+func (fr *FiberResponse) cookieError() error {
+	err, _ := fr.ctx.Locals(cookieErrorKey).(error)
+	if err != nil {
+		fr.ctx.Locals(cookieErrorKey, nil)
+	}
+	return err
+}
+
 // Response data methods
 func (fr *FiberResponse) Size() int64 {
+	// Streamed bodies are written after the handler returns; reading them
+	// here would buffer the whole stream
+	if fr.ctx.Response().IsBodyStream() {
+		return -1
+	}
 	return int64(len(fr.ctx.Response().Body()))
 }
 
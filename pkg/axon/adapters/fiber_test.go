@@ -302,4 +302,56 @@ func TestFiberAdapter_WildcardPath(t *testing.T) {
 	if body != expectedBody {
 		t.Errorf("Expected body '%s', got '%s'", expectedBody, body)
 	}
-}
\ No newline at end of file
+}
+
+func TestFiberAdapter_ServerBodyLimit(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		opts  []AdapterOption
+		limit int
+	}{
+		{"default", nil, int(axon.DefaultMaxBodySize)},
+		{"configured", []AdapterOption{WithMaxBodySize(1 << 10)}, 1 << 10},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := NewFiberAdapter(tt.opts...)
+			serve := serveOverNetwork(t, adapter)
+
+			// Global middleware reading the form runs before any route limit
+			formRead := false
+			adapter.Use(func(next axon.HandlerFunc) axon.HandlerFunc {
+				return func(c axon.RequestContext) error {
+					formRead = true
+					_ = c.FormValue("name")
+					return next(c)
+				}
+			})
+			adapter.RegisterRoute("POST", axon.NewAxonPath("/profile"), func(c axon.RequestContext) error {
+				return c.Response().String(http.StatusOK, "ok")
+			})
+
+			post := func(size int) int {
+				body := "name=" + strings.Repeat("x", size)
+				req, _ := http.NewRequest("POST", "http://example.com/profile", strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				resp, err := serve(req)
+				if err != nil {
+					t.Fatalf("request failed: %v", err)
+				}
+				resp.Body.Close()
+				return resp.StatusCode
+			}
+
+			if status := post(tt.limit / 2); status != http.StatusOK {
+				t.Errorf("body under the limit: expected 200, got %d", status)
+			}
+			formRead = false
+			if status := post(tt.limit + 1); status != http.StatusRequestEntityTooLarge {
+				t.Errorf("body over the limit: expected 413, got %d", status)
+			}
+			if formRead {
+				t.Errorf("expected the body to be rejected before global middleware read it")
+			}
+		})
+	}
+}
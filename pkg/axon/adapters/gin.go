@@ -5,7 +5,6 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/toyz/axon/pkg/axon"
@@ -31,7 +30,7 @@ func (gfh *GinFileHeader) Size() int64 {
 	return gfh.size
 }
 
-func (gfh *GinFileHeader) Open() (interface{}, error) {
+func (gfh *GinFileHeader) Open() (multipart.File, error) {
 	return gfh.file, nil
 }
 
@@ -51,17 +50,23 @@ func (gmf *GinMultipartForm) File() map[string][]axon.FileHeader {
 
 // GinAdapter implements axon.WebServerInterface for Gin framework
 type GinAdapter struct {
-	engine *gin.Engine
+	engine  *gin.Engine
+	options adapterOptions
 }
 
 // NewGinAdapter creates a new Gin adapter
-func NewGinAdapter(g *gin.Engine) *GinAdapter {
-	return &GinAdapter{engine: g}
+func NewGinAdapter(g *gin.Engine, opts ...AdapterOption) *GinAdapter {
+	return &GinAdapter{engine: g, options: newAdapterOptions(opts)}
 }
 
 // NewDefaultGinAdapter creates a new Gin adapter with default Gin instance
-func NewDefaultGinAdapter() *GinAdapter {
-	return &GinAdapter{engine: gin.Default()}
+func NewDefaultGinAdapter(opts ...AdapterOption) *GinAdapter {
+	if !newAdapterOptions(opts).frameworkLogger {
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		return NewGinAdapter(engine, opts...)
+	}
+	return NewGinAdapter(gin.Default(), opts...)
 }
 
 // convertAxonPathToGin converts AxonPath to Gin path format
@@ -181,13 +186,17 @@ func (grg *GinRouteGroup) Group(prefix string) axon.RouteGroup {
 // convertHandler converts axon.HandlerFunc to gin.HandlerFunc
 func (ga *GinAdapter) convertHandler(handler axon.HandlerFunc) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		requestContext := &GinRequestContext{ctx: c}
-		if err := handler(requestContext); err != nil {
-			// Handle error - Gin expects errors to be handled differently
+		requestContext := &GinRequestContext{ctx: c, keyring: ga.options.keyring, proxies: ga.options.proxies}
+		if err := axon.Recover(handler)(requestContext); err != nil {
+			axon.ReportError(ga.options.reporter, requestContext, err)
+			// Handle error - Gin expects errors to be handled differently. The
+			// error goes through Response(), so that headers the handler set
+			// on a middleware's recorder are sent with it.
+			response := requestContext.Response()
 			if httpErr, ok := err.(*axon.HTTPError); ok {
-				c.JSON(httpErr.Code, gin.H{"error": httpErr.Message})
+				_ = response.JSON(httpErr.Code, gin.H{"error": httpErr.Message})
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				_ = response.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			}
 		}
 	}
@@ -196,16 +205,22 @@ func (ga *GinAdapter) convertHandler(handler axon.HandlerFunc) gin.HandlerFunc {
 // convertMiddleware converts axon.MiddlewareFunc to gin.HandlerFunc
 func (ga *GinAdapter) convertMiddleware(middleware axon.MiddlewareFunc) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		requestContext := &GinRequestContext{ctx: c}
+		requestContext := &GinRequestContext{ctx: c, keyring: ga.options.keyring, proxies: ga.options.proxies}
 
 		// Create a "next" function that calls c.Next()
 		next := func(rc axon.RequestContext) error {
+			if rc != requestContext {
+				previous, _ := c.Get(responseOverrideKey)
+				c.Set(responseOverrideKey, rc.Response())
+				defer c.Set(responseOverrideKey, previous)
+			}
 			c.Next()
 			return nil
 		}
 
-		wrappedHandler := middleware(next)
+		wrappedHandler := axon.Recover(middleware(next))
 		if err := wrappedHandler(requestContext); err != nil {
+			axon.ReportError(ga.options.reporter, requestContext, err)
 			// Handle error properly - convert axon.HTTPError
 			if httpErr, ok := err.(*axon.HTTPError); ok {
 				c.AbortWithStatusJSON(httpErr.Code, gin.H{"error": httpErr.Message})
@@ -218,7 +233,9 @@ func (ga *GinAdapter) convertMiddleware(middleware axon.MiddlewareFunc) gin.Hand
 
 // GinRequestContext implements axon.RequestContext for Gin
 type GinRequestContext struct {
-	ctx *gin.Context
+	ctx     *gin.Context
+	keyring *axon.Keyring
+	proxies *axon.TrustedProxies
 }
 
 // Method returns the HTTP method
@@ -250,9 +267,19 @@ func (grc *GinRequestContext) QueryParams() map[string][]string {
 	return grc.ctx.Request.URL.Query()
 }
 
-// RealIP returns the real IP address
+// RealIP returns the client IP address, resolved through trusted proxies
 func (grc *GinRequestContext) RealIP() string {
-	return grc.ctx.ClientIP()
+	return resolveHTTPClient(grc.proxies, grc.ctx.Request).IP
+}
+
+// Scheme returns the client scheme, resolved through trusted proxies
+func (grc *GinRequestContext) Scheme() string {
+	return resolveHTTPClient(grc.proxies, grc.ctx.Request).Scheme
+}
+
+// Host returns the client host, resolved through trusted proxies
+func (grc *GinRequestContext) Host() string {
+	return resolveHTTPClient(grc.proxies, grc.ctx.Request).Host
 }
 
 // ParamNames returns parameter names
@@ -285,12 +312,15 @@ func (grc *GinRequestContext) QueryString() string {
 
 // Request returns the request interface
 func (grc *GinRequestContext) Request() axon.RequestInterface {
-	return &GinRequestInterface{ctx: grc.ctx}
+	return &GinRequestInterface{ctx: grc.ctx, keyring: grc.keyring}
 }
 
 // Response returns the response interface
 func (grc *GinRequestContext) Response() axon.ResponseInterface {
-	return &GinResponseInterface{ctx: grc.ctx}
+	if response, ok := grc.ctx.Value(responseOverrideKey).(axon.ResponseInterface); ok {
+		return response
+	}
+	return &GinResponseInterface{ctx: grc.ctx, keyring: grc.keyring}
 }
 
 // Bind binds request body to a struct
@@ -378,12 +408,13 @@ func (grc *GinRequestContext) MultipartForm() (axon.MultipartForm, error) {
 
 // GinRequestInterface implements axon.RequestInterface for Gin
 type GinRequestInterface struct {
-	ctx *gin.Context
+	ctx     *gin.Context
+	keyring *axon.Keyring
 }
 
 // Header returns a request header
 func (gri *GinRequestInterface) Header(key string) string {
-	return gri.ctx.GetHeader(key)
+	return requestHeader(gri.ctx.Request, key)
 }
 
 // SetHeader sets a request header
@@ -393,8 +424,17 @@ func (gri *GinRequestInterface) SetHeader(key, value string) {
 
 // Body returns the request body
 func (gri *GinRequestInterface) Body() []byte {
-	body, _ := io.ReadAll(gri.ctx.Request.Body)
-	return body
+	return readBody(gri.ctx.Request)
+}
+
+// SetBody replaces the request body
+func (gri *GinRequestInterface) SetBody(body []byte) {
+	setBody(gri.ctx.Request, body)
+}
+
+// BodyStream returns the request body without buffering it
+func (gri *GinRequestInterface) BodyStream() io.ReadCloser {
+	return gri.ctx.Request.Body
 }
 
 // ContentLength returns the content length
@@ -402,25 +442,17 @@ func (gri *GinRequestInterface) ContentLength() int64 {
 	return gri.ctx.Request.ContentLength
 }
 
-// ContentType returns the content type
+// ContentType returns the Content-Type header, including parameters such as
+// the multipart boundary
 func (gri *GinRequestInterface) ContentType() string {
-	return gri.ctx.ContentType()
+	return gri.ctx.GetHeader("Content-Type")
 }
 
 // Cookies returns the request cookies
 func (gri *GinRequestInterface) Cookies() []axon.AxonCookie {
 	var cookies []axon.AxonCookie
 	for _, cookie := range gri.ctx.Request.Cookies() {
-		cookies = append(cookies, axon.AxonCookie{
-			Name:     cookie.Name,
-			Value:    cookie.Value,
-			Path:     cookie.Path,
-			Domain:   cookie.Domain,
-			MaxAge:   cookie.MaxAge,
-			Secure:   cookie.Secure,
-			HttpOnly: cookie.HttpOnly,
-			SameSite: convertGinSameSite(cookie.SameSite),
-		})
+		cookies = append(cookies, fromHTTPCookie(cookie))
 	}
 	return cookies
 }
@@ -435,25 +467,29 @@ func (gri *GinRequestInterface) Cookie(name string) (axon.AxonCookie, error) {
 	// Gin's Cookie method only returns the value, so we need to find the full cookie
 	for _, c := range gri.ctx.Request.Cookies() {
 		if c.Name == name {
-			return axon.AxonCookie{
-				Name:     c.Name,
-				Value:    c.Value,
-				Path:     c.Path,
-				Domain:   c.Domain,
-				MaxAge:   c.MaxAge,
-				Secure:   c.Secure,
-				HttpOnly: c.HttpOnly,
-				SameSite: convertGinSameSite(c.SameSite),
-			}, nil
+			return fromHTTPCookie(c), nil
 		}
 	}
 
 	return axon.AxonCookie{Name: name, Value: cookie}, nil
 }
 
+// SignedCookie returns the verified value of a signed cookie
+func (gri *GinRequestInterface) SignedCookie(name string) (string, error) {
+	c, _ := gri.ctx.Request.Cookie(name)
+	return readSignedCookie(gri.keyring, name, cookieValue(c))
+}
+
+// EncryptedCookie returns the decrypted value of an encrypted cookie
+func (gri *GinRequestInterface) EncryptedCookie(name string) (string, error) {
+	c, _ := gri.ctx.Request.Cookie(name)
+	return readEncryptedCookie(gri.keyring, name, cookieValue(c))
+}
+
 // GinResponseInterface implements axon.ResponseInterface for Gin
 type GinResponseInterface struct {
-	ctx *gin.Context
+	ctx     *gin.Context
+	keyring *axon.Keyring
 }
 
 // Status returns the response status code
@@ -476,38 +512,61 @@ func (gri *GinResponseInterface) SetHeader(key, value string) {
 	gri.ctx.Header(key, value)
 }
 
+// AddHeader adds a response header value
+func (gri *GinResponseInterface) AddHeader(key, value string) {
+	gri.ctx.Writer.Header().Add(key, value)
+}
+
 // JSON writes a JSON response
 func (gri *GinResponseInterface) JSON(code int, i interface{}) error {
+	if err := gri.cookieError(); err != nil {
+		return err
+	}
 	gri.ctx.JSON(code, i)
 	return nil
 }
 
 // JSONPretty writes a pretty JSON response
 func (gri *GinResponseInterface) JSONPretty(code int, i interface{}, indent string) error {
+	if err := gri.cookieError(); err != nil {
+		return err
+	}
 	gri.ctx.IndentedJSON(code, i)
 	return nil
 }
 
 // String writes a string response
 func (gri *GinResponseInterface) String(code int, s string) error {
+	if err := gri.cookieError(); err != nil {
+		return err
+	}
 	gri.ctx.String(code, s)
 	return nil
 }
 
 // HTML writes an HTML response
 func (gri *GinResponseInterface) HTML(code int, html string) error {
+	if err := gri.cookieError(); err != nil {
+		return err
+	}
 	gri.ctx.Data(code, "text/html; charset=utf-8", []byte(html))
 	return nil
 }
 
 // Blob writes a blob response
 func (gri *GinResponseInterface) Blob(code int, contentType string, b []byte) error {
+	if err := gri.cookieError(); err != nil {
+		return err
+	}
 	gri.ctx.Data(code, contentType, b)
 	return nil
 }
 
 // Stream writes a streaming response
 func (gri *GinResponseInterface) Stream(code int, contentType string, r interface{}) error {
+	if err := gri.cookieError(); err != nil {
+		return err
+	}
 	if reader, ok := r.(io.Reader); ok {
 		gri.ctx.DataFromReader(code, -1, contentType, reader, nil)
 		return nil
@@ -515,22 +574,38 @@ func (gri *GinResponseInterface) Stream(code int, contentType string, r interfac
 	return axon.NewHTTPError(500, "Invalid stream reader")
 }
 
-// SetCookie sets a response cookie
+// WriteStream writes a streamed response, flushed by write
+func (gri *GinResponseInterface) WriteStream(code int, contentType string, write func(axon.StreamWriter) error) error {
+	if err := gri.cookieError(); err != nil {
+		return err
+	}
+	return writeHTTPStream(gri.ctx.Writer, gri.ctx.Request, code, contentType, write)
+}
+
+// SetCookie sets a response cookie. A protected cookie that cannot be set is
+// dropped and fails the response when it is written.
 func (gri *GinResponseInterface) SetCookie(cookie axon.AxonCookie) {
-	maxAge := cookie.MaxAge
-	if cookie.MaxAge == 0 && !cookie.Expires.IsZero() {
-		maxAge = int(time.Until(cookie.Expires).Seconds())
+	cookie, err := protectCookie(gri.keyring, cookie)
+	if err != nil {
+		gri.ctx.Set(cookieErrorKey, err)
+		return
+	}
+	httpCookie := toHTTPCookie(cookie)
+	if httpCookie.Path == "" {
+		// gin.Context.SetCookie defaults the path to "/"
+		httpCookie.Path = "/"
 	}
+	http.SetCookie(gri.ctx.Writer, httpCookie)
+}
 
-	gri.ctx.SetCookie(
-		cookie.Name,
-		cookie.Value,
-		maxAge,
-		cookie.Path,
-		cookie.Domain,
-		cookie.Secure,
-		cookie.HttpOnly,
-	)
+// cookieError returns the error of a protected cookie that could not be set.
+// It is returned once, so the error response itself can still be written.
+func (gri *GinResponseInterface) cookieError() error {
+	err, _ := gri.ctx.Value(cookieErrorKey).(error)
+	if err != nil {
+		gri.ctx.Set(cookieErrorKey, nil)
+	}
+	return err
 }
 
 // Size returns the response size
@@ -547,17 +622,3 @@ func (gri *GinResponseInterface) Written() bool {
 func (gri *GinResponseInterface) Writer() interface{} {
 	return gri.ctx.Writer
 }
-
-// convertGinSameSite converts http.SameSite to axon.SameSiteMode
-func convertGinSameSite(sameSite http.SameSite) axon.SameSiteMode {
-	switch sameSite {
-	case http.SameSiteStrictMode:
-		return axon.SameSiteStrictMode
-	case http.SameSiteLaxMode:
-		return axon.SameSiteLaxMode
-	case http.SameSiteNoneMode:
-		return axon.SameSiteNoneMode
-	default:
-		return axon.SameSiteDefaultMode
-	}
-}
@@ -0,0 +1,245 @@
+package adapters
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/toyz/axon/pkg/axon"
+)
+
+// AdapterOption configures behaviour shared by all adapters
+type AdapterOption func(*adapterOptions)
+
+// adapterOptions holds the settings applied by AdapterOption
+type adapterOptions struct {
+	keyring         *axon.Keyring
+	proxies         *axon.TrustedProxies
+	frameworkLogger bool
+	reporter        axon.ErrorReporter
+	maxBodySize     *int64
+}
+
+// WithKeyring sets the keyring used for signed and encrypted cookies
+func WithKeyring(keyring *axon.Keyring) AdapterOption {
+	return func(o *adapterOptions) {
+		o.keyring = keyring
+	}
+}
+
+// WithTrustedProxies sets the proxies whose Forwarded and X-Forwarded-* headers
+// are used to resolve RealIP, Scheme and Host. Without it the headers are ignored.
+func WithTrustedProxies(proxies *axon.TrustedProxies) AdapterOption {
+	return func(o *adapterOptions) {
+		o.proxies = proxies
+	}
+}
+
+// WithErrorReporter sets the reporter that receives panics and 5xx errors
+// raised by axon handlers and middleware
+func WithErrorReporter(reporter axon.ErrorReporter) AdapterOption {
+	return func(o *adapterOptions) {
+		o.reporter = reporter
+	}
+}
+
+// WithMaxBodySize sets the server-level request body limit of the Fiber
+// adapter, which streams bodies over Fiber's BodyLimit instead of rejecting
+// them. Without it the limit is the largest -MaxBodySize of the registered
+// routes, and at least axon.DefaultMaxBodySize; routes without a limit
+// (-MaxBodySize=0) need it to accept larger bodies. 0 removes the limit.
+func WithMaxBodySize(size int64) AdapterOption {
+	return func(o *adapterOptions) {
+		o.maxBodySize = &size
+	}
+}
+
+// WithoutFrameworkLogger stops the default Gin and Fiber adapters from installing
+// their framework's request logger, e.g. when axon.AccessLog is used instead
+func WithoutFrameworkLogger() AdapterOption {
+	return func(o *adapterOptions) {
+		o.frameworkLogger = false
+	}
+}
+
+// newAdapterOptions applies opts to the default options
+func newAdapterOptions(opts []AdapterOption) adapterOptions {
+	options := adapterOptions{frameworkLogger: true}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// cookieErrorKey is the framework context key under which a protected cookie
+// that could not be set is recorded. The response it belongs to then fails
+// with that error when it is written.
+const cookieErrorKey = "axon.cookie.error"
+
+// protectCookie signs or encrypts a cookie before it is written. A protected
+// cookie without a configured keyring is a setup error: it fails with a 500
+// wrapping axon.ErrNoKeyring rather than sending the value in plain text.
+func protectCookie(keyring *axon.Keyring, cookie axon.AxonCookie) (axon.AxonCookie, error) {
+	if !cookie.Signed && !cookie.Encrypted {
+		return cookie, nil
+	}
+	protected, err := keyring.ProtectCookie(cookie)
+	if err != nil {
+		return cookie, axon.NewHTTPError(http.StatusInternalServerError, "Failed to set cookie",
+			fmt.Errorf("axon: cannot set cookie %q: %w (configure the adapter with adapters.WithKeyring)", cookie.Name, err))
+	}
+	return protected, nil
+}
+
+// readSignedCookie verifies a signed cookie value; an empty value is treated as a missing cookie
+// so every adapter reports absence the same way
+func readSignedCookie(keyring *axon.Keyring, name, value string) (string, error) {
+	if value == "" {
+		return "", http.ErrNoCookie
+	}
+	return keyring.Verify(name, value)
+}
+
+// readEncryptedCookie decrypts an encrypted cookie value
+func readEncryptedCookie(keyring *axon.Keyring, name, value string) (string, error) {
+	if value == "" {
+		return "", http.ErrNoCookie
+	}
+	return keyring.Decrypt(name, value)
+}
+
+// resolveHTTPClient resolves the client side of a net/http request
+func resolveHTTPClient(proxies *axon.TrustedProxies, r *http.Request) axon.ClientInfo {
+	return proxies.Resolve(axon.ProxyRequest{
+		RemoteAddr: r.RemoteAddr,
+		TLS:        r.TLS != nil,
+		Host:       r.Host,
+		Header:     r.Header.Values,
+	})
+}
+
+// readBody reads the request body and replaces it with a copy, so that it can
+// still be read by the handler or read again
+func readBody(r *http.Request) []byte {
+	if r.Body == nil {
+		return nil
+	}
+	body, _ := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body
+}
+
+// responseOverrideKey is the framework context key under which a response
+// replaced by middleware with axon.WithResponse is passed on to the rest of
+// the chain, which gets a new RequestContext from the framework context
+const responseOverrideKey = "axon.response"
+
+// httpStreamWriter is the axon.StreamWriter of a net/http response
+type httpStreamWriter struct {
+	writer     http.ResponseWriter
+	controller *http.ResponseController
+	done       <-chan struct{}
+}
+
+// writeHTTPStream starts a streamed net/http response and runs write
+func writeHTTPStream(w http.ResponseWriter, r *http.Request, code int, contentType string, write func(axon.StreamWriter) error) error {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Del("Content-Length")
+	w.WriteHeader(code)
+	return write(&httpStreamWriter{writer: w, controller: http.NewResponseController(w), done: r.Context().Done()})
+}
+
+func (s *httpStreamWriter) Write(p []byte) (int, error) {
+	return s.writer.Write(p)
+}
+
+func (s *httpStreamWriter) Flush() error {
+	return s.controller.Flush()
+}
+
+func (s *httpStreamWriter) Done() <-chan struct{} {
+	return s.done
+}
+
+// setBody replaces the body of a net/http request
+func setBody(r *http.Request, body []byte) {
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+}
+
+// requestHeader returns a header of a net/http request. net/http moves the Host
+// header into Request.Host, so it is read from there to match Fiber.
+func requestHeader(r *http.Request, key string) string {
+	if http.CanonicalHeaderKey(key) == "Host" {
+		return r.Host
+	}
+	return r.Header.Get(key)
+}
+
+// cookieValue returns the value of a net/http cookie, or "" if it is nil
+func cookieValue(cookie *http.Cookie) string {
+	if cookie == nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// toHTTPCookie converts an AxonCookie to a net/http cookie
+func toHTTPCookie(cookie axon.AxonCookie) *http.Cookie {
+	return &http.Cookie{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Path:     cookie.Path,
+		Domain:   cookie.Domain,
+		Expires:  cookie.Expires,
+		MaxAge:   cookie.MaxAge,
+		Secure:   cookie.Secure,
+		HttpOnly: cookie.HttpOnly,
+		SameSite: toHTTPSameSite(cookie.SameSite),
+	}
+}
+
+// fromHTTPCookie converts a net/http cookie to an AxonCookie
+func fromHTTPCookie(cookie *http.Cookie) axon.AxonCookie {
+	return axon.AxonCookie{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Path:     cookie.Path,
+		Domain:   cookie.Domain,
+		Expires:  cookie.Expires,
+		MaxAge:   cookie.MaxAge,
+		Secure:   cookie.Secure,
+		HttpOnly: cookie.HttpOnly,
+		SameSite: fromHTTPSameSite(cookie.SameSite),
+	}
+}
+
+// toHTTPSameSite maps axon SameSite modes to net/http, whose constants are numbered differently
+func toHTTPSameSite(mode axon.SameSiteMode) http.SameSite {
+	switch mode {
+	case axon.SameSiteLaxMode:
+		return http.SameSiteLaxMode
+	case axon.SameSiteStrictMode:
+		return http.SameSiteStrictMode
+	case axon.SameSiteNoneMode:
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteDefaultMode
+	}
+}
+
+// fromHTTPSameSite maps net/http SameSite modes to axon
+func fromHTTPSameSite(sameSite http.SameSite) axon.SameSiteMode {
+	switch sameSite {
+	case http.SameSiteStrictMode:
+		return axon.SameSiteStrictMode
+	case http.SameSiteLaxMode:
+		return axon.SameSiteLaxMode
+	case http.SameSiteNoneMode:
+		return axon.SameSiteNoneMode
+	default:
+		return axon.SameSiteDefaultMode
+	}
+}
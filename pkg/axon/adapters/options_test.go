@@ -0,0 +1,1147 @@
+package adapters
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labstack/echo/v4"
+	"github.com/toyz/axon/pkg/axon"
+)
+
+// cookieTestServer is an adapter that can serve a test request
+type cookieTestServer struct {
+	name   string
+	server axon.WebServerInterface
+	serve  func(req *http.Request) (*http.Response, error)
+}
+
+func newCookieTestServers(t *testing.T, opts ...AdapterOption) []cookieTestServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	echoAdapter := NewEchoAdapter(echo.New(), opts...)
+	ginAdapter := NewGinAdapter(gin.New(), opts...)
+	fiberAdapter := NewFiberAdapter(opts...)
+
+	recorderServe := func(h http.Handler) func(req *http.Request) (*http.Response, error) {
+		return func(req *http.Request) (*http.Response, error) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			return rec.Result(), nil
+		}
+	}
+
+	return []cookieTestServer{
+		{name: "echo", server: echoAdapter, serve: recorderServe(echoAdapter.engine)},
+		{name: "gin", server: ginAdapter, serve: recorderServe(ginAdapter.engine)},
+		{name: "fiber", server: fiberAdapter, serve: func(req *http.Request) (*http.Response, error) {
+			return fiberAdapter.app.Test(req, -1)
+		}},
+	}
+}
+
+func TestAdapters_ProtectedCookies(t *testing.T) {
+	keyring, err := axon.NewKeyring(bytes.Repeat([]byte("k"), axon.MinKeyringSecretSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range newCookieTestServers(t, WithKeyring(keyring)) {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.server.RegisterRoute("GET", axon.NewAxonPath("/set"), func(c axon.RequestContext) error {
+				c.Response().SetCookie(axon.AxonCookie{Name: "sig", Value: "user=42", Path: "/", SameSite: axon.SameSiteLaxMode, Signed: true})
+				c.Response().SetCookie(axon.AxonCookie{Name: "enc", Value: "secret", Path: "/", HttpOnly: true, Encrypted: true})
+				return c.Response().String(http.StatusOK, "ok")
+			})
+			tc.server.RegisterRoute("GET", axon.NewAxonPath("/get"), func(c axon.RequestContext) error {
+				signed, err := c.Request().SignedCookie("sig")
+				if err != nil {
+					return c.Response().String(http.StatusBadRequest, "sig: "+err.Error())
+				}
+				encrypted, err := c.Request().EncryptedCookie("enc")
+				if err != nil {
+					return c.Response().String(http.StatusBadRequest, "enc: "+err.Error())
+				}
+				return c.Response().String(http.StatusOK, signed+"|"+encrypted)
+			})
+			tc.server.RegisterRoute("GET", axon.NewAxonPath("/missing"), func(c axon.RequestContext) error {
+				if _, err := c.Request().SignedCookie("sig"); !errors.Is(err, http.ErrNoCookie) {
+					return c.Response().String(http.StatusBadRequest, "expected ErrNoCookie")
+				}
+				return c.Response().String(http.StatusOK, "ok")
+			})
+
+			resp, err := tc.serve(httptest.NewRequest("GET", "/set", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			cookies := resp.Cookies()
+			if len(cookies) != 2 {
+				t.Fatalf("expected 2 cookies, got %d: %v", len(cookies), resp.Header["Set-Cookie"])
+			}
+			for _, cookie := range cookies {
+				if cookie.Value == "user=42" || cookie.Value == "secret" {
+					t.Errorf("cookie %s was sent unprotected", cookie.Name)
+				}
+				if cookie.Name == "sig" && cookie.SameSite != http.SameSiteLaxMode {
+					t.Errorf("expected SameSite=Lax on %s, got %v", cookie.Name, cookie.SameSite)
+				}
+			}
+
+			req := httptest.NewRequest("GET", "/get", nil)
+			for _, cookie := range cookies {
+				req.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+			}
+			resp, err = tc.serve(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != http.StatusOK || string(body) != "user=42|secret" {
+				t.Errorf("got %d %q", resp.StatusCode, body)
+			}
+
+			tampered := httptest.NewRequest("GET", "/get", nil)
+			for _, cookie := range cookies {
+				tampered.AddCookie(&http.Cookie{Name: cookie.Name, Value: "x" + cookie.Value})
+			}
+			resp, _ = tc.serve(tampered)
+			if body, _ := io.ReadAll(resp.Body); resp.StatusCode != http.StatusBadRequest || !bytes.Contains(body, []byte(axon.ErrInvalidCookie.Error())) {
+				t.Errorf("tampered cookie: got %d %q", resp.StatusCode, body)
+			}
+
+			resp, _ = tc.serve(httptest.NewRequest("GET", "/missing", nil))
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("missing cookie: got %d", resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestAdapters_ProtectedCookieWithoutKeyring(t *testing.T) {
+	reporter := axon.NewMemoryErrorReporter()
+
+	for _, tc := range newCookieTestServers(t, WithErrorReporter(reporter)) {
+		t.Run(tc.name, func(t *testing.T) {
+			reporter.Reset()
+			tc.server.RegisterRoute("GET", axon.NewAxonPath("/get"), func(c axon.RequestContext) error {
+				if _, err := c.Request().SignedCookie("sig"); !errors.Is(err, axon.ErrNoKeyring) {
+					return c.Response().String(http.StatusBadRequest, "expected ErrNoKeyring")
+				}
+				return c.Response().String(http.StatusOK, "ok")
+			})
+			tc.server.RegisterRoute("GET", axon.NewAxonPath("/set"), func(c axon.RequestContext) error {
+				c.Response().SetCookie(axon.AxonCookie{Name: "sig", Value: "user=42", Signed: true})
+				return c.Response().String(http.StatusOK, "ok")
+			})
+
+			req := httptest.NewRequest("GET", "/get", nil)
+			req.AddCookie(&http.Cookie{Name: "sig", Value: "a.b"})
+			resp, err := tc.serve(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("got %d", resp.StatusCode)
+			}
+
+			// Setting a protected cookie fails the response instead of panicking
+			// or sending the value unprotected
+			resp, err = tc.serve(httptest.NewRequest("GET", "/set", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if resp.StatusCode != http.StatusInternalServerError {
+				t.Errorf("expected 500, got %d", resp.StatusCode)
+			}
+			if cookies := resp.Header.Values("Set-Cookie"); len(cookies) != 0 {
+				t.Errorf("expected no cookie, got %v", cookies)
+			}
+			reports := reporter.Reports()
+			if len(reports) != 1 || !errors.Is(reports[0].Err, axon.ErrNoKeyring) {
+				t.Errorf("expected the missing keyring to be reported, got %+v", reports)
+			}
+		})
+	}
+}
+
+func TestAdapters_CSRF(t *testing.T) {
+	keyring, err := axon.NewKeyring(bytes.Repeat([]byte("k"), axon.MinKeyringSecretSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	csrf, err := axon.NewCSRF(axon.CSRFConfig{SignCookie: true, Routes: axon.NewInMemoryRouteRegistry()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range newCookieTestServers(t, WithKeyring(keyring)) {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.server.Use(csrf.Handle)
+			handler := func(c axon.RequestContext) error {
+				return c.Response().String(http.StatusOK, "ok")
+			}
+			tc.server.RegisterRoute("GET", axon.NewAxonPath("/form"), handler)
+			tc.server.RegisterRoute("POST", axon.NewAxonPath("/submit"), handler)
+
+			resp, err := tc.serve(httptest.NewRequest("GET", "http://example.com/form", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			token := resp.Header.Get("X-CSRF-Token")
+			cookies := resp.Cookies()
+			if token == "" || len(cookies) != 1 {
+				t.Fatalf("expected a token and a cookie, got %q and %v", token, resp.Header["Set-Cookie"])
+			}
+
+			post := func(origin, token string) int {
+				req := httptest.NewRequest("POST", "http://example.com/submit", nil)
+				req.AddCookie(&http.Cookie{Name: cookies[0].Name, Value: cookies[0].Value})
+				req.Header.Set("Origin", origin)
+				if token != "" {
+					req.Header.Set("X-CSRF-Token", token)
+				}
+				resp, err := tc.serve(req)
+				if err != nil {
+					t.Fatalf("request failed: %v", err)
+				}
+				return resp.StatusCode
+			}
+
+			if status := post("http://example.com", token); status != http.StatusOK {
+				t.Errorf("same-origin request with token: got %d", status)
+			}
+			if status := post("http://example.com", ""); status != http.StatusForbidden {
+				t.Errorf("request without token: got %d", status)
+			}
+			if status := post("https://evil.example", token); status != http.StatusForbidden {
+				t.Errorf("cross-origin request: got %d", status)
+			}
+		})
+	}
+}
+
+func TestAdapters_CSRFWithUploads(t *testing.T) {
+	routes := axon.NewInMemoryRouteRegistry()
+	routes.RegisterRoute(axon.RouteInfo{Method: "POST", Path: "/avatar", StreamsBody: true})
+	csrf, err := axon.NewCSRF(axon.CSRFConfig{Routes: routes})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range newCookieTestServers(t) {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.server.Use(csrf.Handle)
+			tc.server.RegisterRoute("GET", axon.NewAxonPath("/form"), func(c axon.RequestContext) error {
+				return c.Response().String(http.StatusOK, "ok")
+			})
+			tc.server.RegisterRoute("POST", axon.NewAxonPath("/avatar"), axon.WithBodyLimit(axon.BodyLimitPolicy{MaxSize: 1 << 20, Stream: true})(func(c axon.RequestContext) error {
+				form, err := axon.ReadMultipartForm(c, axon.UploadRule{Field: "avatar", MaxCount: 1, Required: true})
+				if err != nil {
+					return err
+				}
+				defer form.RemoveAll()
+				return c.Response().String(http.StatusOK, fmt.Sprint(form.Files("avatar")[0].Size))
+			}))
+			tc.server.RegisterRoute("POST", axon.NewAxonPath("/profile"), func(c axon.RequestContext) error {
+				return c.Response().String(http.StatusOK, c.FormValue("name"))
+			})
+
+			resp, err := tc.serve(httptest.NewRequest("GET", "http://example.com/form", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			token, cookies := resp.Header.Get("X-CSRF-Token"), resp.Cookies()
+			if token == "" || len(cookies) != 1 {
+				t.Fatalf("expected a token and a cookie, got %q and %v", token, resp.Header["Set-Cookie"])
+			}
+
+			post := func(path, headerToken, formToken string) (int, string) {
+				var buf bytes.Buffer
+				writer := multipart.NewWriter(&buf)
+				_ = writer.WriteField("name", "alice")
+				if formToken != "" {
+					_ = writer.WriteField("csrf_token", formToken)
+				}
+				part, _ := writer.CreateFormFile("avatar", "me.png")
+				_, _ = part.Write(bytes.Repeat([]byte{1}, 1000))
+				_ = writer.Close()
+
+				req := httptest.NewRequest("POST", "http://example.com"+path, &buf)
+				req.Header.Set("Content-Type", writer.FormDataContentType())
+				req.AddCookie(&http.Cookie{Name: cookies[0].Name, Value: cookies[0].Value})
+				if headerToken != "" {
+					req.Header.Set("X-CSRF-Token", headerToken)
+				}
+				resp, err := tc.serve(req)
+				if err != nil {
+					t.Fatalf("request failed: %v", err)
+				}
+				body, _ := io.ReadAll(resp.Body)
+				return resp.StatusCode, string(body)
+			}
+
+			// The upload body is left for the streaming reader
+			if status, body := post("/avatar", token, ""); status != http.StatusOK || body != "1000" {
+				t.Errorf("upload with header token: got %d %q", status, body)
+			}
+			// Upload routes take the token in the header only
+			if status, _ := post("/avatar", "", token); status != http.StatusForbidden {
+				t.Errorf("upload with form token: expected 403, got %d", status)
+			}
+			// Other multipart forms may still carry the token in a field
+			if status, body := post("/profile", "", token); status != http.StatusOK || body != "alice" {
+				t.Errorf("form with form token: got %d %q", status, body)
+			}
+		})
+	}
+}
+
+func TestAdapters_TrustedProxies(t *testing.T) {
+	// httptest requests come from 192.0.2.1; fiber's app.Test uses 0.0.0.0
+	proxies, err := axon.NewTrustedProxies("192.0.2.1", "0.0.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forwardedRequest := func() *http.Request {
+		req := httptest.NewRequest("GET", "http://internal.local/client", nil)
+		req.Header.Set("X-Forwarded-For", "1.1.1.1, 203.0.113.9")
+		req.Header.Set("X-Forwarded-Proto", "https")
+		req.Header.Set("X-Forwarded-Host", "www.example.com")
+		return req
+	}
+	handler := func(c axon.RequestContext) error {
+		return c.Response().String(http.StatusOK, c.RealIP()+"|"+c.Scheme()+"|"+c.Host())
+	}
+
+	for _, tc := range newCookieTestServers(t, WithTrustedProxies(proxies)) {
+		t.Run(tc.name+"/trusted", func(t *testing.T) {
+			tc.server.RegisterRoute("GET", axon.NewAxonPath("/client"), handler)
+
+			resp, err := tc.serve(forwardedRequest())
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if body, _ := io.ReadAll(resp.Body); string(body) != "203.0.113.9|https|www.example.com" {
+				t.Errorf("got %q", body)
+			}
+		})
+	}
+
+	for _, tc := range newCookieTestServers(t) {
+		t.Run(tc.name+"/untrusted", func(t *testing.T) {
+			tc.server.RegisterRoute("GET", axon.NewAxonPath("/client"), handler)
+
+			resp, err := tc.serve(forwardedRequest())
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if bytes.Contains(body, []byte("203.0.113.9")) || !bytes.HasSuffix(body, []byte("|http|internal.local")) {
+				t.Errorf("forwarding headers must be ignored without trusted proxies, got %q", body)
+			}
+		})
+	}
+}
+
+func TestAdapters_AccessLog(t *testing.T) {
+	for _, tc := range newCookieTestServers(t) {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			accessLog := axon.NewAccessLog(axon.AccessLogConfig{
+				Logger:  slog.New(slog.NewJSONHandler(&buf, nil)),
+				LogBody: true,
+			})
+			tc.server.Use(accessLog.Handle)
+
+			tc.server.RegisterRoute("POST", axon.NewAxonPath("/users"), func(c axon.RequestContext) error {
+				var user struct{ Name string }
+				if err := json.Unmarshal(c.Request().Body(), &user); err != nil {
+					return axon.NewHTTPError(http.StatusBadRequest, "invalid body")
+				}
+				return c.Response().String(http.StatusCreated, user.Name)
+			}, axon.WithRouteMatch(axon.RouteMatch{Method: "POST", Path: "/users", Controller: "UserController", Handler: "Create"}))
+			tc.server.RegisterRoute("GET", axon.NewAxonPath("/users/{id}"), func(c axon.RequestContext) error {
+				return axon.NewHTTPError(http.StatusNotFound, "user not found")
+			})
+
+			req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"alice","password":"hunter2"}`))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := tc.serve(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != http.StatusCreated || string(body) != "alice" {
+				t.Fatalf("expected the handler to read the logged body, got %d %q", resp.StatusCode, body)
+			}
+
+			if _, err := tc.serve(httptest.NewRequest("GET", "/users/7", nil)); err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			var records []map[string]interface{}
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				var record map[string]interface{}
+				if err := json.Unmarshal([]byte(line), &record); err != nil {
+					t.Fatalf("invalid log line %q: %v", line, err)
+				}
+				records = append(records, record)
+			}
+			if len(records) != 2 {
+				t.Fatalf("expected 2 log records, got %d: %s", len(records), buf.String())
+			}
+
+			created := records[0]
+			if created["route"] != "/users" || created["status"] != float64(201) || created["bytes"] != float64(5) {
+				t.Errorf("unexpected record for created user: %v", created)
+			}
+			if logged, _ := created["body"].(map[string]interface{}); logged["password"] != axon.RedactedValue || logged["name"] != "alice" {
+				t.Errorf("expected redacted body, got %v", created["body"])
+			}
+
+			missing := records[1]
+			if missing["status"] != float64(404) || missing["level"] != "WARN" || missing["path"] != "/users/7" {
+				t.Errorf("unexpected record for missing user: %v", missing)
+			}
+		})
+	}
+}
+
+func TestAdapters_PanicRecovery(t *testing.T) {
+	reporter := axon.NewMemoryErrorReporter()
+	errDatabase := errors.New("database unavailable")
+
+	for _, tc := range newCookieTestServers(t, WithErrorReporter(reporter)) {
+		t.Run(tc.name, func(t *testing.T) {
+			reporter.Reset()
+			authenticate := func(next axon.HandlerFunc) axon.HandlerFunc {
+				return func(c axon.RequestContext) error {
+					axon.SetPrincipal(c, &axon.BasicPrincipal{Subject: "alice"})
+					return next(c)
+				}
+			}
+			panicking := func(next axon.HandlerFunc) axon.HandlerFunc {
+				return func(c axon.RequestContext) error {
+					panic("middleware boom")
+				}
+			}
+
+			tc.server.RegisterRoute("GET", axon.NewAxonPath("/panic"), func(c axon.RequestContext) error {
+				panic("handler boom")
+			}, axon.WithRouteMatch(axon.RouteMatch{Method: "GET", Path: "/panic", Controller: "TestController", Handler: "Panic"}), authenticate)
+			tc.server.RegisterRoute("GET", axon.NewAxonPath("/middleware-panic"), func(c axon.RequestContext) error {
+				return c.Response().String(http.StatusOK, "unreachable")
+			}, panicking)
+			tc.server.RegisterRoute("GET", axon.NewAxonPath("/unavailable"), func(c axon.RequestContext) error {
+				return axon.NewHTTPError(http.StatusServiceUnavailable, "down for maintenance")
+			})
+			tc.server.RegisterRoute("GET", axon.NewAxonPath("/missing"), func(c axon.RequestContext) error {
+				return axon.NewHTTPError(http.StatusNotFound, "not found")
+			})
+			// Generated handleError wraps plain handler errors this way
+			tc.server.RegisterRoute("GET", axon.NewAxonPath("/failed"), func(c axon.RequestContext) error {
+				return axon.NewHTTPError(http.StatusInternalServerError, errDatabase.Error(), errDatabase)
+			})
+
+			for path, want := range map[string]int{
+				"/panic":            http.StatusInternalServerError,
+				"/middleware-panic": http.StatusInternalServerError,
+				"/unavailable":      http.StatusServiceUnavailable,
+				"/missing":          http.StatusNotFound,
+				"/failed":           http.StatusInternalServerError,
+			} {
+				resp, err := tc.serve(httptest.NewRequest("GET", path, nil))
+				if err != nil {
+					t.Fatalf("request failed: %v", err)
+				}
+				body, _ := io.ReadAll(resp.Body)
+				if resp.StatusCode != want {
+					t.Errorf("%s: expected %d, got %d", path, want, resp.StatusCode)
+				}
+				if strings.Contains(string(body), "boom") || strings.Contains(string(body), "goroutine") {
+					t.Errorf("%s: panic details leaked into the response: %s", path, body)
+				}
+			}
+
+			reports := map[string]axon.ErrorReport{}
+			for _, report := range reporter.Reports() {
+				reports[report.Path] = report
+			}
+			if len(reports) != 4 || len(reporter.Reports()) != 4 {
+				t.Fatalf("expected one report each for the panics, the 503 and the handler error, got %+v", reporter.Reports())
+			}
+
+			handlerPanic := reports["/panic"]
+			if handlerPanic.Panic == nil || handlerPanic.Panic.Value != "handler boom" || len(handlerPanic.Panic.Stack) == 0 {
+				t.Errorf("expected the handler panic with a stack, got %+v", handlerPanic)
+			}
+			if handlerPanic.Route.Controller != "TestController" || handlerPanic.Principal != "alice" || handlerPanic.Status != http.StatusInternalServerError {
+				t.Errorf("expected route, principal and status on the report, got %+v", handlerPanic)
+			}
+			if middlewarePanic := reports["/middleware-panic"]; middlewarePanic.Panic == nil || middlewarePanic.Panic.Value != "middleware boom" {
+				t.Errorf("expected the middleware panic, got %+v", middlewarePanic)
+			}
+			if unavailable := reports["/unavailable"]; unavailable.Panic != nil || unavailable.Status != http.StatusServiceUnavailable {
+				t.Errorf("expected the 503 error, got %+v", unavailable)
+			}
+			if failed := reports["/failed"]; !errors.Is(failed.Err, errDatabase) || failed.Status != http.StatusInternalServerError {
+				t.Errorf("expected the handler error, got %+v", failed)
+			}
+		})
+	}
+}
+
+func TestAdapters_ResponseCache(t *testing.T) {
+	for _, tc := range newCookieTestServers(t) {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := axon.NewResponseCache(axon.ResponseCacheConfig{})
+			calls := 0
+			handler := axon.WithCache(cache, axon.CachePolicy{
+				Route: "ProductController.GetProduct",
+				Path:  "/products/{id:int}",
+				TTL:   time.Minute,
+				Vary:  []string{"Accept-Language"},
+			})(func(c axon.RequestContext) error {
+				calls++
+				c.Response().AddHeader("Link", "</app.css>; rel=preload")
+				c.Response().AddHeader("Link", "</app.js>; rel=preload")
+				return c.Response().JSON(http.StatusOK, map[string]interface{}{"id": c.Param("id"), "call": calls})
+			})
+			tc.server.RegisterRoute("GET", axon.NewAxonPath("/products/{id:int}"), handler)
+
+			get := func(path, language string) (*http.Response, string) {
+				req := httptest.NewRequest("GET", path, nil)
+				req.Header.Set("Accept-Language", language)
+				resp, err := tc.serve(req)
+				if err != nil {
+					t.Fatalf("request failed: %v", err)
+				}
+				body, _ := io.ReadAll(resp.Body)
+				return resp, string(body)
+			}
+
+			first, firstBody := get("/products/1", "en")
+			if first.Header.Get("X-Cache") != "MISS" || first.Header.Get("Cache-Control") != "public, max-age=60" {
+				t.Errorf("expected a cacheable miss, got headers %v", first.Header)
+			}
+
+			second, secondBody := get("/products/1", "en")
+			if second.Header.Get("X-Cache") != "HIT" || second.Header.Get("Age") == "" {
+				t.Errorf("expected a hit with an Age, got headers %v", second.Header)
+			}
+			if secondBody != firstBody || !strings.HasPrefix(second.Header.Get("Content-Type"), "application/json") {
+				t.Errorf("expected the cached JSON body %s, got %s (%s)", firstBody, secondBody, second.Header.Get("Content-Type"))
+			}
+			if second.Header.Get("Vary") != "Accept-Language" {
+				t.Errorf("expected Vary: Accept-Language, got %q", second.Header.Get("Vary"))
+			}
+			for _, resp := range []*http.Response{first, second} {
+				if links := resp.Header.Values("Link"); len(links) != 2 || links[0] != "</app.css>; rel=preload" || links[1] != "</app.js>; rel=preload" {
+					t.Errorf("expected both Link values on %s, got %v", resp.Header.Get("X-Cache"), links)
+				}
+			}
+
+			if resp, _ := get("/products/2", "en"); resp.Header.Get("X-Cache") != "MISS" {
+				t.Errorf("expected other path parameters to miss")
+			}
+			if resp, _ := get("/products/1", "de"); resp.Header.Get("X-Cache") != "MISS" {
+				t.Errorf("expected another Accept-Language to miss")
+			}
+
+			if err := cache.Invalidate("ProductController.GetProduct", map[string]string{"id": "1"}); err != nil {
+				t.Fatalf("invalidate failed: %v", err)
+			}
+			if resp, _ := get("/products/1", "en"); resp.Header.Get("X-Cache") != "MISS" {
+				t.Errorf("expected a miss after invalidation")
+			}
+			if calls != 4 {
+				t.Errorf("expected the handler to run 4 times, ran %d", calls)
+			}
+		})
+	}
+}
+
+func TestAdapters_ETag(t *testing.T) {
+	for _, tc := range newCookieTestServers(t) {
+		t.Run(tc.name, func(t *testing.T) {
+			name := "alice"
+			current := func(c axon.RequestContext) error {
+				return c.Response().JSON(http.StatusOK, map[string]string{"name": name})
+			}
+			update := func(c axon.RequestContext) error {
+				name = "bob"
+				return c.Response().JSON(http.StatusOK, map[string]string{"name": name})
+			}
+			tc.server.RegisterRoute("GET", axon.NewAxonPath("/users/{id:int}"), axon.WithETag(axon.ETagPolicy{})(current))
+			tc.server.RegisterRoute("PUT", axon.NewAxonPath("/users/{id:int}"), axon.WithETag(axon.ETagPolicy{Current: current})(update))
+
+			request := func(method string, header, value string) *http.Response {
+				req := httptest.NewRequest(method, "/users/1", nil)
+				if header != "" {
+					req.Header.Set(header, value)
+				}
+				resp, err := tc.serve(req)
+				if err != nil {
+					t.Fatalf("request failed: %v", err)
+				}
+				return resp
+			}
+
+			first := request("GET", "", "")
+			etag := first.Header.Get("ETag")
+			if first.StatusCode != http.StatusOK || etag == "" {
+				t.Fatalf("expected 200 with an ETag, got %d %q", first.StatusCode, etag)
+			}
+
+			notModified := request("GET", "If-None-Match", etag)
+			body, _ := io.ReadAll(notModified.Body)
+			if notModified.StatusCode != http.StatusNotModified || len(body) != 0 || notModified.Header.Get("ETag") != etag {
+				t.Errorf("expected an empty 304 with the ETag, got %d %q %q", notModified.StatusCode, body, notModified.Header.Get("ETag"))
+			}
+
+			if resp := request("PUT", "If-Match", `"stale"`); resp.StatusCode != http.StatusPreconditionFailed {
+				t.Errorf("expected 412 for a stale If-Match, got %d", resp.StatusCode)
+			}
+			if name != "alice" {
+				t.Fatalf("the update ran despite the failed precondition")
+			}
+			if resp := request("PUT", "If-Match", etag); resp.StatusCode != http.StatusOK {
+				t.Errorf("expected 200 for a matching If-Match, got %d", resp.StatusCode)
+			}
+			if resp := request("GET", "If-None-Match", etag); resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") == etag {
+				t.Errorf("expected a new representation after the update, got %d", resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestAdapters_Idempotency(t *testing.T) {
+	for _, tc := range newCookieTestServers(t) {
+		t.Run(tc.name, func(t *testing.T) {
+			idempotency := axon.NewIdempotency(axon.IdempotencyConfig{})
+			calls := 0
+			handler := axon.WithIdempotency(idempotency, axon.IdempotencyPolicy{Route: "OrderController.CreateOrder", Path: "/orders"})(func(c axon.RequestContext) error {
+				calls++
+				var order map[string]string
+				if err := c.Bind(&order); err != nil {
+					return err
+				}
+				order["id"] = strings.Repeat("x", calls)
+				return c.Response().JSON(http.StatusCreated, order)
+			})
+			tc.server.RegisterRoute("POST", axon.NewAxonPath("/orders"), handler)
+
+			post := func(key, body string) (*http.Response, string) {
+				req := httptest.NewRequest("POST", "/orders", strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set(axon.IdempotencyKeyHeader, key)
+				resp, err := tc.serve(req)
+				if err != nil {
+					t.Fatalf("request failed: %v", err)
+				}
+				respBody, _ := io.ReadAll(resp.Body)
+				return resp, string(respBody)
+			}
+
+			// The handler still binds the body after the middleware fingerprinted it
+			first, firstBody := post("key-1", `{"item":"book"}`)
+			if first.StatusCode != http.StatusCreated || !strings.Contains(firstBody, `"item":"book"`) {
+				t.Fatalf("expected the order to be created, got %d %s", first.StatusCode, firstBody)
+			}
+
+			retry, retryBody := post("key-1", `{"item":"book"}`)
+			if retry.StatusCode != http.StatusCreated || retryBody != firstBody || retry.Header.Get("Idempotent-Replayed") != "true" {
+				t.Errorf("expected the stored response to be replayed, got %d %s %v", retry.StatusCode, retryBody, retry.Header)
+			}
+
+			if resp, _ := post("key-1", `{"item":"pen"}`); resp.StatusCode != http.StatusUnprocessableEntity {
+				t.Errorf("expected 422 for a reused key, got %d", resp.StatusCode)
+			}
+			if calls != 1 {
+				t.Errorf("expected the handler to run once, ran %d", calls)
+			}
+		})
+	}
+}
+
+func TestAdapters_Compression(t *testing.T) {
+	compression, err := axon.NewCompression(axon.CompressionConfig{DecompressRequests: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := strings.Repeat("axon ", 500)
+
+	for _, tc := range newCookieTestServers(t) {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.server.Use(compression.Handle)
+			tc.server.RegisterRoute("POST", axon.NewAxonPath("/echo"), func(c axon.RequestContext) error {
+				var body map[string]string
+				if err := c.Bind(&body); err != nil {
+					return err
+				}
+				return c.Response().JSON(http.StatusOK, body)
+			})
+
+			var compressed bytes.Buffer
+			writer := gzip.NewWriter(&compressed)
+			_ = json.NewEncoder(writer).Encode(map[string]string{"text": payload})
+			writer.Close()
+
+			req := httptest.NewRequest("POST", "/echo", &compressed)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Content-Encoding", "gzip")
+			req.Header.Set("Accept-Encoding", "gzip")
+			resp, err := tc.serve(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Encoding") != "gzip" {
+				t.Fatalf("expected a gzip response, got %d %v", resp.StatusCode, resp.Header)
+			}
+			if !strings.Contains(resp.Header.Get("Vary"), "Accept-Encoding") {
+				t.Errorf("expected Vary: Accept-Encoding, got %q", resp.Header.Get("Vary"))
+			}
+
+			reader, err := gzip.NewReader(resp.Body)
+			if err != nil {
+				t.Fatalf("invalid gzip body: %v", err)
+			}
+			var body map[string]string
+			if err := json.NewDecoder(reader).Decode(&body); err != nil {
+				t.Fatalf("invalid JSON body: %v", err)
+			}
+			if body["text"] != payload {
+				t.Errorf("expected the decompressed request to round-trip, got %d bytes", len(body["text"]))
+			}
+		})
+	}
+}
+
+func TestAdapters_BodyLimit(t *testing.T) {
+	// Fiber's server-level limit covers the largest route limit, as it does
+	// for generated routes
+	for _, tc := range newCookieTestServers(t, WithMaxBodySize(8<<20)) {
+		t.Run(tc.name, func(t *testing.T) {
+			if fiberAdapter, ok := tc.server.(*FiberAdapter); ok {
+				// Rejected bodies are left unread and the connection is
+				// closed, which fiber's app.Test reports as an error
+				tc.serve = serveOverNetwork(t, fiberAdapter)
+			}
+			bound := false
+			tc.server.RegisterRoute("POST", axon.NewAxonPath("/bind"), axon.WithBodyLimit(axon.BodyLimitPolicy{MaxSize: 1 << 10})(func(c axon.RequestContext) error {
+				var body map[string]string
+				if err := c.Bind(&body); err != nil {
+					return err
+				}
+				bound = true
+				return c.Response().String(http.StatusOK, body["text"])
+			}))
+			upload := func(c axon.RequestContext) error {
+				n, err := io.Copy(io.Discard, axon.GetBodyStream(c))
+				if err != nil {
+					return err
+				}
+				return c.Response().String(http.StatusOK, strconv.FormatInt(n, 10))
+			}
+			tc.server.RegisterRoute("PUT", axon.NewAxonPath("/upload"), axon.WithBodyLimit(axon.BodyLimitPolicy{MaxSize: 8 << 20, Stream: true})(upload))
+			tc.server.RegisterRoute("PUT", axon.NewAxonPath("/upload/small"), axon.WithBodyLimit(axon.BodyLimitPolicy{MaxSize: 1 << 10, Stream: true})(upload))
+			// Routes without -MaxBodySize are generated with the default limit
+			tc.server.RegisterRoute("POST", axon.NewAxonPath("/default"), axon.WithBodyLimit(axon.BodyLimitPolicy{MaxSize: axon.DefaultMaxBodySize})(func(c axon.RequestContext) error {
+				bound = true
+				return c.Response().String(http.StatusOK, strconv.Itoa(len(c.Request().Body())))
+			}))
+
+			jsonBody := func(size int) string {
+				return `{"text":"` + strings.Repeat("x", size) + `"}`
+			}
+			// chunked hides the length of a body, as with Transfer-Encoding: chunked
+			chunked := func(body string) io.Reader {
+				return io.MultiReader(strings.NewReader(body))
+			}
+
+			tests := []struct {
+				name   string
+				method string
+				path   string
+				body   io.Reader
+				status int
+				want   string
+			}{
+				{"within limit", "POST", "/bind", strings.NewReader(jsonBody(10)), http.StatusOK, strings.Repeat("x", 10)},
+				{"declared too large", "POST", "/bind", strings.NewReader(jsonBody(2 << 10)), http.StatusRequestEntityTooLarge, ""},
+				{"chunked within limit", "POST", "/bind", chunked(jsonBody(10)), http.StatusOK, strings.Repeat("x", 10)},
+				{"chunked too large", "POST", "/bind", chunked(jsonBody(2 << 10)), http.StatusRequestEntityTooLarge, ""},
+				// Larger than Fiber's default BodyLimit of 4MB
+				{"streamed", "PUT", "/upload", strings.NewReader(strings.Repeat("x", 5<<20)), http.StatusOK, strconv.Itoa(5 << 20)},
+				{"streamed too large", "PUT", "/upload/small", chunked(strings.Repeat("x", 2<<10)), http.StatusRequestEntityTooLarge, ""},
+				{"default limit", "POST", "/default", strings.NewReader(strings.Repeat("x", 1<<20)), http.StatusOK, strconv.Itoa(1 << 20)},
+				{"default limit too large", "POST", "/default", strings.NewReader(strings.Repeat("x", 4<<20+1<<10)), http.StatusRequestEntityTooLarge, ""},
+				{"default limit chunked too large", "POST", "/default", chunked(strings.Repeat("x", 5<<20)), http.StatusRequestEntityTooLarge, ""},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					bound = false
+					req := httptest.NewRequest(tt.method, tt.path, tt.body)
+					req.Header.Set("Content-Type", "application/json")
+					if req.ContentLength < 0 {
+						req.TransferEncoding = []string{"chunked"}
+					}
+					resp, err := tc.serve(req)
+					if err != nil {
+						t.Fatalf("request failed: %v", err)
+					}
+					if resp.StatusCode != tt.status {
+						t.Fatalf("expected status %d, got %d", tt.status, resp.StatusCode)
+					}
+					if tt.status != http.StatusOK {
+						if bound {
+							t.Errorf("expected the body not to be bound")
+						}
+						if !resp.Close && resp.Header.Get("Connection") != "close" {
+							t.Errorf("expected the connection to be closed")
+						}
+						return
+					}
+					body, _ := io.ReadAll(resp.Body)
+					if string(body) != tt.want {
+						t.Errorf("expected body %q, got %d bytes", tt.want, len(body))
+					}
+				})
+			}
+		})
+	}
+}
+
+// serveOverNetwork serves requests with a Fiber app listening on a local port.
+// The request is written in the background while the response is read, so a
+// response sent before the body is read is received even though the server
+// then closes the connection on the rest of the body.
+func serveOverNetwork(t *testing.T, adapter *FiberAdapter) func(req *http.Request) (*http.Response, error) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = adapter.app.Listener(listener) }()
+	t.Cleanup(func() { _ = adapter.app.Shutdown() })
+
+	return func(req *http.Request) (*http.Response, error) {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			return nil, err
+		}
+		t.Cleanup(func() { conn.Close() })
+		go func() { _ = req.Write(conn) }()
+		return http.ReadResponse(bufio.NewReader(conn), req)
+	}
+}
+
+func TestAdapters_MultipartUpload(t *testing.T) {
+	type avatarUpload struct {
+		Caption string             `form:"caption"`
+		Avatar  *axon.UploadedFile `form:"avatar"`
+	}
+
+	for _, tc := range newCookieTestServers(t, WithMaxBodySize(8<<20)) {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.server.RegisterRoute("POST", axon.NewAxonPath("/avatar"), axon.WithBodyLimit(axon.BodyLimitPolicy{MaxSize: 8 << 20, Stream: true})(func(c axon.RequestContext) error {
+				form, err := axon.ReadMultipartForm(c, axon.UploadRule{Field: "avatar", MaxCount: 1, Accept: []string{"image/png"}, Required: true})
+				if err != nil {
+					return err
+				}
+				defer form.RemoveAll()
+				var body avatarUpload
+				if err := form.Bind(&body); err != nil {
+					return err
+				}
+				return c.Response().String(http.StatusOK, fmt.Sprintf("%s:%d:%s", body.Caption, body.Avatar.Size, body.Avatar.ContentType))
+			}))
+
+			multipartBody := func(content []byte) (io.Reader, string) {
+				var buf bytes.Buffer
+				writer := multipart.NewWriter(&buf)
+				_ = writer.WriteField("caption", "me")
+				part, _ := writer.CreateFormFile("avatar", "me.png")
+				_, _ = part.Write(content)
+				_ = writer.Close()
+				return &buf, writer.FormDataContentType()
+			}
+
+			// Larger than Fiber's default BodyLimit of 4MB, and spilled to disk
+			png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 5<<20)...)
+			tests := []struct {
+				name    string
+				content []byte
+				status  int
+				want    string
+			}{
+				{"uploaded", png, http.StatusOK, fmt.Sprintf("me:%d:image/png", len(png))},
+				{"wrong type", []byte("plain text"), http.StatusUnsupportedMediaType, ""},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					body, contentType := multipartBody(tt.content)
+					req := httptest.NewRequest("POST", "/avatar", body)
+					req.Header.Set("Content-Type", contentType)
+					resp, err := tc.serve(req)
+					if err != nil {
+						t.Fatalf("request failed: %v", err)
+					}
+					if resp.StatusCode != tt.status {
+						t.Fatalf("expected status %d, got %d", tt.status, resp.StatusCode)
+					}
+					if tt.want != "" {
+						got, _ := io.ReadAll(resp.Body)
+						if string(got) != tt.want {
+							t.Errorf("expected body %q, got %q", tt.want, got)
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestAdapters_Stream(t *testing.T) {
+	type item struct {
+		ID int `json:"id"`
+	}
+	items := func(fail bool) iter.Seq2[item, error] {
+		return func(yield func(item, error) bool) {
+			if fail {
+				yield(item{}, axon.NewHTTPError(http.StatusNotFound, "no items"))
+				return
+			}
+			for i := 1; i <= 3; i++ {
+				if !yield(item{ID: i}, nil) {
+					return
+				}
+			}
+		}
+	}
+
+	for _, tc := range newCookieTestServers(t) {
+		t.Run(tc.name, func(t *testing.T) {
+			// Buffering decorators pass streams straight through
+			tc.server.RegisterRoute("GET", axon.NewAxonPath("/items"), axon.WithETag(axon.ETagPolicy{})(func(c axon.RequestContext) error {
+				return axon.StreamSeq2(c, items(c.QueryParam("fail") != ""))
+			}))
+
+			tests := []struct {
+				name        string
+				target      string
+				accept      string
+				status      int
+				contentType string
+				want        string
+			}{
+				{"json array", "/items", "", http.StatusOK, "application/json", "[{\"id\":1}\n,{\"id\":2}\n,{\"id\":3}\n]"},
+				{"ndjson", "/items", "application/x-ndjson", http.StatusOK, axon.NDJSONContentType, "{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n"},
+				{"first error", "/items?fail=1", "", http.StatusNotFound, "", ""},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					req := httptest.NewRequest("GET", tt.target, nil)
+					if tt.accept != "" {
+						req.Header.Set("Accept", tt.accept)
+					}
+					resp, err := tc.serve(req)
+					if err != nil {
+						t.Fatalf("request failed: %v", err)
+					}
+					if resp.StatusCode != tt.status {
+						t.Fatalf("expected status %d, got %d", tt.status, resp.StatusCode)
+					}
+					if tt.want == "" {
+						return
+					}
+					if got := resp.Header.Get("Content-Type"); got != tt.contentType {
+						t.Errorf("expected content type %q, got %q", tt.contentType, got)
+					}
+					if resp.Header.Get("ETag") != "" {
+						t.Errorf("expected streamed responses not to be tagged")
+					}
+					got, _ := io.ReadAll(resp.Body)
+					if string(got) != tt.want {
+						t.Errorf("expected body %q, got %q", tt.want, got)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestAdapters_Patch(t *testing.T) {
+	type user struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	compression, err := axon.NewCompression(axon.CompressionConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range newCookieTestServers(t) {
+		t.Run(tc.name, func(t *testing.T) {
+			// Headers of errors are sent through middlewares recording the response
+			tc.server.Use(compression.Handle)
+			tc.server.RegisterRoute("PATCH", axon.NewAxonPath("/user"), func(c axon.RequestContext) error {
+				var patch axon.Patch[user]
+				if err := axon.BindPatch(c, &patch); err != nil {
+					return err
+				}
+				current := user{Name: "Alice", Email: "alice@example.com"}
+				if err := patch.Apply(&current); err != nil {
+					return err
+				}
+				return c.Response().JSON(http.StatusOK, current)
+			})
+
+			tests := []struct {
+				name        string
+				contentType string
+				body        string
+				status      int
+				want        string
+			}{
+				{"merge patch", axon.MergePatchContentType, `{"email":null}`, http.StatusOK, `{"name":"Alice","email":""}`},
+				{"json patch", axon.JSONPatchContentType, `[{"op":"replace","path":"/name","value":"Bob"}]`, http.StatusOK, `{"name":"Bob","email":"alice@example.com"}`},
+				{"failed test", axon.JSONPatchContentType, `[{"op":"test","path":"/name","value":"Bob"}]`, http.StatusConflict, ""},
+				{"unsupported type", "text/plain", `name=Bob`, http.StatusUnsupportedMediaType, ""},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					req := httptest.NewRequest("PATCH", "/user", strings.NewReader(tt.body))
+					req.Header.Set("Content-Type", tt.contentType)
+					resp, err := tc.serve(req)
+					if err != nil {
+						t.Fatalf("request failed: %v", err)
+					}
+					if resp.StatusCode != tt.status {
+						t.Fatalf("expected status %d, got %d", tt.status, resp.StatusCode)
+					}
+					if tt.status == http.StatusUnsupportedMediaType && resp.Header.Get("Accept-Patch") == "" {
+						t.Errorf("expected an Accept-Patch header")
+					}
+					if tt.want != "" {
+						got, _ := io.ReadAll(resp.Body)
+						if strings.TrimSpace(string(got)) != tt.want {
+							t.Errorf("expected body %s, got %s", tt.want, got)
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestAdapters_Pagination(t *testing.T) {
+	pagination := axon.NewPagination(axon.PaginationConfig{DefaultLimit: 2})
+	items := []string{"a", "b", "c", "d", "e"}
+
+	for _, tc := range newCookieTestServers(t) {
+		t.Run(tc.name, func(t *testing.T) {
+			match := axon.RouteMatch{Method: "GET", Path: "/lists/{name}/items"}
+			tc.server.RegisterRoute("GET", axon.NewAxonPath("/lists/{name}/items"), func(c axon.RequestContext) error {
+				page, err := pagination.Bind(c)
+				if err != nil {
+					return err
+				}
+				end := min(page.Offset+page.Limit, len(items))
+				return pagination.WritePage(c, axon.NewOffsetPage(page, items[min(page.Offset, end):end], len(items)))
+			}, axon.WithRouteMatch(match))
+
+			resp, err := tc.serve(httptest.NewRequest("GET", "/lists/todo/items?offset=2&q=x", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", resp.StatusCode)
+			}
+			wantLink := `</lists/todo/items?limit=2&offset=4&q=x>; rel="next", </lists/todo/items?limit=2&offset=0&q=x>; rel="prev"`
+			if got := resp.Header.Get("Link"); got != wantLink {
+				t.Errorf("expected Link %s, got %s", wantLink, got)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			want := `{"items":["c","d"],"page":{"limit":2,"offset":2,"total":5}}`
+			if strings.TrimSpace(string(body)) != want {
+				t.Errorf("expected body %s, got %s", want, body)
+			}
+
+			resp, err = tc.serve(httptest.NewRequest("GET", "/lists/todo/items?cursor=forged", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("expected forged cursors to be rejected with 400, got %d", resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestAdapters_Fields(t *testing.T) {
+	type address struct {
+		City    string `json:"city"`
+		Country string `json:"country"`
+	}
+	type user struct {
+		ID      int     `json:"id"`
+		Name    string  `json:"name"`
+		Address address `json:"address"`
+	}
+	policy := axon.FieldsPolicy{Paths: []string{"id", "name", "address", "address.city", "address.country"}}
+
+	for _, tc := range newCookieTestServers(t) {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.server.RegisterRoute("GET", axon.NewAxonPath("/users"), axon.WithFields(policy)(func(c axon.RequestContext) error {
+				return c.Response().JSON(http.StatusOK, []user{{ID: 1, Name: "Ada", Address: address{City: "London", Country: "UK"}}})
+			}))
+
+			resp, err := tc.serve(httptest.NewRequest("GET", "/users?fields=name,address.city", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", resp.StatusCode)
+			}
+			if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+				t.Errorf("expected a JSON response, got %s", contentType)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			want := `[{"name":"Ada","address":{"city":"London"}}]`
+			if strings.TrimSpace(string(body)) != want {
+				t.Errorf("expected body %s, got %s", want, body)
+			}
+
+			resp, err = tc.serve(httptest.NewRequest("GET", "/users?fields=password", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("expected unknown fields to be rejected with 400, got %d", resp.StatusCode)
+			}
+		})
+	}
+}
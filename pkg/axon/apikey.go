@@ -0,0 +1,222 @@
+package axon
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// APIKeyRecord describes a stored API key. Only the SHA-256 hash of the key is kept.
+type APIKeyRecord struct {
+	// ID identifies the key (becomes the principal subject)
+	ID string `json:"id"`
+
+	// Hash is the hex-encoded SHA-256 of the raw key (see HashAPIKey)
+	Hash string `json:"hash"`
+
+	// Scopes are granted as permissions and satisfy -Permissions requirements
+	Scopes []string `json:"scopes,omitempty"`
+
+	// Roles satisfy -Roles requirements
+	Roles []string `json:"roles,omitempty"`
+
+	// Disabled keys are rejected
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// KeyStore looks up API keys by hash
+type KeyStore interface {
+	// FindByHash returns the record whose hash matches (hex SHA-256), or nil if none does.
+	// Implementations must compare hashes in constant time.
+	FindByHash(hash string) (*APIKeyRecord, error)
+}
+
+// HashAPIKey returns the hex-encoded SHA-256 hash stored for a raw API key
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// MemoryKeyStore is an in-memory KeyStore
+type MemoryKeyStore struct {
+	mu      sync.RWMutex
+	records []APIKeyRecord
+}
+
+// NewMemoryKeyStore creates an in-memory key store
+func NewMemoryKeyStore(records ...APIKeyRecord) *MemoryKeyStore {
+	s := &MemoryKeyStore{}
+	for _, record := range records {
+		s.Add(record)
+	}
+	return s
+}
+
+// Add stores a record
+func (s *MemoryKeyStore) Add(record APIKeyRecord) {
+	// Hashes are compared with the lower-case output of HashAPIKey
+	record.Hash = strings.ToLower(record.Hash)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+}
+
+// AddKey hashes rawKey and stores it under id with the given scopes
+func (s *MemoryKeyStore) AddKey(id, rawKey string, scopes ...string) {
+	s.Add(APIKeyRecord{ID: id, Hash: HashAPIKey(rawKey), Scopes: scopes})
+}
+
+// Remove deletes the record with the given ID
+func (s *MemoryKeyStore) Remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.records[:0]
+	for _, record := range s.records {
+		if record.ID != id {
+			kept = append(kept, record)
+		}
+	}
+	s.records = kept
+}
+
+// FindByHash returns the matching record using constant-time comparison
+func (s *MemoryKeyStore) FindByHash(hash string) (*APIKeyRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return findRecordByHash(s.records, hash), nil
+}
+
+// FileKeyStore is a KeyStore backed by a JSON file containing an array of APIKeyRecord
+type FileKeyStore struct {
+	path string
+
+	mu      sync.RWMutex
+	records []APIKeyRecord
+}
+
+// NewFileKeyStore loads API key records from a JSON file
+func NewFileKeyStore(path string) (*FileKeyStore, error) {
+	s := &FileKeyStore{path: path}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload re-reads the key file; on error the previous records are kept
+func (s *FileKeyStore) Reload() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read API key file %s: %w", s.path, err)
+	}
+
+	var records []APIKeyRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("failed to parse API key file %s: %w", s.path, err)
+	}
+
+	for i, record := range records {
+		if record.ID == "" {
+			return fmt.Errorf("API key file %s: record %d has no id", s.path, i)
+		}
+		if decoded, err := hex.DecodeString(record.Hash); err != nil || len(decoded) != sha256.Size {
+			return fmt.Errorf("API key file %s: record %q must have a hex SHA-256 hash", s.path, record.ID)
+		}
+		// Hashes are compared with the lower-case output of HashAPIKey
+		records[i].Hash = strings.ToLower(record.Hash)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = records
+	return nil
+}
+
+// FindByHash returns the matching record using constant-time comparison
+func (s *FileKeyStore) FindByHash(hash string) (*APIKeyRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return findRecordByHash(s.records, hash), nil
+}
+
+// findRecordByHash compares against every record so timing does not reveal which one matched
+func findRecordByHash(records []APIKeyRecord, hash string) *APIKeyRecord {
+	var found *APIKeyRecord
+	for i := range records {
+		if subtle.ConstantTimeCompare([]byte(records[i].Hash), []byte(hash)) == 1 && found == nil {
+			record := records[i]
+			found = &record
+		}
+	}
+	return found
+}
+
+// APIKeyConfig configures where the API key is read from
+type APIKeyConfig struct {
+	// Header carrying the key (default: "X-API-Key"); set to "-" to disable
+	Header string
+
+	// QueryParam carrying the key (default: disabled)
+	QueryParam string
+}
+
+// APIKeyAuthenticator authenticates requests by API key
+type APIKeyAuthenticator struct {
+	config APIKeyConfig
+	store  KeyStore
+}
+
+// NewAPIKeyAuthenticator creates an API key authenticator backed by store
+func NewAPIKeyAuthenticator(config APIKeyConfig, store KeyStore) *APIKeyAuthenticator {
+	if config.Header == "" {
+		config.Header = "X-API-Key"
+	}
+	return &APIKeyAuthenticator{config: config, store: store}
+}
+
+// Authenticate implements Authenticator. The principal's permissions are the key's scopes.
+func (a *APIKeyAuthenticator) Authenticate(c RequestContext) (Principal, error) {
+	key := a.extractKey(c)
+	if key == "" {
+		return nil, NewHTTPError(http.StatusUnauthorized, "missing API key")
+	}
+
+	record, err := a.store.FindByHash(HashAPIKey(key))
+	if err != nil {
+		return nil, NewHTTPError(http.StatusInternalServerError, "API key lookup failed", err)
+	}
+	if record == nil || record.Disabled {
+		return nil, NewHTTPError(http.StatusUnauthorized, "invalid API key")
+	}
+
+	return &BasicPrincipal{
+		Subject:     record.ID,
+		Roles:       record.Roles,
+		Permissions: record.Scopes,
+	}, nil
+}
+
+// Handle makes the authenticator usable directly as middleware
+func (a *APIKeyAuthenticator) Handle(next HandlerFunc) HandlerFunc {
+	return Authenticate(a)(next)
+}
+
+// extractKey reads the key from the configured header, then the query parameter
+func (a *APIKeyAuthenticator) extractKey(c RequestContext) string {
+	if a.config.Header != "-" {
+		if key := c.Request().Header(a.config.Header); key != "" {
+			return key
+		}
+	}
+	if a.config.QueryParam != "" {
+		return c.QueryParam(a.config.QueryParam)
+	}
+	return ""
+}
@@ -0,0 +1,128 @@
+package axon
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashAPIKey(t *testing.T) {
+	hash := HashAPIKey("secret")
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, HashAPIKey("secret"))
+	assert.NotEqual(t, hash, HashAPIKey("Secret"))
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	store := NewMemoryKeyStore()
+	store.AddKey("partner-a", "key-a", "orders:read", "orders:write")
+	store.Add(APIKeyRecord{ID: "partner-b", Hash: HashAPIKey("key-b"), Disabled: true})
+
+	authenticator := NewAPIKeyAuthenticator(APIKeyConfig{QueryParam: "api_key"}, store)
+
+	t.Run("header", func(t *testing.T) {
+		principal, err := authenticator.Authenticate(newValueRequestContext().withHeader("X-API-Key", "key-a"))
+		require.NoError(t, err)
+		assert.Equal(t, "partner-a", principal.GetSubject())
+		assert.Equal(t, []string{"orders:read", "orders:write"}, principal.(PermissionHolder).GetPermissions())
+	})
+
+	t.Run("query parameter", func(t *testing.T) {
+		c := newValueRequestContext()
+		c.query["api_key"] = "key-a"
+		principal, err := authenticator.Authenticate(c)
+		require.NoError(t, err)
+		assert.Equal(t, "partner-a", principal.GetSubject())
+	})
+
+	for name, c := range map[string]*valueRequestContext{
+		"missing":  newValueRequestContext(),
+		"unknown":  newValueRequestContext().withHeader("X-API-Key", "nope"),
+		"disabled": newValueRequestContext().withHeader("X-API-Key", "key-b"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := authenticator.Authenticate(c)
+			var httpErr *HTTPError
+			require.ErrorAs(t, err, &httpErr)
+			assert.Equal(t, http.StatusUnauthorized, httpErr.Code)
+		})
+	}
+
+	t.Run("query disabled by default", func(t *testing.T) {
+		c := newValueRequestContext()
+		c.query["api_key"] = "key-a"
+		_, err := NewAPIKeyAuthenticator(APIKeyConfig{}, store).Authenticate(c)
+		assert.Error(t, err)
+	})
+
+	t.Run("scopes satisfy permission requirements", func(t *testing.T) {
+		handler := func(c RequestContext) error { return nil }
+		chain := authenticator.Handle(RequireAuthorization(nil, AuthorizationRequirement{Permissions: []string{"orders:write"}})(handler))
+		assert.NoError(t, chain(newValueRequestContext().withHeader("X-API-Key", "key-a")))
+
+		chain = authenticator.Handle(RequireAuthorization(nil, AuthorizationRequirement{Permissions: []string{"orders:delete"}})(handler))
+		err := chain(newValueRequestContext().withHeader("X-API-Key", "key-a"))
+		var httpErr *HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusForbidden, httpErr.Code)
+	})
+
+	t.Run("removed keys are rejected", func(t *testing.T) {
+		store.Remove("partner-a")
+		_, err := authenticator.Authenticate(newValueRequestContext().withHeader("X-API-Key", "key-a"))
+		assert.Error(t, err)
+	})
+}
+
+func TestMemoryKeyStore_UpperCaseHashes(t *testing.T) {
+	store := NewMemoryKeyStore(APIKeyRecord{ID: "ci", Hash: strings.ToUpper(HashAPIKey("ci-key"))})
+	store.Add(APIKeyRecord{ID: "deploy", Hash: strings.ToUpper(HashAPIKey("deploy-key"))})
+
+	for id, key := range map[string]string{"ci": "ci-key", "deploy": "deploy-key"} {
+		record, err := store.FindByHash(HashAPIKey(key))
+		require.NoError(t, err)
+		require.NotNil(t, record, id)
+		assert.Equal(t, id, record.ID)
+	}
+}
+
+func TestFileKeyStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	content := `[{"id": "ci", "hash": "` + HashAPIKey("ci-key") + `", "scopes": ["deploy"], "roles": ["automation"]}]`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	store, err := NewFileKeyStore(path)
+	require.NoError(t, err)
+
+	record, err := store.FindByHash(HashAPIKey("ci-key"))
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, "ci", record.ID)
+	assert.Equal(t, []string{"automation"}, record.Roles)
+
+	record, err = store.FindByHash(HashAPIKey("other"))
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	// Upper-case hashes match too
+	content = `[{"id": "ci", "hash": "` + strings.ToUpper(HashAPIKey("ci-key")) + `"}]`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	require.NoError(t, store.Reload())
+	record, err = store.FindByHash(HashAPIKey("ci-key"))
+	require.NoError(t, err)
+	assert.NotNil(t, record)
+
+	// Invalid content is rejected and the previous records are kept
+	require.NoError(t, os.WriteFile(path, []byte(`[{"id": "bad", "hash": "plaintext"}]`), 0o600))
+	assert.Error(t, store.Reload())
+	record, _ = store.FindByHash(HashAPIKey("ci-key"))
+	assert.NotNil(t, record)
+
+	_, err = NewFileKeyStore(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
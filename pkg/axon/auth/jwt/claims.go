@@ -0,0 +1,129 @@
+package jwt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/toyz/axon/pkg/axon"
+)
+
+// Claims holds the registered claims of a verified token plus its raw payload
+type Claims struct {
+	Issuer    string       `json:"iss,omitempty"`
+	Subject   string       `json:"sub,omitempty"`
+	Audience  Audience     `json:"aud,omitempty"`
+	ExpiresAt *NumericDate `json:"exp,omitempty"`
+	NotBefore *NumericDate `json:"nbf,omitempty"`
+	IssuedAt  *NumericDate `json:"iat,omitempty"`
+	ID        string       `json:"jti,omitempty"`
+
+	// Raw is the decoded JSON payload, used to map custom claims
+	Raw json.RawMessage `json:"-"`
+}
+
+// Decode unmarshals the raw payload into v (e.g. a struct with custom claim fields)
+func (c *Claims) Decode(v interface{}) error {
+	return json.Unmarshal(c.Raw, v)
+}
+
+// Audience is the "aud" claim, which may be a single string or an array
+type Audience []string
+
+// UnmarshalJSON accepts both a string and an array of strings
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("aud must be a string or array of strings")
+	}
+	*a = many
+	return nil
+}
+
+// Contains reports whether the audience includes any of the given values
+func (a Audience) Contains(values ...string) bool {
+	for _, want := range values {
+		for _, have := range a {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NumericDate is a JWT timestamp in seconds since the Unix epoch
+type NumericDate struct {
+	time.Time
+}
+
+// UnmarshalJSON decodes integer or fractional seconds
+func (d *NumericDate) UnmarshalJSON(data []byte) error {
+	var seconds json.Number
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return fmt.Errorf("invalid numeric date: %w", err)
+	}
+	f, err := seconds.Float64()
+	if err != nil {
+		return fmt.Errorf("invalid numeric date: %w", err)
+	}
+	whole := int64(f)
+	d.Time = time.Unix(whole, int64((f-float64(whole))*float64(time.Second))).UTC()
+	return nil
+}
+
+// MarshalJSON encodes the date as whole seconds
+func (d NumericDate) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d", d.Unix())), nil
+}
+
+// ClaimsMapper converts verified claims into the request principal
+type ClaimsMapper func(claims *Claims) (axon.Principal, error)
+
+// DefaultClaimsMapper maps "sub" to the subject, a "roles" array to roles and
+// "permissions" (array) or "scope" (space separated) to permissions.
+func DefaultClaimsMapper(claims *Claims) (axon.Principal, error) {
+	var custom struct {
+		Roles       []string `json:"roles"`
+		Permissions []string `json:"permissions"`
+		Scope       string   `json:"scope"`
+	}
+	if err := claims.Decode(&custom); err != nil {
+		return nil, err
+	}
+
+	permissions := custom.Permissions
+	if len(permissions) == 0 && custom.Scope != "" {
+		permissions = strings.Fields(custom.Scope)
+	}
+
+	return &axon.BasicPrincipal{
+		Subject:     claims.Subject,
+		Roles:       custom.Roles,
+		Permissions: permissions,
+	}, nil
+}
+
+// MapInto returns a ClaimsMapper that decodes the token payload into a new T
+// using its json tags. *T must implement axon.Principal (and may implement
+// axon.RoleHolder / axon.PermissionHolder to take part in -Roles/-Permissions checks).
+//
+//	fx.Provide(func() jwt.ClaimsMapper { return jwt.MapInto[UserPrincipal]() })
+func MapInto[T any, P interface {
+	*T
+	axon.Principal
+}]() ClaimsMapper {
+	return func(claims *Claims) (axon.Principal, error) {
+		principal := P(new(T))
+		if err := claims.Decode(principal); err != nil {
+			return nil, err
+		}
+		return principal, nil
+	}
+}
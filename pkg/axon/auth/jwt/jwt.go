@@ -0,0 +1,311 @@
+// Package jwt provides an axon.Authenticator that verifies JSON Web Tokens
+// signed with RS256, ES256, EdDSA or HS256 against a local JWKS file or an
+// in-memory key set.
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/toyz/axon/pkg/axon"
+)
+
+// Supported signing algorithms
+const (
+	RS256 = "RS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+	HS256 = "HS256"
+)
+
+// ClaimsContextKey is the request context key under which verified claims are stored
+const ClaimsContextKey = "axon.jwt.claims"
+
+// Verification errors
+var (
+	ErrMissingToken    = errors.New("jwt: missing bearer token")
+	ErrMalformed       = errors.New("jwt: malformed token")
+	ErrAlgorithm       = errors.New("jwt: algorithm not allowed")
+	ErrUnknownKey      = errors.New("jwt: no matching key")
+	ErrSignature       = errors.New("jwt: invalid signature")
+	ErrExpired         = errors.New("jwt: token expired")
+	ErrMissingExpiry   = errors.New("jwt: token has no exp claim")
+	ErrNotYetValid     = errors.New("jwt: token not valid yet")
+	ErrInvalidIssuer   = errors.New("jwt: invalid issuer")
+	ErrInvalidAudience = errors.New("jwt: invalid audience")
+)
+
+// Config configures token verification
+type Config struct {
+	// Issuer is the required "iss" claim (empty disables the check)
+	Issuer string
+
+	// Audience lists accepted "aud" values; the token must contain at least one (empty disables the check)
+	Audience []string
+
+	// Algorithms lists the accepted signing algorithms (default: RS256, ES256, EdDSA, HS256)
+	Algorithms []string
+
+	// ClockSkew is the leeway applied to exp and nbf (default: 0)
+	ClockSkew time.Duration
+
+	// AllowMissingExpiry accepts tokens without an exp claim (default: false)
+	AllowMissingExpiry bool
+
+	// JWKSFile is the local JWKS file to load when no KeySet is provided
+	JWKSFile string
+
+	// ReloadInterval is how often JWKSFile is checked for changes (default: 30s)
+	ReloadInterval time.Duration
+
+	// Header is the request header carrying the token (default: "Authorization")
+	Header string
+
+	// Scheme is the expected header prefix (default: "Bearer")
+	Scheme string
+}
+
+// Authenticator verifies bearer tokens and maps their claims to an axon.Principal
+type Authenticator struct {
+	config     Config
+	keys       KeySet
+	mapper     ClaimsMapper
+	algorithms map[string]bool
+	now        func() time.Time
+}
+
+// NewAuthenticator creates an Authenticator; mapper may be nil to use DefaultClaimsMapper
+func NewAuthenticator(config Config, keys KeySet, mapper ClaimsMapper) (*Authenticator, error) {
+	if keys == nil {
+		return nil, fmt.Errorf("jwt: a key set is required")
+	}
+	if mapper == nil {
+		mapper = DefaultClaimsMapper
+	}
+	if config.Header == "" {
+		config.Header = "Authorization"
+	}
+	if config.Scheme == "" {
+		config.Scheme = "Bearer"
+	}
+	if len(config.Algorithms) == 0 {
+		config.Algorithms = []string{RS256, ES256, EdDSA, HS256}
+	}
+
+	algorithms := make(map[string]bool, len(config.Algorithms))
+	for _, alg := range config.Algorithms {
+		switch alg {
+		case RS256, ES256, EdDSA, HS256:
+			algorithms[alg] = true
+		default:
+			return nil, fmt.Errorf("jwt: unsupported algorithm %q", alg)
+		}
+	}
+
+	return &Authenticator{
+		config:     config,
+		keys:       keys,
+		mapper:     mapper,
+		algorithms: algorithms,
+		now:        time.Now,
+	}, nil
+}
+
+// Authenticate implements axon.Authenticator. Failures are returned as 401 errors.
+func (a *Authenticator) Authenticate(c axon.RequestContext) (axon.Principal, error) {
+	token, err := a.extractToken(c)
+	if err == nil {
+		var claims *Claims
+		claims, err = a.Verify(token)
+		if err == nil {
+			var principal axon.Principal
+			principal, err = a.mapper(claims)
+			if err == nil {
+				c.Set(ClaimsContextKey, claims)
+				return principal, nil
+			}
+		}
+	}
+
+	c.Response().SetHeader("WWW-Authenticate", fmt.Sprintf(`%s error="invalid_token"`, a.config.Scheme))
+	return nil, axon.NewHTTPError(http.StatusUnauthorized, "invalid or missing token", err)
+}
+
+// Handle makes the Authenticator usable directly as axon middleware
+func (a *Authenticator) Handle(next axon.HandlerFunc) axon.HandlerFunc {
+	return axon.Authenticate(a)(next)
+}
+
+// ClaimsFromContext returns the verified claims attached by the Authenticator
+func ClaimsFromContext(c axon.RequestContext) (*Claims, bool) {
+	claims, ok := c.Get(ClaimsContextKey).(*Claims)
+	return claims, ok
+}
+
+// extractToken reads the token from the configured header
+func (a *Authenticator) extractToken(c axon.RequestContext) (string, error) {
+	value := c.Request().Header(a.config.Header)
+	prefix := a.config.Scheme + " "
+	if len(value) <= len(prefix) || !strings.EqualFold(value[:len(prefix)], prefix) {
+		return "", ErrMissingToken
+	}
+	return strings.TrimSpace(value[len(prefix):]), nil
+}
+
+// header is the JOSE header of a token
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify checks the token signature and registered claims and returns the claims
+func (a *Authenticator) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	var hdr header
+	if err := json.Unmarshal(headerJSON, &hdr); err != nil {
+		return nil, ErrMalformed
+	}
+	if !a.algorithms[hdr.Alg] {
+		return nil, fmt.Errorf("%w: %q", ErrAlgorithm, hdr.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	keys, err := a.keys.Keys(hdr.Kid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownKey, err)
+	}
+
+	signingInput := []byte(parts[0] + "." + parts[1])
+	verified, candidates := false, 0
+	for _, key := range keys {
+		if key.Algorithm != "" && key.Algorithm != hdr.Alg {
+			continue
+		}
+		ok, compatible := verifySignature(hdr.Alg, key.Key, signingInput, signature)
+		if !compatible {
+			continue
+		}
+		candidates++
+		if ok {
+			verified = true
+			break
+		}
+	}
+	if candidates == 0 {
+		return nil, ErrUnknownKey
+	}
+	if !verified {
+		return nil, ErrSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	claims := &Claims{Raw: payload}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+
+	if err := a.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// validateClaims checks iss, aud, exp and nbf
+func (a *Authenticator) validateClaims(claims *Claims) error {
+	now := a.now()
+	skew := a.config.ClockSkew
+
+	if claims.ExpiresAt == nil {
+		if !a.config.AllowMissingExpiry {
+			return ErrMissingExpiry
+		}
+	} else if !now.Before(claims.ExpiresAt.Add(skew)) {
+		return ErrExpired
+	}
+
+	if claims.NotBefore != nil && now.Add(skew).Before(claims.NotBefore.Time) {
+		return ErrNotYetValid
+	}
+
+	if a.config.Issuer != "" && claims.Issuer != a.config.Issuer {
+		return ErrInvalidIssuer
+	}
+
+	if len(a.config.Audience) > 0 && !claims.Audience.Contains(a.config.Audience...) {
+		return ErrInvalidAudience
+	}
+
+	return nil
+}
+
+// verifySignature verifies signature with key for alg. compatible is false when
+// the key type cannot be used with alg, which prevents algorithm confusion.
+func verifySignature(alg string, key interface{}, signingInput, signature []byte) (ok, compatible bool) {
+	digest := sha256.Sum256(signingInput)
+
+	switch alg {
+	case RS256:
+		pub, isRSA := key.(*rsa.PublicKey)
+		if !isRSA {
+			return false, false
+		}
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) == nil, true
+
+	case ES256:
+		pub, isEC := key.(*ecdsa.PublicKey)
+		if !isEC || pub.Curve.Params().Name != "P-256" {
+			return false, false
+		}
+		if len(signature) != 64 {
+			return false, true
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(pub, digest[:], r, s), true
+
+	case EdDSA:
+		pub, isEd := key.(ed25519.PublicKey)
+		if !isEd {
+			return false, false
+		}
+		return ed25519.Verify(pub, signingInput, signature), true
+
+	case HS256:
+		secret, isSecret := key.([]byte)
+		if !isSecret {
+			return false, false
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signingInput)
+		return hmac.Equal(mac.Sum(nil), signature), true
+	}
+
+	return false, false
+}
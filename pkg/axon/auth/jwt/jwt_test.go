@@ -0,0 +1,354 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toyz/axon/pkg/axon"
+	"github.com/toyz/axon/pkg/axon/adapters"
+	"go.uber.org/fx"
+)
+
+var testNow = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// sign builds a compact JWS for tests
+func sign(t *testing.T, alg, kid string, key interface{}, claims map[string]interface{}) string {
+	t.Helper()
+
+	hdr := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		hdr["kid"] = kid
+	}
+	headerJSON, _ := json.Marshal(hdr)
+	payloadJSON, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(payloadJSON)
+	digest := sha256.Sum256([]byte(input))
+
+	var sig []byte
+	var err error
+	switch alg {
+	case RS256:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+	case ES256:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), digest[:])
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	case EdDSA:
+		sig = ed25519.Sign(key.(ed25519.PrivateKey), []byte(input))
+	case HS256:
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write([]byte(input))
+		sig = mac.Sum(nil)
+	default:
+		sig = []byte("x")
+	}
+	require.NoError(t, err)
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":   "https://auth.example.com",
+		"aud":   []string{"api", "other"},
+		"sub":   "user-42",
+		"exp":   testNow.Add(time.Hour).Unix(),
+		"nbf":   testNow.Add(-time.Minute).Unix(),
+		"roles": []string{"admin"},
+		"scope": "users:read users:write",
+	}
+}
+
+func newTestAuthenticator(t *testing.T, keys KeySet, mapper ClaimsMapper) *Authenticator {
+	t.Helper()
+	a, err := NewAuthenticator(Config{
+		Issuer:    "https://auth.example.com",
+		Audience:  []string{"api"},
+		ClockSkew: 30 * time.Second,
+	}, keys, mapper)
+	require.NoError(t, err)
+	a.now = func() time.Time { return testNow }
+	return a
+}
+
+func TestVerify_Algorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	secret := []byte("super-secret-hmac-key-32-bytes!!")
+
+	keys := NewMemoryKeySet(
+		Key{ID: "rsa", Key: &rsaKey.PublicKey},
+		Key{ID: "ec", Key: &ecKey.PublicKey},
+		Key{ID: "ed", Key: edPub},
+		Key{ID: "hs", Key: secret},
+	)
+	a := newTestAuthenticator(t, keys, nil)
+
+	tests := []struct {
+		alg string
+		kid string
+		key interface{}
+	}{
+		{RS256, "rsa", rsaKey},
+		{ES256, "ec", ecKey},
+		{EdDSA, "ed", edPriv},
+		{HS256, "hs", secret},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			claims, err := a.Verify(sign(t, tt.alg, tt.kid, tt.key, validClaims()))
+			require.NoError(t, err)
+			assert.Equal(t, "user-42", claims.Subject)
+
+			// Tampered payload must fail
+			token := sign(t, tt.alg, tt.kid, tt.key, validClaims())
+			other := sign(t, tt.alg, tt.kid, tt.key, map[string]interface{}{"sub": "attacker", "exp": testNow.Add(time.Hour).Unix()})
+			forged := token[:len(token)-len(token[lastDot(token):])] + other[lastDot(other):]
+			_, err = a.Verify(forged)
+			assert.ErrorIs(t, err, ErrSignature)
+		})
+	}
+
+	t.Run("algorithm confusion is rejected", func(t *testing.T) {
+		// An HS256 token "signed" with the RSA key id must not match the RSA key
+		_, err := a.Verify(sign(t, HS256, "rsa", []byte("whatever"), validClaims()))
+		assert.ErrorIs(t, err, ErrUnknownKey)
+	})
+
+	t.Run("none is rejected", func(t *testing.T) {
+		_, err := a.Verify(sign(t, "none", "", nil, validClaims()))
+		assert.ErrorIs(t, err, ErrAlgorithm)
+	})
+}
+
+func lastDot(s string) int {
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] == '.' {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestVerify_Claims(t *testing.T) {
+	secret := []byte("claims-secret")
+	a := newTestAuthenticator(t, NewMemoryKeySet(Key{Key: secret}), nil)
+
+	tests := []struct {
+		name   string
+		mutate func(map[string]interface{})
+		want   error
+	}{
+		{"valid", func(c map[string]interface{}) {}, nil},
+		{"expired", func(c map[string]interface{}) { c["exp"] = testNow.Add(-time.Minute).Unix() }, ErrExpired},
+		{"expired within skew", func(c map[string]interface{}) { c["exp"] = testNow.Add(-10 * time.Second).Unix() }, nil},
+		{"missing exp", func(c map[string]interface{}) { delete(c, "exp") }, ErrMissingExpiry},
+		{"not yet valid", func(c map[string]interface{}) { c["nbf"] = testNow.Add(time.Minute).Unix() }, ErrNotYetValid},
+		{"nbf within skew", func(c map[string]interface{}) { c["nbf"] = testNow.Add(10 * time.Second).Unix() }, nil},
+		{"wrong issuer", func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" }, ErrInvalidIssuer},
+		{"wrong audience", func(c map[string]interface{}) { c["aud"] = "someone-else" }, ErrInvalidAudience},
+		{"string audience", func(c map[string]interface{}) { c["aud"] = "api" }, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			tt.mutate(claims)
+			_, err := a.Verify(sign(t, HS256, "", secret, claims))
+			if tt.want == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.want)
+			}
+		})
+	}
+}
+
+type userPrincipal struct {
+	ID    string   `json:"sub"`
+	Email string   `json:"email"`
+	Roles []string `json:"roles"`
+}
+
+func (u *userPrincipal) GetSubject() string { return u.ID }
+func (u *userPrincipal) GetRoles() []string { return u.Roles }
+
+func TestClaimsMappers(t *testing.T) {
+	secret := []byte("mapper-secret")
+	claims := validClaims()
+	claims["email"] = "jane@example.com"
+
+	t.Run("default mapper", func(t *testing.T) {
+		a := newTestAuthenticator(t, NewMemoryKeySet(Key{Key: secret}), nil)
+		verified, err := a.Verify(sign(t, HS256, "", secret, claims))
+		require.NoError(t, err)
+
+		principal, err := DefaultClaimsMapper(verified)
+		require.NoError(t, err)
+		basic := principal.(*axon.BasicPrincipal)
+		assert.Equal(t, "user-42", basic.Subject)
+		assert.Equal(t, []string{"admin"}, basic.Roles)
+		assert.Equal(t, []string{"users:read", "users:write"}, basic.Permissions)
+	})
+
+	t.Run("user-defined principal", func(t *testing.T) {
+		a := newTestAuthenticator(t, NewMemoryKeySet(Key{Key: secret}), MapInto[userPrincipal]())
+		verified, err := a.Verify(sign(t, HS256, "", secret, claims))
+		require.NoError(t, err)
+
+		principal, err := a.mapper(verified)
+		require.NoError(t, err)
+		user := principal.(*userPrincipal)
+		assert.Equal(t, "user-42", user.ID)
+		assert.Equal(t, "jane@example.com", user.Email)
+	})
+}
+
+func TestFileKeySet_ReloadsOnChange(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "jwks.json")
+	first, second := []byte("first-secret"), []byte("second-secret")
+
+	writeJWKS := func(kid string, secret []byte) {
+		doc := map[string]interface{}{
+			"keys": []map[string]string{{"kty": "oct", "kid": kid, "k": base64.RawURLEncoding.EncodeToString(secret)}},
+		}
+		data, _ := json.Marshal(doc)
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+	}
+
+	writeJWKS("k1", first)
+	keys, err := NewFileKeySet(path, time.Minute)
+	require.NoError(t, err)
+
+	clock := testNow
+	keys.now = func() time.Time { return clock }
+	keys.lastCheck = clock
+
+	a := newTestAuthenticator(t, keys, nil)
+	_, err = a.Verify(sign(t, HS256, "k1", first, validClaims()))
+	require.NoError(t, err)
+
+	// Rotate: new kid with a new secret; bump mtime so the change is detected
+	writeJWKS("k2-rotated", second)
+	require.NoError(t, os.Chtimes(path, testNow.Add(time.Hour), testNow.Add(time.Hour)))
+
+	// Unknown kid forces a re-check once the minimum interval has passed
+	clock = clock.Add(2 * time.Second)
+	_, err = a.Verify(sign(t, HS256, "k2-rotated", second, validClaims()))
+	require.NoError(t, err)
+
+	// The old key is gone after rotation
+	_, err = a.Verify(sign(t, HS256, "k1", first, validClaims()))
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	// A broken file keeps the last good keys
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o600))
+	require.NoError(t, os.Chtimes(path, testNow.Add(2*time.Hour), testNow.Add(2*time.Hour)))
+	clock = clock.Add(2 * time.Minute)
+	_, err = a.Verify(sign(t, HS256, "k2-rotated", second, validClaims()))
+	assert.NoError(t, err)
+}
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	enc := base64.RawURLEncoding.EncodeToString
+	doc := map[string]interface{}{
+		"keys": []map[string]string{
+			{"kty": "RSA", "kid": "r", "alg": "RS256", "n": enc(rsaKey.N.Bytes()), "e": enc(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "EC", "kid": "e", "crv": "P-256", "x": enc(ecKey.X.FillBytes(make([]byte, 32))), "y": enc(ecKey.Y.FillBytes(make([]byte, 32)))},
+			{"kty": "OKP", "kid": "o", "crv": "Ed25519", "x": enc(edPub)},
+			{"kty": "RSA", "kid": "enc-only", "use": "enc", "n": enc(rsaKey.N.Bytes()), "e": "AQAB"},
+		},
+	}
+	data, _ := json.Marshal(doc)
+
+	keys, err := ParseJWKS(data)
+	require.NoError(t, err)
+	require.Len(t, keys, 3)
+	assert.True(t, rsaKey.PublicKey.Equal(keys[0].Key))
+	assert.Equal(t, "RS256", keys[0].Algorithm)
+	assert.True(t, ecKey.PublicKey.Equal(keys[1].Key))
+	assert.True(t, edPub.Equal(keys[2].Key))
+
+	_, err = ParseJWKS([]byte(`{"keys":[{"kty":"EC","crv":"P-384","x":"AA","y":"AA"}]}`))
+	assert.Error(t, err)
+}
+
+func TestAuthenticator_Middleware(t *testing.T) {
+	secret := []byte("middleware-secret")
+	a := newTestAuthenticator(t, NewMemoryKeySet(Key{Key: secret}), MapInto[userPrincipal]())
+
+	e := echo.New()
+	server := adapters.NewEchoAdapter(e)
+	handler := axon.RequireAuthorization(nil, axon.AuthorizationRequirement{Roles: []string{"admin"}})(func(c axon.RequestContext) error {
+		principal, _ := axon.GetPrincipal(c)
+		return c.Response().String(http.StatusOK, principal.GetSubject())
+	})
+	server.RegisterRoute("GET", axon.NewAxonPath("/me"), handler, a.Handle)
+
+	req := httptest.NewRequest("GET", "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+sign(t, HS256, "", secret, validClaims()))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "user-42", rec.Body.String())
+
+	req = httptest.NewRequest("GET", "/me", nil)
+	req.Header.Set("Authorization", "Bearer not.a.token")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Header().Get("WWW-Authenticate"), "invalid_token")
+}
+
+func TestModule(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "jwks.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`), 0o600))
+
+	var authenticator *Authenticator
+	app := fx.New(
+		fx.NopLogger,
+		fx.Supply(Config{Issuer: "https://auth.example.com", JWKSFile: path}),
+		fx.Provide(func() ClaimsMapper { return MapInto[userPrincipal]() }),
+		Module,
+		fx.Populate(&authenticator),
+	)
+	require.NoError(t, app.Err())
+	require.NotNil(t, authenticator)
+
+	missing := fx.New(fx.NopLogger, fx.Supply(Config{}), Module, fx.Populate(&authenticator))
+	assert.Error(t, missing.Err())
+}
@@ -0,0 +1,291 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+)
+
+// Key is a verification key loaded from a JWKS or configured in memory
+type Key struct {
+	// ID is the key ID matched against the token "kid" header (optional)
+	ID string
+
+	// Algorithm restricts the key to a single algorithm (optional)
+	Algorithm string
+
+	// Key is the key material: *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey or []byte (HS256)
+	Key interface{}
+}
+
+// KeySet provides the keys used to verify token signatures
+type KeySet interface {
+	// Keys returns the candidate keys for a token; kid is empty when the token has no "kid" header
+	Keys(kid string) ([]Key, error)
+}
+
+// MemoryKeySet is an in-memory KeySet whose keys can be replaced at runtime for rotation
+type MemoryKeySet struct {
+	mu   sync.RWMutex
+	keys []Key
+}
+
+// NewMemoryKeySet creates an in-memory key set
+func NewMemoryKeySet(keys ...Key) *MemoryKeySet {
+	return &MemoryKeySet{keys: keys}
+}
+
+// Keys returns the keys matching kid (or all keys when kid is empty)
+func (s *MemoryKeySet) Keys(kid string) ([]Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return filterKeys(s.keys, kid), nil
+}
+
+// SetKeys atomically replaces the key set
+func (s *MemoryKeySet) SetKeys(keys ...Key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+// SetJWKS atomically replaces the key set with the keys in a JWKS document
+func (s *MemoryKeySet) SetJWKS(data []byte) error {
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return err
+	}
+	s.SetKeys(keys...)
+	return nil
+}
+
+// FileKeySet loads keys from a local JWKS file and reloads them when the file changes
+type FileKeySet struct {
+	path     string
+	interval time.Duration
+
+	mu        sync.RWMutex
+	keys      []Key
+	modTime   time.Time
+	size      int64
+	lastCheck time.Time
+	now       func() time.Time
+}
+
+// minUnknownKidRecheck limits how often an unknown kid forces a file check
+const minUnknownKidRecheck = time.Second
+
+// NewFileKeySet loads the JWKS at path. The file is re-checked at most once per
+// interval (and sooner when a token references an unknown kid); a file that fails
+// to parse leaves the previous keys in place.
+func NewFileKeySet(path string, interval time.Duration) (*FileKeySet, error) {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	s := &FileKeySet{path: path, interval: interval, now: time.Now}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Keys returns the keys matching kid (or all keys when kid is empty)
+func (s *FileKeySet) Keys(kid string) ([]Key, error) {
+	now := s.now()
+
+	s.mu.RLock()
+	keys := filterKeys(s.keys, kid)
+	due := now.Sub(s.lastCheck) >= s.interval ||
+		(kid != "" && len(keys) == 0 && now.Sub(s.lastCheck) >= minUnknownKidRecheck)
+	s.mu.RUnlock()
+
+	if !due {
+		return keys, nil
+	}
+
+	// Keep serving the old keys if the file is temporarily broken
+	_ = s.reloadIfChanged()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return filterKeys(s.keys, kid), nil
+}
+
+// reloadIfChanged reloads the file when its modification time or size changed
+func (s *FileKeySet) reloadIfChanged() error {
+	info, err := os.Stat(s.path)
+
+	s.mu.Lock()
+	s.lastCheck = s.now()
+	unchanged := err == nil && info.ModTime().Equal(s.modTime) && info.Size() == s.size
+	s.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to stat JWKS file %s: %w", s.path, err)
+	}
+	if unchanged {
+		return nil
+	}
+	return s.reload()
+}
+
+// reload reads and parses the JWKS file
+func (s *FileKeySet) reload() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat JWKS file %s: %w", s.path, err)
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read JWKS file %s: %w", s.path, err)
+	}
+
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse JWKS file %s: %w", s.path, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	s.modTime = info.ModTime()
+	s.size = info.Size()
+	s.lastCheck = s.now()
+	return nil
+}
+
+// filterKeys returns keys with the given ID, or all keys when kid is empty
+func filterKeys(keys []Key, kid string) []Key {
+	if kid == "" {
+		return append([]Key(nil), keys...)
+	}
+	var matched []Key
+	for _, key := range keys {
+		if key.ID == kid {
+			matched = append(matched, key)
+		}
+	}
+	return matched
+}
+
+// jwk is the JSON representation of a single JSON Web Key
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// ParseJWKS parses a JSON Web Key Set document ({"keys": [...]}).
+// Keys marked for encryption ("use": "enc") are skipped.
+func ParseJWKS(data []byte) ([]Key, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make([]Key, 0, len(doc.Keys))
+	for i, raw := range doc.Keys {
+		if raw.Use == "enc" {
+			continue
+		}
+		material, err := raw.material()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %d (kid %q): %w", i, raw.Kid, err)
+		}
+		keys = append(keys, Key{ID: raw.Kid, Algorithm: raw.Alg, Key: material})
+	}
+	return keys, nil
+}
+
+// material decodes the key material for the JWK's key type
+func (k jwk) material() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("exponent: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("exponent out of range")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !pub.Curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve P-256")
+		}
+		return pub, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key size %d", len(x))
+		}
+		return ed25519.PublicKey(x), nil
+
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return nil, fmt.Errorf("k: %w", err)
+		}
+		if len(secret) == 0 {
+			return nil, fmt.Errorf("empty symmetric key")
+		}
+		return secret, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// decodeBigInt decodes a base64url-encoded big-endian integer
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,45 @@
+package jwt
+
+import (
+	"fmt"
+
+	"go.uber.org/fx"
+)
+
+// Params are the fx dependencies used to build the Authenticator.
+// Applications must supply a Config; KeySet and ClaimsMapper are optional.
+type Params struct {
+	fx.In
+
+	Config Config
+	KeySet KeySet       `optional:"true"`
+	Mapper ClaimsMapper `optional:"true"`
+}
+
+// NewAuthenticatorFromParams builds an Authenticator from fx-provided dependencies.
+// Without a KeySet, Config.JWKSFile is loaded and watched for changes.
+func NewAuthenticatorFromParams(p Params) (*Authenticator, error) {
+	keys := p.KeySet
+	if keys == nil {
+		if p.Config.JWKSFile == "" {
+			return nil, fmt.Errorf("jwt: provide a jwt.KeySet or set Config.JWKSFile")
+		}
+		fileKeys, err := NewFileKeySet(p.Config.JWKSFile, p.Config.ReloadInterval)
+		if err != nil {
+			return nil, err
+		}
+		keys = fileKeys
+	}
+	return NewAuthenticator(p.Config, keys, p.Mapper)
+}
+
+// Module provides a *jwt.Authenticator.
+//
+//	fx.New(
+//	    fx.Supply(jwt.Config{Issuer: "https://auth.example.com", Audience: []string{"api"}, JWKSFile: "jwks.json"}),
+//	    fx.Provide(func() jwt.ClaimsMapper { return jwt.MapInto[UserPrincipal]() }),
+//	    jwt.Module,
+//	)
+var Module = fx.Module("axon-jwt",
+	fx.Provide(NewAuthenticatorFromParams),
+)
@@ -0,0 +1,30 @@
+package axon
+
+// Authenticator identifies the caller of a request.
+// Implementations return an *HTTPError (typically 401) when credentials are missing or invalid.
+type Authenticator interface {
+	Authenticate(c RequestContext) (Principal, error)
+}
+
+// AuthenticatorFunc adapts a function to the Authenticator interface
+type AuthenticatorFunc func(c RequestContext) (Principal, error)
+
+// Authenticate calls f(c)
+func (f AuthenticatorFunc) Authenticate(c RequestContext) (Principal, error) {
+	return f(c)
+}
+
+// Authenticate returns a middleware that runs authenticator and attaches the
+// resulting principal to the request context for authorization and handlers.
+func Authenticate(authenticator Authenticator) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c RequestContext) error {
+			principal, err := authenticator.Authenticate(c)
+			if err != nil {
+				return err
+			}
+			SetPrincipal(c, principal)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package axon
+
+import (
+	"net/http"
+	"strings"
+)
+
+// PrincipalContextKey is the request context key under which the authenticated principal is stored
+const PrincipalContextKey = "axon.principal"
+
+// Principal identifies the authenticated caller of a request
+type Principal interface {
+	// GetSubject returns a stable identifier for the caller (user ID, key ID, etc.)
+	GetSubject() string
+}
+
+// RoleHolder is implemented by principals that carry roles
+type RoleHolder interface {
+	GetRoles() []string
+}
+
+// PermissionHolder is implemented by principals that carry permissions
+type PermissionHolder interface {
+	GetPermissions() []string
+}
+
+// BasicPrincipal is a simple Principal implementation with roles and permissions
+type BasicPrincipal struct {
+	Subject     string
+	Roles       []string
+	Permissions []string
+}
+
+// GetSubject returns the principal subject
+func (p *BasicPrincipal) GetSubject() string {
+	return p.Subject
+}
+
+// GetRoles returns the principal roles
+func (p *BasicPrincipal) GetRoles() []string {
+	return p.Roles
+}
+
+// GetPermissions returns the principal permissions
+func (p *BasicPrincipal) GetPermissions() []string {
+	return p.Permissions
+}
+
+// SetPrincipal attaches the authenticated principal to the request context.
+// Authentication middleware should call this once the caller has been identified.
+func SetPrincipal(c RequestContext, principal Principal) {
+	c.Set(PrincipalContextKey, principal)
+}
+
+// GetPrincipal returns the authenticated principal for the request, if any
+func GetPrincipal(c RequestContext) (Principal, bool) {
+	principal, ok := c.Get(PrincipalContextKey).(Principal)
+	return principal, ok && principal != nil
+}
+
+// AuthorizationRequirement describes what a route requires from the caller.
+// The caller must hold at least one of Roles (when set) and every one of Permissions.
+type AuthorizationRequirement struct {
+	Roles       []string
+	Permissions []string
+}
+
+// IsEmpty reports whether the requirement has no roles or permissions
+func (r AuthorizationRequirement) IsEmpty() bool {
+	return len(r.Roles) == 0 && len(r.Permissions) == 0
+}
+
+// String returns a human readable form of the requirement (e.g. "roles=admin|ops perms=users:write")
+func (r AuthorizationRequirement) String() string {
+	var parts []string
+	if len(r.Roles) > 0 {
+		parts = append(parts, "roles="+strings.Join(r.Roles, "|"))
+	}
+	if len(r.Permissions) > 0 {
+		parts = append(parts, "perms="+strings.Join(r.Permissions, ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Authorizer decides whether the current request satisfies a route's requirement.
+// It runs after authentication, so the principal (if any) is already on the context.
+// Returning an *HTTPError controls the status code sent to the client.
+type Authorizer interface {
+	Authorize(c RequestContext, requirement AuthorizationRequirement) error
+}
+
+// AuthorizerFunc adapts a function to the Authorizer interface
+type AuthorizerFunc func(c RequestContext, requirement AuthorizationRequirement) error
+
+// Authorize calls f(c, requirement)
+func (f AuthorizerFunc) Authorize(c RequestContext, requirement AuthorizationRequirement) error {
+	return f(c, requirement)
+}
+
+// roleAuthorizer checks requirements against a principal's roles and permissions
+type roleAuthorizer struct{}
+
+// NewRoleAuthorizer creates an Authorizer that checks the request principal's
+// roles (via RoleHolder) and permissions (via PermissionHolder).
+// Requests without a principal are rejected with 401, missing grants with 403.
+func NewRoleAuthorizer() Authorizer {
+	return &roleAuthorizer{}
+}
+
+func (a *roleAuthorizer) Authorize(c RequestContext, requirement AuthorizationRequirement) error {
+	if requirement.IsEmpty() {
+		return nil
+	}
+
+	principal, ok := GetPrincipal(c)
+	if !ok {
+		return NewHTTPError(http.StatusUnauthorized, "authentication required")
+	}
+
+	if len(requirement.Roles) > 0 {
+		var roles []string
+		if holder, ok := principal.(RoleHolder); ok {
+			roles = holder.GetRoles()
+		}
+		if !containsAny(roles, requirement.Roles) {
+			return NewHTTPError(http.StatusForbidden, "insufficient role")
+		}
+	}
+
+	if len(requirement.Permissions) > 0 {
+		var permissions []string
+		if holder, ok := principal.(PermissionHolder); ok {
+			permissions = holder.GetPermissions()
+		}
+		for _, required := range requirement.Permissions {
+			if !containsAny(permissions, []string{required}) {
+				return NewHTTPError(http.StatusForbidden, "missing permission: "+required)
+			}
+		}
+	}
+
+	return nil
+}
+
+// RequireAuthorization returns a middleware that enforces requirement using authorizer.
+// Generated route registration wraps handlers with it so the check runs after all
+// route middlewares (including authentication) and before parameter binding.
+func RequireAuthorization(authorizer Authorizer, requirement AuthorizationRequirement) MiddlewareFunc {
+	if authorizer == nil {
+		authorizer = NewRoleAuthorizer()
+	}
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c RequestContext) error {
+			if err := authorizer.Authorize(c, requirement); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+// containsAny reports whether have contains at least one of want
+func containsAny(have, want []string) bool {
+	for _, w := range want {
+		for _, h := range have {
+			if h == w {
+				return true
+			}
+		}
+	}
+	return false
+}
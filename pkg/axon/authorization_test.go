@@ -0,0 +1,154 @@
+package axon
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleAuthorizer(t *testing.T) {
+	authorizer := NewRoleAuthorizer()
+
+	tests := []struct {
+		name        string
+		principal   Principal
+		requirement AuthorizationRequirement
+		wantStatus  int
+	}{
+		{
+			name:        "empty requirement allows anonymous",
+			requirement: AuthorizationRequirement{},
+		},
+		{
+			name:        "missing principal is unauthorized",
+			requirement: AuthorizationRequirement{Roles: []string{"admin"}},
+			wantStatus:  http.StatusUnauthorized,
+		},
+		{
+			name:        "any matching role is enough",
+			principal:   &BasicPrincipal{Subject: "u1", Roles: []string{"ops"}},
+			requirement: AuthorizationRequirement{Roles: []string{"admin", "ops"}},
+		},
+		{
+			name:        "no matching role is forbidden",
+			principal:   &BasicPrincipal{Subject: "u1", Roles: []string{"viewer"}},
+			requirement: AuthorizationRequirement{Roles: []string{"admin", "ops"}},
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			name:        "all permissions required",
+			principal:   &BasicPrincipal{Subject: "u1", Permissions: []string{"users:read"}},
+			requirement: AuthorizationRequirement{Permissions: []string{"users:read", "users:write"}},
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			name:      "roles and permissions satisfied",
+			principal: &BasicPrincipal{Subject: "u1", Roles: []string{"admin"}, Permissions: []string{"users:read", "users:write"}},
+			requirement: AuthorizationRequirement{
+				Roles:       []string{"admin"},
+				Permissions: []string{"users:write"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newValueRequestContext()
+			if tt.principal != nil {
+				SetPrincipal(c, tt.principal)
+			}
+
+			err := authorizer.Authorize(c, tt.requirement)
+			if tt.wantStatus == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			var httpErr *HTTPError
+			require.ErrorAs(t, err, &httpErr)
+			assert.Equal(t, tt.wantStatus, httpErr.Code)
+		})
+	}
+}
+
+func TestRequireAuthorization(t *testing.T) {
+	called := false
+	handler := func(c RequestContext) error {
+		called = true
+		return nil
+	}
+
+	denyAll := AuthorizerFunc(func(c RequestContext, requirement AuthorizationRequirement) error {
+		return NewHTTPError(http.StatusForbidden, "denied")
+	})
+
+	err := RequireAuthorization(denyAll, AuthorizationRequirement{Roles: []string{"admin"}})(handler)(newValueRequestContext())
+	assert.Error(t, err)
+	assert.False(t, called, "handler must not run when authorization fails")
+
+	c := newValueRequestContext()
+	SetPrincipal(c, &BasicPrincipal{Subject: "u1", Roles: []string{"admin"}})
+	err = RequireAuthorization(nil, AuthorizationRequirement{Roles: []string{"admin"}})(handler)(c)
+	assert.NoError(t, err)
+	assert.True(t, called, "nil authorizer should fall back to the role authorizer")
+}
+
+func TestWriteRouteTable(t *testing.T) {
+	routes := []RouteInfo{
+		{
+			Method:         "DELETE",
+			Path:           "/users/{id:int}",
+			HandlerName:    "DeleteUser",
+			ControllerName: "UserController",
+			Middlewares:    []string{"Auth"},
+			Roles:          []string{"admin", "ops"},
+			Permissions:    []string{"users:write"},
+		},
+		{
+			Method:         "GET",
+			Path:           "/health",
+			HandlerName:    "Health",
+			ControllerName: "HealthController",
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteRouteTable(&buf, routes))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "ROLES")
+	assert.Contains(t, lines[1], "admin,ops")
+	assert.Contains(t, lines[1], "users:write")
+	assert.Contains(t, lines[2], "HealthController.Health")
+	assert.Equal(t, []string{"GET", "/health", "HealthController.Health", "-", "-", "-"}, strings.Fields(lines[2]))
+}
+
+func TestAuthenticate(t *testing.T) {
+	authenticator := AuthenticatorFunc(func(c RequestContext) (Principal, error) {
+		return &BasicPrincipal{Subject: "u1", Roles: []string{"admin"}}, nil
+	})
+
+	var seen Principal
+	handler := func(c RequestContext) error {
+		seen, _ = GetPrincipal(c)
+		return nil
+	}
+
+	chain := Authenticate(authenticator)(RequireAuthorization(nil, AuthorizationRequirement{Roles: []string{"admin"}})(handler))
+	require.NoError(t, chain(newValueRequestContext()))
+	require.NotNil(t, seen)
+	assert.Equal(t, "u1", seen.GetSubject())
+
+	reject := AuthenticatorFunc(func(c RequestContext) (Principal, error) {
+		return nil, NewHTTPError(http.StatusUnauthorized, "no credentials")
+	})
+	err := Authenticate(reject)(handler)(newValueRequestContext())
+	var httpErr *HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusUnauthorized, httpErr.Code)
+}
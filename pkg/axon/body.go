@@ -0,0 +1,205 @@
+package axon
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrBodyTooLarge is returned when a request body exceeds the route's size
+// limit. Reading a BodyStream past the limit fails with an *HTTPError (413)
+// wrapping it, so handlers can return the error as is.
+var ErrBodyTooLarge = errors.New("axon: request body too large")
+
+// DefaultMaxBodySize is the request body limit of routes without
+// -MaxBodySize unless the generator is run with another -max-body-size. It
+// matches the default BodyLimit of Fiber, which streams larger bodies to the
+// route instead of rejecting them.
+const DefaultMaxBodySize int64 = 4 << 20
+
+// bodyLimitKey stores the body size limit of the route on the request context
+const bodyLimitKey = "axon.body.limit"
+
+// BodyStream is the request body as a stream. Handlers take a BodyStream, or
+// an io.Reader, to process uploads without buffering them in memory:
+//
+//	//axon::route PUT /files/{name} -MaxBodySize=1GB
+//	func (c *FileController) Upload(name string, body axon.BodyStream) error
+//
+// The body can only be read once. Reading past the route's -MaxBodySize
+// fails with an error wrapping ErrBodyTooLarge.
+type BodyStream interface {
+	io.ReadCloser
+
+	// ContentLength returns the declared size of the body, or -1 if unknown
+	ContentLength() int64
+
+	// ContentType returns the Content-Type of the body
+	ContentType() string
+}
+
+// BodyLimitPolicy is the body size limit of one route. Generated route
+// registration builds it from -MaxBodySize, the controller setting or the
+// generator default.
+type BodyLimitPolicy struct {
+	// MaxSize is the largest accepted request body, in bytes
+	MaxSize int64
+
+	// Stream reports whether the handler takes a BodyStream; the body is then
+	// limited while the handler reads it instead of being read up front
+	Stream bool
+}
+
+// WithBodyLimit returns a middleware that rejects request bodies larger than
+// policy.MaxSize with 413 Request Entity Too Large. Requests declaring a
+// larger Content-Length are rejected before the handler runs. Bodies of
+// unknown length are read up to the limit before binding, unless the route
+// streams them, in which case the BodyStream enforces the limit. Rejected
+// requests get Connection: close, as the rest of their body is not read.
+func WithBodyLimit(policy BodyLimitPolicy) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		if policy.MaxSize <= 0 {
+			return next
+		}
+		return func(c RequestContext) error {
+			contentLength := c.Request().ContentLength()
+			if contentLength > policy.MaxSize {
+				return rejectBody(c, bodyTooLarge(policy.MaxSize))
+			}
+			c.Set(bodyLimitKey, policy.MaxSize)
+
+			if contentLength < 0 && !policy.Stream {
+				body, err := io.ReadAll(GetBodyStream(c))
+				if err != nil {
+					if errors.Is(err, ErrBodyTooLarge) {
+						return rejectBody(c, err)
+					}
+					return NewHTTPError(http.StatusBadRequest, "Invalid request body", err)
+				}
+				c.Request().SetBody(body)
+			}
+
+			err := next(c)
+			if errors.Is(err, ErrBodyTooLarge) {
+				return rejectBody(c, err)
+			}
+			return err
+		}
+	}
+}
+
+// GetBodyStream returns the request body as a BodyStream, limited to the
+// route's -MaxBodySize. Generated wrappers call it for BodyStream and
+// io.Reader handler parameters.
+func GetBodyStream(c RequestContext) BodyStream {
+	limit, _ := c.Get(bodyLimitKey).(int64)
+	return &bodyStream{
+		ReadCloser:    c.Request().BodyStream(),
+		contentLength: c.Request().ContentLength(),
+		contentType:   c.Request().ContentType(),
+		limit:         limit,
+		remaining:     limit,
+	}
+}
+
+// bodyStream limits a request body to the route's size limit
+type bodyStream struct {
+	io.ReadCloser
+	contentLength int64
+	contentType   string
+	limit         int64 // 0 for no limit
+	remaining     int64
+	err           error
+}
+
+func (b *bodyStream) Read(p []byte) (int, error) {
+	if b.limit <= 0 {
+		return b.ReadCloser.Read(p)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	// Read one byte more than allowed, to tell a body of exactly the limit
+	// from a larger one
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) <= b.remaining {
+		b.remaining -= int64(n)
+		return n, err
+	}
+	n = int(b.remaining)
+	b.remaining = 0
+	b.err = bodyTooLarge(b.limit)
+	return n, b.err
+}
+
+func (b *bodyStream) ContentLength() int64 {
+	return b.contentLength
+}
+
+func (b *bodyStream) ContentType() string {
+	return b.contentType
+}
+
+// rejectBody closes the connection after the error response: the rest of the
+// body is never read, so the connection cannot serve another request
+func rejectBody(c RequestContext, err error) error {
+	c.Response().SetHeader("Connection", "close")
+	return err
+}
+
+// bodyTooLarge returns the 413 error for bodies over limit
+func bodyTooLarge(limit int64) error {
+	return NewHTTPError(http.StatusRequestEntityTooLarge,
+		fmt.Sprintf("Request body must not exceed %s", FormatByteSize(limit)), ErrBodyTooLarge)
+}
+
+// byteSizeUnits are the units of ParseByteSize, largest first
+var byteSizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// ParseByteSize parses a size such as "512KB", "10MB" or "1GB". KB, MB and GB
+// are multiples of 1024; a number without a unit is a size in bytes.
+func ParseByteSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (e.g., 512KB, 10MB, 1GB)", s)
+	}
+	if n > (1<<63-1)/multiplier {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return n * multiplier, nil
+}
+
+// FormatByteSize formats a size in the largest unit of ParseByteSize that
+// divides it exactly
+func FormatByteSize(size int64) string {
+	for _, unit := range byteSizeUnits {
+		if size >= unit.size && size%unit.size == 0 {
+			return strconv.FormatInt(size/unit.size, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(size, 10) + "B"
+}
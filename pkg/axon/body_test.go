@@ -0,0 +1,124 @@
+package axon
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func uploadRequest(body string, chunked bool) *valueRequestContext {
+	c := newValueRequestContext().withHeader("Content-Type", "text/plain")
+	c.method = http.MethodPost
+	c.request.body = []byte(body)
+	c.request.chunked = chunked
+	return c
+}
+
+func TestWithBodyLimit(t *testing.T) {
+	var received string
+	handler := WithBodyLimit(BodyLimitPolicy{MaxSize: 8})(func(c RequestContext) error {
+		received = string(c.Request().Body())
+		return c.Response().String(http.StatusOK, "ok")
+	})
+
+	tests := []struct {
+		name    string
+		body    string
+		chunked bool
+		status  int
+	}{
+		{"within limit", "12345678", false, http.StatusOK},
+		{"declared too large", "123456789", false, http.StatusRequestEntityTooLarge},
+		{"chunked within limit", "1234", true, http.StatusOK},
+		{"chunked too large", "123456789", true, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = ""
+			c := uploadRequest(tt.body, tt.chunked)
+			err := handler(c)
+			if tt.status != http.StatusOK {
+				assert.Equal(t, tt.status, ErrorStatus(err))
+				assert.ErrorIs(t, err, ErrBodyTooLarge)
+				assert.Empty(t, received, "handler must not run")
+				assert.Equal(t, "close", c.response.headers.Get("Connection"))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, received)
+		})
+	}
+
+	// A limit of zero disables the check
+	unlimited := WithBodyLimit(BodyLimitPolicy{})(func(c RequestContext) error { return nil })
+	assert.NoError(t, unlimited(uploadRequest(strings.Repeat("x", 100), true)))
+}
+
+func TestGetBodyStream(t *testing.T) {
+	var read string
+	var readErr error
+	handler := WithBodyLimit(BodyLimitPolicy{MaxSize: 8, Stream: true})(func(c RequestContext) error {
+		body := GetBodyStream(c)
+		defer body.Close()
+		assert.Equal(t, "text/plain", body.ContentType())
+		data, err := io.ReadAll(body)
+		read, readErr = string(data), err
+		return err
+	})
+
+	require.NoError(t, handler(uploadRequest("12345678", true)))
+	assert.Equal(t, "12345678", read)
+
+	// Streamed bodies of unknown length are limited while they are read
+	err := handler(uploadRequest("123456789abc", true))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, ErrorStatus(err))
+	assert.True(t, errors.Is(readErr, ErrBodyTooLarge))
+	assert.Equal(t, "12345678", read)
+
+	// Declared sizes are still checked before the handler runs
+	read = ""
+	assert.Equal(t, http.StatusRequestEntityTooLarge, ErrorStatus(handler(uploadRequest("123456789", false))))
+	assert.Empty(t, read)
+
+	// Without a limit the whole body is streamed
+	c := uploadRequest(strings.Repeat("x", 100), true)
+	data, err := io.ReadAll(GetBodyStream(c))
+	require.NoError(t, err)
+	assert.Len(t, data, 100)
+	assert.Equal(t, int64(-1), GetBodyStream(c).ContentLength())
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"0", 0},
+		{"1024", 1024},
+		{"100B", 100},
+		{"512KB", 512 << 10},
+		{"10MB", 10 << 20},
+		{"10mb", 10 << 20},
+		{"1 GB", 1 << 30},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.input)
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.want, got, tt.input)
+	}
+
+	for _, invalid := range []string{"", "MB", "10TB", "-1MB", "1.5MB", "99999999999GB"} {
+		_, err := ParseByteSize(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	assert.Equal(t, "10MB", FormatByteSize(10<<20))
+	assert.Equal(t, "1536KB", FormatByteSize(1536<<10))
+	assert.Equal(t, "100B", FormatByteSize(100))
+}
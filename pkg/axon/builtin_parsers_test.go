@@ -513,6 +513,8 @@ type mockRequestContext struct{}
 func (m *mockRequestContext) Method() string                         { return "GET" }
 func (m *mockRequestContext) Path() string                           { return "/test" }
 func (m *mockRequestContext) RealIP() string                         { return "127.0.0.1" }
+func (m *mockRequestContext) Scheme() string                         { return "http" }
+func (m *mockRequestContext) Host() string                           { return "example.com" }
 func (m *mockRequestContext) Param(key string) string                { return "" }
 func (m *mockRequestContext) ParamNames() []string                   { return nil }
 func (m *mockRequestContext) ParamValues() []string                  { return nil }
@@ -0,0 +1,391 @@
+package axon
+
+import (
+	"container/list"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+// CacheVaryPrincipal in CachePolicy.Vary caches responses per authenticated principal
+const CacheVaryPrincipal = "principal"
+
+// Defaults of the response cache
+const (
+	defaultCacheEntries     = 1024
+	defaultCacheMaxBodySize = 1 << 20
+)
+
+// CacheEntry is a response stored by the response cache. Entries are shared
+// between requests once stored and must not be modified.
+type CacheEntry struct {
+	Status   int
+	Header   http.Header
+	Body     []byte
+	StoredAt time.Time
+
+	// TTL is how long the entry is fresh after StoredAt
+	TTL time.Duration
+
+	// ExpiresAt is when the entry can no longer be served, stale or not
+	ExpiresAt time.Time
+}
+
+// CacheStore stores cached responses. Keys of one route start with the route
+// name, followed by its path parameters, so DeletePrefix can invalidate a route
+// or one resource of a route.
+type CacheStore interface {
+	// Get returns the entry with the given key, or nil if it does not exist or has expired
+	Get(key string) (*CacheEntry, error)
+
+	// Set creates or replaces an entry; it may be discarded after entry.ExpiresAt
+	Set(key string, entry *CacheEntry) error
+
+	// DeletePrefix removes all entries whose key starts with prefix
+	DeletePrefix(prefix string) error
+}
+
+// ResponseCacheConfig configures the response cache
+type ResponseCacheConfig struct {
+	// Store holds the cached responses (default: NewMemoryCacheStore(1024))
+	Store CacheStore
+
+	// StaleWhileRevalidate is how long an expired entry may still be served
+	// while one request recomputes it (default: 0, disabled)
+	StaleWhileRevalidate time.Duration
+
+	// MaxBodySize is the largest response body that is cached (default: 1MB)
+	MaxBodySize int
+}
+
+// ResponseCache caches the encoded responses of GET routes annotated with
+// -Cache=duration. A response is cached when the handler returns 200, sets no
+// cookies, does not stream and does not send Cache-Control no-store or private.
+//
+// Entries are keyed by route, path parameters, query string and the request
+// values named by -CacheVary. Responses carry Cache-Control, Age and an
+// X-Cache header (HIT, MISS or STALE). When StaleWhileRevalidate is set, the
+// first request after an entry expires recomputes it while concurrent
+// requests are served the stale entry.
+type ResponseCache struct {
+	config       ResponseCacheConfig
+	now          func() time.Time
+	mu           sync.Mutex
+	revalidating map[string]bool
+}
+
+// CachePolicy is the cache configuration of one route. Generated route
+// registration builds it from -Cache and -CacheVary.
+type CachePolicy struct {
+	// Route names the route for invalidation, as "Controller.Handler"
+	Route string
+
+	// Path is the route template; its parameters are part of the cache key
+	Path string
+
+	// TTL is how long responses stay fresh
+	TTL time.Duration
+
+	// Vary lists request headers, or CacheVaryPrincipal, whose values select
+	// separate cache entries. Generated routes with authorization or
+	// middleware always include CacheVaryPrincipal.
+	Vary []string
+}
+
+// NewResponseCache creates a response cache
+func NewResponseCache(config ResponseCacheConfig) *ResponseCache {
+	if config.Store == nil {
+		config.Store = NewMemoryCacheStore(defaultCacheEntries)
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = defaultCacheMaxBodySize
+	}
+	return &ResponseCache{config: config, now: time.Now, revalidating: make(map[string]bool)}
+}
+
+// Invalidate removes the cached responses of a route. With params, only the
+// responses for those path parameter values are removed; params must then
+// name every path parameter of the route.
+//
+//	cache.Invalidate("ProductController.GetProduct", map[string]string{"id": "42"})
+func (rc *ResponseCache) Invalidate(route string, params map[string]string) error {
+	prefix := route + "\x00"
+	if len(params) > 0 {
+		values := make(url.Values, len(params))
+		for name, value := range params {
+			values.Set(name, value)
+		}
+		prefix += values.Encode() + "\x00"
+	}
+	return rc.config.Store.DeletePrefix(prefix)
+}
+
+// WithCache returns a middleware that serves a route from cache. Generated
+// route registration applies it for -Cache, inside authorization so cached
+// responses are only served to callers that may see them. Non-GET requests
+// and a nil cache pass through.
+func WithCache(cache *ResponseCache, policy CachePolicy) MiddlewareFunc {
+	params := pathParamNames(policy.Path)
+
+	return func(next HandlerFunc) HandlerFunc {
+		if cache == nil {
+			return next
+		}
+		return func(c RequestContext) error {
+			if c.Method() != http.MethodGet {
+				return next(c)
+			}
+			key := requestKey(c, policy.Route, params, policy.Vary)
+
+			entry, _ := cache.config.Store.Get(key)
+			if entry != nil {
+				now := cache.now()
+				if now.Before(entry.StoredAt.Add(entry.TTL)) {
+					return cache.serve(c, entry, "HIT", now)
+				}
+				if now.Before(entry.ExpiresAt) {
+					if !cache.startRevalidation(key) {
+						return cache.serve(c, entry, "STALE", now)
+					}
+					defer cache.endRevalidation(key)
+				}
+			}
+
+			recorder := NewResponseRecorder(c.Response())
+			if err := next(WithResponse(c, recorder)); err != nil {
+				return err
+			}
+			if cache.cacheable(recorder) {
+				cache.store(key, policy, recorder)
+			}
+			return recorder.Flush()
+		}
+	}
+}
+
+// cacheable reports whether a recorded response may be stored
+func (rc *ResponseCache) cacheable(recorder *ResponseRecorder) bool {
+	if !recorder.Buffered() || recorder.SetsCookies() || recorder.Status() != http.StatusOK {
+		return false
+	}
+	if len(recorder.Body()) > rc.config.MaxBodySize {
+		return false
+	}
+	cacheControl := strings.ToLower(recorder.RecordedHeader().Get("Cache-Control"))
+	return !strings.Contains(cacheControl, "no-store") && !strings.Contains(cacheControl, "private")
+}
+
+// store saves a recorded response and adds the caching headers to it
+func (rc *ResponseCache) store(key string, policy CachePolicy, recorder *ResponseRecorder) {
+	header := recorder.RecordedHeader()
+	if header.Get("Cache-Control") == "" {
+		header.Set("Cache-Control", rc.cacheControl(policy))
+	}
+	var vary []string
+	for _, name := range policy.Vary {
+		if !strings.EqualFold(name, CacheVaryPrincipal) {
+			vary = append(vary, http.CanonicalHeaderKey(name))
+		}
+	}
+	if len(vary) > 0 {
+		header.Set("Vary", strings.Join(vary, ", "))
+	}
+
+	now := rc.now()
+	_ = rc.config.Store.Set(key, &CacheEntry{
+		Status:    recorder.Status(),
+		Header:    cloneHeader(header),
+		Body:      recorder.Body(),
+		StoredAt:  now,
+		TTL:       policy.TTL,
+		ExpiresAt: now.Add(policy.TTL + rc.config.StaleWhileRevalidate),
+	})
+	header.Set("X-Cache", "MISS")
+}
+
+// cloneHeader deep-copies a header, since values set from request data may
+// share memory that some adapters (Fiber) reuse once the request completes
+func cloneHeader(header http.Header) http.Header {
+	clone := make(http.Header, len(header))
+	for key, values := range header {
+		for _, value := range values {
+			clone[key] = append(clone[key], strings.Clone(value))
+		}
+	}
+	return clone
+}
+
+// cacheControl builds the Cache-Control header of a cached route
+func (rc *ResponseCache) cacheControl(policy CachePolicy) string {
+	scope := "public"
+	for _, name := range policy.Vary {
+		if strings.EqualFold(name, CacheVaryPrincipal) {
+			scope = "private"
+		}
+	}
+	value := scope + ", max-age=" + strconv.FormatInt(int64(policy.TTL/time.Second), 10)
+	if rc.config.StaleWhileRevalidate > 0 {
+		value += ", stale-while-revalidate=" + strconv.FormatInt(int64(rc.config.StaleWhileRevalidate/time.Second), 10)
+	}
+	return value
+}
+
+// serve writes a cached entry with its age
+func (rc *ResponseCache) serve(c RequestContext, entry *CacheEntry, state string, now time.Time) error {
+	for key, values := range entry.Header {
+		if key != "Content-Type" {
+			replayHeader(c.Response(), key, values)
+		}
+	}
+	c.Response().SetHeader("Age", strconv.FormatInt(int64(now.Sub(entry.StoredAt)/time.Second), 10))
+	c.Response().SetHeader("X-Cache", state)
+	return c.Response().Blob(entry.Status, entry.Header.Get("Content-Type"), entry.Body)
+}
+
+// startRevalidation claims the revalidation of a stale entry; it returns
+// false if another request is already revalidating it
+func (rc *ResponseCache) startRevalidation(key string) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.revalidating[key] {
+		return false
+	}
+	rc.revalidating[key] = true
+	return true
+}
+
+// endRevalidation releases a revalidation claim
+func (rc *ResponseCache) endRevalidation(key string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	delete(rc.revalidating, key)
+}
+
+// MemoryCacheStore is an in-memory CacheStore that evicts the least recently
+// used entry once it holds its maximum number of entries
+type MemoryCacheStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List
+	now        func() time.Time
+}
+
+// memoryCacheItem is an element of the LRU list
+type memoryCacheItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewMemoryCacheStore creates an in-memory store holding up to maxEntries responses
+func NewMemoryCacheStore(maxEntries int) *MemoryCacheStore {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheEntries
+	}
+	return &MemoryCacheStore{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		now:        time.Now,
+	}
+}
+
+// Get returns the entry and marks it as recently used
+func (s *MemoryCacheStore) Get(key string) (*CacheEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	item := element.Value.(*memoryCacheItem)
+	if !s.now().Before(item.entry.ExpiresAt) {
+		s.remove(element)
+		return nil, nil
+	}
+	s.lru.MoveToFront(element)
+	return item.entry, nil
+}
+
+// Set stores the entry, evicting the least recently used entry when full
+func (s *MemoryCacheStore) Set(key string, entry *CacheEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.entries[key]; ok {
+		element.Value.(*memoryCacheItem).entry = entry
+		s.lru.MoveToFront(element)
+		return nil
+	}
+	s.entries[key] = s.lru.PushFront(&memoryCacheItem{key: key, entry: entry})
+	for s.lru.Len() > s.maxEntries {
+		s.remove(s.lru.Back())
+	}
+	return nil
+}
+
+// DeletePrefix removes all entries whose key starts with prefix
+func (s *MemoryCacheStore) DeletePrefix(prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, element := range s.entries {
+		if strings.HasPrefix(key, prefix) {
+			s.remove(element)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of stored entries, including expired ones not yet removed
+func (s *MemoryCacheStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.Len()
+}
+
+// remove deletes an element from the map and the LRU list
+func (s *MemoryCacheStore) remove(element *list.Element) {
+	s.lru.Remove(element)
+	delete(s.entries, element.Value.(*memoryCacheItem).key)
+}
+
+// ResponseCacheParams are the fx dependencies of the response cache. Both are
+// optional; an injected CacheStore is used unless the config sets one.
+type ResponseCacheParams struct {
+	fx.In
+
+	Config *ResponseCacheConfig `optional:"true"`
+	Store  CacheStore           `optional:"true"`
+}
+
+// NewResponseCacheFromParams builds the response cache from fx-provided dependencies
+func NewResponseCacheFromParams(p ResponseCacheParams) *ResponseCache {
+	var config ResponseCacheConfig
+	if p.Config != nil {
+		config = *p.Config
+	}
+	if config.Store == nil {
+		config.Store = p.Store
+	}
+	return NewResponseCache(config)
+}
+
+// ResponseCacheModule provides the *axon.ResponseCache that generated route
+// registration takes when a route is annotated with -Cache. It uses an
+// in-memory LRU store unless a CacheStore is provided.
+//
+//	fx.New(
+//	    fx.Supply(&axon.ResponseCacheConfig{StaleWhileRevalidate: time.Minute}),
+//	    axon.ResponseCacheModule,
+//	    controllers.AutogenModule,
+//	)
+var ResponseCacheModule = fx.Module("axon-response-cache",
+	fx.Provide(NewResponseCacheFromParams),
+)
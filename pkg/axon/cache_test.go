@@ -0,0 +1,265 @@
+package axon
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCache is a response cache with a controllable clock
+type testCache struct {
+	*ResponseCache
+	store   *MemoryCacheStore
+	clock   time.Time
+	handler HandlerFunc
+	calls   int
+}
+
+func newTestCache(t *testing.T, config ResponseCacheConfig, policy CachePolicy) *testCache {
+	t.Helper()
+	store := NewMemoryCacheStore(10)
+	config.Store = store
+	tc := &testCache{ResponseCache: NewResponseCache(config), store: store, clock: time.Unix(1700000000, 0)}
+	tc.now = func() time.Time { return tc.clock }
+	store.now = tc.ResponseCache.now
+
+	tc.handler = WithCache(tc.ResponseCache, policy)(func(c RequestContext) error {
+		tc.calls++
+		return c.Response().JSON(http.StatusOK, fmt.Sprintf("response %d", tc.calls))
+	})
+	return tc
+}
+
+func (tc *testCache) do(t *testing.T, c *valueRequestContext) *recordingResponse {
+	t.Helper()
+	require.NoError(t, tc.handler(c))
+	return c.response
+}
+
+func TestWithCache_HitAndExpiry(t *testing.T) {
+	tc := newTestCache(t, ResponseCacheConfig{}, CachePolicy{Route: "ProductController.List", Path: "/products", TTL: 30 * time.Second})
+
+	first := tc.do(t, newValueRequestContext())
+	assert.Equal(t, "MISS", first.headers.Get("X-Cache"))
+	assert.Equal(t, "public, max-age=30", first.headers.Get("Cache-Control"))
+	assert.Equal(t, `"response 1"`, string(first.body))
+
+	tc.clock = tc.clock.Add(10 * time.Second)
+	second := tc.do(t, newValueRequestContext())
+	assert.Equal(t, "HIT", second.headers.Get("X-Cache"))
+	assert.Equal(t, "10", second.headers.Get("Age"))
+	assert.Equal(t, "public, max-age=30", second.headers.Get("Cache-Control"))
+	assert.Equal(t, "application/json", second.headers.Get("Content-Type"))
+	assert.Equal(t, `"response 1"`, string(second.body))
+	assert.Equal(t, 1, tc.calls)
+
+	tc.clock = tc.clock.Add(30 * time.Second)
+	third := tc.do(t, newValueRequestContext())
+	assert.Equal(t, "MISS", third.headers.Get("X-Cache"))
+	assert.Equal(t, `"response 2"`, string(third.body))
+}
+
+func TestWithCache_MultiValueHeaders(t *testing.T) {
+	policy := CachePolicy{Route: "ProductController.List", Path: "/products", TTL: 30 * time.Second}
+	tc := newTestCache(t, ResponseCacheConfig{}, policy)
+	tc.handler = WithCache(tc.ResponseCache, policy)(func(c RequestContext) error {
+		tc.calls++
+		c.Response().AddHeader("Link", "</app.css>; rel=preload")
+		c.Response().AddHeader("Link", "</app.js>; rel=preload")
+		return c.Response().JSON(http.StatusOK, "products")
+	})
+
+	links := []string{"</app.css>; rel=preload", "</app.js>; rel=preload"}
+	first := tc.do(t, newValueRequestContext())
+	assert.Equal(t, "MISS", first.headers.Get("X-Cache"))
+	assert.Equal(t, links, first.headers.Values("Link"))
+
+	second := tc.do(t, newValueRequestContext())
+	assert.Equal(t, "HIT", second.headers.Get("X-Cache"))
+	assert.Equal(t, links, second.headers.Values("Link"), "every value is replayed")
+	assert.Equal(t, 1, tc.calls)
+}
+
+func TestWithCache_KeyIncludesParamsQueryAndVary(t *testing.T) {
+	tc := newTestCache(t, ResponseCacheConfig{}, CachePolicy{
+		Route: "ProductController.Get",
+		Path:  "/products/{id:int}",
+		TTL:   time.Minute,
+		Vary:  []string{"Accept-Language"},
+	})
+
+	request := func(id, page, language string) *valueRequestContext {
+		c := newValueRequestContext().withHeader("Accept-Language", language)
+		c.params["id"] = id
+		if page != "" {
+			c.query["page"] = page
+		}
+		return c
+	}
+
+	assert.Equal(t, "MISS", tc.do(t, request("1", "", "en")).headers.Get("X-Cache"))
+	assert.Equal(t, "HIT", tc.do(t, request("1", "", "en")).headers.Get("X-Cache"))
+	assert.Equal(t, "MISS", tc.do(t, request("2", "", "en")).headers.Get("X-Cache"))
+	assert.Equal(t, "MISS", tc.do(t, request("1", "2", "en")).headers.Get("X-Cache"))
+	response := tc.do(t, request("1", "", "de"))
+	assert.Equal(t, "MISS", response.headers.Get("X-Cache"))
+	assert.Equal(t, "Accept-Language", response.headers.Get("Vary"))
+	assert.Equal(t, 4, tc.calls)
+}
+
+func TestWithCache_VaryPrincipal(t *testing.T) {
+	tc := newTestCache(t, ResponseCacheConfig{}, CachePolicy{Route: "AccountController.Me", Path: "/me", TTL: time.Minute, Vary: []string{"principal"}})
+
+	as := func(subject string) *valueRequestContext {
+		c := newValueRequestContext()
+		SetPrincipal(c, &BasicPrincipal{Subject: subject})
+		return c
+	}
+
+	alice := tc.do(t, as("alice"))
+	assert.Equal(t, "private, max-age=60", alice.headers.Get("Cache-Control"))
+	assert.Empty(t, alice.headers.Get("Vary"))
+	assert.Equal(t, `"response 1"`, string(tc.do(t, as("alice")).body))
+	assert.Equal(t, `"response 2"`, string(tc.do(t, as("bob")).body))
+}
+
+func TestWithCache_StaleWhileRevalidate(t *testing.T) {
+	tc := newTestCache(t, ResponseCacheConfig{StaleWhileRevalidate: time.Minute}, CachePolicy{Route: "ProductController.List", Path: "/products", TTL: 30 * time.Second})
+
+	first := tc.do(t, newValueRequestContext())
+	assert.Equal(t, "public, max-age=30, stale-while-revalidate=60", first.headers.Get("Cache-Control"))
+
+	// While one request revalidates, others are served the stale entry
+	tc.clock = tc.clock.Add(45 * time.Second)
+	key := requestKey(newValueRequestContext(), "ProductController.List", nil, nil)
+	require.True(t, tc.startRevalidation(key))
+	stale := tc.do(t, newValueRequestContext())
+	assert.Equal(t, "STALE", stale.headers.Get("X-Cache"))
+	assert.Equal(t, "45", stale.headers.Get("Age"))
+	assert.Equal(t, `"response 1"`, string(stale.body))
+	tc.endRevalidation(key)
+
+	// Otherwise the request recomputes the entry
+	fresh := tc.do(t, newValueRequestContext())
+	assert.Equal(t, "MISS", fresh.headers.Get("X-Cache"))
+	assert.Equal(t, `"response 2"`, string(fresh.body))
+	assert.Equal(t, "HIT", tc.do(t, newValueRequestContext()).headers.Get("X-Cache"))
+
+	// Past the stale window the entry is gone
+	tc.clock = tc.clock.Add(2 * time.Minute)
+	assert.Equal(t, "MISS", tc.do(t, newValueRequestContext()).headers.Get("X-Cache"))
+	assert.Equal(t, 3, tc.calls)
+}
+
+func TestWithCache_NotCacheable(t *testing.T) {
+	policy := CachePolicy{Route: "Controller.Handler", Path: "/test", TTL: time.Minute}
+
+	tests := []struct {
+		name    string
+		handler HandlerFunc
+	}{
+		{"error status", func(c RequestContext) error { return c.Response().String(http.StatusNotFound, "missing") }},
+		{"cookies", func(c RequestContext) error {
+			c.Response().SetCookie(AxonCookie{Name: "visit", Value: "1"})
+			return c.Response().String(http.StatusOK, "ok")
+		}},
+		{"no-store", func(c RequestContext) error {
+			c.Response().SetHeader("Cache-Control", "no-store")
+			return c.Response().String(http.StatusOK, "ok")
+		}},
+		{"stream", func(c RequestContext) error { return c.Response().Stream(http.StatusOK, "text/plain", nil) }},
+		{"error", func(c RequestContext) error { return NewHTTPError(http.StatusInternalServerError) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryCacheStore(10)
+			handler := WithCache(NewResponseCache(ResponseCacheConfig{Store: store}), policy)(tt.handler)
+			_ = handler(newValueRequestContext())
+			assert.Zero(t, store.Len())
+		})
+	}
+
+	t.Run("too large", func(t *testing.T) {
+		store := NewMemoryCacheStore(10)
+		cache := NewResponseCache(ResponseCacheConfig{Store: store, MaxBodySize: 4})
+		handler := WithCache(cache, policy)(func(c RequestContext) error { return c.Response().String(http.StatusOK, "too long") })
+		require.NoError(t, handler(newValueRequestContext()))
+		assert.Zero(t, store.Len())
+	})
+}
+
+func TestWithCache_Bypass(t *testing.T) {
+	calls := 0
+	next := func(c RequestContext) error {
+		calls++
+		return c.Response().String(http.StatusOK, "ok")
+	}
+	policy := CachePolicy{Route: "Controller.Handler", Path: "/test", TTL: time.Minute}
+
+	// Without a cache the handler is returned unchanged
+	handler := WithCache(nil, policy)(next)
+	require.NoError(t, handler(newValueRequestContext()))
+
+	handler = WithCache(NewResponseCache(ResponseCacheConfig{}), policy)(next)
+	for i := 0; i < 2; i++ {
+		c := newValueRequestContext()
+		c.method = http.MethodHead
+		require.NoError(t, handler(c))
+		assert.Empty(t, c.response.headers.Get("X-Cache"))
+	}
+	assert.Equal(t, 3, calls)
+}
+
+func TestResponseCache_Invalidate(t *testing.T) {
+	tc := newTestCache(t, ResponseCacheConfig{}, CachePolicy{Route: "ProductController.Get", Path: "/products/{id:int}", TTL: time.Minute})
+
+	get := func(id string) string {
+		c := newValueRequestContext()
+		c.params["id"] = id
+		return tc.do(t, c).headers.Get("X-Cache")
+	}
+
+	get("1")
+	get("2")
+	require.NoError(t, tc.Invalidate("ProductController.Get", map[string]string{"id": "1"}))
+	assert.Equal(t, "MISS", get("1"))
+	assert.Equal(t, "HIT", get("2"))
+
+	require.NoError(t, tc.Invalidate("ProductController.Get", nil))
+	assert.Equal(t, "MISS", get("1"))
+	assert.Equal(t, "MISS", get("2"))
+
+	// Route names do not match by prefix of one another
+	require.NoError(t, tc.Invalidate("ProductController.G", nil))
+	assert.Equal(t, "HIT", get("1"))
+}
+
+func TestMemoryCacheStore_LRU(t *testing.T) {
+	store := NewMemoryCacheStore(2)
+	entry := &CacheEntry{Status: http.StatusOK, ExpiresAt: time.Now().Add(time.Hour)}
+
+	require.NoError(t, store.Set("a", entry))
+	require.NoError(t, store.Set("b", entry))
+	got, err := store.Get("a")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+
+	// "b" is the least recently used entry and is evicted
+	require.NoError(t, store.Set("c", entry))
+	assert.Equal(t, 2, store.Len())
+	got, _ = store.Get("b")
+	assert.Nil(t, got)
+	got, _ = store.Get("a")
+	assert.NotNil(t, got)
+
+	expired := &CacheEntry{Status: http.StatusOK, ExpiresAt: time.Now().Add(-time.Second)}
+	require.NoError(t, store.Set("d", expired))
+	got, _ = store.Get("d")
+	assert.Nil(t, got)
+	assert.Equal(t, 1, store.Len())
+}
@@ -0,0 +1,100 @@
+package axon
+
+import (
+	"net/http"
+	"sync"
+)
+
+// CoalescePolicy is the coalescing configuration of one route. Generated route
+// registration builds it from -Coalesce and -CoalesceKey.
+type CoalescePolicy struct {
+	// Route names the route, as "Controller.Handler"
+	Route string
+
+	// Path is the route template; its parameters are part of the key
+	Path string
+
+	// Key lists request headers, or CacheVaryPrincipal, that are added to the
+	// key, so requests differing in them are not coalesced. Generated routes
+	// with authorization or middleware always include CacheVaryPrincipal.
+	Key []string
+}
+
+// coalescedCall is a handler execution shared by concurrent requests
+type coalescedCall struct {
+	done   chan struct{}
+	err    error
+	shared bool // whether the response can be copied to the other callers
+	status int
+	header http.Header
+	body   []byte
+}
+
+// WithCoalescing returns a middleware that lets concurrent GET requests with
+// the same route, path parameters, query string and policy.Key values share
+// one handler execution. The first request runs the handler; requests
+// arriving while it runs wait and receive a copy of its response, or the same
+// error. Responses that set cookies or stream are not shared: waiting
+// requests then run the handler themselves. Non-GET requests pass through.
+func WithCoalescing(policy CoalescePolicy) MiddlewareFunc {
+	params := pathParamNames(policy.Path)
+	var mu sync.Mutex
+	calls := make(map[string]*coalescedCall)
+
+	return func(next HandlerFunc) HandlerFunc {
+		// A panic must still release the waiting requests
+		next = Recover(next)
+
+		return func(c RequestContext) error {
+			if c.Method() != http.MethodGet {
+				return next(c)
+			}
+			key := requestKey(c, policy.Route, params, policy.Key)
+
+			mu.Lock()
+			if call, ok := calls[key]; ok {
+				mu.Unlock()
+				<-call.done
+				if !call.shared {
+					return next(c)
+				}
+				if call.err != nil {
+					return call.err
+				}
+				return writeCoalesced(c, call)
+			}
+			call := &coalescedCall{done: make(chan struct{})}
+			calls[key] = call
+			mu.Unlock()
+
+			recorder := NewResponseRecorder(c.Response())
+			call.err = next(WithResponse(c, recorder))
+			call.shared = call.err != nil || (recorder.Buffered() && !recorder.SetsCookies())
+			if call.shared && call.err == nil {
+				call.status = recorder.Status()
+				call.header = cloneHeader(recorder.RecordedHeader())
+				call.body = recorder.Body()
+			}
+
+			mu.Lock()
+			delete(calls, key)
+			mu.Unlock()
+			close(call.done)
+
+			if call.err != nil {
+				return call.err
+			}
+			return recorder.Flush()
+		}
+	}
+}
+
+// writeCoalesced writes a copy of a shared response
+func writeCoalesced(c RequestContext, call *coalescedCall) error {
+	for key, values := range call.header {
+		if key != "Content-Type" {
+			replayHeader(c.Response(), key, values)
+		}
+	}
+	return c.Response().Blob(call.status, call.header.Get("Content-Type"), append([]byte(nil), call.body...))
+}
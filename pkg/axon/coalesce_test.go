@@ -0,0 +1,169 @@
+package axon
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingHandler counts executions and blocks each one until released
+type blockingHandler struct {
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+	handle  func(c RequestContext) error
+}
+
+func newBlockingHandler(handle func(c RequestContext) error) *blockingHandler {
+	return &blockingHandler{started: make(chan struct{}, 16), release: make(chan struct{}), handle: handle}
+}
+
+func (h *blockingHandler) Handle(c RequestContext) error {
+	h.calls.Add(1)
+	h.started <- struct{}{}
+	<-h.release
+	return h.handle(c)
+}
+
+// runConcurrently starts the first request, then the others while it is running
+func runConcurrently(t *testing.T, handler HandlerFunc, blocking *blockingHandler, requests []*valueRequestContext) []error {
+	t.Helper()
+	errs := make([]error, len(requests))
+	var wg sync.WaitGroup
+	for i, c := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = handler(c)
+		}()
+		if i == 0 {
+			<-blocking.started
+		}
+	}
+	// Give the other requests time to join the running call
+	time.Sleep(50 * time.Millisecond)
+	close(blocking.release)
+	wg.Wait()
+	return errs
+}
+
+func TestWithCoalescing_SharesResponse(t *testing.T) {
+	blocking := newBlockingHandler(func(c RequestContext) error {
+		c.Response().SetHeader("X-Custom", "yes")
+		c.Response().AddHeader("Link", "</report.css>; rel=preload")
+		c.Response().AddHeader("Link", "</report.js>; rel=preload")
+		return c.Response().JSON(http.StatusOK, "expensive")
+	})
+	handler := WithCoalescing(CoalescePolicy{Route: "ReportController.Get", Path: "/reports/{id}"})(blocking.Handle)
+
+	requests := make([]*valueRequestContext, 5)
+	for i := range requests {
+		requests[i] = newValueRequestContext()
+		requests[i].params["id"] = "7"
+	}
+	for _, err := range runConcurrently(t, handler, blocking, requests) {
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(1), blocking.calls.Load())
+	for _, c := range requests {
+		assert.Equal(t, http.StatusOK, c.response.status)
+		assert.Equal(t, `"expensive"`, string(c.response.body))
+		assert.Equal(t, "application/json", c.response.headers.Get("Content-Type"))
+		assert.Equal(t, "yes", c.response.headers.Get("X-Custom"))
+		assert.Equal(t, []string{"</report.css>; rel=preload", "</report.js>; rel=preload"}, c.response.headers.Values("Link"))
+	}
+
+	// Each caller gets its own copy of the body
+	requests[1].response.body[0] = 'X'
+	assert.Equal(t, `"expensive"`, string(requests[2].response.body))
+
+	// Later requests run the handler again
+	blocking.release = make(chan struct{})
+	close(blocking.release)
+	require.NoError(t, handler(newValueRequestContext()))
+	assert.Equal(t, int32(2), blocking.calls.Load())
+}
+
+func TestWithCoalescing_Key(t *testing.T) {
+	blocking := newBlockingHandler(func(c RequestContext) error {
+		return c.Response().String(http.StatusOK, "ok")
+	})
+	handler := WithCoalescing(CoalescePolicy{Route: "ReportController.Get", Path: "/reports/{id}", Key: []string{"X-Tenant", "principal"}})(blocking.Handle)
+
+	request := func(id, tenant, subject, page string) *valueRequestContext {
+		c := newValueRequestContext().withHeader("X-Tenant", tenant)
+		c.params["id"] = id
+		if page != "" {
+			c.query["page"] = page
+		}
+		SetPrincipal(c, &BasicPrincipal{Subject: subject})
+		return c
+	}
+
+	runConcurrently(t, handler, blocking, []*valueRequestContext{
+		request("1", "acme", "alice", ""),
+		request("1", "acme", "alice", ""), // joins the first
+		request("2", "acme", "alice", ""),
+		request("1", "other", "alice", ""),
+		request("1", "acme", "bob", ""),
+		request("1", "acme", "alice", "2"),
+	})
+	assert.Equal(t, int32(5), blocking.calls.Load())
+}
+
+func TestWithCoalescing_SharesErrors(t *testing.T) {
+	blocking := newBlockingHandler(func(c RequestContext) error {
+		return NewHTTPError(http.StatusBadGateway, "upstream failed")
+	})
+	handler := WithCoalescing(CoalescePolicy{Route: "ReportController.Get", Path: "/reports"})(blocking.Handle)
+
+	errs := runConcurrently(t, handler, blocking, []*valueRequestContext{newValueRequestContext(), newValueRequestContext()})
+	assert.Equal(t, int32(1), blocking.calls.Load())
+	for _, err := range errs {
+		assert.Equal(t, http.StatusBadGateway, ErrorStatus(err))
+	}
+}
+
+func TestWithCoalescing_Panic(t *testing.T) {
+	blocking := newBlockingHandler(func(c RequestContext) error {
+		panic("boom")
+	})
+	handler := WithCoalescing(CoalescePolicy{Route: "ReportController.Get", Path: "/reports"})(blocking.Handle)
+
+	errs := runConcurrently(t, handler, blocking, []*valueRequestContext{newValueRequestContext(), newValueRequestContext()})
+	for _, err := range errs {
+		var panicErr *PanicError
+		assert.ErrorAs(t, err, &panicErr)
+	}
+}
+
+func TestWithCoalescing_NotShared(t *testing.T) {
+	blocking := newBlockingHandler(func(c RequestContext) error {
+		c.Response().SetCookie(AxonCookie{Name: "visit", Value: "1"})
+		return c.Response().String(http.StatusOK, "ok")
+	})
+	handler := WithCoalescing(CoalescePolicy{Route: "ReportController.Get", Path: "/reports"})(blocking.Handle)
+
+	// The waiting request runs the handler itself, after the first completes
+	requests := []*valueRequestContext{newValueRequestContext(), newValueRequestContext()}
+	for _, err := range runConcurrently(t, handler, blocking, requests) {
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), blocking.calls.Load())
+	for _, c := range requests {
+		_, ok := c.response.cookie("visit")
+		assert.True(t, ok)
+	}
+
+	// Other methods are never coalesced
+	post := newValueRequestContext()
+	post.method = http.MethodPost
+	require.NoError(t, handler(post))
+	assert.Equal(t, int32(3), blocking.calls.Load())
+}
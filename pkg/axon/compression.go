@@ -0,0 +1,434 @@
+package axon
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"go.uber.org/fx"
+)
+
+// Content codings supported by the compression middleware
+const (
+	EncodingBrotli = "br"
+	EncodingZstd   = "zstd"
+	EncodingGzip   = "gzip"
+)
+
+// compressionDisabledKey marks requests to routes annotated with -Compress=false
+const compressionDisabledKey = "axon.compression.disabled"
+
+// Defaults of the compression middleware
+const (
+	defaultCompressionMinSize  = 1024
+	defaultMaxDecompressedSize = 10 << 20
+	defaultBrotliLevel         = 5
+)
+
+// defaultSkipContentTypes are media types that are already compressed. Entries
+// ending in "/" match every subtype.
+var defaultSkipContentTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+	"font/woff2",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/zstd",
+	"application/x-brotli",
+	"application/pdf",
+	"application/octet-stream",
+}
+
+// CompressionConfig configures response compression
+type CompressionConfig struct {
+	// Encodings lists the content codings to offer, most preferred first
+	// (default: br, zstd, gzip). The client's Accept-Encoding q-values win;
+	// this order breaks ties.
+	Encodings []string
+
+	// MinSize is the smallest response body that is compressed (default: 1KB)
+	MinSize int
+
+	// SkipContentTypes lists media types that are not compressed because they
+	// are already compressed; entries ending in "/" match every subtype
+	// (default: images, video, audio, fonts, archives, PDF and
+	// application/octet-stream). Types with a +json or +xml suffix, such as
+	// image/svg+xml, are always compressible.
+	SkipContentTypes []string
+
+	// DecompressRequests decodes request bodies sent with a gzip, br or zstd
+	// Content-Encoding before they reach the handler. Otherwise request
+	// bodies are passed through as sent.
+	DecompressRequests bool
+
+	// MaxDecompressedSize is the largest request body with a Content-Encoding,
+	// both as sent and once decoded; larger bodies fail with 413 (default: 10MB)
+	MaxDecompressedSize int64
+}
+
+// Compression is a middleware that compresses responses with the best
+// encoding the client accepts. Responses that are smaller than MinSize, have
+// an already-compressed content type, already carry a Content-Encoding, or
+// are streamed are sent unchanged. Compressible responses get
+// Vary: Accept-Encoding whether or not they are compressed.
+//
+// Strong ETags of compressed responses get the coding appended ("tag-gzip"),
+// since the bytes differ from the uncompressed representation; the suffix is
+// removed from If-None-Match and If-Match before the handler runs, so
+// conditional requests keep working. Routes opt out with -Compress=false.
+type Compression struct {
+	config      CompressionConfig
+	gzipWriters sync.Pool
+	brotli      sync.Pool
+	zstdOnce    sync.Once
+	zstd        *zstd.Encoder
+	zstdErr     error
+}
+
+// NewCompression creates a compression middleware
+func NewCompression(config CompressionConfig) (*Compression, error) {
+	if len(config.Encodings) == 0 {
+		config.Encodings = []string{EncodingBrotli, EncodingZstd, EncodingGzip}
+	}
+	for _, encoding := range config.Encodings {
+		switch encoding {
+		case EncodingBrotli, EncodingZstd, EncodingGzip:
+		default:
+			return nil, fmt.Errorf("axon: unsupported compression encoding %q (expected br, zstd or gzip)", encoding)
+		}
+	}
+	if config.MinSize <= 0 {
+		config.MinSize = defaultCompressionMinSize
+	}
+	if config.SkipContentTypes == nil {
+		config.SkipContentTypes = defaultSkipContentTypes
+	}
+	if config.MaxDecompressedSize <= 0 {
+		config.MaxDecompressedSize = defaultMaxDecompressedSize
+	}
+	return &Compression{config: config}, nil
+}
+
+// WithoutCompression returns a middleware that exempts a route from response
+// compression. Generated route registration applies it for -Compress=false,
+// e.g. for responses that mix secrets with attacker-controlled input.
+func WithoutCompression() MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c RequestContext) error {
+			c.Set(compressionDisabledKey, true)
+			return next(c)
+		}
+	}
+}
+
+// Handle implements the middleware
+func (m *Compression) Handle(next HandlerFunc) HandlerFunc {
+	return func(c RequestContext) error {
+		if m.config.DecompressRequests {
+			if err := m.decompressRequest(c); err != nil {
+				return err
+			}
+		}
+
+		encoding := m.negotiate(c.Request().Header("Accept-Encoding"))
+		suffixed := false
+		for _, name := range []string{"If-None-Match", "If-Match"} {
+			if value := c.Request().Header(name); value != "" {
+				if stripped, ok := stripETagEncodings(value); ok {
+					c.Request().SetHeader(name, stripped)
+					suffixed = true
+				}
+			}
+		}
+
+		recorder := NewResponseRecorder(c.Response())
+		if err := next(WithResponse(c, recorder)); err != nil {
+			// Keep headers set by inner middleware on the error response
+			recorder.copyHeaders(true)
+			return err
+		}
+		if !recorder.Buffered() {
+			return recorder.Flush()
+		}
+		if disabled, _ := c.Get(compressionDisabledKey).(bool); disabled {
+			return recorder.Flush()
+		}
+
+		header := recorder.RecordedHeader()
+		if recorder.Status() == http.StatusNotModified {
+			// The 304 stands for the representation the client holds
+			if encoding != "" && suffixed {
+				header.Set("ETag", etagWithEncoding(header.Get("ETag"), encoding))
+			}
+			return recorder.Flush()
+		}
+		if header.Get("Content-Encoding") != "" || !m.compressible(header.Get("Content-Type")) {
+			return recorder.Flush()
+		}
+		addVary(header, "Accept-Encoding")
+		if encoding == "" || len(recorder.Body()) < m.config.MinSize || bodylessStatus(recorder.Status()) {
+			return recorder.Flush()
+		}
+
+		compressed, err := m.compress(encoding, recorder.Body())
+		if err != nil {
+			return recorder.Flush()
+		}
+		header.Set("Content-Encoding", encoding)
+		header.Del("Content-Length")
+		if etag := header.Get("ETag"); etag != "" {
+			header.Set("ETag", etagWithEncoding(etag, encoding))
+		}
+		recorder.SetBody(compressed)
+		return recorder.Flush()
+	}
+}
+
+// negotiate picks the offered encoding with the highest q-value in
+// Accept-Encoding, or "" if the client accepts none of them
+func (m *Compression) negotiate(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	qualities := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if name == "*" {
+			wildcard = q
+		} else {
+			qualities[name] = q
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range m.config.Encodings {
+		q, ok := qualities[encoding]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// compressible reports whether a content type may be compressed
+func (m *Compression) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") {
+		return true
+	}
+	for _, skip := range m.config.SkipContentTypes {
+		if mediaType == skip || (strings.HasSuffix(skip, "/") && strings.HasPrefix(mediaType, skip)) {
+			return false
+		}
+	}
+	return true
+}
+
+// compress encodes a body with the given coding
+func (m *Compression) compress(encoding string, body []byte) ([]byte, error) {
+	switch encoding {
+	case EncodingZstd:
+		m.zstdOnce.Do(func() {
+			m.zstd, m.zstdErr = zstd.NewWriter(nil)
+		})
+		if m.zstdErr != nil {
+			return nil, m.zstdErr
+		}
+		return m.zstd.EncodeAll(body, make([]byte, 0, len(body)/2)), nil
+	case EncodingBrotli:
+		var buf bytes.Buffer
+		writer, _ := m.brotli.Get().(*brotli.Writer)
+		if writer == nil {
+			writer = brotli.NewWriterLevel(&buf, defaultBrotliLevel)
+		} else {
+			writer.Reset(&buf)
+		}
+		defer m.brotli.Put(writer)
+		if _, err := writer.Write(body); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		var buf bytes.Buffer
+		writer, _ := m.gzipWriters.Get().(*gzip.Writer)
+		if writer == nil {
+			writer = gzip.NewWriter(&buf)
+		} else {
+			writer.Reset(&buf)
+		}
+		defer m.gzipWriters.Put(writer)
+		if _, err := writer.Write(body); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+// decompressRequest decodes a request body sent with a Content-Encoding. The
+// body is decoded as it is read, and both the encoded and the decoded body are
+// limited to MaxDecompressedSize, as this runs before any route body limit.
+func (m *Compression) decompressRequest(c RequestContext) error {
+	encoding := strings.ToLower(strings.TrimSpace(c.Request().Header("Content-Encoding")))
+	if encoding == "" || encoding == "identity" {
+		return nil
+	}
+
+	limit := m.config.MaxDecompressedSize
+	if c.Request().ContentLength() > limit {
+		return rejectBody(c, bodyTooLarge(limit))
+	}
+	body := &bodyStream{ReadCloser: c.Request().BodyStream(), limit: limit, remaining: limit}
+	defer body.Close()
+
+	var reader io.Reader
+	switch encoding {
+	case EncodingGzip, "x-gzip":
+		gzipReader, err := gzip.NewReader(body)
+		if err != nil {
+			return decompressError(c, body, NewHTTPError(http.StatusBadRequest, "invalid gzip request body", err))
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	case EncodingBrotli:
+		reader = brotli.NewReader(body)
+	case EncodingZstd:
+		zstdReader, err := zstd.NewReader(body)
+		if err != nil {
+			return decompressError(c, body, NewHTTPError(http.StatusBadRequest, "invalid zstd request body", err))
+		}
+		defer zstdReader.Close()
+		reader = zstdReader
+	default:
+		return NewHTTPError(http.StatusUnsupportedMediaType, "unsupported Content-Encoding", fmt.Errorf("axon: cannot decode request Content-Encoding %q", encoding))
+	}
+
+	decoded, err := io.ReadAll(&bodyStream{ReadCloser: io.NopCloser(reader), limit: limit, remaining: limit})
+	if err != nil {
+		return decompressError(c, body, NewHTTPError(http.StatusBadRequest, "invalid compressed request body", err))
+	}
+	c.Request().SetBody(decoded)
+	c.Request().SetHeader("Content-Encoding", "")
+	return nil
+}
+
+// decompressError returns 413 when the encoded or the decoded body exceeded
+// the limit, which decoders may report as a corrupt body, and err otherwise
+func decompressError(c RequestContext, body *bodyStream, err error) error {
+	if body.err != nil || errors.Is(err, ErrBodyTooLarge) {
+		return rejectBody(c, bodyTooLarge(body.limit))
+	}
+	return err
+}
+
+// addVary adds a header name to Vary unless it is already listed
+func addVary(header http.Header, name string) {
+	vary := header.Get("Vary")
+	for _, existing := range strings.Split(vary, ",") {
+		if strings.EqualFold(strings.TrimSpace(existing), name) || strings.TrimSpace(existing) == "*" {
+			return
+		}
+	}
+	if vary == "" {
+		header.Set("Vary", name)
+		return
+	}
+	header.Set("Vary", vary+", "+name)
+}
+
+// bodylessStatus reports whether a status code never has a body
+func bodylessStatus(status int) bool {
+	return status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified
+}
+
+// etagWithEncoding appends the coding to a strong tag; weak tags already
+// allow for different bytes and are kept
+func etagWithEncoding(etag, encoding string) string {
+	if !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) || len(etag) < 2 {
+		return etag
+	}
+	return etag[:len(etag)-1] + "-" + encoding + `"`
+}
+
+// stripETagEncodings removes the coding suffixes added by etagWithEncoding
+// from the tags of an If-None-Match or If-Match header
+func stripETagEncodings(header string) (string, bool) {
+	stripped := false
+	tags := strings.Split(header, ",")
+	for i, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		for _, encoding := range []string{EncodingBrotli, EncodingZstd, EncodingGzip} {
+			if trimmed, ok := strings.CutSuffix(tag, "-"+encoding+`"`); ok {
+				tag = trimmed + `"`
+				stripped = true
+				break
+			}
+		}
+		tags[i] = tag
+	}
+	return strings.Join(tags, ", "), stripped
+}
+
+// CompressionParams are the fx dependencies of the compression middleware.
+// The config is optional; without it the defaults are used.
+type CompressionParams struct {
+	fx.In
+
+	Config *CompressionConfig `optional:"true"`
+}
+
+// NewCompressionFromParams builds the middleware from fx-provided dependencies
+func NewCompressionFromParams(p CompressionParams) (*Compression, error) {
+	var config CompressionConfig
+	if p.Config != nil {
+		config = *p.Config
+	}
+	return NewCompression(config)
+}
+
+// CompressionModule provides a *Compression and registers it as global
+// middleware, so it works the same on every adapter. Include it before the
+// generated modules so it wraps every route.
+//
+//	fx.New(
+//	    fx.Supply(&axon.CompressionConfig{DecompressRequests: true}),
+//	    axon.CompressionModule,
+//	    controllers.AutogenModule,
+//	)
+var CompressionModule = fx.Module("axon-compression",
+	fx.Provide(NewCompressionFromParams),
+	fx.Invoke(func(server WebServerInterface, compression *Compression) {
+		server.Use(compression.Handle)
+	}),
+)
@@ -0,0 +1,253 @@
+package axon
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decompress decodes a body with the given content coding
+func decompress(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var reader io.Reader
+	switch encoding {
+	case EncodingGzip:
+		gzipReader, err := gzip.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		reader = gzipReader
+	case EncodingBrotli:
+		reader = brotli.NewReader(bytes.NewReader(body))
+	case EncodingZstd:
+		zstdReader, err := zstd.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		defer zstdReader.Close()
+		reader = zstdReader
+	}
+	decoded, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return string(decoded)
+}
+
+func newTestCompression(t *testing.T, config CompressionConfig) *Compression {
+	t.Helper()
+	compression, err := NewCompression(config)
+	require.NoError(t, err)
+	return compression
+}
+
+var largeText = strings.Repeat("compress me ", 200)
+
+func TestCompression_Negotiation(t *testing.T) {
+	compression := newTestCompression(t, CompressionConfig{})
+	handler := compression.Handle(func(c RequestContext) error {
+		return c.Response().String(http.StatusOK, largeText)
+	})
+
+	tests := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{"gzip", EncodingGzip},
+		{"gzip, deflate, br", EncodingBrotli},
+		{"gzip, zstd", EncodingZstd},
+		{"br;q=0.5, gzip", EncodingGzip},
+		{"*", EncodingBrotli},
+		{"*, br;q=0", EncodingZstd},
+		{"gzip;q=0", ""},
+		{"deflate", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			c := newValueRequestContext().withHeader("Accept-Encoding", tt.acceptEncoding)
+			require.NoError(t, handler(c))
+			assert.Equal(t, tt.want, c.response.headers.Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", c.response.headers.Get("Vary"))
+			if tt.want == "" {
+				assert.Equal(t, largeText, string(c.response.body))
+				return
+			}
+			assert.Less(t, len(c.response.body), len(largeText))
+			assert.Equal(t, largeText, decompress(t, tt.want, c.response.body))
+		})
+	}
+}
+
+func TestCompression_Skips(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  HandlerFunc
+		encoding string // Content-Encoding set by the handler
+		vary     bool
+	}{
+		{"small body", func(c RequestContext) error { return c.Response().String(http.StatusOK, "small") }, "", true},
+		{"compressed content type", func(c RequestContext) error {
+			return c.Response().Blob(http.StatusOK, "image/png", []byte(largeText))
+		}, "", false},
+		{"already encoded", func(c RequestContext) error {
+			c.Response().SetHeader("Content-Encoding", "zstd")
+			return c.Response().Blob(http.StatusOK, "application/json", []byte(largeText))
+		}, "zstd", false},
+		{"stream", func(c RequestContext) error {
+			return c.Response().Stream(http.StatusOK, "text/plain", strings.NewReader(largeText))
+		}, "", false},
+		{"opted out", WithoutCompression()(func(c RequestContext) error {
+			return c.Response().String(http.StatusOK, largeText)
+		}), "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestCompression(t, CompressionConfig{}).Handle(tt.handler)
+			c := newValueRequestContext().withHeader("Accept-Encoding", "gzip")
+			require.NoError(t, handler(c))
+			assert.Equal(t, tt.encoding, c.response.headers.Get("Content-Encoding"))
+			assert.Equal(t, tt.vary, c.response.headers.Get("Vary") == "Accept-Encoding")
+		})
+	}
+
+	t.Run("svg is text", func(t *testing.T) {
+		handler := newTestCompression(t, CompressionConfig{}).Handle(func(c RequestContext) error {
+			return c.Response().Blob(http.StatusOK, "image/svg+xml", []byte(largeText))
+		})
+		c := newValueRequestContext().withHeader("Accept-Encoding", "gzip")
+		require.NoError(t, handler(c))
+		assert.Equal(t, "gzip", c.response.headers.Get("Content-Encoding"))
+	})
+}
+
+func TestCompression_Vary(t *testing.T) {
+	handler := newTestCompression(t, CompressionConfig{}).Handle(func(c RequestContext) error {
+		c.Response().SetHeader("Vary", "Accept-Language")
+		return c.Response().String(http.StatusOK, largeText)
+	})
+	c := newValueRequestContext().withHeader("Accept-Encoding", "gzip")
+	require.NoError(t, handler(c))
+	assert.Equal(t, "Accept-Language, Accept-Encoding", c.response.headers.Get("Vary"))
+}
+
+func TestCompression_ETag(t *testing.T) {
+	handler := newTestCompression(t, CompressionConfig{}).Handle(WithETag(ETagPolicy{})(func(c RequestContext) error {
+		return c.Response().String(http.StatusOK, largeText)
+	}))
+
+	plain := newValueRequestContext()
+	require.NoError(t, handler(plain))
+	etag := plain.response.headers.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// The compressed representation gets its own strong tag
+	compressed := newValueRequestContext().withHeader("Accept-Encoding", "gzip")
+	require.NoError(t, handler(compressed))
+	gzipETag := compressed.response.headers.Get("ETag")
+	assert.Equal(t, strings.TrimSuffix(etag, `"`)+`-gzip"`, gzipETag)
+
+	// ...which still matches If-None-Match
+	revalidate := newValueRequestContext().withHeader("Accept-Encoding", "gzip").withHeader("If-None-Match", gzipETag)
+	require.NoError(t, handler(revalidate))
+	assert.Equal(t, http.StatusNotModified, revalidate.response.status)
+	assert.Equal(t, gzipETag, revalidate.response.headers.Get("ETag"))
+
+	// Weak tags are kept as they are
+	weak := newTestCompression(t, CompressionConfig{}).Handle(func(c RequestContext) error {
+		c.Response().SetHeader("ETag", `W/"v1"`)
+		return c.Response().String(http.StatusOK, largeText)
+	})
+	c := newValueRequestContext().withHeader("Accept-Encoding", "gzip")
+	require.NoError(t, weak(c))
+	assert.Equal(t, `W/"v1"`, c.response.headers.Get("ETag"))
+}
+
+func TestCompression_DecompressRequests(t *testing.T) {
+	var gzipped bytes.Buffer
+	writer := gzip.NewWriter(&gzipped)
+	_, _ = writer.Write([]byte(`{"name":"axon"}`))
+	require.NoError(t, writer.Close())
+
+	var received string
+	echoBody := func(c RequestContext) error {
+		received = string(c.Request().Body())
+		return c.Response().String(http.StatusOK, "ok")
+	}
+
+	request := func(encoding string, body []byte) *valueRequestContext {
+		c := newValueRequestContext().withHeader("Content-Encoding", encoding)
+		c.method = http.MethodPost
+		c.request.body = body
+		return c
+	}
+
+	handler := newTestCompression(t, CompressionConfig{DecompressRequests: true}).Handle(echoBody)
+	c := request("gzip", gzipped.Bytes())
+	require.NoError(t, handler(c))
+	assert.Equal(t, `{"name":"axon"}`, received)
+	assert.Empty(t, c.request.headers["Content-Encoding"])
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, ErrorStatus(handler(request("compress", []byte("x")))))
+	assert.Equal(t, http.StatusBadRequest, ErrorStatus(handler(request("gzip", []byte("not gzip")))))
+
+	limited := newTestCompression(t, CompressionConfig{DecompressRequests: true, MaxDecompressedSize: 4}).Handle(echoBody)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, ErrorStatus(limited(request("gzip", gzipped.Bytes()))))
+
+	// Without DecompressRequests the body is passed through
+	passthrough := newTestCompression(t, CompressionConfig{}).Handle(echoBody)
+	require.NoError(t, passthrough(request("gzip", gzipped.Bytes())))
+	assert.Equal(t, gzipped.String(), received)
+}
+
+func TestCompression_DecompressRequestLimits(t *testing.T) {
+	gzipBytes := func(data []byte) []byte {
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		_, _ = writer.Write(data)
+		require.NoError(t, writer.Close())
+		return buf.Bytes()
+	}
+	// Random bytes do not compress, so their encoded size exceeds the limit
+	random := make([]byte, 4<<10)
+	_, _ = rand.Read(random)
+
+	called := false
+	handler := newTestCompression(t, CompressionConfig{DecompressRequests: true, MaxDecompressedSize: 1 << 10}).Handle(func(c RequestContext) error {
+		called = true
+		return c.Response().String(http.StatusOK, "ok")
+	})
+
+	tests := []struct {
+		name    string
+		body    []byte
+		chunked bool
+	}{
+		{"declared too large", gzipBytes(random), false},
+		{"chunked too large", gzipBytes(random), true},
+		{"decompressed too large", gzipBytes(bytes.Repeat([]byte("a"), 64<<10)), false},
+	}
+	for _, tt := range tests {
+		c := newValueRequestContext().withHeader("Content-Encoding", "gzip")
+		c.method = http.MethodPost
+		c.request.body = tt.body
+		c.request.chunked = tt.chunked
+
+		called = false
+		err := handler(c)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, ErrorStatus(err), tt.name)
+		assert.ErrorIs(t, err, ErrBodyTooLarge, tt.name)
+		assert.Equal(t, "close", c.response.headers.Get("Connection"), tt.name)
+		assert.False(t, called, "%s: the handler is not run", tt.name)
+	}
+}
+
+func TestNewCompression_InvalidEncoding(t *testing.T) {
+	_, err := NewCompression(CompressionConfig{Encodings: []string{"deflate"}})
+	assert.ErrorContains(t, err, "unsupported compression encoding")
+}
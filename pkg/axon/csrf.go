@@ -0,0 +1,381 @@
+package axon
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CSRFContextKey is the request context key under which the CSRF token for the response is stored
+const CSRFContextKey = "axon.csrf.token"
+
+// csrfSessionKey is the session value holding the synchronizer token secret
+const csrfSessionKey = "axon.csrf"
+
+// csrfPendingKey holds a CSRF failure deferred until the matched route is known
+const csrfPendingKey = "axon.csrf.pending"
+
+// csrfTokenSize is the size of a CSRF token secret in bytes
+const csrfTokenSize = 32
+
+// CSRFMode selects where the CSRF token secret is kept
+type CSRFMode int
+
+const (
+	// CSRFDoubleSubmit keeps the secret in a cookie; requests must echo it in a header or form field
+	CSRFDoubleSubmit CSRFMode = iota
+
+	// CSRFSynchronizer keeps the secret in the server-side session
+	CSRFSynchronizer
+)
+
+// CSRFConfig configures CSRF protection
+type CSRFConfig struct {
+	// Mode selects double-submit cookie (default) or synchronizer token
+	Mode CSRFMode
+
+	// HeaderName is the request header carrying the token; it is also set on
+	// responses to safe requests so clients can pick it up (default: "X-CSRF-Token")
+	HeaderName string
+
+	// FormField is the form field carrying the token when the header is absent (default: "csrf_token")
+	FormField string
+
+	// CookieName is the double-submit cookie name (default: "axon_csrf")
+	CookieName string
+
+	// CookiePath is the double-submit cookie path (default: "/")
+	CookiePath string
+
+	// CookieDomain is the double-submit cookie domain (default: host only)
+	CookieDomain string
+
+	// InsecureCookie drops the Secure flag, for local development over plain HTTP
+	InsecureCookie bool
+
+	// SameSite is the double-submit cookie SameSite mode (default: Lax)
+	SameSite SameSiteMode
+
+	// SignCookie signs the double-submit cookie with the adapter keyring so it
+	// cannot be planted by a sibling subdomain
+	SignCookie bool
+
+	// Sessions stores the token in synchronizer mode (required for CSRFSynchronizer)
+	Sessions *SessionManager
+
+	// TrustedOrigins lists additional origins (e.g. "https://app.example.com")
+	// allowed to send unsafe requests; the request's own host is always allowed
+	TrustedOrigins []string
+
+	// Routes is consulted for routes marked -NoCSRF (default: DefaultRouteRegistry).
+	// A failed check on a request whose path fits such a route is returned by
+	// the generated route's WithRouteMatch, unless it is the exempt route.
+	Routes RouteRegistry
+
+	// Skip exempts additional requests from verification
+	Skip func(c RequestContext) bool
+}
+
+// CSRF is a middleware that protects cookie-authenticated routes against
+// cross-site request forgery. Safe methods (GET, HEAD, OPTIONS, TRACE) are
+// never verified but are issued a token. Other methods must come from the
+// request's own host or a trusted origin (when Origin or Referer is sent)
+// and must carry a token matching the cookie or session secret.
+type CSRF struct {
+	config         CSRFConfig
+	trustedOrigins map[string]bool
+}
+
+// NewCSRF creates a CSRF middleware
+func NewCSRF(config CSRFConfig) (*CSRF, error) {
+	if config.Mode == CSRFSynchronizer && config.Sessions == nil {
+		return nil, fmt.Errorf("axon: CSRF synchronizer mode requires a SessionManager")
+	}
+	if config.HeaderName == "" {
+		config.HeaderName = "X-CSRF-Token"
+	}
+	if config.FormField == "" {
+		config.FormField = "csrf_token"
+	}
+	if config.CookieName == "" {
+		config.CookieName = "axon_csrf"
+	}
+	if config.CookiePath == "" {
+		config.CookiePath = "/"
+	}
+	if config.SameSite == SameSiteDefaultMode {
+		config.SameSite = SameSiteLaxMode
+	}
+	if config.Routes == nil {
+		config.Routes = DefaultRouteRegistry
+	}
+
+	trusted := make(map[string]bool, len(config.TrustedOrigins))
+	for _, origin := range config.TrustedOrigins {
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("axon: invalid CSRF trusted origin %q (expected scheme://host)", origin)
+		}
+		trusted[strings.ToLower(u.Scheme+"://"+u.Host)] = true
+	}
+
+	return &CSRF{config: config, trustedOrigins: trusted}, nil
+}
+
+// CSRFToken returns the token to embed in forms or send back in the CSRF header.
+// It is empty if the CSRF middleware did not run for the request.
+func CSRFToken(c RequestContext) string {
+	token, _ := c.Get(CSRFContextKey).(string)
+	return token
+}
+
+// Handle implements the middleware
+func (m *CSRF) Handle(next HandlerFunc) HandlerFunc {
+	return func(c RequestContext) error {
+		if isSafeMethod(c.Method()) {
+			secret, err := m.secret(c, true)
+			if err != nil {
+				return err
+			}
+			token := maskCSRFToken(secret)
+			c.Set(CSRFContextKey, token)
+			c.Response().SetHeader(m.config.HeaderName, token)
+			return next(c)
+		}
+
+		if m.config.Skip != nil && m.config.Skip(c) {
+			return next(c)
+		}
+
+		if err := m.verify(c); err != nil {
+			if !m.mayBeExempt(c) {
+				return err
+			}
+			// Which route handles the request is only known once the router
+			// has matched it, so the failure is left for WithRouteMatch to
+			// return unless that route is marked -NoCSRF
+			c.Set(csrfPendingKey, err)
+		}
+		return next(c)
+	}
+}
+
+// verify checks the origin and token of an unsafe request
+func (m *CSRF) verify(c RequestContext) error {
+	if err := m.checkOrigin(c); err != nil {
+		return err
+	}
+
+	secret, err := m.secret(c, false)
+	if err != nil {
+		return err
+	}
+	if secret == nil {
+		return NewHTTPError(http.StatusForbidden, "missing CSRF token")
+	}
+	if !validCSRFToken(m.requestToken(c), secret) {
+		return NewHTTPError(http.StatusForbidden, "invalid CSRF token")
+	}
+
+	c.Set(CSRFContextKey, maskCSRFToken(secret))
+	return nil
+}
+
+// mayBeExempt reports whether the request path fits a route marked -NoCSRF.
+// Templates ignore parameter types and router priority, so this only decides
+// whether a failure is deferred to the matched route; it never exempts a request.
+func (m *CSRF) mayBeExempt(c RequestContext) bool {
+	return m.fitsRoute(c, func(route RouteInfo) bool { return route.NoCSRF })
+}
+
+// fitsRoute reports whether the request path fits the template of a route
+// of the request method for which match returns true
+func (m *CSRF) fitsRoute(c RequestContext, match func(RouteInfo) bool) bool {
+	path := c.Path()
+	for _, route := range m.config.Routes.GetRoutesByMethod(c.Method()) {
+		if match(route) && NewAxonPath(route.Path).Match(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// pendingCSRFError returns the CSRF failure deferred for the request, unless
+// the matched route is exempt
+func pendingCSRFError(c RequestContext, match RouteMatch) error {
+	err, _ := c.Get(csrfPendingKey).(error)
+	if err == nil || match.NoCSRF {
+		return nil
+	}
+	return err
+}
+
+// checkOrigin rejects unsafe requests whose Origin (or Referer) is neither the
+// request's own host (as resolved through trusted proxies) nor a trusted
+// origin. Requests without either header are left to the token check.
+func (m *CSRF) checkOrigin(c RequestContext) error {
+	source := c.Request().Header("Origin")
+	if source == "" {
+		source = c.Request().Header("Referer")
+	}
+	if source == "" {
+		return nil
+	}
+
+	u, err := url.Parse(source)
+	if err != nil || u.Host == "" {
+		return NewHTTPError(http.StatusForbidden, "cross-origin request rejected")
+	}
+	if strings.EqualFold(u.Host, c.Host()) {
+		return nil
+	}
+	if m.trustedOrigins[strings.ToLower(u.Scheme+"://"+u.Host)] {
+		return nil
+	}
+	return NewHTTPError(http.StatusForbidden, "cross-origin request rejected")
+}
+
+// requestToken reads the submitted token from the header, then the form field.
+// The body of a request that may be for a route reading it as it streams in
+// (uploads, body streams) is left alone, as parsing the form here would drain
+// it before the handler and bypass the route's body limit; such requests must
+// send the token in the header.
+func (m *CSRF) requestToken(c RequestContext) string {
+	if token := c.Request().Header(m.config.HeaderName); token != "" {
+		return token
+	}
+	if m.fitsRoute(c, func(route RouteInfo) bool { return route.StreamsBody }) {
+		return ""
+	}
+	contentType := c.Request().ContentType()
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") ||
+		strings.HasPrefix(contentType, "multipart/form-data") {
+		return c.FormValue(m.config.FormField)
+	}
+	return ""
+}
+
+// secret returns the token secret for the request, creating and persisting a
+// new one when create is set. A nil secret means the request has none.
+func (m *CSRF) secret(c RequestContext, create bool) ([]byte, error) {
+	if m.config.Mode == CSRFSynchronizer {
+		return m.sessionSecret(c, create)
+	}
+	return m.cookieSecret(c, create)
+}
+
+// cookieSecret reads or issues the double-submit cookie
+func (m *CSRF) cookieSecret(c RequestContext, create bool) ([]byte, error) {
+	var value string
+	if m.config.SignCookie {
+		value, _ = c.Request().SignedCookie(m.config.CookieName)
+	} else if cookie, err := c.Request().Cookie(m.config.CookieName); err == nil {
+		value = cookie.Value
+	}
+	if secret := decodeCSRFSecret(value); secret != nil || !create {
+		return secret, nil
+	}
+
+	secret, err := newCSRFSecret()
+	if err != nil {
+		return nil, err
+	}
+	c.Response().SetCookie(AxonCookie{
+		Name:     m.config.CookieName,
+		Value:    base64.RawURLEncoding.EncodeToString(secret),
+		Path:     m.config.CookiePath,
+		Domain:   m.config.CookieDomain,
+		Secure:   !m.config.InsecureCookie,
+		HttpOnly: true,
+		SameSite: m.config.SameSite,
+		Signed:   m.config.SignCookie,
+	})
+	return secret, nil
+}
+
+// sessionSecret reads or issues the synchronizer token kept in the session.
+// A new token is saved immediately so the session cookie is written before
+// the handler produces the response.
+func (m *CSRF) sessionSecret(c RequestContext, create bool) ([]byte, error) {
+	sess, err := m.config.Sessions.Load(c)
+	if err != nil {
+		return nil, err
+	}
+	value, _ := sess.Get(csrfSessionKey)
+	if secret := decodeCSRFSecret(value); secret != nil || !create {
+		return secret, nil
+	}
+
+	secret, err := newCSRFSecret()
+	if err != nil {
+		return nil, err
+	}
+	sess.Set(csrfSessionKey, base64.RawURLEncoding.EncodeToString(secret))
+	if err := m.config.Sessions.Save(c, sess); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// isSafeMethod reports whether a method is exempt from CSRF verification
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// newCSRFSecret generates a random token secret
+func newCSRFSecret() ([]byte, error) {
+	secret := make([]byte, csrfTokenSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, NewHTTPError(http.StatusInternalServerError, "failed to generate CSRF token", err)
+	}
+	return secret, nil
+}
+
+// decodeCSRFSecret decodes a stored secret, returning nil if it is missing or malformed
+func decodeCSRFSecret(value string) []byte {
+	secret, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(secret) != csrfTokenSize {
+		return nil
+	}
+	return secret
+}
+
+// maskCSRFToken XORs the secret with a fresh one-time pad so the token differs
+// on every response, which keeps it from being recovered through compression
+// side channels (BREACH)
+func maskCSRFToken(secret []byte) string {
+	masked := make([]byte, 2*len(secret))
+	pad := masked[:len(secret)]
+	if _, err := rand.Read(pad); err != nil {
+		panic(fmt.Sprintf("axon: failed to generate CSRF mask: %v", err))
+	}
+	for i := range secret {
+		masked[len(secret)+i] = pad[i] ^ secret[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+// validCSRFToken compares a submitted token against the secret in constant time.
+// Both masked tokens and the raw secret (as base64url) are accepted.
+func validCSRFToken(token string, secret []byte) bool {
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return false
+	}
+	if len(decoded) == 2*len(secret) {
+		pad, masked := decoded[:len(secret)], decoded[len(secret):]
+		for i := range masked {
+			masked[i] ^= pad[i]
+		}
+		decoded = masked
+	}
+	return subtle.ConstantTimeCompare(decoded, secret) == 1
+}
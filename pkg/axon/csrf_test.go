@@ -0,0 +1,299 @@
+package axon
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runCSRF runs the middleware for c and reports whether the next handler was called
+func runCSRF(m *CSRF, c *valueRequestContext) (bool, error) {
+	called := false
+	err := m.Handle(func(RequestContext) error {
+		called = true
+		return nil
+	})(c)
+	return called, err
+}
+
+// runCSRFRoute runs the middleware for c followed by the generated route
+// matching it, and reports whether the handler was called
+func runCSRFRoute(m *CSRF, c *valueRequestContext, match RouteMatch) (bool, error) {
+	called := false
+	err := m.Handle(WithRouteMatch(match)(func(RequestContext) error {
+		called = true
+		return nil
+	}))(c)
+	return called, err
+}
+
+// csrfPost builds an unsafe request from the given host
+func csrfPost(host string) *valueRequestContext {
+	c := newValueRequestContext()
+	c.method = http.MethodPost
+	c.withHeader("Host", host)
+	return c
+}
+
+func assertCSRFStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	var httpErr *HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, status, httpErr.Code)
+}
+
+func TestCSRF_DoubleSubmit(t *testing.T) {
+	m, err := NewCSRF(CSRFConfig{Routes: NewInMemoryRouteRegistry()})
+	require.NoError(t, err)
+
+	// Safe requests are issued a cookie and a masked token
+	get := newValueRequestContext()
+	called, err := runCSRF(m, get)
+	require.NoError(t, err)
+	assert.True(t, called)
+
+	cookie, ok := get.response.cookie("axon_csrf")
+	require.True(t, ok)
+	assert.True(t, cookie.HttpOnly)
+	assert.True(t, cookie.Secure)
+	assert.Equal(t, SameSiteLaxMode, cookie.SameSite)
+	token := CSRFToken(get)
+	require.NotEmpty(t, token)
+	assert.Equal(t, token, get.response.Header("X-CSRF-Token"))
+
+	// Tokens are masked differently on every response but stay valid
+	again := newValueRequestContext().withCookie("axon_csrf", cookie.Value)
+	_, err = runCSRF(m, again)
+	require.NoError(t, err)
+	_, reissued := again.response.cookie("axon_csrf")
+	assert.False(t, reissued, "an existing cookie is reused")
+	assert.NotEqual(t, token, CSRFToken(again))
+
+	tests := []struct {
+		name       string
+		setup      func(c *valueRequestContext)
+		wantStatus int
+	}{
+		{
+			name: "header token",
+			setup: func(c *valueRequestContext) {
+				c.withCookie("axon_csrf", cookie.Value).withHeader("X-CSRF-Token", token)
+			},
+		},
+		{
+			name: "second masked token",
+			setup: func(c *valueRequestContext) {
+				c.withCookie("axon_csrf", cookie.Value).withHeader("X-CSRF-Token", CSRFToken(again))
+			},
+		},
+		{
+			name: "raw secret",
+			setup: func(c *valueRequestContext) {
+				c.withCookie("axon_csrf", cookie.Value).withHeader("X-CSRF-Token", cookie.Value)
+			},
+		},
+		{
+			name: "form field",
+			setup: func(c *valueRequestContext) {
+				c.withCookie("axon_csrf", cookie.Value).withHeader("Content-Type", "application/x-www-form-urlencoded")
+				c.form["csrf_token"] = token
+			},
+		},
+		{
+			name: "form field ignored for JSON bodies",
+			setup: func(c *valueRequestContext) {
+				c.withCookie("axon_csrf", cookie.Value).withHeader("Content-Type", "application/json")
+				c.form["csrf_token"] = token
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "missing token",
+			setup: func(c *valueRequestContext) {
+				c.withCookie("axon_csrf", cookie.Value)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "missing cookie",
+			setup: func(c *valueRequestContext) {
+				c.withHeader("X-CSRF-Token", token)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "token for another cookie",
+			setup: func(c *valueRequestContext) {
+				other := base64.RawURLEncoding.EncodeToString(make([]byte, csrfTokenSize))
+				c.withCookie("axon_csrf", other).withHeader("X-CSRF-Token", token)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := csrfPost("example.com")
+			tt.setup(c)
+
+			called, err := runCSRF(m, c)
+			if tt.wantStatus == 0 {
+				require.NoError(t, err)
+				assert.True(t, called)
+				return
+			}
+			assertCSRFStatus(t, err, tt.wantStatus)
+			assert.False(t, called, "handler must not run when CSRF verification fails")
+		})
+	}
+}
+
+func TestCSRF_Origin(t *testing.T) {
+	m, err := NewCSRF(CSRFConfig{
+		Routes:         NewInMemoryRouteRegistry(),
+		TrustedOrigins: []string{"https://app.example.com"},
+	})
+	require.NoError(t, err)
+
+	get := newValueRequestContext()
+	_, err = runCSRF(m, get)
+	require.NoError(t, err)
+	cookie, _ := get.response.cookie("axon_csrf")
+
+	tests := []struct {
+		name    string
+		header  string
+		value   string
+		allowed bool
+	}{
+		{name: "same host origin", header: "Origin", value: "https://api.example.com", allowed: true},
+		{name: "trusted origin", header: "Origin", value: "https://app.example.com", allowed: true},
+		{name: "trusted host over another scheme", header: "Origin", value: "http://app.example.com"},
+		{name: "cross origin", header: "Origin", value: "https://evil.example"},
+		{name: "opaque origin", header: "Origin", value: "null"},
+		{name: "same host referer", header: "Referer", value: "https://api.example.com/settings", allowed: true},
+		{name: "cross referer", header: "Referer", value: "https://evil.example/page"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := csrfPost("api.example.com")
+			c.withCookie("axon_csrf", cookie.Value).withHeader("X-CSRF-Token", CSRFToken(get))
+			c.withHeader(tt.header, tt.value)
+
+			_, err := runCSRF(m, c)
+			if tt.allowed {
+				assert.NoError(t, err)
+				return
+			}
+			assertCSRFStatus(t, err, http.StatusForbidden)
+		})
+	}
+
+	_, err = NewCSRF(CSRFConfig{TrustedOrigins: []string{"app.example.com"}})
+	assert.Error(t, err, "trusted origins need a scheme")
+}
+
+func TestCSRF_Exemptions(t *testing.T) {
+	routes := NewInMemoryRouteRegistry()
+	routes.RegisterRoute(RouteInfo{Method: "POST", Path: "/webhooks/{provider}", NoCSRF: true})
+	routes.RegisterRoute(RouteInfo{Method: "POST", Path: "/settings"})
+
+	m, err := NewCSRF(CSRFConfig{
+		Routes: routes,
+		Skip: func(c RequestContext) bool {
+			return c.Request().Header("Authorization") != ""
+		},
+	})
+	require.NoError(t, err)
+
+	webhook := csrfPost("example.com")
+	webhook.path = "/webhooks/stripe"
+	called, err := runCSRFRoute(m, webhook, RouteMatch{Method: "POST", Path: "/webhooks/{provider}", NoCSRF: true})
+	require.NoError(t, err)
+	assert.True(t, called)
+
+	webhookPut := csrfPost("example.com")
+	webhookPut.method = http.MethodPut
+	webhookPut.path = "/webhooks/stripe"
+	_, err = runCSRF(m, webhookPut)
+	assertCSRFStatus(t, err, http.StatusForbidden)
+
+	settings := csrfPost("example.com")
+	settings.path = "/settings"
+	_, err = runCSRF(m, settings)
+	assertCSRFStatus(t, err, http.StatusForbidden)
+
+	bearer := csrfPost("example.com").withHeader("Authorization", "Bearer token")
+	bearer.path = "/settings"
+	called, err = runCSRF(m, bearer)
+	require.NoError(t, err)
+	assert.True(t, called)
+}
+
+func TestCSRF_ExemptionFollowsMatchedRoute(t *testing.T) {
+	routes := NewInMemoryRouteRegistry()
+	routes.RegisterRoute(RouteInfo{Method: "POST", Path: "/webhooks/{id:int}", NoCSRF: true})
+	routes.RegisterRoute(RouteInfo{Method: "POST", Path: "/webhooks/settings"})
+
+	m, err := NewCSRF(CSRFConfig{Routes: routes})
+	require.NoError(t, err)
+
+	// The static sibling fits the exempt template but is matched by the router itself
+	settings := csrfPost("example.com")
+	settings.path = "/webhooks/settings"
+	called, err := runCSRFRoute(m, settings, RouteMatch{Method: "POST", Path: "/webhooks/settings"})
+	assertCSRFStatus(t, err, http.StatusForbidden)
+	assert.False(t, called, "the protected route must not run")
+
+	webhook := csrfPost("example.com")
+	webhook.path = "/webhooks/42"
+	called, err = runCSRFRoute(m, webhook, RouteMatch{Method: "POST", Path: "/webhooks/{id:int}", NoCSRF: true})
+	require.NoError(t, err)
+	assert.True(t, called)
+}
+
+func TestCSRF_Synchronizer(t *testing.T) {
+	_, err := NewCSRF(CSRFConfig{Mode: CSRFSynchronizer})
+	assert.Error(t, err, "synchronizer mode requires sessions")
+
+	sessions, store, _ := newTestSessionManager(SessionConfig{})
+	m, err := NewCSRF(CSRFConfig{Mode: CSRFSynchronizer, Sessions: sessions, Routes: NewInMemoryRouteRegistry()})
+	require.NoError(t, err)
+
+	// The token is stored in the session, which is saved before the handler runs
+	get := newValueRequestContext()
+	_, err = runCSRF(m, get)
+	require.NoError(t, err)
+	sessionCookie, ok := get.response.cookie("axon_session")
+	require.True(t, ok)
+	_, ok = get.response.cookie("axon_csrf")
+	assert.False(t, ok, "synchronizer mode does not set a CSRF cookie")
+	assert.Equal(t, 1, store.Len())
+	token := CSRFToken(get)
+
+	// The handler sees the same session instance the middleware loaded
+	post := csrfPost("example.com").withCookie("axon_session", sessionCookie.Value).withHeader("X-CSRF-Token", token)
+	var handlerSession Session
+	err = m.Handle(func(c RequestContext) error {
+		handlerSession, err = sessions.Load(c)
+		return err
+	})(post)
+	require.NoError(t, err)
+	assert.Same(t, post.Get(SessionContextKey), handlerSession)
+
+	// A token from another session is rejected
+	otherGet := newValueRequestContext()
+	_, err = runCSRF(m, otherGet)
+	require.NoError(t, err)
+	forged := csrfPost("example.com").withCookie("axon_session", sessionCookie.Value).withHeader("X-CSRF-Token", CSRFToken(otherGet))
+	_, err = runCSRF(m, forged)
+	assertCSRFStatus(t, err, http.StatusForbidden)
+
+	// Without a session there is nothing to compare against
+	_, err = runCSRF(m, csrfPost("example.com").withHeader("X-CSRF-Token", token))
+	assertCSRFStatus(t, err, http.StatusForbidden)
+}
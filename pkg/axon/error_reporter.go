@@ -0,0 +1,191 @@
+package axon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errorReportedContextKey marks a request whose error has already been reported
+const errorReportedContextKey = "axon.error_reported"
+
+// ErrorReport describes a panic or 5xx error raised while handling a request
+type ErrorReport struct {
+	// Time is when the error was reported
+	Time time.Time
+
+	// Err is the error returned by the handler or middleware
+	Err error
+
+	// Status is the response status for Err
+	Status int
+
+	// Panic is set when the error was caused by a recovered panic
+	Panic *PanicError
+
+	// Method and Path identify the request
+	Method string
+	Path   string
+
+	// Route is the matched route; it is zero if no generated route matched
+	Route RouteMatch
+
+	// RequestID is the ID set by the RequestID middleware, if installed
+	RequestID string
+
+	// Principal is the subject of the authenticated principal, if any
+	Principal string
+}
+
+// ErrorReporter receives panics and 5xx errors. Adapters configured with
+// adapters.WithErrorReporter call Report once per failed request, before the
+// error response is written. The RequestContext must not be retained after
+// Report returns.
+type ErrorReporter interface {
+	Report(c RequestContext, report ErrorReport)
+}
+
+// ErrorReporterFunc adapts a function to the ErrorReporter interface
+type ErrorReporterFunc func(c RequestContext, report ErrorReport)
+
+// Report calls f(c, report)
+func (f ErrorReporterFunc) Report(c RequestContext, report ErrorReport) {
+	f(c, report)
+}
+
+// ReportError sends err to reporter if it is a panic or a 5xx error and has not
+// been reported for this request yet. Adapters call it wherever a handler or
+// middleware error is turned into a response.
+func ReportError(reporter ErrorReporter, c RequestContext, err error) {
+	if reporter == nil || err == nil || c.Get(errorReportedContextKey) != nil {
+		return
+	}
+
+	var panicErr *PanicError
+	isPanic := errors.As(err, &panicErr)
+	status := ErrorStatus(err)
+	if !isPanic && status < 500 {
+		return
+	}
+	c.Set(errorReportedContextKey, true)
+
+	// Request strings are cloned because some adapters (Fiber) reuse their
+	// memory once the request completes, and reporters may keep the report
+	report := ErrorReport{
+		Time:      time.Now(),
+		Err:       err,
+		Status:    status,
+		Panic:     panicErr,
+		Method:    strings.Clone(c.Method()),
+		Path:      strings.Clone(c.Path()),
+		RequestID: strings.Clone(GetRequestID(c)),
+	}
+	report.Route, _ = GetRouteMatch(c)
+	if principal, ok := GetPrincipal(c); ok {
+		report.Principal = principal.GetSubject()
+	}
+	reporter.Report(c, report)
+}
+
+// MemoryErrorReporter keeps reports in memory, for tests and debugging
+type MemoryErrorReporter struct {
+	mu      sync.Mutex
+	reports []ErrorReport
+}
+
+// NewMemoryErrorReporter creates an empty in-memory reporter
+func NewMemoryErrorReporter() *MemoryErrorReporter {
+	return &MemoryErrorReporter{}
+}
+
+// Report records the report
+func (r *MemoryErrorReporter) Report(_ RequestContext, report ErrorReport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports = append(r.reports, report)
+}
+
+// Reports returns the recorded reports, oldest first
+func (r *MemoryErrorReporter) Reports() []ErrorReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ErrorReport(nil), r.reports...)
+}
+
+// Reset discards the recorded reports
+func (r *MemoryErrorReporter) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports = nil
+}
+
+// FileErrorReporter appends reports to a file as JSON lines
+type FileErrorReporter struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// fileErrorReport is the JSON form of an ErrorReport
+type fileErrorReport struct {
+	Time       time.Time `json:"time"`
+	Error      string    `json:"error"`
+	Status     int       `json:"status"`
+	Panic      bool      `json:"panic,omitempty"`
+	Stack      string    `json:"stack,omitempty"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Route      string    `json:"route,omitempty"`
+	Controller string    `json:"controller,omitempty"`
+	Handler    string    `json:"handler,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+	Principal  string    `json:"principal,omitempty"`
+}
+
+// NewFileErrorReporter opens (or creates) path for appending reports
+func NewFileErrorReporter(path string) (*FileErrorReporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("axon: failed to open error report file: %w", err)
+	}
+	return &FileErrorReporter{file: file}, nil
+}
+
+// Report appends the report as one JSON line. Write failures are ignored so
+// that reporting never changes the response.
+func (r *FileErrorReporter) Report(_ RequestContext, report ErrorReport) {
+	entry := fileErrorReport{
+		Time:       report.Time,
+		Error:      report.Err.Error(),
+		Status:     report.Status,
+		Panic:      report.Panic != nil,
+		Method:     report.Method,
+		Path:       report.Path,
+		Route:      report.Route.Path,
+		Controller: report.Route.Controller,
+		Handler:    report.Route.Handler,
+		RequestID:  report.RequestID,
+		Principal:  report.Principal,
+	}
+	if report.Panic != nil {
+		entry.Stack = string(report.Panic.Stack)
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, _ = r.file.Write(append(line, '\n'))
+}
+
+// Close closes the report file
+func (r *FileErrorReporter) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
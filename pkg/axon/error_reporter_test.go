@@ -0,0 +1,109 @@
+package axon
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportError(t *testing.T) {
+	reporter := NewMemoryErrorReporter()
+
+	c := newValueRequestContext()
+	c.method, c.path = http.MethodPost, "/orders/7"
+	c.Set(RequestIDContextKey, "req-1")
+	c.Set(RouteContextKey, RouteMatch{Method: "POST", Path: "/orders/{id:int}", Controller: "OrderController", Handler: "Update"})
+	SetPrincipal(c, &BasicPrincipal{Subject: "alice"})
+
+	err := Recover(func(RequestContext) error { panic("boom") })(c)
+	ReportError(reporter, c, err)
+
+	reports := reporter.Reports()
+	require.Len(t, reports, 1)
+	report := reports[0]
+	assert.Equal(t, err, report.Err)
+	assert.Equal(t, http.StatusInternalServerError, report.Status)
+	require.NotNil(t, report.Panic)
+	assert.Equal(t, "boom", report.Panic.Value)
+	assert.Equal(t, "POST", report.Method)
+	assert.Equal(t, "/orders/7", report.Path)
+	assert.Equal(t, "OrderController", report.Route.Controller)
+	assert.Equal(t, "/orders/{id:int}", report.Route.Path)
+	assert.Equal(t, "req-1", report.RequestID)
+	assert.Equal(t, "alice", report.Principal)
+	assert.False(t, report.Time.IsZero())
+}
+
+func TestReportError_Filtering(t *testing.T) {
+	reporter := NewMemoryErrorReporter()
+
+	ReportError(reporter, newValueRequestContext(), NewHTTPError(http.StatusNotFound, "missing"))
+	ReportError(reporter, newValueRequestContext(), nil)
+	assert.Empty(t, reporter.Reports(), "4xx errors are not reported")
+
+	ReportError(reporter, newValueRequestContext(), NewHTTPError(http.StatusServiceUnavailable, "down"))
+	ReportError(reporter, newValueRequestContext(), errors.New("plain"))
+	require.Len(t, reporter.Reports(), 2)
+	assert.Equal(t, http.StatusServiceUnavailable, reporter.Reports()[0].Status)
+	assert.Nil(t, reporter.Reports()[0].Panic)
+	assert.Equal(t, http.StatusInternalServerError, reporter.Reports()[1].Status)
+
+	// An error is reported once per request, however many layers return it
+	c := newValueRequestContext()
+	err := errors.New("database down")
+	ReportError(reporter, c, err)
+	ReportError(reporter, c, err)
+	assert.Len(t, reporter.Reports(), 3)
+
+	reporter.Reset()
+	assert.Empty(t, reporter.Reports())
+
+	// A nil reporter is ignored
+	ReportError(nil, newValueRequestContext(), err)
+}
+
+func TestErrorReporterFunc(t *testing.T) {
+	var got ErrorReport
+	reporter := ErrorReporterFunc(func(_ RequestContext, report ErrorReport) { got = report })
+
+	ReportError(reporter, newValueRequestContext(), errors.New("failed"))
+	assert.EqualError(t, got.Err, "failed")
+}
+
+func TestFileErrorReporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "errors.log")
+	reporter, err := NewFileErrorReporter(path)
+	require.NoError(t, err)
+
+	c := newValueRequestContext()
+	c.Set(RouteContextKey, RouteMatch{Method: "GET", Path: "/test", Controller: "TestController", Handler: "Get"})
+	ReportError(reporter, c, Recover(func(RequestContext) error { panic("boom") })(c))
+	ReportError(reporter, newValueRequestContext(), NewHTTPError(http.StatusBadGateway, "upstream failed"))
+	require.NoError(t, reporter.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	var first, second map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+
+	assert.Equal(t, "panic: boom", first["error"])
+	assert.Equal(t, true, first["panic"])
+	assert.Contains(t, first["stack"], "TestFileErrorReporter")
+	assert.Equal(t, "TestController", first["controller"])
+	assert.EqualValues(t, 500, first["status"])
+
+	assert.Equal(t, "upstream failed", second["error"])
+	assert.EqualValues(t, 502, second["status"])
+	assert.NotContains(t, second, "stack")
+}
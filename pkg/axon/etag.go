@@ -0,0 +1,162 @@
+package axon
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ETagger is implemented by returned values that know their own entity tag,
+// typically derived from a version number or update time. ETag returns either
+// a complete tag built with StrongETag or WeakETag, or a bare value, which is
+// used as a strong tag.
+type ETagger interface {
+	ETag() string
+}
+
+// StrongETag formats value as a strong entity tag ("value"). Strong tags
+// promise byte-identical representations.
+func StrongETag(value string) string {
+	return `"` + value + `"`
+}
+
+// WeakETag formats value as a weak entity tag (W/"value"). Weak tags promise
+// semantically equivalent representations and never satisfy If-Match.
+func WeakETag(value string) string {
+	return `W/"` + value + `"`
+}
+
+// SetETagFrom sets the ETag header from data if it implements ETagger.
+// Generated wrappers call it for routes annotated with -ETag.
+func SetETagFrom(c RequestContext, data interface{}) {
+	if tagger, ok := data.(ETagger); ok {
+		if etag := tagger.ETag(); etag != "" {
+			c.Response().SetHeader("ETag", formatETag(etag))
+		}
+	}
+}
+
+// ETagPolicy is the ETag configuration of one route. Generated route
+// registration builds it for -ETag.
+type ETagPolicy struct {
+	// Current renders the current representation of the resource, to check
+	// If-Match on PUT, PATCH and DELETE. Generated code passes the GET
+	// handler of the same path; without it If-Match is not checked.
+	Current HandlerFunc
+}
+
+// WithETag returns a middleware that adds entity tags and conditional
+// requests to a route.
+//
+// On GET and HEAD, a 200 response gets the ETag set by the handler (or by an
+// ETagger result), or else a strong tag hashed from the encoded body. If the
+// request's If-None-Match matches it, the response becomes 304 Not Modified.
+//
+// On PUT, PATCH and DELETE with If-Match, the tag of the current
+// representation is computed with policy.Current first, and the request fails
+// with 412 Precondition Failed unless it matches, so clients cannot overwrite
+// changes they have not seen.
+func WithETag(policy ETagPolicy) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c RequestContext) error {
+			switch c.Method() {
+			case http.MethodGet, http.MethodHead:
+				return serveWithETag(c, next)
+			case http.MethodPut, http.MethodPatch, http.MethodDelete:
+				if ifMatch := c.Request().Header("If-Match"); ifMatch != "" && policy.Current != nil {
+					if err := checkIfMatch(c, ifMatch, policy.Current); err != nil {
+						return err
+					}
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// serveWithETag tags a successful response and answers If-None-Match
+func serveWithETag(c RequestContext, next HandlerFunc) error {
+	recorder := NewResponseRecorder(c.Response())
+	if err := next(WithResponse(c, recorder)); err != nil {
+		return err
+	}
+	if !recorder.Buffered() || recorder.Status() != http.StatusOK {
+		return recorder.Flush()
+	}
+
+	etag := recorder.RecordedHeader().Get("ETag")
+	if etag == "" {
+		etag = hashETag(recorder.Body())
+		recorder.SetHeader("ETag", etag)
+	}
+	if ifNoneMatch := c.Request().Header("If-None-Match"); ifNoneMatch != "" && ETagMatches(ifNoneMatch, etag, false) {
+		recorder.SetStatus(http.StatusNotModified)
+		recorder.SetBody(nil)
+	}
+	return recorder.Flush()
+}
+
+// checkIfMatch renders the current representation and compares its tag with If-Match
+func checkIfMatch(c RequestContext, ifMatch string, current HandlerFunc) error {
+	recorder := NewResponseRecorder(c.Response())
+	err := current(WithResponse(c, recorder))
+	if err != nil && ErrorStatus(err) != http.StatusNotFound {
+		return err
+	}
+
+	// A missing resource matches no tag, not even *
+	if err == nil && recorder.Buffered() && recorder.Status() == http.StatusOK {
+		etag := recorder.RecordedHeader().Get("ETag")
+		if etag == "" {
+			etag = hashETag(recorder.Body())
+		}
+		if ETagMatches(ifMatch, etag, true) {
+			return nil
+		}
+	}
+	return NewHTTPError(http.StatusPreconditionFailed, "Precondition Failed", errors.New("If-Match does not match the current ETag"))
+}
+
+// ETagMatches reports whether etag is listed in an If-Match or If-None-Match
+// header value. Strong comparison (for If-Match) requires both tags to be
+// strong; weak comparison (for If-None-Match) ignores the W/ prefix. "*"
+// matches any tag.
+func ETagMatches(header, etag string, strong bool) bool {
+	if etag == "" {
+		return false
+	}
+	if strong && strings.HasPrefix(etag, "W/") {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strong && strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+		if strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// formatETag quotes a bare value as a strong tag and keeps complete tags as they are
+func formatETag(etag string) string {
+	if strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+	return StrongETag(etag)
+}
+
+// hashETag builds a strong tag from a response body
+func hashETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return StrongETag(base64.RawURLEncoding.EncodeToString(sum[:16]))
+}
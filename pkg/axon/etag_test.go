@@ -0,0 +1,167 @@
+package axon
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// versioned is a result that provides its own entity tag
+type versioned struct {
+	Version string
+	Weak    bool
+}
+
+func (v versioned) ETag() string {
+	if v.Weak {
+		return WeakETag(v.Version)
+	}
+	return v.Version
+}
+
+func TestWithETag_HashedBody(t *testing.T) {
+	handler := WithETag(ETagPolicy{})(func(c RequestContext) error {
+		return c.Response().String(http.StatusOK, "hello")
+	})
+
+	c := newValueRequestContext()
+	require.NoError(t, handler(c))
+	etag := c.response.headers.Get("ETag")
+	assert.Regexp(t, `^"[A-Za-z0-9_-]{22}"$`, etag)
+	assert.Equal(t, "hello", string(c.response.body))
+
+	// The same body always gets the same tag
+	again := newValueRequestContext()
+	require.NoError(t, handler(again))
+	assert.Equal(t, etag, again.response.headers.Get("ETag"))
+
+	notModified := newValueRequestContext().withHeader("If-None-Match", `"other", `+etag)
+	require.NoError(t, handler(notModified))
+	assert.Equal(t, http.StatusNotModified, notModified.response.status)
+	assert.Empty(t, notModified.response.body)
+	assert.Equal(t, etag, notModified.response.headers.Get("ETag"))
+
+	changed := newValueRequestContext().withHeader("If-None-Match", `"other"`)
+	require.NoError(t, handler(changed))
+	assert.Equal(t, http.StatusOK, changed.response.status)
+}
+
+func TestWithETag_ETagger(t *testing.T) {
+	tests := []struct {
+		name string
+		data versioned
+		want string
+	}{
+		{"bare value is strong", versioned{Version: "v7"}, `"v7"`},
+		{"weak tag", versioned{Version: "v7", Weak: true}, `W/"v7"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := WithETag(ETagPolicy{})(func(c RequestContext) error {
+				SetETagFrom(c, tt.data)
+				return c.Response().JSON(http.StatusOK, tt.data)
+			})
+
+			c := newValueRequestContext()
+			require.NoError(t, handler(c))
+			assert.Equal(t, tt.want, c.response.headers.Get("ETag"))
+
+			// If-None-Match uses weak comparison, so both forms match
+			for _, header := range []string{`"v7"`, `W/"v7"`, "*"} {
+				c := newValueRequestContext().withHeader("If-None-Match", header)
+				require.NoError(t, handler(c))
+				assert.Equal(t, http.StatusNotModified, c.response.status, header)
+			}
+		})
+	}
+}
+
+func TestWithETag_OnlySuccessfulResponses(t *testing.T) {
+	handler := WithETag(ETagPolicy{})(func(c RequestContext) error {
+		return c.Response().String(http.StatusAccepted, "queued")
+	})
+	c := newValueRequestContext().withHeader("If-None-Match", "*")
+	require.NoError(t, handler(c))
+	assert.Equal(t, http.StatusAccepted, c.response.status)
+	assert.Empty(t, c.response.headers.Get("ETag"))
+
+	failing := WithETag(ETagPolicy{})(func(c RequestContext) error {
+		return NewHTTPError(http.StatusNotFound, "missing")
+	})
+	assert.Equal(t, http.StatusNotFound, ErrorStatus(failing(newValueRequestContext())))
+}
+
+func TestWithETag_IfMatch(t *testing.T) {
+	version := "v1"
+	found := true
+	current := func(c RequestContext) error {
+		if !found {
+			return NewHTTPError(http.StatusNotFound, "missing")
+		}
+		SetETagFrom(c, versioned{Version: version})
+		return c.Response().JSON(http.StatusOK, version)
+	}
+
+	updates := 0
+	handler := WithETag(ETagPolicy{Current: current})(func(c RequestContext) error {
+		updates++
+		version = "v2"
+		return c.Response().String(http.StatusOK, "updated")
+	})
+
+	put := func(ifMatch string) error {
+		c := newValueRequestContext()
+		c.method = http.MethodPut
+		if ifMatch != "" {
+			c.withHeader("If-Match", ifMatch)
+		}
+		return handler(c)
+	}
+
+	require.NoError(t, put(`"v1"`))
+	assert.Equal(t, 1, updates)
+
+	// The client's copy is now stale
+	err := put(`"v1"`)
+	assert.Equal(t, http.StatusPreconditionFailed, ErrorStatus(err))
+	assert.Equal(t, 1, updates)
+
+	// Weak tags never satisfy If-Match
+	assert.Equal(t, http.StatusPreconditionFailed, ErrorStatus(put(`W/"v2"`)))
+
+	require.NoError(t, put(`"v2"`))
+	require.NoError(t, put("*"))
+	require.NoError(t, put(""), "requests without If-Match are not checked")
+	assert.Equal(t, 4, updates)
+
+	// A missing resource matches nothing, not even *
+	found = false
+	assert.Equal(t, http.StatusPreconditionFailed, ErrorStatus(put("*")))
+	assert.Equal(t, 4, updates)
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		etag   string
+		strong bool
+		want   bool
+	}{
+		{`"a"`, `"a"`, false, true},
+		{`"a"`, `"a"`, true, true},
+		{`W/"a"`, `"a"`, false, true},
+		{`W/"a"`, `"a"`, true, false},
+		{`"a"`, `W/"a"`, true, false},
+		{`"x", "y" , "a"`, `"a"`, true, true},
+		{`"b"`, `"a"`, false, false},
+		{"*", `"a"`, true, true},
+		{"*", "", false, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ETagMatches(tt.header, tt.etag, tt.strong), "%s vs %s (strong=%v)", tt.header, tt.etag, tt.strong)
+	}
+}
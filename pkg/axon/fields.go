@@ -0,0 +1,191 @@
+package axon
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// FieldsPolicy configures the sparse fieldsets of a route. Generated routes
+// annotated with -Fields build it from the response type.
+type FieldsPolicy struct {
+	// Paths are the dotted JSON paths clients can select, such as
+	// address.city. A path ending in .* allows any path below it. Nil allows
+	// any path, for responses whose type is not known.
+	Paths []string
+
+	// Page is set for routes returning an axon.Page: fields then select
+	// members of its items, and its page metadata is kept
+	Page bool
+}
+
+// Allows reports whether clients can select path
+func (p FieldsPolicy) Allows(path string) bool {
+	if p.Paths == nil || slices.Contains(p.Paths, path) {
+		return true
+	}
+	for _, allowed := range p.Paths {
+		if prefix, open := strings.CutSuffix(allowed, "*"); open && strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithFields projects successful JSON responses down to the members listed
+// in the fields query parameter, such as ?fields=id,name,address.city.
+// Selecting a member keeps all of it, and arrays are projected item by
+// item. Members are kept in the order the handler wrote them. Requests
+// without fields get the whole response; fields the policy does not allow
+// are rejected with 400. Streamed responses are not projected.
+func WithFields(policy FieldsPolicy) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c RequestContext) error {
+			fields := c.QueryParam("fields")
+			if fields == "" {
+				return next(c)
+			}
+			tree, err := parseFields(fields, policy)
+			if err != nil {
+				return err
+			}
+
+			recorder := NewResponseRecorder(c.Response())
+			if err := next(WithResponse(c, recorder)); err != nil {
+				return err
+			}
+			if !recorder.Buffered() || recorder.Status() < 200 || recorder.Status() >= 300 || !isJSON(recorder.RecordedHeader().Get("Content-Type")) {
+				return recorder.Flush()
+			}
+
+			var projected bytes.Buffer
+			if err := tree.project(recorder.Body(), &projected); err != nil {
+				return fmt.Errorf("projecting response fields: %w", err)
+			}
+			recorder.SetBody(projected.Bytes())
+			return recorder.Flush()
+		}
+	}
+}
+
+// fieldTree holds the selected members of a JSON value by name; a member
+// with no children is kept whole
+type fieldTree map[string]fieldTree
+
+// parseFields parses the fields query parameter into the members to keep
+func parseFields(fields string, policy FieldsPolicy) (fieldTree, error) {
+	tree := fieldTree{}
+	for _, path := range strings.Split(fields, ",") {
+		path = strings.TrimSpace(path)
+		names := strings.Split(path, ".")
+		if slices.Contains(names, "") {
+			return nil, NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid field %q", path))
+		}
+		if !policy.Allows(path) {
+			return nil, NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unknown field %q", path))
+		}
+
+		node := tree
+		for i, name := range names {
+			child, seen := node[name]
+			if seen && len(child) == 0 {
+				break // an ancestor is already kept whole
+			}
+			if i == len(names)-1 {
+				node[name] = fieldTree{}
+				break
+			}
+			if child == nil {
+				child = fieldTree{}
+				node[name] = child
+			}
+			node = child
+		}
+	}
+
+	if policy.Page {
+		return fieldTree{"items": tree, "page": {}}, nil
+	}
+	return tree, nil
+}
+
+// project writes the selected members of the JSON value data to out. Objects
+// keep the selected members, arrays are projected item by item, and other
+// values are kept as is.
+func (t fieldTree) project(data []byte, out *bytes.Buffer) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+
+	switch data[0] {
+	case '{':
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		if _, err := decoder.Token(); err != nil {
+			return err
+		}
+		out.WriteByte('{')
+		first := true
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			var value json.RawMessage
+			if err := decoder.Decode(&value); err != nil {
+				return err
+			}
+			name, _ := token.(string)
+			child, ok := t[name]
+			if !ok {
+				continue
+			}
+
+			if !first {
+				out.WriteByte(',')
+			}
+			first = false
+			key, _ := json.Marshal(name)
+			out.Write(key)
+			out.WriteByte(':')
+			if len(child) == 0 {
+				out.Write(value)
+			} else if err := child.project(value, out); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		out.WriteByte('[')
+		for i, item := range items {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := t.project(item, out); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	default:
+		out.Write(data)
+	}
+	return nil
+}
+
+// isJSON reports whether a content type is JSON, such as application/json
+// or application/problem+json
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
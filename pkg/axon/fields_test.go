@@ -0,0 +1,178 @@
+package axon
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fieldsAddress struct {
+	City    string `json:"city"`
+	Country string `json:"country"`
+}
+
+type fieldsUser struct {
+	ID      int           `json:"id"`
+	Name    string        `json:"name"`
+	Email   string        `json:"email"`
+	Address fieldsAddress `json:"address"`
+	Tags    []string      `json:"tags"`
+}
+
+var testFieldsPolicy = FieldsPolicy{
+	Paths: []string{"id", "name", "email", "address", "address.city", "address.country", "tags", "meta.*"},
+}
+
+// serveFields runs handler behind WithFields with the given fields query
+func serveFields(t *testing.T, policy FieldsPolicy, fields string, handler HandlerFunc) (*recordingResponse, error) {
+	t.Helper()
+	c := newValueRequestContext()
+	if fields != "" {
+		c.query["fields"] = fields
+	}
+	err := WithFields(policy)(handler)(c)
+	return c.response, err
+}
+
+func TestWithFields_ProjectsObject(t *testing.T) {
+	user := fieldsUser{ID: 1, Name: "Ada", Email: "ada@example.com", Address: fieldsAddress{City: "London", Country: "UK"}, Tags: []string{"admin"}}
+	response, err := serveFields(t, testFieldsPolicy, "name, address.city,id", func(c RequestContext) error {
+		return c.Response().JSON(http.StatusOK, user)
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, response.status)
+	assert.Equal(t, "application/json", response.headers.Get("Content-Type"))
+	assert.Equal(t, `{"id":1,"name":"Ada","address":{"city":"London"}}`, string(response.body), "members keep the order the handler wrote them in")
+}
+
+func TestWithFields_ProjectsArrayItems(t *testing.T) {
+	users := []fieldsUser{{ID: 1, Name: "Ada"}, {ID: 2, Name: "Grace"}}
+	response, err := serveFields(t, testFieldsPolicy, "id", func(c RequestContext) error {
+		return c.Response().JSON(http.StatusOK, users)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `[{"id":1},{"id":2}]`, string(response.body))
+}
+
+func TestWithFields_WholeAncestorWins(t *testing.T) {
+	user := fieldsUser{Address: fieldsAddress{City: "London", Country: "UK"}}
+	for _, fields := range []string{"address,address.city", "address.city,address"} {
+		response, err := serveFields(t, testFieldsPolicy, fields, func(c RequestContext) error {
+			return c.Response().JSON(http.StatusOK, user)
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `{"address":{"city":"London","country":"UK"}}`, string(response.body), fields)
+	}
+}
+
+func TestWithFields_OpenPaths(t *testing.T) {
+	body := map[string]any{"meta": map[string]any{"build": map[string]any{"sha": "abc", "date": "today"}, "env": "prod"}}
+	response, err := serveFields(t, testFieldsPolicy, "meta.build.sha", func(c RequestContext) error {
+		return c.Response().JSON(http.StatusOK, body)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"meta":{"build":{"sha":"abc"}}}`, string(response.body))
+}
+
+func TestWithFields_Page(t *testing.T) {
+	page := pageBody{Items: []fieldsUser{{ID: 1, Name: "Ada"}}, Page: pageMetadata{Limit: 20}}
+	response, err := serveFields(t, FieldsPolicy{Paths: testFieldsPolicy.Paths, Page: true}, "name", func(c RequestContext) error {
+		return c.Response().JSON(http.StatusOK, page)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"items":[{"name":"Ada"}],"page":{"limit":20}}`, string(response.body))
+
+	_, err = serveFields(t, FieldsPolicy{Paths: testFieldsPolicy.Paths, Page: true}, "page", func(c RequestContext) error {
+		return c.Response().JSON(http.StatusOK, page)
+	})
+	assert.Equal(t, http.StatusBadRequest, ErrorStatus(err), "fields select item members, not the page")
+}
+
+func TestWithFields_AnyPath(t *testing.T) {
+	response, err := serveFields(t, FieldsPolicy{}, "b", func(c RequestContext) error {
+		return c.Response().JSON(http.StatusOK, map[string]int{"a": 1, "b": 2})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"b":2}`, string(response.body))
+}
+
+func TestWithFields_PassesThrough(t *testing.T) {
+	t.Run("without fields", func(t *testing.T) {
+		called := false
+		response, err := serveFields(t, testFieldsPolicy, "", func(c RequestContext) error {
+			called = true
+			return c.Response().String(http.StatusOK, "plain")
+		})
+		require.NoError(t, err)
+		assert.True(t, called)
+		assert.Equal(t, "plain", string(response.body))
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		response, err := serveFields(t, testFieldsPolicy, "id", func(c RequestContext) error {
+			return c.Response().JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, response.status)
+		assert.Equal(t, `{"error":"not found"}`, string(response.body))
+	})
+
+	t.Run("not JSON", func(t *testing.T) {
+		response, err := serveFields(t, testFieldsPolicy, "id", func(c RequestContext) error {
+			return c.Response().String(http.StatusOK, `{"id":1,"name":"Ada"}`)
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `{"id":1,"name":"Ada"}`, string(response.body))
+	})
+
+	t.Run("handler error", func(t *testing.T) {
+		failure := errors.New("boom")
+		_, err := serveFields(t, testFieldsPolicy, "id", func(c RequestContext) error {
+			return failure
+		})
+		assert.ErrorIs(t, err, failure)
+	})
+
+	t.Run("JSON media type", func(t *testing.T) {
+		response, err := serveFields(t, testFieldsPolicy, "id", func(c RequestContext) error {
+			return c.Response().Blob(http.StatusOK, "application/vnd.api+json; charset=utf-8", []byte(`{"id":1,"name":"Ada"}`))
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `{"id":1}`, string(response.body), "+json media types are projected")
+	})
+}
+
+func TestWithFields_Rejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields string
+	}{
+		{"unknown field", "password"},
+		{"unknown nested field", "address.zip"},
+		{"empty path", "id,"},
+		{"empty segment", "address..city"},
+		{"leading dot", ".id"},
+	}
+
+	for _, tt := range tests {
+		called := false
+		_, err := serveFields(t, testFieldsPolicy, tt.fields, func(c RequestContext) error {
+			called = true
+			return nil
+		})
+		assert.Equal(t, http.StatusBadRequest, ErrorStatus(err), tt.name)
+		assert.False(t, called, "%s: the handler is not run", tt.name)
+	}
+}
+
+func TestFieldsPolicy_Allows(t *testing.T) {
+	assert.True(t, testFieldsPolicy.Allows("address.city"))
+	assert.True(t, testFieldsPolicy.Allows("meta.anything.below"))
+	assert.False(t, testFieldsPolicy.Allows("meta"), "an open path allows paths below it")
+	assert.False(t, testFieldsPolicy.Allows("metadata.x"))
+	assert.True(t, FieldsPolicy{}.Allows("anything"))
+}
@@ -0,0 +1,174 @@
+package axon
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// valueRequestContext is a mock RequestContext that keeps Set/Get values, headers,
+// cookies, query and form parameters and records what is written to the response
+type valueRequestContext struct {
+	mockRequestContext
+	method   string
+	path     string
+	values   map[string]interface{}
+	request  *valueRequest
+	response *recordingResponse
+	query    map[string]string
+	form     map[string]string
+	params   map[string]string
+}
+
+func newValueRequestContext() *valueRequestContext {
+	return &valueRequestContext{
+		method:   http.MethodGet,
+		path:     "/test",
+		values:   make(map[string]interface{}),
+		request:  &valueRequest{headers: make(map[string]string), cookies: make(map[string]string)},
+		response: &recordingResponse{headers: make(http.Header)},
+		query:    make(map[string]string),
+		form:     make(map[string]string),
+		params:   make(map[string]string),
+	}
+}
+
+func (m *valueRequestContext) Method() string                  { return m.method }
+func (m *valueRequestContext) Path() string                    { return m.path }
+func (m *valueRequestContext) Host() string                    { return m.request.headers["Host"] }
+func (m *valueRequestContext) Get(key string) interface{}      { return m.values[key] }
+func (m *valueRequestContext) Set(key string, val interface{}) { m.values[key] = val }
+func (m *valueRequestContext) Request() RequestInterface       { return m.request }
+func (m *valueRequestContext) Response() ResponseInterface     { return m.response }
+func (m *valueRequestContext) Param(key string) string         { return m.params[key] }
+func (m *valueRequestContext) QueryParam(key string) string    { return m.query[key] }
+func (m *valueRequestContext) QueryParams() map[string][]string {
+	params := make(map[string][]string, len(m.query))
+	for key, value := range m.query {
+		params[key] = []string{value}
+	}
+	return params
+}
+func (m *valueRequestContext) FormValue(name string) string { return m.form[name] }
+func (m *valueRequestContext) withHeader(key, value string) *valueRequestContext {
+	m.request.headers[key] = value
+	return m
+}
+func (m *valueRequestContext) withCookie(name, value string) *valueRequestContext {
+	m.request.cookies[name] = value
+	return m
+}
+
+// valueRequest is a mock RequestInterface backed by header and cookie maps and a body
+type valueRequest struct {
+	headers map[string]string
+	cookies map[string]string
+	body    []byte
+	chunked bool // whether the body length is unknown, as with chunked encoding
+}
+
+func (r *valueRequest) Header(key string) string    { return r.headers[key] }
+func (r *valueRequest) SetHeader(key, value string) { r.headers[key] = value }
+func (r *valueRequest) Body() []byte                { return r.body }
+func (r *valueRequest) SetBody(body []byte)         { r.body, r.chunked = body, false }
+func (r *valueRequest) BodyStream() io.ReadCloser   { return io.NopCloser(bytes.NewReader(r.body)) }
+func (r *valueRequest) ContentType() string         { return r.headers["Content-Type"] }
+func (r *valueRequest) Cookies() []AxonCookie       { return nil }
+func (r *valueRequest) ContentLength() int64 {
+	if r.chunked {
+		return -1
+	}
+	return int64(len(r.body))
+}
+func (r *valueRequest) Cookie(name string) (AxonCookie, error) {
+	value, ok := r.cookies[name]
+	if !ok {
+		return AxonCookie{}, http.ErrNoCookie
+	}
+	return AxonCookie{Name: name, Value: value}, nil
+}
+func (r *valueRequest) SignedCookie(name string) (string, error) {
+	return "", http.ErrNoCookie
+}
+func (r *valueRequest) EncryptedCookie(name string) (string, error) {
+	return "", http.ErrNoCookie
+}
+
+// recordingResponse is a mock ResponseInterface that records status, headers, cookies and body
+type recordingResponse struct {
+	status  int
+	headers http.Header
+	cookies []AxonCookie
+	body    []byte
+	stream  *bufferStream // the body written with WriteStream
+}
+
+// bufferStream is a StreamWriter writing to a buffer. Writes fail after the
+// client disconnects by closing done.
+type bufferStream struct {
+	bytes.Buffer
+	flushes int
+	done    chan struct{}
+}
+
+func (b *bufferStream) Write(p []byte) (int, error) {
+	select {
+	case <-b.done:
+		return 0, io.ErrClosedPipe
+	default:
+		return b.Buffer.Write(p)
+	}
+}
+func (b *bufferStream) Flush() error          { b.flushes++; return nil }
+func (b *bufferStream) Done() <-chan struct{} { return b.done }
+
+func (r *recordingResponse) Status() int                 { return r.status }
+func (r *recordingResponse) SetStatus(code int)          { r.status = code }
+func (r *recordingResponse) Header(key string) string    { return r.headers.Get(key) }
+func (r *recordingResponse) SetHeader(key, value string) { r.headers.Set(key, value) }
+func (r *recordingResponse) AddHeader(key, value string) { r.headers.Add(key, value) }
+func (r *recordingResponse) JSON(code int, i interface{}) error {
+	return r.Blob(code, "application/json", []byte(fmt.Sprint(i)))
+}
+func (r *recordingResponse) JSONPretty(code int, i interface{}, indent string) error {
+	return r.JSON(code, i)
+}
+func (r *recordingResponse) String(code int, s string) error {
+	return r.Blob(code, "text/plain", []byte(s))
+}
+func (r *recordingResponse) HTML(code int, html string) error {
+	return r.Blob(code, "text/html", []byte(html))
+}
+func (r *recordingResponse) Blob(code int, contentType string, b []byte) error {
+	r.status, r.body = code, b
+	r.headers.Set("Content-Type", contentType)
+	return nil
+}
+func (r *recordingResponse) Stream(code int, contentType string, reader interface{}) error {
+	return r.Blob(code, contentType, nil)
+}
+func (r *recordingResponse) WriteStream(code int, contentType string, write func(w StreamWriter) error) error {
+	r.status = code
+	r.headers.Set("Content-Type", contentType)
+	if r.stream == nil {
+		r.stream = &bufferStream{}
+	}
+	err := write(r.stream)
+	r.body = r.stream.Bytes()
+	return err
+}
+func (r *recordingResponse) SetCookie(cookie AxonCookie) { r.cookies = append(r.cookies, cookie) }
+func (r *recordingResponse) Size() int64                 { return int64(len(r.body)) }
+func (r *recordingResponse) Written() bool               { return r.status != 0 }
+func (r *recordingResponse) Writer() interface{}         { return nil }
+
+// cookie returns the last cookie set with the given name
+func (r *recordingResponse) cookie(name string) (AxonCookie, bool) {
+	for i := len(r.cookies) - 1; i >= 0; i-- {
+		if r.cookies[i].Name == name {
+			return r.cookies[i], true
+		}
+	}
+	return AxonCookie{}, false
+}
@@ -0,0 +1,316 @@
+package axon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+// IdempotencyKeyHeader is the request header carrying the client's idempotency key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Defaults of idempotency handling
+const (
+	defaultIdempotencyTTL         = 24 * time.Hour
+	defaultIdempotencyLockTimeout = time.Minute
+	defaultIdempotencyMaxBodySize = 1 << 20
+	maxIdempotencyKeyLength       = 255
+)
+
+// IdempotencyRecord is the state of one idempotency key: reserved while the
+// first request runs, then the stored response. Records are shared between
+// requests once stored and must not be modified.
+type IdempotencyRecord struct {
+	// Fingerprint identifies the request the key was first used with
+	Fingerprint string
+
+	// Completed reports whether the response below has been stored
+	Completed bool
+
+	Status int
+	Header http.Header
+	Body   []byte
+
+	// ExpiresAt is when the record may be discarded
+	ExpiresAt time.Time
+}
+
+// IdempotencyStore stores idempotency records. Reserve must be atomic, so
+// that concurrent requests with one key cannot both run the handler.
+type IdempotencyStore interface {
+	// Reserve stores record if key has no record, or an expired one, and
+	// returns nil; otherwise it returns the existing record
+	Reserve(key string, record *IdempotencyRecord) (*IdempotencyRecord, error)
+
+	// Complete replaces the record of a reserved key with the stored response
+	Complete(key string, record *IdempotencyRecord) error
+
+	// Delete removes a record, releasing the key
+	Delete(key string) error
+}
+
+// IdempotencyConfig configures idempotency handling
+type IdempotencyConfig struct {
+	// Store holds the idempotency records (default: NewMemoryIdempotencyStore())
+	Store IdempotencyStore
+
+	// TTL is how long responses are replayed for (default: 24h)
+	TTL time.Duration
+
+	// LockTimeout is how long a key stays reserved by a request that never
+	// completes, for example because the server stopped (default: 1m)
+	LockTimeout time.Duration
+
+	// MaxBodySize is the largest response body that is stored (default: 1MB)
+	MaxBodySize int
+}
+
+// Idempotency makes routes annotated with -Idempotent safe to retry. The
+// first request carrying an Idempotency-Key header runs the handler and its
+// response (status, headers and body) is stored. Retries with the same key
+// get the stored response, marked with Idempotent-Replayed: true, without
+// running the handler again.
+//
+// A retry that arrives while the first request is still running fails with
+// 409 Conflict; reusing a key for a different request (method, path
+// parameters, query string or body) fails with 422 Unprocessable Entity.
+// Keys are scoped per route and authenticated principal. Requests without the
+// header are not affected.
+type Idempotency struct {
+	config IdempotencyConfig
+	now    func() time.Time
+}
+
+// IdempotencyPolicy is the idempotency configuration of one route. Generated
+// route registration builds it for -Idempotent.
+type IdempotencyPolicy struct {
+	// Route names the route, as "Controller.Handler"
+	Route string
+
+	// Path is the route template; its parameters are part of the fingerprint
+	Path string
+}
+
+// NewIdempotency creates idempotency handling
+func NewIdempotency(config IdempotencyConfig) *Idempotency {
+	if config.Store == nil {
+		config.Store = NewMemoryIdempotencyStore()
+	}
+	if config.TTL <= 0 {
+		config.TTL = defaultIdempotencyTTL
+	}
+	if config.LockTimeout <= 0 {
+		config.LockTimeout = defaultIdempotencyLockTimeout
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = defaultIdempotencyMaxBodySize
+	}
+	return &Idempotency{config: config, now: time.Now}
+}
+
+// WithIdempotency returns a middleware that replays the stored response of
+// requests retried with the same Idempotency-Key. Generated route
+// registration applies it for -Idempotent, inside authorization. Requests
+// without the header and a nil Idempotency pass through.
+//
+// Responses are stored unless the handler returns an error, panics, streams, fails
+// with a 5xx status or exceeds MaxBodySize; the key is then released so the
+// request can be retried. Cookies are not replayed.
+func WithIdempotency(idempotency *Idempotency, policy IdempotencyPolicy) MiddlewareFunc {
+	params := pathParamNames(policy.Path)
+
+	return func(next HandlerFunc) HandlerFunc {
+		if idempotency == nil {
+			return next
+		}
+		// A panic must still release the key, or retries get 409 until LockTimeout
+		next = Recover(next)
+
+		return func(c RequestContext) error {
+			idempotencyKey := c.Request().Header(IdempotencyKeyHeader)
+			if idempotencyKey == "" {
+				return next(c)
+			}
+			if len(idempotencyKey) > maxIdempotencyKeyLength {
+				return NewHTTPError(http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+			}
+
+			key := policy.Route + "\x00" + idempotencySubject(c) + "\x00" + idempotencyKey
+			fingerprint := requestFingerprint(c, params)
+
+			existing, err := idempotency.config.Store.Reserve(key, &IdempotencyRecord{
+				Fingerprint: fingerprint,
+				ExpiresAt:   idempotency.now().Add(idempotency.config.LockTimeout),
+			})
+			if err != nil {
+				return NewHTTPError(http.StatusInternalServerError, "Internal Server Error", err)
+			}
+			if existing != nil {
+				switch {
+				case existing.Fingerprint != fingerprint:
+					return NewHTTPError(http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request",
+						errors.New("idempotency key reused with a different request fingerprint"))
+				case !existing.Completed:
+					return NewHTTPError(http.StatusConflict, "A request with this Idempotency-Key is already in progress")
+				}
+				return replayIdempotent(c, existing)
+			}
+
+			recorder := NewResponseRecorder(c.Response())
+			if err := next(WithResponse(c, recorder)); err != nil {
+				_ = idempotency.config.Store.Delete(key)
+				return err
+			}
+			if !idempotency.storable(recorder) {
+				_ = idempotency.config.Store.Delete(key)
+				return recorder.Flush()
+			}
+			_ = idempotency.config.Store.Complete(key, &IdempotencyRecord{
+				Fingerprint: fingerprint,
+				Completed:   true,
+				Status:      recorder.Status(),
+				Header:      cloneHeader(recorder.RecordedHeader()),
+				Body:        recorder.Body(),
+				ExpiresAt:   idempotency.now().Add(idempotency.config.TTL),
+			})
+			return recorder.Flush()
+		}
+	}
+}
+
+// storable reports whether a recorded response may be stored for replay
+func (i *Idempotency) storable(recorder *ResponseRecorder) bool {
+	return recorder.Buffered() && recorder.Status() < http.StatusInternalServerError && len(recorder.Body()) <= i.config.MaxBodySize
+}
+
+// idempotencySubject scopes keys to the authenticated principal, so clients
+// cannot replay responses of other users
+func idempotencySubject(c RequestContext) string {
+	if principal, ok := GetPrincipal(c); ok {
+		return principal.GetSubject()
+	}
+	return ""
+}
+
+// requestFingerprint hashes what identifies a request: its method, path
+// parameters, query string and body
+func requestFingerprint(c RequestContext, params []string) string {
+	hash := sha256.New()
+	hash.Write([]byte(requestKey(c, c.Method(), params, nil)))
+	hash.Write(c.Request().Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// replayIdempotent writes a stored response
+func replayIdempotent(c RequestContext, record *IdempotencyRecord) error {
+	for key, values := range record.Header {
+		if key != "Content-Type" {
+			replayHeader(c.Response(), key, values)
+		}
+	}
+	c.Response().SetHeader("Idempotent-Replayed", "true")
+	return c.Response().Blob(record.Status, record.Header.Get("Content-Type"), record.Body)
+}
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore. Expired records are
+// removed as new keys are reserved.
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	records   map[string]*IdempotencyRecord
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryIdempotencyStore creates an in-memory idempotency store
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: make(map[string]*IdempotencyRecord), now: time.Now}
+}
+
+// Reserve stores record unless key has a record that has not expired
+func (s *MemoryIdempotencyStore) Reserve(key string, record *IdempotencyRecord) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) > time.Minute {
+		s.sweep(now)
+	}
+	if existing, ok := s.records[key]; ok && now.Before(existing.ExpiresAt) {
+		return existing, nil
+	}
+	s.records[key] = record
+	return nil, nil
+}
+
+// Complete replaces the record of key
+func (s *MemoryIdempotencyStore) Complete(key string, record *IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = record
+	return nil
+}
+
+// Delete removes the record of key
+func (s *MemoryIdempotencyStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// Len returns the number of stored records, including expired ones not yet removed
+func (s *MemoryIdempotencyStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
+
+// sweep removes expired records
+func (s *MemoryIdempotencyStore) sweep(now time.Time) {
+	for key, record := range s.records {
+		if !now.Before(record.ExpiresAt) {
+			delete(s.records, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+// IdempotencyParams are the fx dependencies of idempotency handling. Both are
+// optional; an injected IdempotencyStore is used unless the config sets one.
+type IdempotencyParams struct {
+	fx.In
+
+	Config *IdempotencyConfig `optional:"true"`
+	Store  IdempotencyStore   `optional:"true"`
+}
+
+// NewIdempotencyFromParams builds idempotency handling from fx-provided dependencies
+func NewIdempotencyFromParams(p IdempotencyParams) *Idempotency {
+	var config IdempotencyConfig
+	if p.Config != nil {
+		config = *p.Config
+	}
+	if config.Store == nil {
+		config.Store = p.Store
+	}
+	return NewIdempotency(config)
+}
+
+// IdempotencyModule provides the *axon.Idempotency that generated route
+// registration takes when a route is annotated with -Idempotent. It uses an
+// in-memory store unless an IdempotencyStore is provided; use a shared store
+// when running more than one instance.
+//
+//	fx.New(
+//	    fx.Provide(func(client *redis.Client) axon.IdempotencyStore { return NewRedisIdempotencyStore(client) }),
+//	    axon.IdempotencyModule,
+//	    controllers.AutogenModule,
+//	)
+var IdempotencyModule = fx.Module("axon-idempotency",
+	fx.Provide(NewIdempotencyFromParams),
+)
@@ -0,0 +1,181 @@
+package axon
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testIdempotency is idempotency handling with a controllable clock
+type testIdempotency struct {
+	*Idempotency
+	store *MemoryIdempotencyStore
+	clock time.Time
+	calls int
+}
+
+func newTestIdempotency(t *testing.T, handler func(tc *testIdempotency, c RequestContext) error) (*testIdempotency, HandlerFunc) {
+	t.Helper()
+	store := NewMemoryIdempotencyStore()
+	tc := &testIdempotency{Idempotency: NewIdempotency(IdempotencyConfig{Store: store, TTL: time.Hour}), store: store, clock: time.Unix(1700000000, 0)}
+	tc.now = func() time.Time { return tc.clock }
+	store.now = tc.Idempotency.now
+
+	wrapped := WithIdempotency(tc.Idempotency, IdempotencyPolicy{Route: "OrderController.Create", Path: "/orders"})(func(c RequestContext) error {
+		tc.calls++
+		return handler(tc, c)
+	})
+	return tc, wrapped
+}
+
+func createOrder(tc *testIdempotency, c RequestContext) error {
+	c.Response().SetHeader("Location", fmt.Sprintf("/orders/%d", tc.calls))
+	c.Response().AddHeader("Link", "</orders>; rel=collection")
+	c.Response().AddHeader("Link", "</customers/7>; rel=customer")
+	return c.Response().JSON(http.StatusCreated, fmt.Sprintf("order %d", tc.calls))
+}
+
+func orderRequest(key, body string) *valueRequestContext {
+	c := newValueRequestContext()
+	c.method = http.MethodPost
+	c.request.body = []byte(body)
+	if key != "" {
+		c.withHeader(IdempotencyKeyHeader, key)
+	}
+	return c
+}
+
+func TestWithIdempotency_Replay(t *testing.T) {
+	tc, handler := newTestIdempotency(t, createOrder)
+
+	first := orderRequest("key-1", `{"item":"book"}`)
+	require.NoError(t, handler(first))
+	assert.Equal(t, http.StatusCreated, first.response.status)
+	assert.Empty(t, first.response.headers.Get("Idempotent-Replayed"))
+
+	retry := orderRequest("key-1", `{"item":"book"}`)
+	require.NoError(t, handler(retry))
+	assert.Equal(t, 1, tc.calls)
+	assert.Equal(t, http.StatusCreated, retry.response.status)
+	assert.Equal(t, `"order 1"`, string(retry.response.body))
+	assert.Equal(t, "/orders/1", retry.response.headers.Get("Location"))
+	assert.Equal(t, first.response.headers.Values("Link"), retry.response.headers.Values("Link"))
+	assert.Equal(t, "application/json", retry.response.headers.Get("Content-Type"))
+	assert.Equal(t, "true", retry.response.headers.Get("Idempotent-Replayed"))
+
+	// Other keys, and requests without a key, run the handler
+	require.NoError(t, handler(orderRequest("key-2", `{"item":"book"}`)))
+	require.NoError(t, handler(orderRequest("", `{"item":"book"}`)))
+	assert.Equal(t, 3, tc.calls)
+
+	// Once the TTL passes, the key can be used again
+	tc.clock = tc.clock.Add(2 * time.Hour)
+	require.NoError(t, handler(orderRequest("key-1", `{"item":"book"}`)))
+	assert.Equal(t, 4, tc.calls)
+}
+
+func TestWithIdempotency_FingerprintMismatch(t *testing.T) {
+	tc, handler := newTestIdempotency(t, createOrder)
+
+	require.NoError(t, handler(orderRequest("key-1", `{"item":"book"}`)))
+	err := handler(orderRequest("key-1", `{"item":"pen"}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, ErrorStatus(err))
+
+	query := orderRequest("key-1", `{"item":"book"}`)
+	query.query["dry_run"] = "true"
+	assert.Equal(t, http.StatusUnprocessableEntity, ErrorStatus(handler(query)))
+	assert.Equal(t, 1, tc.calls)
+}
+
+func TestWithIdempotency_InFlight(t *testing.T) {
+	var retryErr error
+	var handler HandlerFunc
+	tc, handler := newTestIdempotency(t, func(tc *testIdempotency, c RequestContext) error {
+		// A retry arrives while the first request is still running
+		retryErr = handler(orderRequest("key-1", `{}`))
+		return createOrder(tc, c)
+	})
+
+	require.NoError(t, handler(orderRequest("key-1", `{}`)))
+	assert.Equal(t, http.StatusConflict, ErrorStatus(retryErr))
+	assert.Equal(t, 1, tc.calls)
+
+	// A reservation that is never completed expires after LockTimeout
+	require.NoError(t, tc.store.Complete("stuck", &IdempotencyRecord{ExpiresAt: tc.clock.Add(time.Minute)}))
+	existing, err := tc.store.Reserve("stuck", &IdempotencyRecord{ExpiresAt: tc.clock.Add(time.Minute)})
+	require.NoError(t, err)
+	assert.NotNil(t, existing)
+	tc.clock = tc.clock.Add(2 * time.Minute)
+	existing, err = tc.store.Reserve("stuck", &IdempotencyRecord{ExpiresAt: tc.clock.Add(time.Minute)})
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+}
+
+func TestWithIdempotency_ReleasesKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(tc *testIdempotency, c RequestContext) error
+	}{
+		{"error", func(tc *testIdempotency, c RequestContext) error {
+			return NewHTTPError(http.StatusBadRequest, "invalid")
+		}},
+		{"server error", func(tc *testIdempotency, c RequestContext) error {
+			return c.Response().String(http.StatusServiceUnavailable, "busy")
+		}},
+		{"stream", func(tc *testIdempotency, c RequestContext) error {
+			return c.Response().Stream(http.StatusOK, "text/plain", nil)
+		}},
+		{"panic", func(tc *testIdempotency, c RequestContext) error {
+			panic("boom")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc, handler := newTestIdempotency(t, tt.handler)
+			_ = handler(orderRequest("key-1", `{}`))
+			_ = handler(orderRequest("key-1", `{}`))
+			assert.Equal(t, 2, tc.calls)
+			assert.Zero(t, tc.store.Len())
+		})
+	}
+}
+
+func TestWithIdempotency_ScopedToPrincipal(t *testing.T) {
+	tc, handler := newTestIdempotency(t, createOrder)
+
+	as := func(subject string) *valueRequestContext {
+		c := orderRequest("key-1", `{}`)
+		SetPrincipal(c, &BasicPrincipal{Subject: subject})
+		return c
+	}
+
+	require.NoError(t, handler(as("alice")))
+	bob := as("bob")
+	require.NoError(t, handler(bob))
+	assert.Equal(t, 2, tc.calls)
+	assert.Equal(t, `"order 2"`, string(bob.response.body))
+}
+
+func TestWithIdempotency_Bypass(t *testing.T) {
+	calls := 0
+	next := func(c RequestContext) error {
+		calls++
+		return c.Response().String(http.StatusOK, "ok")
+	}
+	policy := IdempotencyPolicy{Route: "OrderController.Create", Path: "/orders"}
+
+	// Without idempotency handling the handler is returned unchanged
+	handler := WithIdempotency(nil, policy)(next)
+	require.NoError(t, handler(orderRequest("key-1", `{}`)))
+	require.NoError(t, handler(orderRequest("key-1", `{}`)))
+	assert.Equal(t, 2, calls)
+
+	handler = WithIdempotency(NewIdempotency(IdempotencyConfig{}), policy)(next)
+	long := orderRequest(string(make([]byte, 256)), `{}`)
+	assert.Equal(t, http.StatusBadRequest, ErrorStatus(handler(long)))
+}
@@ -0,0 +1,201 @@
+package axon
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MinKeyringSecretSize is the minimum length of a keyring secret in bytes
+const MinKeyringSecretSize = 32
+
+// Cookie protection errors
+var (
+	ErrInvalidCookie = errors.New("axon: cookie signature or encryption is invalid")
+	ErrNoKeyring     = errors.New("axon: no keyring configured for signed or encrypted cookies")
+)
+
+// Keyring signs (HMAC-SHA256) and encrypts (AES-256-GCM) cookie values.
+//
+// The first secret is the primary key and is used for all new values; the
+// remaining secrets are only used for verification and decryption. To rotate,
+// prepend a new secret and keep the previous ones until their cookies expire.
+// Values are bound to the cookie name, so a value cannot be replayed under a
+// different cookie.
+type Keyring struct {
+	keys []keyringKey
+}
+
+// keyringKey holds the purpose-specific keys derived from one secret
+type keyringKey struct {
+	signing []byte
+	aead    cipher.AEAD
+}
+
+// NewKeyring creates a keyring from raw secrets, primary first.
+// Each secret must be at least MinKeyringSecretSize bytes.
+func NewKeyring(secrets ...[]byte) (*Keyring, error) {
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("axon: keyring requires at least one secret")
+	}
+
+	keys := make([]keyringKey, 0, len(secrets))
+	for i, secret := range secrets {
+		if len(secret) < MinKeyringSecretSize {
+			return nil, fmt.Errorf("axon: keyring secret %d must be at least %d bytes", i, MinKeyringSecretSize)
+		}
+
+		block, err := aes.NewCipher(deriveKey(secret, "axon.cookie.encrypt"))
+		if err != nil {
+			return nil, fmt.Errorf("axon: keyring secret %d: %w", i, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("axon: keyring secret %d: %w", i, err)
+		}
+
+		keys = append(keys, keyringKey{
+			signing: deriveKey(secret, "axon.cookie.sign"),
+			aead:    aead,
+		})
+	}
+
+	return &Keyring{keys: keys}, nil
+}
+
+// NewKeyringFromBase64 creates a keyring from base64-encoded secrets (standard or URL alphabet), primary first
+func NewKeyringFromBase64(secrets ...string) (*Keyring, error) {
+	raw := make([][]byte, 0, len(secrets))
+	for i, secret := range secrets {
+		decoded, err := decodeBase64(strings.TrimSpace(secret))
+		if err != nil {
+			return nil, fmt.Errorf("axon: keyring secret %d is not valid base64: %w", i, err)
+		}
+		raw = append(raw, decoded)
+	}
+	return NewKeyring(raw...)
+}
+
+// Sign returns value with an HMAC bound to the cookie name, using the primary key
+func (k *Keyring) Sign(name, value string) (string, error) {
+	if k == nil {
+		return "", ErrNoKeyring
+	}
+	mac := cookieMAC(k.keys[0].signing, name, value)
+	return base64.RawURLEncoding.EncodeToString([]byte(value)) + "." + base64.RawURLEncoding.EncodeToString(mac), nil
+}
+
+// Verify checks a value produced by Sign against every key and returns the original value
+func (k *Keyring) Verify(name, signed string) (string, error) {
+	if k == nil {
+		return "", ErrNoKeyring
+	}
+
+	encodedValue, encodedMAC, ok := strings.Cut(signed, ".")
+	if !ok {
+		return "", ErrInvalidCookie
+	}
+	value, err := base64.RawURLEncoding.DecodeString(encodedValue)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+
+	for _, key := range k.keys {
+		if hmac.Equal(mac, cookieMAC(key.signing, name, string(value))) {
+			return string(value), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+// Encrypt seals value with the primary key, using the cookie name as additional data
+func (k *Keyring) Encrypt(name, value string) (string, error) {
+	if k == nil {
+		return "", ErrNoKeyring
+	}
+
+	aead := k.keys[0].aead
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("axon: failed to generate cookie nonce: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(name))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt, trying every key
+func (k *Keyring) Decrypt(name, sealed string) (string, error) {
+	if k == nil {
+		return "", ErrNoKeyring
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+
+	for _, key := range k.keys {
+		nonceSize := key.aead.NonceSize()
+		if len(data) < nonceSize+key.aead.Overhead() {
+			return "", ErrInvalidCookie
+		}
+		if plain, err := key.aead.Open(nil, data[:nonceSize], data[nonceSize:], []byte(name)); err == nil {
+			return string(plain), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+// ProtectCookie returns cookie with its value signed or encrypted according to
+// its Signed and Encrypted flags. Encrypted takes precedence since AEAD already
+// authenticates the value. Cookies without either flag are returned unchanged.
+func (k *Keyring) ProtectCookie(cookie AxonCookie) (AxonCookie, error) {
+	var err error
+	switch {
+	case cookie.Encrypted:
+		cookie.Value, err = k.Encrypt(cookie.Name, cookie.Value)
+	case cookie.Signed:
+		cookie.Value, err = k.Sign(cookie.Name, cookie.Value)
+	}
+	if err != nil {
+		return AxonCookie{}, err
+	}
+	cookie.Signed, cookie.Encrypted = false, false
+	return cookie, nil
+}
+
+// deriveKey derives a 32-byte key for a single purpose from a secret
+func deriveKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// cookieMAC computes the HMAC of a cookie name and value
+func cookieMAC(key []byte, name, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+// decodeBase64 accepts padded or unpadded standard and URL-safe base64
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	if strings.ContainsAny(s, "-_") {
+		return base64.RawURLEncoding.DecodeString(s)
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
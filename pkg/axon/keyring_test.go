@@ -0,0 +1,154 @@
+package axon
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testSecret(b byte) []byte {
+	return bytes.Repeat([]byte{b}, MinKeyringSecretSize)
+}
+
+func TestNewKeyring(t *testing.T) {
+	if _, err := NewKeyring(); err == nil {
+		t.Error("expected error for empty keyring")
+	}
+	if _, err := NewKeyring([]byte("short")); err == nil {
+		t.Error("expected error for short secret")
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(testSecret(1))
+	urlEncoded := base64.RawURLEncoding.EncodeToString(bytes.Repeat([]byte{0xfb}, MinKeyringSecretSize))
+	if _, err := NewKeyringFromBase64(encoded, urlEncoded); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := NewKeyringFromBase64("not base64!"); err == nil {
+		t.Error("expected error for invalid base64")
+	}
+}
+
+func TestKeyringSign(t *testing.T) {
+	keyring, _ := NewKeyring(testSecret(1))
+
+	signed, err := keyring.Sign("session", "user=42")
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if strings.Contains(signed, "user=42") {
+		t.Errorf("signed value should be encoded, got %q", signed)
+	}
+
+	value, err := keyring.Verify("session", signed)
+	if err != nil || value != "user=42" {
+		t.Errorf("Verify = %q, %v", value, err)
+	}
+
+	if _, err := keyring.Verify("other", signed); !errors.Is(err, ErrInvalidCookie) {
+		t.Errorf("expected ErrInvalidCookie for wrong name, got %v", err)
+	}
+
+	forged := base64.RawURLEncoding.EncodeToString([]byte("user=1")) + signed[strings.Index(signed, "."):]
+	if _, err := keyring.Verify("session", forged); !errors.Is(err, ErrInvalidCookie) {
+		t.Errorf("expected ErrInvalidCookie for tampered value, got %v", err)
+	}
+
+	for _, malformed := range []string{"", "nodot", "!!.!!", "dmFsdWU.!!"} {
+		if _, err := keyring.Verify("session", malformed); !errors.Is(err, ErrInvalidCookie) {
+			t.Errorf("Verify(%q): expected ErrInvalidCookie, got %v", malformed, err)
+		}
+	}
+}
+
+func TestKeyringEncrypt(t *testing.T) {
+	keyring, _ := NewKeyring(testSecret(1))
+
+	sealed, err := keyring.Encrypt("prefs", "theme=dark")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if again, _ := keyring.Encrypt("prefs", "theme=dark"); again == sealed {
+		t.Error("expected a fresh nonce for every encryption")
+	}
+
+	value, err := keyring.Decrypt("prefs", sealed)
+	if err != nil || value != "theme=dark" {
+		t.Errorf("Decrypt = %q, %v", value, err)
+	}
+
+	if _, err := keyring.Decrypt("other", sealed); !errors.Is(err, ErrInvalidCookie) {
+		t.Errorf("expected ErrInvalidCookie for wrong name, got %v", err)
+	}
+
+	raw, _ := base64.RawURLEncoding.DecodeString(sealed)
+	raw[len(raw)-1] ^= 1
+	if _, err := keyring.Decrypt("prefs", base64.RawURLEncoding.EncodeToString(raw)); !errors.Is(err, ErrInvalidCookie) {
+		t.Errorf("expected ErrInvalidCookie for tampered value, got %v", err)
+	}
+
+	for _, malformed := range []string{"", "!!", "c2hvcnQ"} {
+		if _, err := keyring.Decrypt("prefs", malformed); !errors.Is(err, ErrInvalidCookie) {
+			t.Errorf("Decrypt(%q): expected ErrInvalidCookie, got %v", malformed, err)
+		}
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	oldKeyring, _ := NewKeyring(testSecret(1))
+	signed, _ := oldKeyring.Sign("session", "v")
+	sealed, _ := oldKeyring.Encrypt("session", "v")
+
+	rotated, _ := NewKeyring(testSecret(2), testSecret(1))
+	if value, err := rotated.Verify("session", signed); err != nil || value != "v" {
+		t.Errorf("rotated Verify = %q, %v", value, err)
+	}
+	if value, err := rotated.Decrypt("session", sealed); err != nil || value != "v" {
+		t.Errorf("rotated Decrypt = %q, %v", value, err)
+	}
+
+	// New values use the primary key and no longer verify with the retired one
+	newSigned, _ := rotated.Sign("session", "v")
+	if _, err := oldKeyring.Verify("session", newSigned); !errors.Is(err, ErrInvalidCookie) {
+		t.Errorf("expected primary key to sign new values, got %v", err)
+	}
+
+	retired, _ := NewKeyring(testSecret(2))
+	if _, err := retired.Verify("session", signed); !errors.Is(err, ErrInvalidCookie) {
+		t.Errorf("expected retired key to be rejected, got %v", err)
+	}
+}
+
+func TestKeyringProtectCookie(t *testing.T) {
+	keyring, _ := NewKeyring(testSecret(1))
+
+	plain := AxonCookie{Name: "a", Value: "v"}
+	if got, err := keyring.ProtectCookie(plain); err != nil || got != plain {
+		t.Errorf("plain cookie changed: %+v, %v", got, err)
+	}
+
+	signed, err := keyring.ProtectCookie(AxonCookie{Name: "a", Value: "v", Signed: true})
+	if err != nil || signed.Signed {
+		t.Fatalf("ProtectCookie(signed) = %+v, %v", signed, err)
+	}
+	if value, _ := keyring.Verify("a", signed.Value); value != "v" {
+		t.Errorf("signed cookie did not verify: %q", signed.Value)
+	}
+
+	encrypted, err := keyring.ProtectCookie(AxonCookie{Name: "a", Value: "v", Signed: true, Encrypted: true})
+	if err != nil || encrypted.Encrypted {
+		t.Fatalf("ProtectCookie(encrypted) = %+v, %v", encrypted, err)
+	}
+	if value, _ := keyring.Decrypt("a", encrypted.Value); value != "v" {
+		t.Errorf("encrypted cookie did not decrypt: %q", encrypted.Value)
+	}
+
+	var missing *Keyring
+	if _, err := missing.ProtectCookie(AxonCookie{Name: "a", Signed: true}); !errors.Is(err, ErrNoKeyring) {
+		t.Errorf("expected ErrNoKeyring, got %v", err)
+	}
+	if _, err := missing.Verify("a", "x.y"); !errors.Is(err, ErrNoKeyring) {
+		t.Errorf("expected ErrNoKeyring, got %v", err)
+	}
+}
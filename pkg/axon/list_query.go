@@ -0,0 +1,186 @@
+package axon
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// FilterOperator compares a field with the values of a Filter
+type FilterOperator string
+
+const (
+	FilterEq       FilterOperator = "eq"       // equal to the value
+	FilterNe       FilterOperator = "ne"       // not equal to the value
+	FilterGt       FilterOperator = "gt"       // greater than the value
+	FilterGte      FilterOperator = "gte"      // greater than or equal to the value
+	FilterLt       FilterOperator = "lt"       // less than the value
+	FilterLte      FilterOperator = "lte"      // less than or equal to the value
+	FilterIn       FilterOperator = "in"       // equal to one of the comma-separated values
+	FilterContains FilterOperator = "contains" // containing the value as a substring
+)
+
+// filterOperators are the operators in the order filters on a field are listed
+var filterOperators = []FilterOperator{FilterEq, FilterNe, FilterGt, FilterGte, FilterLt, FilterLte, FilterIn, FilterContains}
+
+// ListQueryPolicy lists the fields a route's ListQuery can sort and filter
+// by. Generated wrappers build it from the route's -Sortable and -Filterable
+// flags.
+type ListQueryPolicy struct {
+	Sortable   []string
+	Filterable []string
+}
+
+// ListQuery is how a client asked a list to be sorted and filtered, parsed
+// from the sort and filter query parameters:
+//
+//	?sort=-created_at,name&filter[status]=active&filter[age][gte]=18
+//
+// Handlers take it as a parameter, and the fields it can use are declared on
+// the route:
+//
+//	//axon::route GET /users -Sortable=created_at,name -Filterable=status,age
+//	func (c *UserController) ListUsers(query axon.ListQuery) ([]*User, error)
+//
+// Fields are the names clients use, which repositories map to columns. Values
+// are left as strings for repositories to convert and bind as parameters.
+type ListQuery struct {
+	// Sort are the sort keys, most significant first
+	Sort []SortField
+
+	// Filters are the conditions items must all meet, ordered by field and
+	// then operator
+	Filters []Filter
+}
+
+// SortField is a sort key of a ListQuery
+type SortField struct {
+	Field      string
+	Descending bool // whether the field was prefixed with -
+}
+
+// Filter is a condition of a ListQuery: Field Operator Values
+type Filter struct {
+	Field    string
+	Operator FilterOperator
+
+	// Values holds the value compared with, or the values of FilterIn
+	Values []string
+}
+
+// Value returns the value compared with, or the first value of FilterIn
+func (f Filter) Value() string {
+	if len(f.Values) == 0 {
+		return ""
+	}
+	return f.Values[0]
+}
+
+// FiltersOn returns the filters on field
+func (q ListQuery) FiltersOn(field string) []Filter {
+	var filters []Filter
+	for _, filter := range q.Filters {
+		if filter.Field == field {
+			filters = append(filters, filter)
+		}
+	}
+	return filters
+}
+
+// IsZero reports whether the query neither sorts nor filters
+func (q ListQuery) IsZero() bool {
+	return len(q.Sort) == 0 && len(q.Filters) == 0
+}
+
+// BindListQuery reads the list query of c. Sorting or filtering by fields
+// policy does not allow, unknown operators and malformed parameters are
+// rejected with 400.
+func BindListQuery(c RequestContext, policy ListQueryPolicy) (ListQuery, error) {
+	var query ListQuery
+
+	if sort := c.QueryParam("sort"); sort != "" {
+		for _, key := range strings.Split(sort, ",") {
+			key = strings.TrimSpace(key)
+			field := SortField{Field: strings.TrimLeft(key, "+-"), Descending: strings.HasPrefix(key, "-")}
+			if field.Field == "" || len(key)-len(field.Field) > 1 {
+				return ListQuery{}, NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid sort key %q", key))
+			}
+			if !slices.Contains(policy.Sortable, field.Field) {
+				return ListQuery{}, NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Cannot sort by %q%s", field.Field, allowedFields(policy.Sortable)))
+			}
+			if slices.ContainsFunc(query.Sort, func(s SortField) bool { return s.Field == field.Field }) {
+				return ListQuery{}, NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Cannot sort by %q twice", field.Field))
+			}
+			query.Sort = append(query.Sort, field)
+		}
+	}
+
+	for key, values := range c.QueryParams() {
+		if key != "filter" && !strings.HasPrefix(key, "filter[") {
+			continue
+		}
+		filter, err := parseFilterKey(key)
+		if err != nil {
+			return ListQuery{}, NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if !slices.Contains(policy.Filterable, filter.Field) {
+			return ListQuery{}, NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Cannot filter by %q%s", filter.Field, allowedFields(policy.Filterable)))
+		}
+		if len(values) != 1 {
+			return ListQuery{}, NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Query parameter %s must be given once", key))
+		}
+		filter.Values = values
+		if filter.Operator == FilterIn {
+			filter.Values = strings.Split(values[0], ",")
+		}
+		query.Filters = append(query.Filters, filter)
+	}
+
+	// Query parameters are unordered, so filters are sorted for repositories
+	// to build the same statements for the same query
+	slices.SortFunc(query.Filters, func(a, b Filter) int {
+		if a.Field != b.Field {
+			return strings.Compare(a.Field, b.Field)
+		}
+		return slices.Index(filterOperators, a.Operator) - slices.Index(filterOperators, b.Operator)
+	})
+	return query, nil
+}
+
+// parseFilterKey parses a filter[field] or filter[field][operator] query
+// parameter name
+func parseFilterKey(key string) (Filter, error) {
+	invalid := fmt.Errorf("Invalid query parameter %s: filters are written filter[field]=value or filter[field][operator]=value", key)
+
+	rest, ok := strings.CutPrefix(key, "filter[")
+	if !ok {
+		return Filter{}, invalid
+	}
+	field, rest, ok := strings.Cut(rest, "]")
+	if !ok || field == "" {
+		return Filter{}, invalid
+	}
+	filter := Filter{Field: field, Operator: FilterEq}
+	if rest == "" {
+		return filter, nil
+	}
+
+	operator, ok := strings.CutPrefix(rest, "[")
+	if !ok || !strings.HasSuffix(operator, "]") {
+		return Filter{}, invalid
+	}
+	filter.Operator = FilterOperator(strings.TrimSuffix(operator, "]"))
+	if !slices.Contains(filterOperators, filter.Operator) {
+		return Filter{}, fmt.Errorf("Unknown filter operator %q; operators are eq, ne, gt, gte, lt, lte, in and contains", filter.Operator)
+	}
+	return filter, nil
+}
+
+// allowedFields describes the fields of a policy for error messages
+func allowedFields(fields []string) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	return "; allowed fields are " + strings.Join(fields, ", ")
+}
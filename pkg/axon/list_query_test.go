@@ -0,0 +1,85 @@
+package axon
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testListQueryPolicy = ListQueryPolicy{
+	Sortable:   []string{"created_at", "name"},
+	Filterable: []string{"status", "age", "address.city"},
+}
+
+func listQueryContext(query map[string]string) *valueRequestContext {
+	c := newValueRequestContext()
+	c.query = query
+	return c
+}
+
+func TestBindListQuery(t *testing.T) {
+	query, err := BindListQuery(listQueryContext(map[string]string{
+		"sort":                     "-created_at, +name",
+		"filter[status]":           "active",
+		"filter[age][lte]":         "65",
+		"filter[age][gte]":         "18",
+		"filter[address.city][in]": "Paris,Lyon",
+		"page":                     "2",
+	}), testListQueryPolicy)
+	require.NoError(t, err)
+
+	assert.Equal(t, []SortField{{Field: "created_at", Descending: true}, {Field: "name"}}, query.Sort)
+	assert.Equal(t, []Filter{
+		{Field: "address.city", Operator: FilterIn, Values: []string{"Paris", "Lyon"}},
+		{Field: "age", Operator: FilterGte, Values: []string{"18"}},
+		{Field: "age", Operator: FilterLte, Values: []string{"65"}},
+		{Field: "status", Operator: FilterEq, Values: []string{"active"}},
+	}, query.Filters, "filters are ordered by field and operator")
+	assert.Len(t, query.FiltersOn("age"), 2)
+	assert.Equal(t, "active", query.FiltersOn("status")[0].Value())
+
+	query, err = BindListQuery(listQueryContext(map[string]string{}), testListQueryPolicy)
+	require.NoError(t, err)
+	assert.True(t, query.IsZero())
+}
+
+func TestBindListQuery_Rejected(t *testing.T) {
+	tests := []struct {
+		name  string
+		query map[string]string
+	}{
+		{"unknown sort field", map[string]string{"sort": "email"}},
+		{"filterable but not sortable", map[string]string{"sort": "status"}},
+		{"empty sort key", map[string]string{"sort": "name,"}},
+		{"double prefix", map[string]string{"sort": "--name"}},
+		{"sorted twice", map[string]string{"sort": "name,-name"}},
+		{"unknown filter field", map[string]string{"filter[email]": "a@example.com"}},
+		{"unknown operator", map[string]string{"filter[age][like]": "1"}},
+		{"bare filter", map[string]string{"filter": "active"}},
+		{"empty field", map[string]string{"filter[]": "active"}},
+		{"unclosed bracket", map[string]string{"filter[status": "active"}},
+		{"trailing text", map[string]string{"filter[age][gte]x": "1"}},
+	}
+
+	for _, tt := range tests {
+		_, err := BindListQuery(listQueryContext(tt.query), testListQueryPolicy)
+		assert.Equal(t, http.StatusBadRequest, ErrorStatus(err), tt.name)
+	}
+}
+
+func TestBindListQuery_RepeatedFilter(t *testing.T) {
+	c := &repeatedQueryContext{valueRequestContext: newValueRequestContext()}
+	_, err := BindListQuery(c, testListQueryPolicy)
+	assert.Equal(t, http.StatusBadRequest, ErrorStatus(err))
+}
+
+// repeatedQueryContext sends ?filter[status]=active&filter[status]=banned
+type repeatedQueryContext struct {
+	*valueRequestContext
+}
+
+func (c *repeatedQueryContext) QueryParams() map[string][]string {
+	return map[string][]string{"filter[status]": {"active", "banned"}}
+}
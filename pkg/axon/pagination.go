@@ -0,0 +1,353 @@
+package axon
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.uber.org/fx"
+)
+
+const (
+	// DefaultPageLimit is the page size of requests without a limit
+	DefaultPageLimit = 20
+
+	// DefaultMaxPageLimit is the largest page size clients can request
+	DefaultMaxPageLimit = 100
+)
+
+// PaginationConfig configures PageRequest binding and Page rendering
+type PaginationConfig struct {
+	// DefaultLimit is the page size of requests without a limit query
+	// parameter. Defaults to DefaultPageLimit.
+	DefaultLimit int
+
+	// MaxLimit caps the limit clients can request; larger limits are lowered
+	// to it. Defaults to DefaultMaxPageLimit.
+	MaxLimit int
+
+	// Keyring signs cursors, so that clients cannot forge them. Without one,
+	// a random key is used, and cursors do not survive a restart or carry
+	// over to other instances.
+	Keyring *Keyring
+}
+
+// Pagination binds PageRequest handler parameters and renders Page results.
+// Generated route registration takes one when a handler uses either.
+type Pagination struct {
+	config  PaginationConfig
+	keyring *Keyring
+}
+
+// NewPagination creates pagination handling with config
+func NewPagination(config PaginationConfig) *Pagination {
+	if config.DefaultLimit <= 0 {
+		config.DefaultLimit = DefaultPageLimit
+	}
+	if config.MaxLimit <= 0 {
+		config.MaxLimit = DefaultMaxPageLimit
+	}
+	config.DefaultLimit = min(config.DefaultLimit, config.MaxLimit)
+
+	keyring := config.Keyring
+	if keyring == nil {
+		secret := make([]byte, MinKeyringSecretSize)
+		if _, err := rand.Read(secret); err != nil {
+			panic(fmt.Sprintf("axon: generating cursor key: %v", err))
+		}
+		keyring, _ = NewKeyring(secret)
+	}
+	return &Pagination{config: config, keyring: keyring}
+}
+
+// PaginationParams are the fx dependencies of pagination. Both are optional;
+// the injected Keyring signs cursors unless the config sets one.
+type PaginationParams struct {
+	fx.In
+
+	Config  *PaginationConfig `optional:"true"`
+	Keyring *Keyring          `optional:"true"`
+}
+
+// NewPaginationFromParams builds pagination handling from fx-provided dependencies
+func NewPaginationFromParams(p PaginationParams) *Pagination {
+	var config PaginationConfig
+	if p.Config != nil {
+		config = *p.Config
+	}
+	if config.Keyring == nil {
+		config.Keyring = p.Keyring
+	}
+	return NewPagination(config)
+}
+
+// PaginationModule provides the *axon.Pagination that generated route
+// registration takes when a handler takes an axon.PageRequest or returns an
+// axon.Page. Cursors are signed with the application's *axon.Keyring if
+// one is provided.
+//
+//	fx.New(
+//	    fx.Supply(&axon.PaginationConfig{MaxLimit: 50}),
+//	    axon.PaginationModule,
+//	    controllers.AutogenModule,
+//	)
+var PaginationModule = fx.Module("axon-pagination",
+	fx.Provide(NewPaginationFromParams),
+)
+
+// PageRequest is the page a client asked for, bound from the limit, offset
+// and cursor query parameters. Handlers take it as a parameter:
+//
+//	//axon::route GET /users
+//	func (c *UserController) ListUsers(page axon.PageRequest) (axon.Page[*User], error)
+//
+// Clients page either by offset or by cursor, never both. Cursors are
+// opaque to clients: handlers return their own cursor values in a Page, and
+// receive them back verified in Cursor.
+type PageRequest struct {
+	// Limit is the page size, between 1 and the configured MaxLimit
+	Limit int
+
+	// Offset is the number of items before the page
+	Offset int
+
+	// Cursor is the handler's cursor of the page, or "" for the first page
+	// and offset requests
+	Cursor string
+}
+
+// Bind reads the page request of c. Invalid limits and offsets, tampered
+// cursors and requests combining offset and cursor are rejected with 400.
+func (p *Pagination) Bind(c RequestContext) (PageRequest, error) {
+	request := PageRequest{Limit: p.config.DefaultLimit}
+
+	if value := c.QueryParam("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return PageRequest{}, NewHTTPError(http.StatusBadRequest, "Query parameter limit must be a positive integer")
+		}
+		request.Limit = min(limit, p.config.MaxLimit)
+	}
+
+	offset, cursor := c.QueryParam("offset"), c.QueryParam("cursor")
+	if offset != "" && cursor != "" {
+		return PageRequest{}, NewHTTPError(http.StatusBadRequest, "Query parameters offset and cursor cannot be combined")
+	}
+	if offset != "" {
+		value, err := strconv.Atoi(offset)
+		if err != nil || value < 0 {
+			return PageRequest{}, NewHTTPError(http.StatusBadRequest, "Query parameter offset must be a non-negative integer")
+		}
+		request.Offset = value
+	}
+	if cursor != "" {
+		value, err := p.keyring.Verify(cursorName(c), cursor)
+		if err != nil || value == "" {
+			return PageRequest{}, NewHTTPError(http.StatusBadRequest, "Invalid cursor")
+		}
+		request.Cursor = value
+	}
+	return request, nil
+}
+
+// Page is one page of a list, returned by handlers as a Page[T] or *Page[T].
+// Generated wrappers render it as
+//
+//	{"items": [...], "page": {"limit": 20, "offset": 40, "total": 95}}
+//
+// with Link headers (RFC 8288) to the next and previous pages. Build it with
+// NewOffsetPage or NewCursorPage.
+type Page[T any] struct {
+	// Items are the items of the page
+	Items []T
+
+	// Request is the request the page answers
+	Request PageRequest
+
+	// Total is the number of items of all pages, or -1 if unknown
+	Total int
+
+	// NextCursor and PrevCursor are the handler's cursors of the adjacent
+	// pages, or "" if there are none. Their pages are linked with signed
+	// cursors instead of offsets.
+	NextCursor string
+	PrevCursor string
+}
+
+// NewOffsetPage returns the page of items at request's offset. Pass a
+// negative total if it is unknown; the next page is then linked whenever
+// the page is full.
+func NewOffsetPage[T any](request PageRequest, items []T, total int) Page[T] {
+	return Page[T]{Items: items, Request: request, Total: max(total, -1)}
+}
+
+// NewCursorPage returns a page of items with the cursors of the pages after
+// and before it, "" for none
+func NewCursorPage[T any](request PageRequest, items []T, next, prev string) Page[T] {
+	return Page[T]{Items: items, Request: request, Total: -1, NextCursor: next, PrevCursor: prev}
+}
+
+// PageResult is a Page[T] or *Page[T] returned by a handler
+type PageResult interface {
+	pageView() pageView
+}
+
+// pageView is a Page without its item type
+type pageView struct {
+	items      any
+	count      int
+	request    PageRequest
+	total      int
+	nextCursor string
+	prevCursor string
+}
+
+func (p Page[T]) pageView() pageView {
+	items := p.Items
+	if items == nil {
+		items = []T{}
+	}
+	return pageView{
+		items:      items,
+		count:      len(p.Items),
+		request:    p.Request,
+		total:      p.Total,
+		nextCursor: p.NextCursor,
+		prevCursor: p.PrevCursor,
+	}
+}
+
+// usesCursors reports whether the page links its neighbours by cursor
+func (v pageView) usesCursors() bool {
+	return v.request.Cursor != "" || v.nextCursor != "" || v.prevCursor != ""
+}
+
+// pageBody is the JSON rendering of a page
+type pageBody struct {
+	Items any          `json:"items"`
+	Page  pageMetadata `json:"page"`
+}
+
+// pageMetadata describes a rendered page
+type pageMetadata struct {
+	Limit      int    `json:"limit"`
+	Offset     *int   `json:"offset,omitempty"`
+	Total      *int   `json:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// WritePage renders a page returned by a handler, with Link headers to the
+// next and previous pages of the current route. Generated wrappers call it
+// for handlers returning a Page.
+func (p *Pagination) WritePage(c RequestContext, result PageResult) error {
+	if result == nil {
+		return NewHTTPError(http.StatusInternalServerError, "handler returned nil page")
+	}
+	if value := reflect.ValueOf(result); value.Kind() == reflect.Pointer && value.IsNil() {
+		return NewHTTPError(http.StatusInternalServerError, "handler returned nil page")
+	}
+	page := result.pageView()
+
+	limit := page.request.Limit
+	if limit <= 0 {
+		limit = p.config.DefaultLimit
+	}
+	metadata := pageMetadata{Limit: limit}
+	if page.total >= 0 {
+		metadata.Total = &page.total
+	}
+
+	var next, prev url.Values
+	if page.usesCursors() {
+		name := cursorName(c)
+		for _, link := range []struct {
+			cursor string
+			field  *string
+			values *url.Values
+		}{
+			{page.nextCursor, &metadata.NextCursor, &next},
+			{page.prevCursor, &metadata.PrevCursor, &prev},
+		} {
+			if link.cursor == "" {
+				continue
+			}
+			signed, err := p.keyring.Sign(name, link.cursor)
+			if err != nil {
+				return err
+			}
+			*link.field = signed
+			*link.values = url.Values{"limit": {strconv.Itoa(limit)}, "cursor": {signed}}
+		}
+	} else {
+		offset := page.request.Offset
+		metadata.Offset = &offset
+		if (page.total >= 0 && offset+page.count < page.total) || (page.total < 0 && page.count >= limit) {
+			next = url.Values{"limit": {strconv.Itoa(limit)}, "offset": {strconv.Itoa(offset + page.count)}}
+		}
+		if offset > 0 {
+			prev = url.Values{"limit": {strconv.Itoa(limit)}, "offset": {strconv.Itoa(max(offset-limit, 0))}}
+		}
+	}
+
+	var links []string
+	if next != nil {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(c, next)))
+	}
+	if prev != nil {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageURL(c, prev)))
+	}
+	if len(links) > 0 {
+		c.Response().SetHeader("Link", strings.Join(links, ", "))
+	}
+
+	return c.Response().JSON(http.StatusOK, pageBody{Items: page.items, Page: metadata})
+}
+
+// pageURL returns the URL of another page of the current route: its path,
+// and its query with the paging parameters replaced
+func pageURL(c RequestContext, paging url.Values) string {
+	query := url.Values{}
+	for key, values := range c.QueryParams() {
+		if key != "limit" && key != "offset" && key != "cursor" {
+			query[key] = values
+		}
+	}
+	for key, values := range paging {
+		query[key] = values
+	}
+	return listPath(c) + "?" + query.Encode()
+}
+
+// listPath returns the path of the current list, built from the route
+// template and path parameters
+func listPath(c RequestContext) string {
+	match, ok := GetRouteMatch(c)
+	if !ok {
+		return c.Path()
+	}
+	params := make(map[string]string)
+	for _, part := range NewAxonPath(match.Path).Parts() {
+		switch part.Type {
+		case ParameterPart:
+			params[part.Value] = c.Param(part.Value)
+		case WildcardPart:
+			params["*"] = c.Param("*")
+		}
+	}
+	built, err := NewAxonPath(match.Path).Build(params)
+	if err != nil {
+		return c.Path()
+	}
+	return built
+}
+
+// cursorName binds cursors to the list they were issued for, including its
+// path parameters, so that they cannot be replayed on another list
+func cursorName(c RequestContext) string {
+	return "axon.cursor:" + listPath(c)
+}
@@ -0,0 +1,117 @@
+package axon
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPagination(t *testing.T) *Pagination {
+	keyring, err := NewKeyring([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	return NewPagination(PaginationConfig{DefaultLimit: 10, MaxLimit: 50, Keyring: keyring})
+}
+
+func pageRequestContext(route string, query map[string]string) *valueRequestContext {
+	c := newValueRequestContext()
+	c.path = "/orgs/7/users"
+	c.params["org"] = "7"
+	c.query = query
+	c.Set(RouteContextKey, RouteMatch{Method: http.MethodGet, Path: route})
+	return c
+}
+
+func TestPagination_Bind(t *testing.T) {
+	p := newTestPagination(t)
+
+	req, err := p.Bind(pageRequestContext("/orgs/{org}/users", map[string]string{}))
+	require.NoError(t, err)
+	assert.Equal(t, PageRequest{Limit: 10}, req)
+
+	req, err = p.Bind(pageRequestContext("/orgs/{org}/users", map[string]string{"limit": "500", "offset": "20"}))
+	require.NoError(t, err)
+	assert.Equal(t, PageRequest{Limit: 50, Offset: 20}, req, "limits are capped")
+
+	tests := []struct {
+		name  string
+		query map[string]string
+	}{
+		{"zero limit", map[string]string{"limit": "0"}},
+		{"invalid limit", map[string]string{"limit": "ten"}},
+		{"negative offset", map[string]string{"offset": "-1"}},
+		{"offset and cursor", map[string]string{"offset": "1", "cursor": "x"}},
+		{"forged cursor", map[string]string{"cursor": "42"}},
+	}
+	for _, tt := range tests {
+		_, err := p.Bind(pageRequestContext("/orgs/{org}/users", tt.query))
+		assert.Equal(t, http.StatusBadRequest, ErrorStatus(err), tt.name)
+	}
+}
+
+func TestPagination_WriteOffsetPage(t *testing.T) {
+	p := newTestPagination(t)
+	c := pageRequestContext("/orgs/{org}/users", map[string]string{"limit": "10", "offset": "10", "sort": "name"})
+	req, err := p.Bind(c)
+	require.NoError(t, err)
+
+	require.NoError(t, p.WritePage(c, NewOffsetPage(req, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 25)))
+	assert.Equal(t, http.StatusOK, c.response.status)
+	assert.Equal(t,
+		`</orgs/7/users?limit=10&offset=20&sort=name>; rel="next", </orgs/7/users?limit=10&offset=0&sort=name>; rel="prev"`,
+		c.response.headers.Get("Link"))
+
+	// The last page has no next link
+	c = pageRequestContext("/orgs/{org}/users", map[string]string{})
+	require.NoError(t, p.WritePage(c, NewOffsetPage(PageRequest{Limit: 10, Offset: 20}, []int{1, 2, 3, 4, 5}, 25)))
+	assert.Equal(t, `</orgs/7/users?limit=10&offset=10>; rel="prev"`, c.response.headers.Get("Link"))
+
+	// Without a total, full pages link to the next page
+	c = pageRequestContext("/orgs/{org}/users", map[string]string{})
+	require.NoError(t, p.WritePage(c, &Page[int]{Items: []int{1, 2}, Request: PageRequest{Limit: 2}, Total: -1}))
+	assert.Equal(t, `</orgs/7/users?limit=2&offset=2>; rel="next"`, c.response.headers.Get("Link"))
+
+	c = pageRequestContext("/orgs/{org}/users", map[string]string{})
+	require.NoError(t, p.WritePage(c, NewOffsetPage[int](PageRequest{Limit: 10}, nil, 0)))
+	assert.Empty(t, c.response.headers.Get("Link"))
+}
+
+func TestPagination_Cursors(t *testing.T) {
+	p := newTestPagination(t)
+	c := pageRequestContext("/orgs/{org}/users", map[string]string{})
+	req, err := p.Bind(c)
+	require.NoError(t, err)
+
+	require.NoError(t, p.WritePage(c, NewCursorPage(req, []string{"a"}, "id:42", "")))
+	link := c.response.headers.Get("Link")
+	require.Contains(t, link, `rel="next"`)
+	assert.NotContains(t, link, "id:42", "cursors are signed, not sent as is")
+
+	signed, err := p.keyring.Sign("axon.cursor:/orgs/7/users", "id:42")
+	require.NoError(t, err)
+	req, err = p.Bind(pageRequestContext("/orgs/{org}/users", map[string]string{"cursor": signed}))
+	require.NoError(t, err)
+	assert.Equal(t, "id:42", req.Cursor)
+
+	// Cursors are only valid on the route that issued them
+	_, err = p.Bind(pageRequestContext("/orgs/{org}/groups", map[string]string{"cursor": signed}))
+	assert.Equal(t, http.StatusBadRequest, ErrorStatus(err))
+
+	// Nor on the same route with other path parameters
+	other := pageRequestContext("/orgs/{org}/users", map[string]string{"cursor": signed})
+	other.params["org"] = "8"
+	_, err = p.Bind(other)
+	assert.Equal(t, http.StatusBadRequest, ErrorStatus(err))
+
+	// Nor with another key
+	_, err = NewPagination(PaginationConfig{}).Bind(pageRequestContext("/orgs/{org}/users", map[string]string{"cursor": signed}))
+	assert.Equal(t, http.StatusBadRequest, ErrorStatus(err))
+}
+
+func TestPagination_WriteNilPage(t *testing.T) {
+	p := newTestPagination(t)
+	var page *Page[int]
+	err := p.WritePage(pageRequestContext("/orgs/{org}/users", nil), page)
+	assert.Equal(t, http.StatusInternalServerError, ErrorStatus(err))
+}
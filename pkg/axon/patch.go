@@ -0,0 +1,699 @@
+package axon
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MergePatchContentType is the media type of JSON Merge Patch (RFC 7386)
+	// documents
+	MergePatchContentType = "application/merge-patch+json"
+
+	// JSONPatchContentType is the media type of JSON Patch (RFC 6902) documents
+	JSONPatchContentType = "application/json-patch+json"
+)
+
+// PatchKind is the format of a Patch document
+type PatchKind int
+
+const (
+	// MergePatch is a JSON Merge Patch: an object holding the members to
+	// change, where null removes a member
+	MergePatch PatchKind = iota
+
+	// JSONPatch is a JSON Patch: a list of operations on JSON Pointer paths
+	JSONPatch
+)
+
+// PatchOperation is one operation of a JSON Patch document
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Patch is the body of a PATCH request for a resource of type T. Unlike a
+// plain struct body, it tells omitted members from members set to their zero
+// value. Handlers take a Patch[T] and apply it onto the stored resource:
+//
+//	//axon::route PATCH /users/{id:int}
+//	func (c *UserController) PatchUser(id int, patch axon.Patch[models.User]) (*models.User, error) {
+//		user, err := c.UserService.GetUser(id)
+//		if err != nil {
+//			return nil, err
+//		}
+//		if err := patch.Apply(user); err != nil {
+//			return nil, err
+//		}
+//		return user, c.UserService.SaveUser(user)
+//	}
+//
+// Bodies sent as application/merge-patch+json or application/json are read
+// as JSON Merge Patch, and bodies sent as application/json-patch+json as
+// JSON Patch.
+type Patch[T any] struct {
+	kind       PatchKind
+	document   map[string]any // the merge patch
+	operations []PatchOperation
+}
+
+// BindPatch reads the request body into patch. Other content types than JSON
+// Merge Patch and JSON Patch are rejected with 415 and an Accept-Patch header,
+// and malformed documents with 400. Generated wrappers call it for Patch[T]
+// handler parameters.
+func BindPatch[T any](c RequestContext, patch *Patch[T]) error {
+	mediaType, _, _ := mime.ParseMediaType(c.Request().ContentType())
+	switch mediaType {
+	case MergePatchContentType, "application/json":
+		var document map[string]any
+		if err := decodeJSONNumbers(c.Request().Body(), &document); err != nil || document == nil {
+			return NewHTTPError(http.StatusBadRequest, "Merge patch must be a JSON object", err)
+		}
+		*patch = Patch[T]{kind: MergePatch, document: document}
+	case JSONPatchContentType:
+		var operations []PatchOperation
+		if err := json.Unmarshal(c.Request().Body(), &operations); err != nil || operations == nil {
+			return NewHTTPError(http.StatusBadRequest, "JSON Patch must be an array of operations", err)
+		}
+		for i, op := range operations {
+			if err := op.validate(); err != nil {
+				return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Patch operation %d: %s", i, err.Error()))
+			}
+		}
+		*patch = Patch[T]{kind: JSONPatch, operations: operations}
+	default:
+		c.Response().SetHeader("Accept-Patch", MergePatchContentType+", "+JSONPatchContentType)
+		return NewHTTPError(http.StatusUnsupportedMediaType,
+			fmt.Sprintf("Patch must be sent as %s or %s", MergePatchContentType, JSONPatchContentType))
+	}
+	return nil
+}
+
+// NewMergePatch returns a Patch[T] of a JSON Merge Patch document, e.g. to
+// test handlers
+func NewMergePatch[T any](document []byte) (Patch[T], error) {
+	var merge map[string]any
+	if err := decodeJSONNumbers(document, &merge); err != nil {
+		return Patch[T]{}, err
+	}
+	if merge == nil {
+		return Patch[T]{}, fmt.Errorf("merge patch must be a JSON object")
+	}
+	return Patch[T]{kind: MergePatch, document: merge}, nil
+}
+
+// NewJSONPatch returns a Patch[T] of JSON Patch operations, e.g. to test
+// handlers
+func NewJSONPatch[T any](operations ...PatchOperation) (Patch[T], error) {
+	for i, op := range operations {
+		if err := op.validate(); err != nil {
+			return Patch[T]{}, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return Patch[T]{kind: JSONPatch, operations: operations}, nil
+}
+
+// Kind returns the format of the patch
+func (p Patch[T]) Kind() PatchKind {
+	return p.kind
+}
+
+// Operations returns the operations of a JSON Patch, or nil for a merge patch
+func (p Patch[T]) Operations() []PatchOperation {
+	return p.operations
+}
+
+// Has reports whether the patch changes the value at path, a JSON Pointer
+// such as "/address/city", or something within it or above it. The members
+// of a merge patch are present even when set to null or to a zero value.
+func (p Patch[T]) Has(path string) bool {
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return false
+	}
+
+	if p.kind == MergePatch {
+		var node any = p.document
+		for _, token := range tokens {
+			object, ok := node.(map[string]any)
+			if !ok {
+				// A value above path replaces or removes it
+				return true
+			}
+			if node, ok = object[token]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, op := range p.operations {
+		if op.Op == "test" {
+			continue
+		}
+		if pointersOverlap(op.Path, tokens) || (op.Op == "move" && pointersOverlap(op.From, tokens)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fields returns the top-level members the patch changes, by their JSON name
+func (p Patch[T]) Fields() []string {
+	if p.kind == MergePatch {
+		fields := make([]string, 0, len(p.document))
+		for name := range p.document {
+			fields = append(fields, name)
+		}
+		sort.Strings(fields)
+		return fields
+	}
+
+	var fields []string
+	add := func(path string) {
+		if tokens, err := parsePointer(path); err == nil && len(tokens) > 0 && !slices.Contains(fields, tokens[0]) {
+			fields = append(fields, tokens[0])
+		}
+	}
+	for _, op := range p.operations {
+		if op.Op == "test" {
+			continue
+		}
+		add(op.Path)
+		if op.Op == "move" {
+			add(op.From)
+		}
+	}
+	return fields
+}
+
+// Apply applies the patch onto target, through its JSON representation.
+// Fields hidden from JSON are kept. A JSON Patch test operation that fails
+// is answered with 409 Conflict; operations on missing paths, and patches
+// leaving a document that does not decode into T, with 422.
+func (p Patch[T]) Apply(target *T) error {
+	current, err := json.Marshal(target)
+	if err != nil {
+		return fmt.Errorf("axon: encoding patch target: %w", err)
+	}
+	var document any
+	if err := decodeJSONNumbers(current, &document); err != nil {
+		return fmt.Errorf("axon: decoding patch target: %w", err)
+	}
+
+	if p.kind == MergePatch {
+		document = mergePatch(document, p.document)
+	} else {
+		for i, op := range p.operations {
+			if document, err = op.apply(document); err != nil {
+				return operationError(i, op, err)
+			}
+		}
+	}
+
+	patched, err := json.Marshal(document)
+	if err != nil {
+		return fmt.Errorf("axon: encoding patched document: %w", err)
+	}
+	result := *target
+	zeroJSONFields(reflect.ValueOf(&result).Elem())
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&result); err != nil {
+		return NewHTTPError(http.StatusUnprocessableEntity, "Patched document is invalid: "+err.Error(), err)
+	}
+	*target = result
+	return nil
+}
+
+// mergePatch applies a merge patch onto target as RFC 7386 describes
+func mergePatch(target any, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = make(map[string]any, len(patchObject))
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+		} else {
+			targetObject[name] = mergePatch(targetObject[name], value)
+		}
+	}
+	return targetObject
+}
+
+// validate checks that the operation is well formed, without a document
+func (op PatchOperation) validate() error {
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return fmt.Errorf("%s requires a value", op.Op)
+		}
+	case "move", "copy":
+		if _, err := parsePointer(op.From); err != nil {
+			return fmt.Errorf("invalid from %q: %w", op.From, err)
+		}
+		if op.Op == "move" && op.From != op.Path && strings.HasPrefix(op.Path, op.From+"/") {
+			return fmt.Errorf("cannot move %q into itself", op.From)
+		}
+	case "remove":
+	default:
+		return fmt.Errorf("unknown op %q", op.Op)
+	}
+	if _, err := parsePointer(op.Path); err != nil {
+		return fmt.Errorf("invalid path %q: %w", op.Path, err)
+	}
+	return nil
+}
+
+// patchTestFailed is the error of a JSON Patch test operation that failed
+type patchTestFailed struct{}
+
+func (patchTestFailed) Error() string { return "value does not match" }
+
+// apply applies the operation onto document, returning the new document
+func (op PatchOperation) apply(document any) (any, error) {
+	path, _ := parsePointer(op.Path)
+	switch op.Op {
+	case "add":
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		return updatePointer(document, path, func(container any, token string) (any, error) {
+			return addMember(container, token, value)
+		})
+	case "remove":
+		return updatePointer(document, path, func(container any, token string) (any, error) {
+			container, _, err := removeMember(container, token)
+			return container, err
+		})
+	case "replace":
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := getPointer(document, path); err != nil {
+			return nil, err
+		}
+		document, err = updatePointer(document, path, func(container any, token string) (any, error) {
+			container, _, err := removeMember(container, token)
+			return container, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return updatePointer(document, path, func(container any, token string) (any, error) {
+			return addMember(container, token, value)
+		})
+	case "move", "copy":
+		from, _ := parsePointer(op.From)
+		value, err := getPointer(document, from)
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+		if op.Op == "move" {
+			if document, err = updatePointer(document, from, func(container any, token string) (any, error) {
+				container, _, err := removeMember(container, token)
+				return container, err
+			}); err != nil {
+				return nil, err
+			}
+		} else {
+			value = copyJSON(value)
+		}
+		return updatePointer(document, path, func(container any, token string) (any, error) {
+			return addMember(container, token, value)
+		})
+	case "test":
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		actual, err := getPointer(document, path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(actual, value) {
+			return nil, patchTestFailed{}
+		}
+		return document, nil
+	}
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+// value decodes the value of the operation
+func (op PatchOperation) value() (any, error) {
+	var value any
+	if err := decodeJSONNumbers(op.Value, &value); err != nil {
+		return nil, fmt.Errorf("invalid value: %w", err)
+	}
+	return value, nil
+}
+
+// operationError returns the HTTP error of the i-th operation failing
+func operationError(i int, op PatchOperation, err error) error {
+	message := fmt.Sprintf("Patch operation %d (%s %s) failed: %s", i, op.Op, op.Path, err.Error())
+	if _, ok := err.(patchTestFailed); ok {
+		return NewHTTPError(http.StatusConflict, message, err)
+	}
+	return NewHTTPError(http.StatusUnprocessableEntity, message, err)
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("JSON Pointer must start with /")
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// pointersOverlap reports whether pointer is tokens, or a location within or
+// above it
+func pointersOverlap(pointer string, tokens []string) bool {
+	other, err := parsePointer(pointer)
+	if err != nil {
+		return false
+	}
+	n := min(len(other), len(tokens))
+	return slices.Equal(other[:n], tokens[:n])
+}
+
+// getPointer returns the value at the location of tokens
+func getPointer(document any, tokens []string) (any, error) {
+	node := document
+	for _, token := range tokens {
+		var err error
+		if node, err = member(node, token); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+// updatePointer replaces the container of the last token with what update
+// returns for it, and returns the new document
+func updatePointer(document any, tokens []string, update func(container any, token string) (any, error)) (any, error) {
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("the document root cannot be changed")
+	}
+	if len(tokens) == 1 {
+		return update(document, tokens[0])
+	}
+	child, err := member(document, tokens[0])
+	if err != nil {
+		return nil, err
+	}
+	if child, err = updatePointer(child, tokens[1:], update); err != nil {
+		return nil, err
+	}
+	switch container := document.(type) {
+	case map[string]any:
+		container[tokens[0]] = child
+	case []any:
+		index, _ := arrayIndex(tokens[0], len(container)-1)
+		container[index] = child
+	}
+	return document, nil
+}
+
+// member returns the member token of an object or array
+func member(container any, token string) (any, error) {
+	switch container := container.(type) {
+	case map[string]any:
+		value, ok := container[token]
+		if !ok {
+			return nil, fmt.Errorf("member %q does not exist", token)
+		}
+		return value, nil
+	case []any:
+		index, err := arrayIndex(token, len(container)-1)
+		if err != nil {
+			return nil, err
+		}
+		return container[index], nil
+	}
+	return nil, fmt.Errorf("%q is not in an object or array", token)
+}
+
+// addMember sets the member token of an object, or inserts it into an array
+func addMember(container any, token string, value any) (any, error) {
+	switch container := container.(type) {
+	case map[string]any:
+		container[token] = value
+		return container, nil
+	case []any:
+		if token == "-" {
+			return append(container, value), nil
+		}
+		index, err := arrayIndex(token, len(container))
+		if err != nil {
+			return nil, err
+		}
+		return slices.Insert(container, index, value), nil
+	}
+	return nil, fmt.Errorf("%q is not in an object or array", token)
+}
+
+// removeMember removes the member token of an object or array
+func removeMember(container any, token string) (any, any, error) {
+	value, err := member(container, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch container := container.(type) {
+	case map[string]any:
+		delete(container, token)
+		return container, value, nil
+	case []any:
+		index, _ := arrayIndex(token, len(container)-1)
+		return slices.Delete(container, index, index+1), value, nil
+	}
+	return nil, nil, fmt.Errorf("%q is not in an object or array", token)
+}
+
+// arrayIndex parses an array index token, which must not exceed last
+func arrayIndex(token string, last int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if index > last {
+		return 0, fmt.Errorf("array index %d is out of range", index)
+	}
+	return index, nil
+}
+
+// jsonEqual reports whether two decoded JSON values are equal, comparing
+// numbers by value
+func jsonEqual(a, b any) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if a == b {
+			return true
+		}
+		af, errA := a.Float64()
+		bf, errB := b.Float64()
+		return errA == nil && errB == nil && af == bf
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for name, value := range a {
+			other, ok := b[name]
+			if !ok || !jsonEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !jsonEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+// copyJSON deep copies a decoded JSON value
+func copyJSON(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(value))
+		for name, member := range value {
+			copied[name] = copyJSON(member)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(value))
+		for i, member := range value {
+			copied[i] = copyJSON(member)
+		}
+		return copied
+	}
+	return value
+}
+
+// decodeJSONNumbers decodes data into v, keeping numbers as json.Number so
+// that large integers survive a patch
+func decodeJSONNumbers(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return fmt.Errorf("unexpected data after the JSON value")
+	}
+	return nil
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// zeroJSONFields zeroes the fields of a struct that its JSON representation
+// holds, so that decoding the patched document removes what the patch
+// removed while fields hidden from JSON are kept
+func zeroJSONFields(v reflect.Value) {
+	if v.Kind() != reflect.Struct || unmarshalsItself(v.Type()) {
+		v.SetZero()
+		return
+	}
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct {
+			zeroJSONFields(v.Field(i))
+		} else if field.IsExported() {
+			v.Field(i).SetZero()
+		}
+	}
+}
+
+// unmarshalsItself reports whether a type decodes its own JSON
+func unmarshalsItself(t reflect.Type) bool {
+	pointer := reflect.PointerTo(t)
+	return pointer.Implements(jsonUnmarshalerType) || pointer.Implements(textUnmarshalerType)
+}
+
+// Optional is a field of a request body that tells an omitted member from a
+// member set to null and from a member with a value. Use it in DTOs of
+// partial updates:
+//
+//	type UpdateUserRequest struct {
+//		Name  axon.Optional[string]  `json:"name,omitzero"`
+//		Email axon.Optional[*string] `json:"email,omitzero"`
+//	}
+//
+// The omitzero option leaves omitted fields out when the DTO is encoded.
+type Optional[T any] struct {
+	value   T
+	present bool
+	null    bool
+}
+
+// NewOptional returns an Optional holding value
+func NewOptional[T any](value T) Optional[T] {
+	return Optional[T]{value: value, present: true}
+}
+
+// NullOptional returns an Optional set to null
+func NullOptional[T any]() Optional[T] {
+	return Optional[T]{present: true, null: true}
+}
+
+// IsPresent reports whether the member was sent, as a value or as null
+func (o Optional[T]) IsPresent() bool {
+	return o.present
+}
+
+// IsNull reports whether the member was sent as null
+func (o Optional[T]) IsNull() bool {
+	return o.null
+}
+
+// Get returns the value and whether the member was sent with one
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.present && !o.null
+}
+
+// OrElse returns the value, or fallback if the member was omitted or null
+func (o Optional[T]) OrElse(fallback T) T {
+	if value, ok := o.Get(); ok {
+		return value
+	}
+	return fallback
+}
+
+// ApplyTo sets *dst to the value if the member was sent, or to the zero
+// value if it was null; *dst is left alone if the member was omitted
+func (o Optional[T]) ApplyTo(dst *T) {
+	if o.present {
+		*dst = o.value
+	}
+}
+
+// IsZero reports whether the member was omitted, for the omitzero option
+func (o Optional[T]) IsZero() bool {
+	return !o.present
+}
+
+// MarshalJSON encodes the value, or null
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.present || o.null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON decodes a present member
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	var value T
+	o.present = true
+	o.null = string(data) == "null"
+	if !o.null {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+	}
+	o.value = value
+	return nil
+}
@@ -0,0 +1,267 @@
+package axon
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type patchAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type patchUser struct {
+	ID       int          `json:"id"`
+	Name     string       `json:"name"`
+	Tags     []string     `json:"tags"`
+	Address  patchAddress `json:"address"`
+	Nickname *string      `json:"nickname"`
+	Password string       `json:"-"`
+}
+
+func newPatchUser() patchUser {
+	nickname := "al"
+	return patchUser{
+		ID:       1,
+		Name:     "Alice",
+		Tags:     []string{"a", "b"},
+		Address:  patchAddress{City: "Paris", Zip: "75001"},
+		Nickname: &nickname,
+		Password: "secret",
+	}
+}
+
+func patchRequest(contentType, body string) *valueRequestContext {
+	c := newValueRequestContext().withHeader("Content-Type", contentType)
+	c.method = http.MethodPatch
+	c.request.body = []byte(body)
+	return c
+}
+
+func TestBindPatch(t *testing.T) {
+	var patch Patch[patchUser]
+	require.NoError(t, BindPatch(patchRequest(MergePatchContentType, `{"name":"Bob","nickname":null}`), &patch))
+	assert.Equal(t, MergePatch, patch.Kind())
+	assert.Equal(t, []string{"name", "nickname"}, patch.Fields())
+
+	require.NoError(t, BindPatch(patchRequest("application/json; charset=utf-8", `{"name":"Bob"}`), &patch))
+	assert.Equal(t, MergePatch, patch.Kind(), "plain JSON is read as a merge patch")
+
+	require.NoError(t, BindPatch(patchRequest(JSONPatchContentType, `[{"op":"replace","path":"/name","value":"Bob"}]`), &patch))
+	assert.Equal(t, JSONPatch, patch.Kind())
+	assert.Equal(t, []PatchOperation{{Op: "replace", Path: "/name", Value: json.RawMessage(`"Bob"`)}}, patch.Operations())
+
+	c := patchRequest("text/plain", `name=Bob`)
+	err := BindPatch(c, &patch)
+	assert.Equal(t, http.StatusUnsupportedMediaType, ErrorStatus(err))
+	assert.Equal(t, MergePatchContentType+", "+JSONPatchContentType, c.response.headers.Get("Accept-Patch"))
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"merge patch array", MergePatchContentType, `[1]`},
+		{"merge patch null", MergePatchContentType, `null`},
+		{"trailing data", MergePatchContentType, `{} {}`},
+		{"json patch object", JSONPatchContentType, `{"op":"remove","path":"/name"}`},
+		{"unknown op", JSONPatchContentType, `[{"op":"merge","path":"/name"}]`},
+		{"missing value", JSONPatchContentType, `[{"op":"add","path":"/name"}]`},
+		{"invalid path", JSONPatchContentType, `[{"op":"remove","path":"name"}]`},
+		{"move into itself", JSONPatchContentType, `[{"op":"move","from":"/address","path":"/address/city"}]`},
+	}
+	for _, tt := range tests {
+		err := BindPatch(patchRequest(tt.contentType, tt.body), &patch)
+		assert.Equal(t, http.StatusBadRequest, ErrorStatus(err), tt.name)
+	}
+}
+
+func TestPatch_Has(t *testing.T) {
+	merge, err := NewMergePatch[patchUser]([]byte(`{"name":"","address":{"city":"Lyon"},"nickname":null}`))
+	require.NoError(t, err)
+	assert.True(t, merge.Has("/name"), "members set to zero values are present")
+	assert.True(t, merge.Has("/nickname"), "members set to null are present")
+	assert.True(t, merge.Has("/address"))
+	assert.True(t, merge.Has("/address/city"))
+	assert.False(t, merge.Has("/address/zip"))
+	assert.False(t, merge.Has("/tags"))
+	assert.False(t, merge.Has("tags"), "paths are JSON Pointers")
+
+	ops, err := NewJSONPatch[patchUser](
+		PatchOperation{Op: "test", Path: "/id", Value: json.RawMessage(`1`)},
+		PatchOperation{Op: "add", Path: "/tags/-", Value: json.RawMessage(`"c"`)},
+		PatchOperation{Op: "move", From: "/address/zip", Path: "/name"},
+	)
+	require.NoError(t, err)
+	assert.False(t, ops.Has("/id"), "tests do not change anything")
+	assert.True(t, ops.Has("/tags"))
+	assert.True(t, ops.Has("/address/zip"), "moves remove their source")
+	assert.True(t, ops.Has("/address"))
+	assert.False(t, ops.Has("/address/city"))
+	assert.Equal(t, []string{"tags", "name", "address"}, ops.Fields())
+}
+
+func TestPatch_ApplyMergePatch(t *testing.T) {
+	user := newPatchUser()
+	patch, err := NewMergePatch[patchUser]([]byte(`{"name":"Bob","address":{"zip":null},"nickname":null,"tags":["x"]}`))
+	require.NoError(t, err)
+	require.NoError(t, patch.Apply(&user))
+
+	assert.Equal(t, 1, user.ID, "members not in the patch are kept")
+	assert.Equal(t, "Bob", user.Name)
+	assert.Equal(t, []string{"x"}, user.Tags, "arrays are replaced")
+	assert.Equal(t, patchAddress{City: "Paris"}, user.Address, "null removes nested members")
+	assert.Nil(t, user.Nickname)
+	assert.Equal(t, "secret", user.Password, "fields hidden from JSON are kept")
+
+	// Removing a non-pointer member zeroes it
+	patch, err = NewMergePatch[patchUser]([]byte(`{"name":null}`))
+	require.NoError(t, err)
+	require.NoError(t, patch.Apply(&user))
+	assert.Empty(t, user.Name)
+
+	// Members T does not have are rejected
+	patch, err = NewMergePatch[patchUser]([]byte(`{"admin":true}`))
+	require.NoError(t, err)
+	before := user
+	err = patch.Apply(&user)
+	assert.Equal(t, http.StatusUnprocessableEntity, ErrorStatus(err))
+	assert.Equal(t, before, user, "the target is unchanged on failure")
+
+	// Values of the wrong type are rejected
+	patch, err = NewMergePatch[patchUser]([]byte(`{"id":"one"}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, ErrorStatus(patch.Apply(&user)))
+}
+
+func TestPatch_ApplyJSONPatch(t *testing.T) {
+	op := func(op, path, from, value string) PatchOperation {
+		operation := PatchOperation{Op: op, Path: path, From: from}
+		if value != "" {
+			operation.Value = json.RawMessage(value)
+		}
+		return operation
+	}
+
+	tests := []struct {
+		name   string
+		ops    []PatchOperation
+		check  func(t *testing.T, user patchUser)
+		status int
+	}{
+		{"add to array end", []PatchOperation{op("add", "/tags/-", "", `"c"`)}, func(t *testing.T, user patchUser) {
+			assert.Equal(t, []string{"a", "b", "c"}, user.Tags)
+		}, 0},
+		{"insert into array", []PatchOperation{op("add", "/tags/1", "", `"x"`)}, func(t *testing.T, user patchUser) {
+			assert.Equal(t, []string{"a", "x", "b"}, user.Tags)
+		}, 0},
+		{"remove from array", []PatchOperation{op("remove", "/tags/0", "", "")}, func(t *testing.T, user patchUser) {
+			assert.Equal(t, []string{"b"}, user.Tags)
+		}, 0},
+		{"replace nested", []PatchOperation{op("replace", "/address/city", "", `"Lyon"`)}, func(t *testing.T, user patchUser) {
+			assert.Equal(t, patchAddress{City: "Lyon", Zip: "75001"}, user.Address)
+		}, 0},
+		{"move", []PatchOperation{op("move", "/name", "/address/city", "")}, func(t *testing.T, user patchUser) {
+			assert.Equal(t, "Paris", user.Name)
+			assert.Empty(t, user.Address.City)
+		}, 0},
+		{"copy", []PatchOperation{op("copy", "/tags/-", "/name", "")}, func(t *testing.T, user patchUser) {
+			assert.Equal(t, []string{"a", "b", "Alice"}, user.Tags)
+			assert.Equal(t, "Alice", user.Name)
+		}, 0},
+		{"test then replace", []PatchOperation{
+			op("test", "/id", "", `1.0`),
+			op("test", "/address", "", `{"zip":"75001","city":"Paris"}`),
+			op("replace", "/name", "", `"Bob"`),
+		}, func(t *testing.T, user patchUser) {
+			assert.Equal(t, "Bob", user.Name)
+		}, 0},
+		{"test array member", []PatchOperation{op("test", "/tags/0", "", `"a"`)}, nil, 0},
+		{"failed test", []PatchOperation{op("replace", "/name", "", `"Bob"`), op("test", "/id", "", `2`)}, nil, http.StatusConflict},
+		{"replace missing member", []PatchOperation{op("replace", "/address/country", "", `"FR"`)}, nil, http.StatusUnprocessableEntity},
+		{"remove out of range", []PatchOperation{op("remove", "/tags/2", "", "")}, nil, http.StatusUnprocessableEntity},
+		{"add beyond array end", []PatchOperation{op("add", "/tags/3", "", `"x"`)}, nil, http.StatusUnprocessableEntity},
+		{"leading zero index", []PatchOperation{op("remove", "/tags/01", "", "")}, nil, http.StatusUnprocessableEntity},
+		{"add unknown member", []PatchOperation{op("add", "/admin", "", `true`)}, nil, http.StatusUnprocessableEntity},
+		{"replace root", []PatchOperation{op("replace", "", "", `{}`)}, nil, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := NewJSONPatch[patchUser](tt.ops...)
+			require.NoError(t, err)
+			user := newPatchUser()
+			err = patch.Apply(&user)
+			if tt.status != 0 {
+				assert.Equal(t, tt.status, ErrorStatus(err))
+				assert.Equal(t, newPatchUser(), user, "the target is unchanged on failure")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "secret", user.Password)
+			if tt.check != nil {
+				tt.check(t, user)
+			}
+		})
+	}
+}
+
+func TestParsePointer(t *testing.T) {
+	tokens, err := parsePointer("/a~1b/c~0d/~01")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/b", "c~d", "~1"}, tokens)
+
+	tokens, err = parsePointer("")
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+
+	_, err = parsePointer("a/b")
+	assert.Error(t, err)
+}
+
+func TestOptional(t *testing.T) {
+	type update struct {
+		Name     Optional[string]  `json:"name,omitzero"`
+		Nickname Optional[*string] `json:"nickname,omitzero"`
+		Age      Optional[int]     `json:"age,omitzero"`
+	}
+
+	var req update
+	require.NoError(t, json.Unmarshal([]byte(`{"name":"Bob","nickname":null}`), &req))
+
+	name, ok := req.Name.Get()
+	assert.True(t, ok)
+	assert.Equal(t, "Bob", name)
+
+	assert.True(t, req.Nickname.IsPresent())
+	assert.True(t, req.Nickname.IsNull())
+	_, ok = req.Nickname.Get()
+	assert.False(t, ok)
+
+	assert.False(t, req.Age.IsPresent())
+	assert.False(t, req.Age.IsNull())
+	assert.Equal(t, 42, req.Age.OrElse(42))
+
+	nickname := "al"
+	user := patchUser{Name: "Alice", ID: 7, Nickname: &nickname}
+	req.Name.ApplyTo(&user.Name)
+	req.Nickname.ApplyTo(&user.Nickname)
+	req.Age.ApplyTo(&user.ID)
+	assert.Equal(t, "Bob", user.Name)
+	assert.Nil(t, user.Nickname, "null clears the field")
+	assert.Equal(t, 7, user.ID, "omitted members leave the field alone")
+
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"Bob","nickname":null}`, string(data), "omitted members stay omitted")
+
+	data, err = json.Marshal(update{Age: NewOptional(3), Name: NullOptional[string]()})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":null,"age":3}`, string(data))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"age":"old"}`), &req))
+}
@@ -1,6 +1,8 @@
 package axon
 
 import (
+	"fmt"
+	"net/url"
 	"strings"
 )
 
@@ -91,6 +93,58 @@ func (p AxonPath) Parts() []AxonPathPart {
 	return parts
 }
 
+// Match reports whether a concrete request path matches this path template.
+// Parameters match a single non-empty segment and a wildcard matches the rest of the path.
+func (p AxonPath) Match(path string) bool {
+	rest := path
+	for _, part := range p.Parts() {
+		switch part.Type {
+		case StaticPart:
+			if !strings.HasPrefix(rest, part.Value) {
+				return false
+			}
+			rest = rest[len(part.Value):]
+		case ParameterPart:
+			end := strings.IndexByte(rest, '/')
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return false
+			}
+			rest = rest[end:]
+		case WildcardPart:
+			return true
+		}
+	}
+	return rest == ""
+}
+
+// Build returns a concrete path for this template, with its parameters
+// replaced by the escaped values of params. The wildcard takes params["*"].
+// Build is the reverse of Match.
+func (p AxonPath) Build(params map[string]string) (string, error) {
+	var path strings.Builder
+	for _, part := range p.Parts() {
+		switch part.Type {
+		case StaticPart:
+			path.WriteString(part.Value)
+		case ParameterPart:
+			value := params[part.Value]
+			if value == "" {
+				return "", fmt.Errorf("no value for path parameter %q", part.Value)
+			}
+			path.WriteString(url.PathEscape(value))
+		case WildcardPart:
+			segments := strings.Split(params["*"], "/")
+			for i, segment := range segments {
+				segments[i] = url.PathEscape(segment)
+			}
+			path.WriteString(strings.Join(segments, "/"))
+		}
+	}
+	return path.String(), nil
+}
 
 // NewAxonPath creates a new AxonPath from a string
 func NewAxonPath(path string) AxonPath {
@@ -0,0 +1,259 @@
+package axon
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// TrustedProxies is the set of networks whose forwarding headers are believed.
+//
+// Adapters configured with trusted proxies resolve RealIP, Scheme and Host
+// from the Forwarded header (RFC 7239) or, when it is absent, from
+// X-Forwarded-For, X-Forwarded-Proto and X-Forwarded-Host. The forwarding
+// chain is walked from the nearest hop outwards and stops at the first address
+// that is not a trusted proxy, so clients cannot spoof their address by
+// sending the headers themselves. Without trusted proxies the headers are
+// ignored and the connection's peer address, TLS state and Host are used.
+type TrustedProxies struct {
+	prefixes []netip.Prefix
+}
+
+// NewTrustedProxies creates a trusted proxy set from CIDRs ("10.0.0.0/8") or single addresses ("10.0.0.1")
+func NewTrustedProxies(cidrs ...string) (*TrustedProxies, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		if strings.Contains(cidr, "/") {
+			prefix, err := netip.ParsePrefix(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("axon: invalid trusted proxy %q: %w", cidr, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("axon: invalid trusted proxy %q: %w", cidr, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return &TrustedProxies{prefixes: prefixes}, nil
+}
+
+// Contains reports whether addr belongs to a trusted proxy
+func (p *TrustedProxies) Contains(addr netip.Addr) bool {
+	if p == nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range p.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProxyRequest is the connection information an adapter passes to Resolve
+type ProxyRequest struct {
+	// RemoteAddr is the peer address ("ip:port" or "ip")
+	RemoteAddr string
+
+	// TLS reports whether the connection to the server is encrypted
+	TLS bool
+
+	// Host is the Host header received by the server
+	Host string
+
+	// Header returns all values of a request header
+	Header func(name string) []string
+}
+
+// ClientInfo is the client side of a request as seen through trusted proxies
+type ClientInfo struct {
+	IP     string
+	Scheme string
+	Host   string
+}
+
+// Resolve determines the client address, scheme and host of a request. It is
+// safe to call on a nil *TrustedProxies, which trusts no one.
+func (p *TrustedProxies) Resolve(r ProxyRequest) ClientInfo {
+	info := ClientInfo{Scheme: "http", Host: r.Host}
+	if r.TLS {
+		info.Scheme = "https"
+	}
+
+	peer, ok := parseForwardedAddr(r.RemoteAddr)
+	if !ok {
+		info.IP = r.RemoteAddr
+		return info
+	}
+	info.IP = peer.String()
+	if !p.Contains(peer) || r.Header == nil {
+		return info
+	}
+
+	hops, fallback := forwardingHops(r.Header)
+	client := -1
+	for i := len(hops) - 1; i >= 0; i-- {
+		if !hops[i].valid {
+			break
+		}
+		client = i
+		if !p.Contains(hops[i].addr) {
+			break
+		}
+	}
+	if client == -1 {
+		return info
+	}
+
+	info.IP = hops[client].addr.String()
+	if scheme := firstNonEmpty(hops[client].proto, fallback.proto); scheme != "" {
+		info.Scheme = scheme
+	}
+	if host := firstNonEmpty(hops[client].host, fallback.host); host != "" {
+		info.Host = host
+	}
+	return info
+}
+
+// forwardingHop is one entry of the forwarding chain, nearest to the client first
+type forwardingHop struct {
+	addr  netip.Addr
+	valid bool
+	proto string
+	host  string
+}
+
+// forwardingHops reads the forwarding chain from Forwarded or, if absent, the
+// X-Forwarded-* headers. X-Forwarded-Proto and X-Forwarded-Host are matched to
+// hops when they have one value per hop; otherwise their last value (set by
+// the nearest proxy) is returned as the fallback.
+func forwardingHops(header func(string) []string) ([]forwardingHop, forwardingHop) {
+	var hops []forwardingHop
+	var fallback forwardingHop
+
+	if forwarded := splitHeaderList(header("Forwarded")); len(forwarded) > 0 {
+		for _, element := range forwarded {
+			var hop forwardingHop
+			for _, pair := range splitQuoted(element, ';') {
+				name, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+				value = strings.Trim(strings.TrimSpace(value), `"`)
+				switch strings.ToLower(name) {
+				case "for":
+					hop.addr, hop.valid = parseForwardedAddr(value)
+				case "proto":
+					hop.proto = normalizeScheme(value)
+				case "host":
+					hop.host = normalizeHost(value)
+				}
+			}
+			hops = append(hops, hop)
+		}
+		return hops, fallback
+	}
+
+	for _, value := range splitHeaderList(header("X-Forwarded-For")) {
+		var hop forwardingHop
+		hop.addr, hop.valid = parseForwardedAddr(value)
+		hops = append(hops, hop)
+	}
+
+	protos := splitHeaderList(header("X-Forwarded-Proto"))
+	hosts := splitHeaderList(header("X-Forwarded-Host"))
+	for i := range hops {
+		if len(protos) == len(hops) {
+			hops[i].proto = normalizeScheme(protos[i])
+		}
+		if len(hosts) == len(hops) {
+			hops[i].host = normalizeHost(hosts[i])
+		}
+	}
+	if len(protos) > 0 {
+		fallback.proto = normalizeScheme(protos[len(protos)-1])
+	}
+	if len(hosts) > 0 {
+		fallback.host = normalizeHost(hosts[len(hosts)-1])
+	}
+	return hops, fallback
+}
+
+// splitHeaderList splits comma-separated header values, across repeated headers
+func splitHeaderList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range splitQuoted(value, ',') {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// splitQuoted splits s on sep outside of double-quoted strings
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// parseForwardedAddr parses "ip", "ip:port", "[ipv6]" or "[ipv6]:port".
+// Obfuscated identifiers such as "unknown" or "_hidden" are not valid.
+func parseForwardedAddr(value string) (netip.Addr, bool) {
+	value = strings.TrimSpace(value)
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// normalizeScheme accepts only http and https
+func normalizeScheme(value string) string {
+	switch scheme := strings.ToLower(strings.TrimSpace(value)); scheme {
+	case "http", "https":
+		return scheme
+	}
+	return ""
+}
+
+// normalizeHost rejects values that cannot be a host[:port]
+func normalizeHost(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.ContainsAny(value, " /\\@?#\t") {
+		return ""
+	}
+	return value
+}
+
+// firstNonEmpty returns the first non-empty string
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
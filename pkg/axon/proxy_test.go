@@ -0,0 +1,149 @@
+package axon
+
+import (
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTrustedProxies(t *testing.T) {
+	proxies, err := NewTrustedProxies("10.0.0.0/8", " 192.0.2.10 ", "2001:db8::/32", "")
+	require.NoError(t, err)
+
+	assert.True(t, proxies.Contains(netip.MustParseAddr("10.1.2.3")))
+	assert.True(t, proxies.Contains(netip.MustParseAddr("::ffff:10.1.2.3")), "IPv4-mapped addresses match IPv4 networks")
+	assert.True(t, proxies.Contains(netip.MustParseAddr("192.0.2.10")))
+	assert.False(t, proxies.Contains(netip.MustParseAddr("192.0.2.11")))
+	assert.True(t, proxies.Contains(netip.MustParseAddr("2001:db8::1")))
+	assert.False(t, (*TrustedProxies)(nil).Contains(netip.MustParseAddr("10.1.2.3")))
+
+	_, err = NewTrustedProxies("10.0.0.0/33")
+	assert.Error(t, err)
+	_, err = NewTrustedProxies("proxy.internal")
+	assert.Error(t, err)
+}
+
+func TestTrustedProxies_Resolve(t *testing.T) {
+	proxies, err := NewTrustedProxies("10.0.0.0/8")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		proxies *TrustedProxies
+		remote  string
+		tls     bool
+		headers map[string][]string
+		want    ClientInfo
+	}{
+		{
+			name:   "direct connection",
+			remote: "198.51.100.7:5000",
+			tls:    true,
+			want:   ClientInfo{IP: "198.51.100.7", Scheme: "https", Host: "api.example.com"},
+		},
+		{
+			name:    "headers ignored without trusted proxies",
+			proxies: nil,
+			remote:  "10.0.0.1:5000",
+			headers: map[string][]string{"X-Forwarded-For": {"203.0.113.9"}, "X-Forwarded-Proto": {"https"}},
+			want:    ClientInfo{IP: "10.0.0.1", Scheme: "http", Host: "api.example.com"},
+		},
+		{
+			name:    "headers ignored from untrusted peer",
+			proxies: proxies,
+			remote:  "198.51.100.7:5000",
+			headers: map[string][]string{"X-Forwarded-For": {"203.0.113.9"}},
+			want:    ClientInfo{IP: "198.51.100.7", Scheme: "http", Host: "api.example.com"},
+		},
+		{
+			name:    "x-forwarded headers from trusted proxy",
+			proxies: proxies,
+			remote:  "10.0.0.1:5000",
+			headers: map[string][]string{
+				"X-Forwarded-For":   {"203.0.113.9"},
+				"X-Forwarded-Proto": {"https"},
+				"X-Forwarded-Host":  {"www.example.com"},
+			},
+			want: ClientInfo{IP: "203.0.113.9", Scheme: "https", Host: "www.example.com"},
+		},
+		{
+			name:    "spoofed entries left of the first untrusted hop are ignored",
+			proxies: proxies,
+			remote:  "10.0.0.1:5000",
+			headers: map[string][]string{"X-Forwarded-For": {"1.1.1.1, 203.0.113.9", "10.0.0.2"}},
+			want:    ClientInfo{IP: "203.0.113.9", Scheme: "http", Host: "api.example.com"},
+		},
+		{
+			name:    "all hops trusted",
+			proxies: proxies,
+			remote:  "10.0.0.1:5000",
+			headers: map[string][]string{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}},
+			want:    ClientInfo{IP: "10.0.0.3", Scheme: "http", Host: "api.example.com"},
+		},
+		{
+			name:    "unknown hop stops the walk",
+			proxies: proxies,
+			remote:  "10.0.0.1:5000",
+			headers: map[string][]string{"X-Forwarded-For": {"203.0.113.9, unknown, 10.0.0.2"}},
+			want:    ClientInfo{IP: "10.0.0.2", Scheme: "http", Host: "api.example.com"},
+		},
+		{
+			name:    "invalid forwarded scheme and host are ignored",
+			proxies: proxies,
+			remote:  "10.0.0.1:5000",
+			headers: map[string][]string{
+				"X-Forwarded-For":   {"203.0.113.9"},
+				"X-Forwarded-Proto": {"javascript"},
+				"X-Forwarded-Host":  {"evil.example/path"},
+			},
+			want: ClientInfo{IP: "203.0.113.9", Scheme: "http", Host: "api.example.com"},
+		},
+		{
+			name:    "forwarded header takes precedence",
+			proxies: proxies,
+			remote:  "10.0.0.1:5000",
+			headers: map[string][]string{
+				"Forwarded":       {`for="[2001:db8:cafe::17]:4711";proto=https;host=www.example.com, for=10.0.0.2`},
+				"X-Forwarded-For": {"198.51.100.1"},
+			},
+			want: ClientInfo{IP: "2001:db8:cafe::17", Scheme: "https", Host: "www.example.com"},
+		},
+		{
+			name:    "forwarded uses the element of the client hop",
+			proxies: proxies,
+			remote:  "10.0.0.1:5000",
+			headers: map[string][]string{
+				"Forwarded": {"for=1.1.1.1;proto=http;host=spoofed.example", "for=203.0.113.9;proto=https;host=www.example.com"},
+			},
+			want: ClientInfo{IP: "203.0.113.9", Scheme: "https", Host: "www.example.com"},
+		},
+		{
+			name:    "obfuscated forwarded identifier",
+			proxies: proxies,
+			remote:  "10.0.0.1:5000",
+			headers: map[string][]string{"Forwarded": {"for=_hidden;proto=https"}},
+			want:    ClientInfo{IP: "10.0.0.1", Scheme: "http", Host: "api.example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for name, values := range tt.headers {
+				for _, value := range values {
+					header.Add(name, value)
+				}
+			}
+			got := tt.proxies.Resolve(ProxyRequest{
+				RemoteAddr: tt.remote,
+				TLS:        tt.tls,
+				Host:       "api.example.com",
+				Header:     header.Values,
+			})
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package axon
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicError is the internal error of the 500 response that replaces a panic
+type PanicError struct {
+	// Value is the value passed to panic
+	Value any
+
+	// Stack is the stack trace of the panicking goroutine
+	Stack []byte
+}
+
+// Error implements the error interface
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Recover returns a handler that turns panics in handler into a 500 HTTPError
+// whose internal error is a *PanicError carrying the stack. Adapters wrap every
+// handler and middleware with it, so panics go through the same error pipeline
+// as returned errors. http.ErrAbortHandler is re-panicked so that net/http can
+// abort the response.
+func Recover(handler HandlerFunc) HandlerFunc {
+	return func(c RequestContext) (err error) {
+		defer func() {
+			if value := recover(); value != nil {
+				if value == http.ErrAbortHandler {
+					panic(value)
+				}
+				err = NewHTTPError(http.StatusInternalServerError, StatusText(http.StatusInternalServerError), &PanicError{Value: value, Stack: debug.Stack()})
+			}
+		}()
+		return handler(c)
+	}
+}
+
+// ErrorStatus returns the status code a handler error is answered with:
+// the code of an HTTPError or HttpError, and 500 for any other error
+func ErrorStatus(err error) int {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	var legacyErr *HttpError
+	if errors.As(err, &legacyErr) {
+		return legacyErr.StatusCode
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,58 @@
+package axon
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecover(t *testing.T) {
+	handler := Recover(func(RequestContext) error {
+		panic("boom")
+	})
+
+	err := handler(newValueRequestContext())
+	require.Error(t, err)
+
+	var httpErr *HTTPError
+	require.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusInternalServerError, httpErr.Code)
+	assert.Equal(t, "Internal Server Error", httpErr.Message)
+
+	var panicErr *PanicError
+	require.True(t, errors.As(err, &panicErr))
+	assert.Equal(t, "boom", panicErr.Value)
+	assert.Equal(t, "panic: boom", panicErr.Error())
+	assert.Contains(t, string(panicErr.Stack), "TestRecover")
+}
+
+func TestRecover_ErrorValue(t *testing.T) {
+	cause := errors.New("nil map write")
+	err := Recover(func(RequestContext) error {
+		panic(cause)
+	})(newValueRequestContext())
+
+	assert.ErrorIs(t, err, cause)
+}
+
+func TestRecover_PassesThrough(t *testing.T) {
+	want := NewHTTPError(http.StatusNotFound, "missing")
+	assert.Equal(t, want, Recover(func(RequestContext) error { return want })(newValueRequestContext()))
+	assert.NoError(t, Recover(func(RequestContext) error { return nil })(newValueRequestContext()))
+}
+
+func TestRecover_AbortHandler(t *testing.T) {
+	handler := Recover(func(RequestContext) error {
+		panic(http.ErrAbortHandler)
+	})
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() { _ = handler(newValueRequestContext()) })
+}
+
+func TestErrorStatus(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, ErrorStatus(NewHTTPError(http.StatusNotFound)))
+	assert.Equal(t, http.StatusConflict, ErrorStatus(ErrConflict("taken")))
+	assert.Equal(t, http.StatusInternalServerError, ErrorStatus(errors.New("plain")))
+}
@@ -1,7 +1,11 @@
 package axon
 
 import (
+	"fmt"
+	"io"
 	"strings"
+	"text/tabwriter"
+	"time"
 )
 
 // MiddlewareInstance represents a middleware with its name and handler
@@ -113,6 +117,43 @@ type RouteInfo struct {
 	// ParameterInstances provides access to the actual parameter instances
 	ParameterInstances []ParameterInstance
 
+	// Roles lists the roles the caller must hold one of (from -Roles)
+	Roles []string
+
+	// Permissions lists the permissions the caller must hold all of (from -Permissions)
+	Permissions []string
+
+	// NoCSRF exempts the route from CSRF protection (from -NoCSRF)
+	NoCSRF bool
+
+	// CSPPolicy names the Content-Security-Policy replacing the default (from -CSP)
+	CSPPolicy string
+
+	// CacheTTL is how long responses are cached (from -Cache); zero if not cached
+	CacheTTL time.Duration
+
+	// ETag marks routes that set ETags and answer conditional requests (from -ETag)
+	ETag bool
+
+	// Coalesce marks routes whose identical concurrent GETs share one execution (from -Coalesce)
+	Coalesce bool
+
+	// Fields marks routes whose JSON responses clients can project with ?fields= (from -Fields)
+	Fields bool
+
+	// Idempotent marks routes that replay responses of requests retried with the same Idempotency-Key (from -Idempotent)
+	Idempotent bool
+
+	// NoCompress marks routes exempt from response compression (from -Compress=false)
+	NoCompress bool
+
+	// MaxBodySize is the request body limit in bytes, 0 for none (from -MaxBodySize)
+	MaxBodySize int64
+
+	// StreamsBody marks routes that read the request body as it streams in,
+	// as a body stream or a multipart form with uploads
+	StreamsBody bool
+
 	// Handler is the actual handler function
 	Handler HandlerFunc
 }
@@ -220,6 +261,33 @@ func GetRoutesByPackage(packageName string) []RouteInfo {
 	return DefaultRouteRegistry.GetRoutesByPackage(packageName)
 }
 
+// WriteRouteTable writes a tabular summary of routes, including their authorization
+// requirements, to w. Pass GetRoutes() to list every registered endpoint.
+func WriteRouteTable(w io.Writer, routes []RouteInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tHANDLER\tMIDDLEWARE\tROLES\tPERMISSIONS")
+	for _, route := range routes {
+		fmt.Fprintf(tw, "%s\t%s\t%s.%s\t%s\t%s\t%s\n",
+			route.Method,
+			route.Path,
+			route.ControllerName,
+			route.HandlerName,
+			routeTableList(route.Middlewares),
+			routeTableList(route.Roles),
+			routeTableList(route.Permissions),
+		)
+	}
+	return tw.Flush()
+}
+
+// routeTableList formats a list for the route table, using "-" for empty lists
+func routeTableList(items []string) string {
+	if len(items) == 0 {
+		return "-"
+	}
+	return strings.Join(items, ",")
+}
+
 // GetRoutesByController returns routes for a specific controller (convenience function)
 func GetRoutesByController(controllerName string) []RouteInfo {
 	return DefaultRouteRegistry.GetRoutesByController(controllerName)
@@ -0,0 +1,141 @@
+package axon
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+
+	"go.uber.org/fx"
+)
+
+// Request context keys for the request ID and the request-scoped logger
+const (
+	RequestIDContextKey = "axon.request_id"
+	LoggerContextKey    = "axon.logger"
+)
+
+// maxRequestIDLength bounds incoming request IDs that are reused
+const maxRequestIDLength = 128
+
+// RequestIDConfig configures the request ID middleware
+type RequestIDConfig struct {
+	// Header is the request and response header carrying the ID (default: "X-Request-ID")
+	Header string
+
+	// Generator creates IDs for requests without a usable one (default: 16 random bytes, hex)
+	Generator func() string
+
+	// Logger is the base of the request-scoped logger (default: slog.Default())
+	Logger *slog.Logger
+}
+
+// RequestID is a middleware that reads the request ID header, or generates an
+// ID when it is missing or malformed, and echoes it on the response. It puts a
+// request-scoped *slog.Logger carrying the ID into the request context;
+// generated route registration adds the route template and controller to it.
+// Handlers receive the logger by declaring a *slog.Logger parameter.
+type RequestID struct {
+	config RequestIDConfig
+}
+
+// NewRequestID creates a request ID middleware
+func NewRequestID(config RequestIDConfig) *RequestID {
+	if config.Header == "" {
+		config.Header = "X-Request-ID"
+	}
+	if config.Generator == nil {
+		config.Generator = newRequestID
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+	return &RequestID{config: config}
+}
+
+// Handle implements the middleware
+func (m *RequestID) Handle(next HandlerFunc) HandlerFunc {
+	return func(c RequestContext) error {
+		id := c.Request().Header(m.config.Header)
+		if !validRequestID(id) {
+			id = m.config.Generator()
+		}
+
+		c.Set(RequestIDContextKey, id)
+		c.Response().SetHeader(m.config.Header, id)
+		c.Set(LoggerContextKey, m.config.Logger.With("request_id", id))
+		return next(c)
+	}
+}
+
+// GetRequestID returns the ID of the current request, or "" if the RequestID middleware did not run
+func GetRequestID(c RequestContext) string {
+	id, _ := c.Get(RequestIDContextKey).(string)
+	return id
+}
+
+// GetLogger returns the request-scoped logger, or slog.Default() outside of a request scope
+func GetLogger(c RequestContext) *slog.Logger {
+	if logger, ok := c.Get(LoggerContextKey).(*slog.Logger); ok && logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
+
+// validRequestID accepts non-empty IDs of printable ASCII without spaces, so
+// client-supplied values cannot break log lines or response headers
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID generates a random request ID
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		panic("axon: failed to generate request ID: " + err.Error())
+	}
+	return hex.EncodeToString(id)
+}
+
+// RequestIDParams are the fx dependencies of the request ID middleware.
+// Both are optional; an injected *slog.Logger is used unless the config sets one.
+type RequestIDParams struct {
+	fx.In
+
+	Config *RequestIDConfig `optional:"true"`
+	Logger *slog.Logger     `optional:"true"`
+}
+
+// NewRequestIDFromParams builds the middleware from fx-provided dependencies
+func NewRequestIDFromParams(p RequestIDParams) *RequestID {
+	var config RequestIDConfig
+	if p.Config != nil {
+		config = *p.Config
+	}
+	if config.Logger == nil {
+		config.Logger = p.Logger
+	}
+	return NewRequestID(config)
+}
+
+// RequestIDModule provides a *RequestID and registers it as global middleware.
+// Include it before the generated modules so it wraps every route.
+//
+//	fx.New(
+//	    fx.Provide(func(l *logging.AppLogger) *slog.Logger { return l.Logger() }),
+//	    axon.RequestIDModule,
+//	    controllers.AutogenModule,
+//	)
+var RequestIDModule = fx.Module("axon-request-id",
+	fx.Provide(NewRequestIDFromParams),
+	fx.Invoke(func(server WebServerInterface, requestID *RequestID) {
+		server.Use(requestID.Handle)
+	}),
+)
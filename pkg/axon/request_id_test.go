@@ -0,0 +1,144 @@
+package axon
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logRecords decodes the JSON lines written by a slog.JSONHandler
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestRequestID_GeneratesID(t *testing.T) {
+	middleware := NewRequestID(RequestIDConfig{})
+	c := newValueRequestContext()
+
+	var seen string
+	err := middleware.Handle(func(c RequestContext) error {
+		seen = GetRequestID(c)
+		return nil
+	})(c)
+	require.NoError(t, err)
+
+	assert.Len(t, seen, 32)
+	assert.Equal(t, seen, c.response.headers.Get("X-Request-ID"))
+
+	other := newValueRequestContext()
+	require.NoError(t, middleware.Handle(func(RequestContext) error { return nil })(other))
+	assert.NotEqual(t, seen, GetRequestID(other))
+}
+
+func TestRequestID_ReusesIncomingID(t *testing.T) {
+	middleware := NewRequestID(RequestIDConfig{})
+	c := newValueRequestContext().withHeader("X-Request-ID", "abc-123")
+
+	require.NoError(t, middleware.Handle(func(RequestContext) error { return nil })(c))
+
+	assert.Equal(t, "abc-123", GetRequestID(c))
+	assert.Equal(t, "abc-123", c.response.headers.Get("X-Request-ID"))
+}
+
+func TestRequestID_ReplacesInvalidID(t *testing.T) {
+	middleware := NewRequestID(RequestIDConfig{
+		Header:    "X-Correlation-ID",
+		Generator: func() string { return "generated" },
+	})
+
+	tests := []string{
+		"",
+		"has space",
+		"line\nbreak",
+		"caf\xc3\xa9",
+		strings.Repeat("a", maxRequestIDLength+1),
+	}
+	for _, incoming := range tests {
+		c := newValueRequestContext().withHeader("X-Correlation-ID", incoming)
+		require.NoError(t, middleware.Handle(func(RequestContext) error { return nil })(c))
+		assert.Equal(t, "generated", GetRequestID(c), "incoming %q", incoming)
+		assert.Equal(t, "generated", c.response.headers.Get("X-Correlation-ID"))
+	}
+}
+
+func TestRequestID_ScopedLogger(t *testing.T) {
+	var buf bytes.Buffer
+	middleware := NewRequestID(RequestIDConfig{
+		Logger: slog.New(slog.NewJSONHandler(&buf, nil)),
+	})
+	route := WithRouteMatch(RouteMatch{
+		Method:     "GET",
+		Path:       "/users/{id:int}",
+		Controller: "UserController",
+		Handler:    "GetUser",
+	})
+
+	c := newValueRequestContext().withHeader("X-Request-ID", "req-1")
+	handler := middleware.Handle(route(func(c RequestContext) error {
+		GetLogger(c).Info("loading user")
+		return nil
+	}))
+	require.NoError(t, handler(c))
+
+	records := logRecords(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "loading user", records[0]["msg"])
+	assert.Equal(t, "req-1", records[0]["request_id"])
+	assert.Equal(t, "/users/{id:int}", records[0]["route"])
+	assert.Equal(t, "UserController", records[0]["controller"])
+
+	match, ok := GetRouteMatch(c)
+	require.True(t, ok)
+	assert.Equal(t, "GetUser", match.Handler)
+}
+
+func TestGetLogger_Fallback(t *testing.T) {
+	c := newValueRequestContext()
+	assert.Same(t, slog.Default(), GetLogger(c))
+	assert.Empty(t, GetRequestID(c))
+
+	_, ok := GetRouteMatch(c)
+	assert.False(t, ok)
+}
+
+func TestNewRequestIDFromParams(t *testing.T) {
+	var injected, configured bytes.Buffer
+
+	middleware := NewRequestIDFromParams(RequestIDParams{
+		Logger: slog.New(slog.NewJSONHandler(&injected, nil)),
+	})
+	c := newValueRequestContext()
+	require.NoError(t, middleware.Handle(func(c RequestContext) error {
+		GetLogger(c).Info("from injected")
+		return nil
+	})(c))
+	assert.Len(t, logRecords(t, &injected), 1)
+
+	// A logger set in the config wins over the injected one
+	middleware = NewRequestIDFromParams(RequestIDParams{
+		Config: &RequestIDConfig{Logger: slog.New(slog.NewJSONHandler(&configured, nil))},
+		Logger: slog.New(slog.NewJSONHandler(&injected, nil)),
+	})
+	c = newValueRequestContext()
+	require.NoError(t, middleware.Handle(func(c RequestContext) error {
+		GetLogger(c).Info("from config")
+		return nil
+	})(c))
+	assert.Len(t, logRecords(t, &configured), 1)
+	assert.Len(t, logRecords(t, &injected), 1)
+}
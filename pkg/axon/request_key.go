@@ -0,0 +1,49 @@
+package axon
+
+import (
+	"net/url"
+	"strings"
+)
+
+// requestKey identifies the requests of a route that get the same response:
+// the route name, path parameters, query string and the request values named
+// by vary (headers, or CacheVaryPrincipal for the authenticated subject). Keys
+// of one route start with route and its encoded path parameters, each followed
+// by a NUL byte, so they can be matched by prefix.
+func requestKey(c RequestContext, route string, params, vary []string) string {
+	pathValues := make(url.Values, len(params))
+	for _, name := range params {
+		pathValues.Set(name, c.Param(name))
+	}
+	varyValues := make(url.Values, len(vary))
+	for _, name := range vary {
+		if strings.EqualFold(name, CacheVaryPrincipal) {
+			if principal, ok := GetPrincipal(c); ok {
+				varyValues.Set(CacheVaryPrincipal, principal.GetSubject())
+			}
+			continue
+		}
+		varyValues.Set(name, c.Request().Header(name))
+	}
+
+	var key strings.Builder
+	key.WriteString(route)
+	key.WriteByte(0)
+	key.WriteString(pathValues.Encode())
+	key.WriteByte(0)
+	key.WriteString(url.Values(c.QueryParams()).Encode())
+	key.WriteByte(0)
+	key.WriteString(varyValues.Encode())
+	return key.String()
+}
+
+// pathParamNames returns the names of the parameters in a route path template
+func pathParamNames(path string) []string {
+	var names []string
+	for _, part := range NewAxonPath(path).Parts() {
+		if part.Type == ParameterPart || part.Type == WildcardPart {
+			names = append(names, part.Value)
+		}
+	}
+	return names
+}
@@ -45,6 +45,12 @@ type Cookie struct {
 	Secure   bool   `json:"secure,omitempty"`
 	HttpOnly bool   `json:"http_only,omitempty"`
 	SameSite string `json:"same_site,omitempty"` // "Strict", "Lax", "None"
+
+	// Signed adds an HMAC to the value (read it back with Request().SignedCookie)
+	Signed bool `json:"signed,omitempty"`
+
+	// Encrypted encrypts the value (read it back with Request().EncryptedCookie)
+	Encrypted bool `json:"encrypted,omitempty"`
 }
 
 // NewResponse creates a new Response with the specified status code and body
@@ -144,7 +150,28 @@ func (r *Response) WithSimpleCookie(name, value string) *Response {
 
 // WithSecureCookie adds a secure, HTTP-only cookie
 func (r *Response) WithSecureCookie(name, value, path string, maxAge int) *Response {
-	return r.WithCookie(&Cookie{
+	return r.WithCookie(secureCookie(name, value, path, maxAge))
+}
+
+// WithSignedCookie adds a secure, HTTP-only cookie whose value is signed with the adapter's Keyring.
+// The value stays readable by the client but cannot be modified.
+func (r *Response) WithSignedCookie(name, value, path string, maxAge int) *Response {
+	cookie := secureCookie(name, value, path, maxAge)
+	cookie.Signed = true
+	return r.WithCookie(cookie)
+}
+
+// WithEncryptedCookie adds a secure, HTTP-only cookie whose value is encrypted with the adapter's Keyring.
+// The value can neither be read nor modified by the client.
+func (r *Response) WithEncryptedCookie(name, value, path string, maxAge int) *Response {
+	cookie := secureCookie(name, value, path, maxAge)
+	cookie.Encrypted = true
+	return r.WithCookie(cookie)
+}
+
+// secureCookie returns a secure, HTTP-only, SameSite=Strict cookie
+func secureCookie(name, value, path string, maxAge int) *Cookie {
+	return &Cookie{
 		Name:     name,
 		Value:    value,
 		Path:     path,
@@ -152,7 +179,7 @@ func (r *Response) WithSecureCookie(name, value, path string, maxAge int) *Respo
 		Secure:   true,
 		HttpOnly: true,
 		SameSite: "Strict",
-	})
+	}
 }
 
 // Convenience constructors with headers
@@ -181,3 +208,123 @@ func (r *Response) WithCacheControl(directive string) *Response {
 func (r *Response) WithETag(etag string) *Response {
 	return r.WithHeader("ETag", etag)
 }
+
+// TypedResponse is a Response whose body type is known. Return it from
+// handlers instead of *Response to keep control of the status, headers and
+// cookies while declaring what the body is:
+//
+//	func (c *UserController) CreateUser(user User) (*axon.TypedResponse[*User], error) {
+//	    created := c.UserService.Create(user)
+//	    return axon.TypedCreated(created).WithHeader("Location", fmt.Sprintf("/users/%d", created.ID)), nil
+//	}
+//
+// The generator records T as the route's response type, and renders the
+// response like a Response.
+type TypedResponse[T any] struct {
+	// Body is the response body that will be JSON-encoded and sent to the client
+	Body T
+
+	// response holds the status code, headers, content type and cookies
+	response *Response
+}
+
+// NewTypedResponse creates a new TypedResponse with the specified status code and body
+func NewTypedResponse[T any](statusCode int, body T) *TypedResponse[T] {
+	return &TypedResponse[T]{Body: body, response: NewResponse(statusCode, nil)}
+}
+
+// TypedOK creates a 200 OK TypedResponse with the given body
+func TypedOK[T any](body T) *TypedResponse[T] {
+	return NewTypedResponse(200, body)
+}
+
+// TypedCreated creates a 201 Created TypedResponse with the given body
+func TypedCreated[T any](body T) *TypedResponse[T] {
+	return NewTypedResponse(201, body)
+}
+
+// untyped returns the Response the typed methods delegate to; a
+// TypedResponse built without a constructor is a 200 OK
+func (r *TypedResponse[T]) untyped() *Response {
+	if r.response == nil {
+		r.response = NewResponse(200, nil)
+	}
+	return r.response
+}
+
+// WithStatus sets the status code of the response
+func (r *TypedResponse[T]) WithStatus(statusCode int) *TypedResponse[T] {
+	r.untyped().StatusCode = statusCode
+	return r
+}
+
+// WithHeader adds a header to the response
+func (r *TypedResponse[T]) WithHeader(key, value string) *TypedResponse[T] {
+	r.untyped().WithHeader(key, value)
+	return r
+}
+
+// WithHeaders adds multiple headers to the response
+func (r *TypedResponse[T]) WithHeaders(headers map[string]string) *TypedResponse[T] {
+	r.untyped().WithHeaders(headers)
+	return r
+}
+
+// WithContentType sets the content type of the response
+func (r *TypedResponse[T]) WithContentType(contentType string) *TypedResponse[T] {
+	r.untyped().WithContentType(contentType)
+	return r
+}
+
+// WithCookie adds a cookie to the response
+func (r *TypedResponse[T]) WithCookie(cookie *Cookie) *TypedResponse[T] {
+	r.untyped().WithCookie(cookie)
+	return r
+}
+
+// WithSimpleCookie adds a simple cookie with just name and value
+func (r *TypedResponse[T]) WithSimpleCookie(name, value string) *TypedResponse[T] {
+	r.untyped().WithSimpleCookie(name, value)
+	return r
+}
+
+// WithSecureCookie adds a secure, HTTP-only cookie
+func (r *TypedResponse[T]) WithSecureCookie(name, value, path string, maxAge int) *TypedResponse[T] {
+	r.untyped().WithSecureCookie(name, value, path, maxAge)
+	return r
+}
+
+// WithSignedCookie adds a secure, HTTP-only cookie whose value is signed with the adapter's Keyring
+func (r *TypedResponse[T]) WithSignedCookie(name, value, path string, maxAge int) *TypedResponse[T] {
+	r.untyped().WithSignedCookie(name, value, path, maxAge)
+	return r
+}
+
+// WithEncryptedCookie adds a secure, HTTP-only cookie whose value is encrypted with the adapter's Keyring
+func (r *TypedResponse[T]) WithEncryptedCookie(name, value, path string, maxAge int) *TypedResponse[T] {
+	r.untyped().WithEncryptedCookie(name, value, path, maxAge)
+	return r
+}
+
+// WithCacheControl sets the Cache-Control header
+func (r *TypedResponse[T]) WithCacheControl(directive string) *TypedResponse[T] {
+	r.untyped().WithCacheControl(directive)
+	return r
+}
+
+// WithETag sets the ETag header
+func (r *TypedResponse[T]) WithETag(etag string) *TypedResponse[T] {
+	r.untyped().WithETag(etag)
+	return r
+}
+
+// Response returns the untyped Response with the body, as generated wrappers
+// render it. Returns nil for a nil TypedResponse.
+func (r *TypedResponse[T]) Response() *Response {
+	if r == nil {
+		return nil
+	}
+	response := r.untyped()
+	response.Body = r.Body
+	return response
+}
@@ -0,0 +1,220 @@
+package axon
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ResponseRecorder is a ResponseInterface that buffers the response written by
+// a handler, so that a decorator can inspect, store or transform it before it
+// is sent with Flush. Cookies, streams and direct use of Writer cannot be
+// buffered: they go straight to the underlying response, and Buffered reports
+// false once that has happened.
+type ResponseRecorder struct {
+	response    ResponseInterface
+	status      int
+	header      http.Header
+	body        []byte
+	cookies     bool
+	passthrough bool
+}
+
+// NewResponseRecorder creates a recorder in front of response
+func NewResponseRecorder(response ResponseInterface) *ResponseRecorder {
+	return &ResponseRecorder{response: response, header: make(http.Header)}
+}
+
+// WithResponse returns a copy of c whose Response() is response. Decorators use
+// it to pass a ResponseRecorder to the handler they wrap.
+func WithResponse(c RequestContext, response ResponseInterface) RequestContext {
+	return &responseContext{RequestContext: c, response: response}
+}
+
+// responseContext is a RequestContext with a replaced response
+type responseContext struct {
+	RequestContext
+	response ResponseInterface
+}
+
+// Response returns the replaced response
+func (c *responseContext) Response() ResponseInterface {
+	return c.response
+}
+
+// Status returns the recorded status
+func (r *ResponseRecorder) Status() int {
+	if r.passthrough {
+		return r.response.Status()
+	}
+	return r.status
+}
+
+// SetStatus records the status
+func (r *ResponseRecorder) SetStatus(code int) {
+	r.status = code
+}
+
+// Header returns a recorded header, or the header already set on the underlying response
+func (r *ResponseRecorder) Header(key string) string {
+	if value := r.header.Get(key); value != "" {
+		return value
+	}
+	return r.response.Header(key)
+}
+
+// SetHeader records a header
+func (r *ResponseRecorder) SetHeader(key, value string) {
+	r.header.Set(key, value)
+}
+
+// AddHeader records a header value, keeping those already recorded
+func (r *ResponseRecorder) AddHeader(key, value string) {
+	r.header.Add(key, value)
+}
+
+// JSON records i encoded as JSON
+func (r *ResponseRecorder) JSON(code int, i interface{}) error {
+	body, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+	return r.Blob(code, "application/json", body)
+}
+
+// JSONPretty records i encoded as indented JSON
+func (r *ResponseRecorder) JSONPretty(code int, i interface{}, indent string) error {
+	body, err := json.MarshalIndent(i, "", indent)
+	if err != nil {
+		return err
+	}
+	return r.Blob(code, "application/json", body)
+}
+
+// String records a plain text response
+func (r *ResponseRecorder) String(code int, s string) error {
+	return r.Blob(code, "text/plain; charset=UTF-8", []byte(s))
+}
+
+// HTML records an HTML response
+func (r *ResponseRecorder) HTML(code int, html string) error {
+	return r.Blob(code, "text/html; charset=UTF-8", []byte(html))
+}
+
+// Blob records a response body with its content type
+func (r *ResponseRecorder) Blob(code int, contentType string, b []byte) error {
+	r.status = code
+	r.header.Set("Content-Type", contentType)
+	r.body = append([]byte(nil), b...)
+	return nil
+}
+
+// Stream is not buffered; the recorded headers and the stream go to the underlying response
+func (r *ResponseRecorder) Stream(code int, contentType string, reader interface{}) error {
+	r.startPassthrough()
+	return r.response.Stream(code, contentType, reader)
+}
+
+// WriteStream is not buffered; the recorded headers and the stream go to the underlying response
+func (r *ResponseRecorder) WriteStream(code int, contentType string, write func(w StreamWriter) error) error {
+	r.startPassthrough()
+	return r.response.WriteStream(code, contentType, write)
+}
+
+// SetCookie sets the cookie on the underlying response
+func (r *ResponseRecorder) SetCookie(cookie AxonCookie) {
+	r.cookies = true
+	r.response.SetCookie(cookie)
+}
+
+// Size returns the size of the recorded body
+func (r *ResponseRecorder) Size() int64 {
+	if r.passthrough {
+		return r.response.Size()
+	}
+	return int64(len(r.body))
+}
+
+// Written reports whether a response has been recorded
+func (r *ResponseRecorder) Written() bool {
+	if r.passthrough {
+		return r.response.Written()
+	}
+	return r.status != 0
+}
+
+// Writer returns the underlying writer; the response is no longer buffered
+func (r *ResponseRecorder) Writer() interface{} {
+	r.startPassthrough()
+	return r.response.Writer()
+}
+
+// Buffered reports whether the whole response is held by the recorder
+func (r *ResponseRecorder) Buffered() bool {
+	return !r.passthrough && r.status != 0
+}
+
+// SetsCookies reports whether the handler set cookies
+func (r *ResponseRecorder) SetsCookies() bool {
+	return r.cookies
+}
+
+// Body returns the recorded body
+func (r *ResponseRecorder) Body() []byte {
+	return r.body
+}
+
+// SetBody replaces the recorded body
+func (r *ResponseRecorder) SetBody(body []byte) {
+	r.body = body
+}
+
+// RecordedHeader returns the headers set through the recorder
+func (r *ResponseRecorder) RecordedHeader() http.Header {
+	return r.header
+}
+
+// Flush writes the recorded headers and response to the underlying response.
+// If the handler wrote nothing, only its headers are applied.
+func (r *ResponseRecorder) Flush() error {
+	if r.passthrough {
+		return nil
+	}
+	if r.status == 0 {
+		r.copyHeaders(true)
+		return nil
+	}
+	r.copyHeaders(false)
+	return r.response.Blob(r.status, r.header.Get("Content-Type"), r.body)
+}
+
+// startPassthrough sends the recorded headers ahead of a write to the underlying response
+func (r *ResponseRecorder) startPassthrough() {
+	if !r.passthrough {
+		r.passthrough = true
+		r.copyHeaders(true)
+	}
+}
+
+// copyHeaders applies the recorded headers to the underlying response. The
+// content type is left out when it is passed to Blob instead.
+func (r *ResponseRecorder) copyHeaders(contentType bool) {
+	for key, values := range r.header {
+		if key == "Content-Type" && !contentType {
+			continue
+		}
+		replayHeader(r.response, key, values)
+	}
+}
+
+// replayHeader writes every value of a recorded header to response. The
+// first replaces a value set before, the others are added after it, so
+// headers such as Set-Cookie, Vary and Link keep all their values.
+func replayHeader(response ResponseInterface, key string, values []string) {
+	for i, value := range values {
+		if i == 0 {
+			response.SetHeader(key, value)
+		} else {
+			response.AddHeader(key, value)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package axon
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseRecorder_BuffersUntilFlush(t *testing.T) {
+	c := newValueRequestContext()
+	recorder := NewResponseRecorder(c.Response())
+
+	handler := func(c RequestContext) error {
+		c.Response().SetHeader("X-Custom", "yes")
+		return c.Response().JSON(http.StatusCreated, map[string]string{"id": "1"})
+	}
+	require.NoError(t, handler(WithResponse(c, recorder)))
+
+	assert.False(t, c.response.Written(), "nothing reaches the response before Flush")
+	assert.True(t, recorder.Buffered())
+	assert.True(t, recorder.Written())
+	assert.Equal(t, http.StatusCreated, recorder.Status())
+	assert.Equal(t, `{"id":"1"}`, string(recorder.Body()))
+	assert.Equal(t, "yes", recorder.Header("X-Custom"))
+
+	recorder.SetBody([]byte(`{"id":"2"}`))
+	require.NoError(t, recorder.Flush())
+	assert.Equal(t, http.StatusCreated, c.response.status)
+	assert.Equal(t, `{"id":"2"}`, string(c.response.body))
+	assert.Equal(t, "application/json", c.response.headers.Get("Content-Type"))
+	assert.Equal(t, "yes", c.response.headers.Get("X-Custom"))
+}
+
+func TestResponseRecorder_Passthrough(t *testing.T) {
+	c := newValueRequestContext()
+	recorder := NewResponseRecorder(c.Response())
+
+	recorder.SetHeader("X-Before", "1")
+	recorder.SetCookie(AxonCookie{Name: "session", Value: "abc"})
+	require.NoError(t, recorder.Stream(http.StatusOK, "text/event-stream", nil))
+
+	assert.False(t, recorder.Buffered())
+	assert.True(t, recorder.SetsCookies())
+	assert.Equal(t, "1", c.response.headers.Get("X-Before"), "headers are sent ahead of the stream")
+	_, ok := c.response.cookie("session")
+	assert.True(t, ok)
+
+	require.NoError(t, recorder.Flush())
+	assert.Equal(t, http.StatusOK, recorder.Status())
+}
+
+func TestResponseRecorder_FlushWithoutBody(t *testing.T) {
+	c := newValueRequestContext()
+	recorder := NewResponseRecorder(c.Response())
+	recorder.SetHeader("Location", "/next")
+
+	assert.False(t, recorder.Buffered())
+	require.NoError(t, recorder.Flush())
+	assert.False(t, c.response.Written())
+	assert.Equal(t, "/next", c.response.headers.Get("Location"))
+}
@@ -57,3 +57,38 @@ func TestResponse_InternalServerError(t *testing.T) {
 	assert.Equal(t, 500, resp.StatusCode)
 	assert.Equal(t, map[string]string{"error": "Database connection failed"}, resp.Body)
 }
+
+func TestTypedResponse(t *testing.T) {
+	type user struct{ ID int }
+
+	resp := TypedCreated(&user{ID: 7}).
+		WithHeader("Location", "/users/7").
+		WithCacheControl("no-store").
+		WithSignedCookie("session", "abc", "/", 3600)
+	assert.Equal(t, 7, resp.Body.ID, "the body keeps its type")
+
+	untyped := resp.Response()
+	assert.Equal(t, 201, untyped.StatusCode)
+	assert.Equal(t, resp.Body, untyped.Body)
+	assert.Equal(t, map[string]string{"Location": "/users/7", "Cache-Control": "no-store"}, untyped.Headers)
+	assert.Len(t, untyped.Cookies, 1)
+	assert.True(t, untyped.Cookies[0].Signed)
+	assert.True(t, untyped.Cookies[0].HttpOnly)
+	assert.Same(t, untyped, resp.Response(), "the typed response wraps one Response")
+
+	// The body is taken when the response is rendered
+	resp.Body = &user{ID: 8}
+	assert.Equal(t, resp.Body, resp.Response().Body)
+
+	assert.Equal(t, 200, TypedOK("ok").Response().StatusCode)
+	assert.Equal(t, 202, TypedOK("ok").WithStatus(202).Response().StatusCode)
+
+	// A TypedResponse built without a constructor is a 200 OK
+	literal := (&TypedResponse[string]{Body: "ok"}).WithETag(`"v1"`).Response()
+	assert.Equal(t, 200, literal.StatusCode)
+	assert.Equal(t, "ok", literal.Body)
+	assert.Equal(t, `"v1"`, literal.Headers["ETag"])
+
+	var missing *TypedResponse[user]
+	assert.Nil(t, missing.Response())
+}
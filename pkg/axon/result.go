@@ -0,0 +1,61 @@
+package axon
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// StatusCoder is implemented by data returned from handlers that chooses its
+// own status code, such as a created resource answering with 201:
+//
+//	func (o *Order) StatusCode() int { return http.StatusCreated }
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// Headerer is implemented by data returned from handlers that sets response
+// headers, such as a Location or Cache-Control header
+type Headerer interface {
+	Headers() map[string]string
+}
+
+// WriteData renders data returned by a handler as JSON, with the status code
+// of a StatusCoder or 200, and the headers of a Headerer. Generated wrappers
+// call it for handlers returning data.
+func WriteData(c RequestContext, data interface{}) error {
+	return WriteDataStatus(c, 0, data)
+}
+
+// WriteDataStatus renders data returned by a handler as JSON with the status
+// code the handler returned alongside it, and the headers of a Headerer.
+// Generated wrappers call it for handlers returning (T, int, error). A zero
+// status falls back to WriteData's. Statuses that have no body, such as 204
+// and 304, are sent without the data.
+func WriteDataStatus(c RequestContext, status int, data interface{}) error {
+	// Nil pointers are rendered as null, without calling their methods
+	if value := reflect.ValueOf(data); value.Kind() == reflect.Pointer && value.IsNil() {
+		data = nil
+	}
+
+	if status == 0 {
+		status = http.StatusOK
+		if coder, ok := data.(StatusCoder); ok {
+			status = coder.StatusCode()
+		}
+	}
+	if status < 100 || status > 599 {
+		return NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("handler returned invalid status code %d", status))
+	}
+
+	if headerer, ok := data.(Headerer); ok {
+		for key, value := range headerer.Headers() {
+			c.Response().SetHeader(key, value)
+		}
+	}
+	// 204, 304 and informational responses are sent without the data
+	if bodylessStatus(status) {
+		return c.Response().Blob(status, "", nil)
+	}
+	return c.Response().JSON(status, data)
+}
@@ -0,0 +1,79 @@
+package axon
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createdOrder is created with 201 and points at its location
+type createdOrder struct {
+	ID string
+}
+
+func (o *createdOrder) StatusCode() int { return http.StatusCreated }
+
+func (o *createdOrder) Headers() map[string]string {
+	return map[string]string{"Location": "/orders/" + o.ID}
+}
+
+func TestWriteData(t *testing.T) {
+	c := newValueRequestContext()
+	require.NoError(t, WriteData(c, map[string]int{"count": 1}))
+	assert.Equal(t, http.StatusOK, c.response.status)
+	assert.Equal(t, "application/json", c.response.headers.Get("Content-Type"))
+
+	c = newValueRequestContext()
+	require.NoError(t, WriteData(c, &createdOrder{ID: "42"}))
+	assert.Equal(t, http.StatusCreated, c.response.status, "StatusCoder chooses the status")
+	assert.Equal(t, "/orders/42", c.response.headers.Get("Location"), "Headerer sets headers")
+}
+
+func TestWriteDataStatus(t *testing.T) {
+	c := newValueRequestContext()
+	require.NoError(t, WriteDataStatus(c, http.StatusAccepted, &createdOrder{ID: "42"}))
+	assert.Equal(t, http.StatusAccepted, c.response.status, "the returned status wins over StatusCoder")
+	assert.Equal(t, "/orders/42", c.response.headers.Get("Location"))
+
+	c = newValueRequestContext()
+	require.NoError(t, WriteDataStatus(c, 0, &createdOrder{ID: "42"}))
+	assert.Equal(t, http.StatusCreated, c.response.status, "a zero status falls back to StatusCoder")
+
+	c = newValueRequestContext()
+	err := WriteDataStatus(c, 42, "data")
+	assert.Equal(t, http.StatusInternalServerError, ErrorStatus(err))
+	assert.Zero(t, c.response.status, "nothing is written for invalid status codes")
+
+	c = newValueRequestContext()
+	err = WriteDataStatus(c, 600, "data")
+	assert.Equal(t, http.StatusInternalServerError, ErrorStatus(err))
+	assert.Zero(t, c.response.status, "codes above 599 are not HTTP statuses")
+}
+
+// unchangedOrder answers with 304 Not Modified
+type unchangedOrder struct{}
+
+func (unchangedOrder) StatusCode() int { return http.StatusNotModified }
+
+func TestWriteDataStatus_Bodyless(t *testing.T) {
+	c := newValueRequestContext()
+	require.NoError(t, WriteDataStatus(c, http.StatusNoContent, map[string]int{"count": 1}))
+	assert.Equal(t, http.StatusNoContent, c.response.status)
+	assert.Empty(t, c.response.body, "204 responses have no body")
+	assert.Empty(t, c.response.headers.Get("Content-Type"))
+
+	c = newValueRequestContext()
+	require.NoError(t, WriteData(c, unchangedOrder{}))
+	assert.Equal(t, http.StatusNotModified, c.response.status)
+	assert.Empty(t, c.response.body, "304 responses have no body")
+}
+
+func TestWriteData_NilPointer(t *testing.T) {
+	var order *createdOrder
+	c := newValueRequestContext()
+	require.NoError(t, WriteData(c, order))
+	assert.Equal(t, http.StatusOK, c.response.status, "the methods of nil pointers are not called")
+	assert.Empty(t, c.response.headers.Get("Location"))
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRouteConverter_AxonToEcho(t *testing.T) {
@@ -233,3 +234,39 @@ func TestRouteConverterConvenienceFunctions(t *testing.T) {
 	convertedBack := EchoToAxon(echoPath, paramInfo)
 	assert.Equal(t, axonPath, convertedBack)
 }
+
+func TestAxonPath_Match(t *testing.T) {
+	testCases := []struct {
+		template string
+		path     string
+		expected bool
+	}{
+		{"/webhooks/stripe", "/webhooks/stripe", true},
+		{"/webhooks/stripe", "/webhooks/stripe/extra", false},
+		{"/users/{id:int}", "/users/42", true},
+		{"/users/{id:int}", "/users/", false},
+		{"/users/{id:int}/posts/{slug}", "/users/42/posts/hello", true},
+		{"/users/{id:int}/posts/{slug}", "/users/42/comments/hello", false},
+		{"/files/{*}", "/files/a/b/c.txt", true},
+		{"/files/{*}", "/other/a", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.template+" "+tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.expected, NewAxonPath(tc.template).Match(tc.path))
+		})
+	}
+}
+
+func TestAxonPath_Build(t *testing.T) {
+	path, err := NewAxonPath("/users/{id:int}/posts/{slug}").Build(map[string]string{"id": "42", "slug": "a b/c"})
+	require.NoError(t, err)
+	assert.Equal(t, "/users/42/posts/a%20b%2Fc", path)
+
+	path, err = NewAxonPath("/files/{*}").Build(map[string]string{"*": "a b/c.txt"})
+	require.NoError(t, err)
+	assert.Equal(t, "/files/a%20b/c.txt", path, "wildcards keep their slashes")
+
+	_, err = NewAxonPath("/users/{id:int}").Build(nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,48 @@
+package axon
+
+// RouteContextKey is the request context key under which the matched route is stored
+const RouteContextKey = "axon.route"
+
+// RouteMatch identifies the generated route handling a request
+type RouteMatch struct {
+	// Method is the HTTP method of the route
+	Method string
+
+	// Path is the full Axon route template (e.g. "/users/{id:int}")
+	Path string
+
+	// Controller is the name of the controller that owns the route
+	Controller string
+
+	// Handler is the name of the handler method
+	Handler string
+
+	// NoCSRF marks a route exempt from CSRF verification
+	NoCSRF bool
+}
+
+// WithRouteMatch returns a middleware that records the matched route on the
+// request context and adds its template and controller to the request-scoped
+// logger. Generated route registration installs it ahead of the route's own
+// middlewares. Global middlewares run before it and can read the route with
+// GetRouteMatch once next returns. A CSRF failure that the CSRF middleware
+// deferred until the route was known is returned here unless the route is
+// marked NoCSRF.
+func WithRouteMatch(match RouteMatch) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c RequestContext) error {
+			c.Set(RouteContextKey, match)
+			c.Set(LoggerContextKey, GetLogger(c).With("route", match.Path, "controller", match.Controller))
+			if err := pendingCSRFError(c, match); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+// GetRouteMatch returns the route handling the request, if a generated route matched
+func GetRouteMatch(c RequestContext) (RouteMatch, bool) {
+	match, ok := c.Get(RouteContextKey).(RouteMatch)
+	return match, ok
+}
@@ -0,0 +1,240 @@
+package axon
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+// CSPNonceContextKey is the request context key under which the CSP nonce is stored
+const CSPNonceContextKey = "axon.csp.nonce"
+
+// securityHeadersContextKey is the request context key under which the active SecurityHeaders is stored
+const securityHeadersContextKey = "axon.security_headers"
+
+// DefaultCSP is the Content-Security-Policy used when none is configured
+const DefaultCSP = "default-src 'self'; base-uri 'self'; object-src 'none'; frame-ancestors 'none'"
+
+// SecurityHeadersConfig configures the security headers middleware. Zero values use the defaults.
+//
+// Content-Security-Policy values may contain {nonce}, which is replaced with a
+// random per-request nonce (e.g. "script-src 'self' 'nonce-{nonce}'"). Handlers
+// read the same nonce with CSPNonce to tag inline scripts and styles.
+type SecurityHeadersConfig struct {
+	// HSTSMaxAge is the Strict-Transport-Security max-age (default: 365 days)
+	HSTSMaxAge time.Duration
+
+	// HSTSIncludeSubdomains adds includeSubDomains to Strict-Transport-Security
+	HSTSIncludeSubdomains bool
+
+	// HSTSPreload adds preload to Strict-Transport-Security
+	HSTSPreload bool
+
+	// DisableHSTS omits Strict-Transport-Security, e.g. for local development
+	DisableHSTS bool
+
+	// ReferrerPolicy is the Referrer-Policy value (default: "strict-origin-when-cross-origin")
+	ReferrerPolicy string
+
+	// FrameOptions is the X-Frame-Options value (default: "DENY")
+	FrameOptions string
+
+	// CSP is the default Content-Security-Policy (default: DefaultCSP)
+	CSP string
+
+	// CSPPolicies are named policies that routes select with -CSP=name
+	CSPPolicies map[string]string
+
+	// CSPReportOnly sends Content-Security-Policy-Report-Only instead of enforcing the policy
+	CSPReportOnly bool
+
+	// Headers are additional headers set on every response (e.g. Permissions-Policy)
+	Headers map[string]string
+}
+
+// SecurityHeaders is a middleware that sets Strict-Transport-Security,
+// X-Content-Type-Options, X-Frame-Options, Referrer-Policy and
+// Content-Security-Policy on every response. Routes annotated with
+// -CSP=name replace the default policy with a named one.
+type SecurityHeaders struct {
+	config  SecurityHeadersConfig
+	headers [][2]string // fixed headers in a stable order
+}
+
+// NewSecurityHeaders creates a security headers middleware
+func NewSecurityHeaders(config SecurityHeadersConfig) (*SecurityHeaders, error) {
+	if config.HSTSMaxAge <= 0 {
+		config.HSTSMaxAge = 365 * 24 * time.Hour
+	}
+	if config.ReferrerPolicy == "" {
+		config.ReferrerPolicy = "strict-origin-when-cross-origin"
+	}
+	if config.FrameOptions == "" {
+		config.FrameOptions = "DENY"
+	}
+	if config.CSP == "" {
+		config.CSP = DefaultCSP
+	}
+	for name, policy := range config.CSPPolicies {
+		if name == "" || strings.TrimSpace(policy) == "" {
+			return nil, fmt.Errorf("axon: CSP policy %q must have a name and a value", name)
+		}
+	}
+
+	headers := [][2]string{
+		{"X-Content-Type-Options", "nosniff"},
+		{"X-Frame-Options", config.FrameOptions},
+		{"Referrer-Policy", config.ReferrerPolicy},
+	}
+	if !config.DisableHSTS {
+		hsts := "max-age=" + strconv.FormatInt(int64(config.HSTSMaxAge/time.Second), 10)
+		if config.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if config.HSTSPreload {
+			hsts += "; preload"
+		}
+		headers = append(headers, [2]string{"Strict-Transport-Security", hsts})
+	}
+	extra := make([]string, 0, len(config.Headers))
+	for name := range config.Headers {
+		extra = append(extra, name)
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		headers = append(headers, [2]string{name, config.Headers[name]})
+	}
+
+	return &SecurityHeaders{config: config, headers: headers}, nil
+}
+
+// Handle implements the middleware
+func (h *SecurityHeaders) Handle(next HandlerFunc) HandlerFunc {
+	return func(c RequestContext) error {
+		nonce, err := newCSPNonce()
+		if err != nil {
+			return err
+		}
+		c.Set(CSPNonceContextKey, nonce)
+		c.Set(securityHeadersContextKey, h)
+
+		for _, header := range h.headers {
+			c.Response().SetHeader(header[0], header[1])
+		}
+		h.setCSP(c, h.config.CSP, nonce)
+		return next(c)
+	}
+}
+
+// setCSP writes a policy with the nonce filled in
+func (h *SecurityHeaders) setCSP(c RequestContext, policy, nonce string) {
+	header := "Content-Security-Policy"
+	if h.config.CSPReportOnly {
+		header = "Content-Security-Policy-Report-Only"
+	}
+	c.Response().SetHeader(header, strings.ReplaceAll(policy, "{nonce}", nonce))
+}
+
+// CSPNonce returns the nonce of the current request's Content-Security-Policy.
+// It is empty if the SecurityHeaders middleware did not run.
+func CSPNonce(c RequestContext) string {
+	nonce, _ := c.Get(CSPNonceContextKey).(string)
+	return nonce
+}
+
+// WithCSPPolicy returns a middleware that replaces the default Content-Security-Policy
+// with the named policy. Generated route registration applies it for -CSP=name.
+// It does nothing when the SecurityHeaders middleware is not installed, and fails
+// with 500 if the policy is not configured.
+func WithCSPPolicy(name string) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c RequestContext) error {
+			h, ok := c.Get(securityHeadersContextKey).(*SecurityHeaders)
+			if !ok {
+				return next(c)
+			}
+			policy, ok := h.config.CSPPolicies[name]
+			if !ok {
+				return NewHTTPError(http.StatusInternalServerError, "unknown CSP policy", fmt.Errorf("axon: CSP policy %q is not configured", name))
+			}
+			h.setCSP(c, policy, CSPNonce(c))
+			return next(c)
+		}
+	}
+}
+
+// CheckPolicies returns an error naming every route in routes whose -CSP
+// policy is not configured. SecurityHeadersModule runs it when the application
+// starts, so a misspelled policy fails startup instead of each request to the route.
+func (h *SecurityHeaders) CheckPolicies(routes RouteRegistry) error {
+	var unknown []string
+	for _, route := range routes.GetAllRoutes() {
+		if route.CSPPolicy == "" {
+			continue
+		}
+		if _, ok := h.config.CSPPolicies[route.CSPPolicy]; !ok {
+			unknown = append(unknown, fmt.Sprintf("%s %s (-CSP=%s)", route.Method, route.Path, route.CSPPolicy))
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("axon: CSP policies are not configured for routes: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// newCSPNonce generates a random nonce for a Content-Security-Policy
+func newCSPNonce() (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", NewHTTPError(http.StatusInternalServerError, "failed to generate CSP nonce", err)
+	}
+	return base64.StdEncoding.EncodeToString(nonce), nil
+}
+
+// SecurityHeadersParams are the fx dependencies of the security headers middleware.
+// The config is optional; without it the defaults are used.
+type SecurityHeadersParams struct {
+	fx.In
+
+	Config *SecurityHeadersConfig `optional:"true"`
+}
+
+// NewSecurityHeadersFromParams builds the middleware from fx-provided dependencies
+func NewSecurityHeadersFromParams(p SecurityHeadersParams) (*SecurityHeaders, error) {
+	var config SecurityHeadersConfig
+	if p.Config != nil {
+		config = *p.Config
+	}
+	return NewSecurityHeaders(config)
+}
+
+// SecurityHeadersModule provides a *SecurityHeaders and registers it as global
+// middleware. Include it before the generated modules so it wraps every route.
+// The application fails to start if a route's -CSP policy is not configured.
+//
+//	fx.New(
+//	    fx.Supply(&axon.SecurityHeadersConfig{
+//	        CSPPolicies: map[string]string{"admin": "default-src 'self'; script-src 'self' 'nonce-{nonce}'"},
+//	    }),
+//	    axon.SecurityHeadersModule,
+//	    controllers.AutogenModule,
+//	)
+var SecurityHeadersModule = fx.Module("axon-security-headers",
+	fx.Provide(NewSecurityHeadersFromParams),
+	fx.Invoke(func(lc fx.Lifecycle, server WebServerInterface, headers *SecurityHeaders) {
+		server.Use(headers.Handle)
+		// Generated modules register their routes after this one, so their
+		// policies are checked once every invoke has run
+		lc.Append(fx.Hook{OnStart: func(context.Context) error {
+			return headers.CheckPolicies(DefaultRouteRegistry)
+		}})
+	}),
+)
@@ -0,0 +1,127 @@
+package axon
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityHeaders_Defaults(t *testing.T) {
+	headers, err := NewSecurityHeadersFromParams(SecurityHeadersParams{})
+	require.NoError(t, err)
+
+	c := newValueRequestContext()
+	var nonce string
+	err = headers.Handle(func(c RequestContext) error {
+		nonce = CSPNonce(c)
+		return nil
+	})(c)
+	require.NoError(t, err)
+
+	assert.Equal(t, "nosniff", c.response.Header("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", c.response.Header("X-Frame-Options"))
+	assert.Equal(t, "strict-origin-when-cross-origin", c.response.Header("Referrer-Policy"))
+	assert.Equal(t, "max-age=31536000", c.response.Header("Strict-Transport-Security"))
+	assert.Equal(t, DefaultCSP, c.response.Header("Content-Security-Policy"))
+	assert.NotEmpty(t, nonce)
+}
+
+func TestSecurityHeaders_Config(t *testing.T) {
+	headers, err := NewSecurityHeaders(SecurityHeadersConfig{
+		HSTSMaxAge:            2 * time.Hour,
+		HSTSIncludeSubdomains: true,
+		HSTSPreload:           true,
+		ReferrerPolicy:        "no-referrer",
+		FrameOptions:          "SAMEORIGIN",
+		CSP:                   "script-src 'nonce-{nonce}'",
+		CSPReportOnly:         true,
+		Headers:               map[string]string{"Permissions-Policy": "camera=()"},
+	})
+	require.NoError(t, err)
+
+	c := newValueRequestContext()
+	require.NoError(t, headers.Handle(func(RequestContext) error { return nil })(c))
+
+	assert.Equal(t, "max-age=7200; includeSubDomains; preload", c.response.Header("Strict-Transport-Security"))
+	assert.Equal(t, "no-referrer", c.response.Header("Referrer-Policy"))
+	assert.Equal(t, "SAMEORIGIN", c.response.Header("X-Frame-Options"))
+	assert.Equal(t, "camera=()", c.response.Header("Permissions-Policy"))
+	assert.Empty(t, c.response.Header("Content-Security-Policy"))
+	assert.Equal(t, "script-src 'nonce-"+CSPNonce(c)+"'", c.response.Header("Content-Security-Policy-Report-Only"))
+
+	headers, err = NewSecurityHeaders(SecurityHeadersConfig{DisableHSTS: true})
+	require.NoError(t, err)
+	c = newValueRequestContext()
+	require.NoError(t, headers.Handle(func(RequestContext) error { return nil })(c))
+	assert.Empty(t, c.response.Header("Strict-Transport-Security"))
+
+	_, err = NewSecurityHeaders(SecurityHeadersConfig{CSPPolicies: map[string]string{"admin": " "}})
+	assert.Error(t, err)
+}
+
+func TestSecurityHeaders_NoncePerRequest(t *testing.T) {
+	headers, err := NewSecurityHeaders(SecurityHeadersConfig{})
+	require.NoError(t, err)
+
+	first, second := newValueRequestContext(), newValueRequestContext()
+	next := func(RequestContext) error { return nil }
+	require.NoError(t, headers.Handle(next)(first))
+	require.NoError(t, headers.Handle(next)(second))
+	assert.NotEqual(t, CSPNonce(first), CSPNonce(second))
+	assert.Empty(t, CSPNonce(newValueRequestContext()))
+}
+
+func TestWithCSPPolicy(t *testing.T) {
+	headers, err := NewSecurityHeaders(SecurityHeadersConfig{
+		CSPPolicies: map[string]string{"admin": "default-src 'self'; script-src 'self' 'nonce-{nonce}'"},
+	})
+	require.NoError(t, err)
+
+	called := false
+	handler := func(RequestContext) error {
+		called = true
+		return nil
+	}
+
+	c := newValueRequestContext()
+	require.NoError(t, headers.Handle(WithCSPPolicy("admin")(handler))(c))
+	assert.True(t, called)
+	policy := c.response.Header("Content-Security-Policy")
+	assert.True(t, strings.HasSuffix(policy, "'nonce-"+CSPNonce(c)+"'"), policy)
+
+	// Unknown policies fail instead of silently keeping the default
+	called = false
+	err = headers.Handle(WithCSPPolicy("missing")(handler))(newValueRequestContext())
+	var httpErr *HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusInternalServerError, httpErr.Code)
+	assert.False(t, called)
+
+	// Without the middleware the override is a no-op
+	c = newValueRequestContext()
+	require.NoError(t, WithCSPPolicy("admin")(handler)(c))
+	assert.Empty(t, c.response.Header("Content-Security-Policy"))
+}
+
+func TestSecurityHeaders_CheckPolicies(t *testing.T) {
+	headers, err := NewSecurityHeaders(SecurityHeadersConfig{
+		CSPPolicies: map[string]string{"admin": "default-src 'self'"},
+	})
+	require.NoError(t, err)
+
+	routes := NewInMemoryRouteRegistry()
+	routes.RegisterRoute(RouteInfo{Method: "GET", Path: "/admin", CSPPolicy: "admin"})
+	routes.RegisterRoute(RouteInfo{Method: "GET", Path: "/home"})
+	require.NoError(t, headers.CheckPolicies(routes))
+
+	// A misspelled policy name is reported with its route
+	routes.RegisterRoute(RouteInfo{Method: "GET", Path: "/reports", CSPPolicy: "amdin"})
+	err = headers.CheckPolicies(routes)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "GET /reports (-CSP=amdin)")
+	assert.NotContains(t, err.Error(), "/admin")
+}
@@ -0,0 +1,624 @@
+package axon
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SessionContextKey is the request context key under which the loaded session is cached
+const SessionContextKey = "axon.session"
+
+// Session is the server-side session of the current request. Declare an
+// axon.Session parameter on a route handler and the generated wrapper loads it
+// before the handler runs and saves it after the handler returns.
+type Session interface {
+	// ID returns the session ID ("" for a new session that has not been saved yet)
+	ID() string
+
+	// IsNew reports whether the session was created by this request
+	IsNew() bool
+
+	// Get returns the value stored under key
+	Get(key string) (string, bool)
+
+	// Set stores a value
+	Set(key, value string)
+
+	// Delete removes a value
+	Delete(key string)
+
+	// Clear removes all values and flash messages
+	Clear()
+
+	// AddFlash queues a message that is returned once by Flashes, typically on the next request
+	AddFlash(key, message string)
+
+	// Flashes returns and removes the queued messages for key
+	Flashes(key string) []string
+
+	// Regenerate issues a new session ID, keeping the data. Call it after login
+	// or any other privilege change to prevent session fixation.
+	Regenerate()
+
+	// Destroy deletes the session from the store and expires the cookie
+	Destroy()
+}
+
+// SessionRecord is the persisted form of a session
+type SessionRecord struct {
+	ID             string              `json:"id"`
+	Values         map[string]string   `json:"values,omitempty"`
+	Flashes        map[string][]string `json:"flashes,omitempty"`
+	Privilege      string              `json:"privilege,omitempty"`
+	CreatedAt      time.Time           `json:"created_at"`
+	LastAccessedAt time.Time           `json:"last_accessed_at"`
+	ExpiresAt      time.Time           `json:"expires_at"`
+}
+
+// SessionStore persists session records
+type SessionStore interface {
+	// Load returns the record with the given ID, or nil if it does not exist or has expired
+	Load(id string) (*SessionRecord, error)
+
+	// Save creates or replaces a record; it may be discarded after record.ExpiresAt
+	Save(record *SessionRecord) error
+
+	// Delete removes a record; deleting a missing record is not an error
+	Delete(id string) error
+}
+
+// SessionConfig configures the session cookie and expiry
+type SessionConfig struct {
+	// CookieName is the name of the session ID cookie (default: "axon_session")
+	CookieName string
+
+	// CookiePath is the cookie path (default: "/")
+	CookiePath string
+
+	// CookieDomain is the cookie domain (default: host only)
+	CookieDomain string
+
+	// InsecureCookie drops the Secure flag, for local development over plain HTTP
+	InsecureCookie bool
+
+	// SameSite is the cookie SameSite mode (default: Lax)
+	SameSite SameSiteMode
+
+	// IdleTimeout expires sessions that have not been used for this long (default: 30m)
+	IdleTimeout time.Duration
+
+	// AbsoluteTimeout expires sessions this long after creation regardless of activity (default: 24h)
+	AbsoluteTimeout time.Duration
+}
+
+// SessionManager loads and saves sessions for requests
+type SessionManager struct {
+	config SessionConfig
+	store  SessionStore
+	now    func() time.Time
+}
+
+// NewSessionManager creates a session manager backed by store
+func NewSessionManager(config SessionConfig, store SessionStore) *SessionManager {
+	if config.CookieName == "" {
+		config.CookieName = "axon_session"
+	}
+	if config.CookiePath == "" {
+		config.CookiePath = "/"
+	}
+	if config.SameSite == SameSiteDefaultMode {
+		config.SameSite = SameSiteLaxMode
+	}
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = 30 * time.Minute
+	}
+	if config.AbsoluteTimeout <= 0 {
+		config.AbsoluteTimeout = 24 * time.Hour
+	}
+	return &SessionManager{config: config, store: store, now: time.Now}
+}
+
+// Load returns the session identified by the request cookie, or a new empty
+// session if there is no cookie or the session has expired. The session is
+// cached on the request, so middleware and the handler share one instance.
+func (m *SessionManager) Load(c RequestContext) (Session, error) {
+	if cached, ok := c.Get(SessionContextKey).(*session); ok && cached.manager == m {
+		return cached, nil
+	}
+
+	sess, err := m.load(c)
+	if err != nil {
+		return nil, err
+	}
+	sess.manager = m
+	c.Set(SessionContextKey, sess)
+	return sess, nil
+}
+
+// load reads the session for a request from the store
+func (m *SessionManager) load(c RequestContext) (*session, error) {
+	now := m.now()
+
+	if cookie, err := c.Request().Cookie(m.config.CookieName); err == nil && cookie.Value != "" {
+		record, err := m.store.Load(cookie.Value)
+		if err != nil {
+			return nil, NewHTTPError(http.StatusInternalServerError, "failed to load session", err)
+		}
+		if record != nil && !m.expired(record, now) {
+			return &session{record: record, loadedID: record.ID}, nil
+		}
+		if record != nil {
+			_ = m.store.Delete(record.ID)
+		}
+	}
+
+	return &session{
+		record: &SessionRecord{CreatedAt: now},
+		isNew:  true,
+	}, nil
+}
+
+// Save persists the session and sets or expires the session cookie. It must be
+// called before the response body is written. The session ID is rotated
+// automatically when the request has a principal that differs from the one
+// the session was last saved with.
+func (m *SessionManager) Save(c RequestContext, s Session) error {
+	sess, ok := s.(*session)
+	if !ok {
+		return fmt.Errorf("axon: session was not loaded by this SessionManager")
+	}
+	record := sess.record
+
+	if sess.destroyed {
+		if sess.loadedID != "" {
+			if err := m.store.Delete(sess.loadedID); err != nil {
+				return NewHTTPError(http.StatusInternalServerError, "failed to delete session", err)
+			}
+		}
+		if !sess.isNew {
+			m.setCookie(c, "", -1)
+		}
+		return nil
+	}
+
+	// Nothing to persist for a new session that was never written to
+	if sess.isNew && !sess.dirty {
+		return nil
+	}
+
+	// Routes without authentication have no principal and keep the session's
+	// privilege, so moving between public and protected routes does not rotate
+	if principal, ok := GetPrincipal(c); ok && principal != nil {
+		privilege := principalFingerprint(principal)
+		if !sess.isNew && record.Privilege != privilege {
+			sess.regenerate = true
+		}
+		record.Privilege = privilege
+	}
+
+	if record.ID == "" || sess.regenerate {
+		id, err := newSessionID()
+		if err != nil {
+			return NewHTTPError(http.StatusInternalServerError, "failed to create session", err)
+		}
+		record.ID = id
+	}
+
+	now := m.now()
+	record.LastAccessedAt = now
+	record.ExpiresAt = now.Add(m.config.IdleTimeout)
+	if absolute := record.CreatedAt.Add(m.config.AbsoluteTimeout); absolute.Before(record.ExpiresAt) {
+		record.ExpiresAt = absolute
+	}
+
+	if err := m.store.Save(record); err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "failed to save session", err)
+	}
+	if sess.loadedID != "" && sess.loadedID != record.ID {
+		if err := m.store.Delete(sess.loadedID); err != nil {
+			return NewHTTPError(http.StatusInternalServerError, "failed to rotate session", err)
+		}
+	}
+	if record.ID != sess.loadedID {
+		m.setCookie(c, record.ID, 0)
+	}
+
+	sess.loadedID, sess.isNew, sess.dirty, sess.regenerate = record.ID, false, false, false
+	return nil
+}
+
+// SaveOnWrite returns a copy of c whose response saves s right before the
+// first body, stream or Writer use, so handlers that write the response
+// themselves still send the session cookie. Generated wrappers use it for
+// handlers returning only an error and call Save again once the handler
+// returns, which persists changes made after the response was written.
+func (m *SessionManager) SaveOnWrite(c RequestContext, s Session) RequestContext {
+	return WithResponse(c, &sessionResponse{
+		ResponseInterface: c.Response(),
+		save:              func() error { return m.Save(c, s) },
+	})
+}
+
+// sessionResponse is a response that saves the session ahead of the first write
+type sessionResponse struct {
+	ResponseInterface
+	save  func() error
+	saved bool
+	err   error
+}
+
+// saveOnce saves the session on the first write and returns the result of that save
+func (r *sessionResponse) saveOnce() error {
+	if !r.saved {
+		r.saved = true
+		r.err = r.save()
+	}
+	return r.err
+}
+
+func (r *sessionResponse) JSON(code int, i interface{}) error {
+	if err := r.saveOnce(); err != nil {
+		return err
+	}
+	return r.ResponseInterface.JSON(code, i)
+}
+
+func (r *sessionResponse) JSONPretty(code int, i interface{}, indent string) error {
+	if err := r.saveOnce(); err != nil {
+		return err
+	}
+	return r.ResponseInterface.JSONPretty(code, i, indent)
+}
+
+func (r *sessionResponse) String(code int, s string) error {
+	if err := r.saveOnce(); err != nil {
+		return err
+	}
+	return r.ResponseInterface.String(code, s)
+}
+
+func (r *sessionResponse) HTML(code int, html string) error {
+	if err := r.saveOnce(); err != nil {
+		return err
+	}
+	return r.ResponseInterface.HTML(code, html)
+}
+
+func (r *sessionResponse) Blob(code int, contentType string, b []byte) error {
+	if err := r.saveOnce(); err != nil {
+		return err
+	}
+	return r.ResponseInterface.Blob(code, contentType, b)
+}
+
+func (r *sessionResponse) Stream(code int, contentType string, reader interface{}) error {
+	if err := r.saveOnce(); err != nil {
+		return err
+	}
+	return r.ResponseInterface.Stream(code, contentType, reader)
+}
+
+func (r *sessionResponse) WriteStream(code int, contentType string, write func(w StreamWriter) error) error {
+	if err := r.saveOnce(); err != nil {
+		return err
+	}
+	return r.ResponseInterface.WriteStream(code, contentType, write)
+}
+
+// Writer saves the session before handing out the writer; a failed save is
+// retried by the generated wrapper's final Save
+func (r *sessionResponse) Writer() interface{} {
+	_ = r.saveOnce()
+	return r.ResponseInterface.Writer()
+}
+
+// expired reports whether a record has passed its idle or absolute timeout
+func (m *SessionManager) expired(record *SessionRecord, now time.Time) bool {
+	return !now.Before(record.LastAccessedAt.Add(m.config.IdleTimeout)) ||
+		!now.Before(record.CreatedAt.Add(m.config.AbsoluteTimeout))
+}
+
+// setCookie writes the session cookie; maxAge -1 expires it
+func (m *SessionManager) setCookie(c RequestContext, id string, maxAge int) {
+	c.Response().SetCookie(AxonCookie{
+		Name:     m.config.CookieName,
+		Value:    id,
+		Path:     m.config.CookiePath,
+		Domain:   m.config.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   !m.config.InsecureCookie,
+		HttpOnly: true,
+		SameSite: m.config.SameSite,
+	})
+}
+
+// session is the Session implementation used by SessionManager
+type session struct {
+	manager    *SessionManager
+	record     *SessionRecord
+	loadedID   string // ID the request presented, "" for new sessions
+	isNew      bool
+	dirty      bool
+	regenerate bool
+	destroyed  bool
+}
+
+func (s *session) ID() string  { return s.record.ID }
+func (s *session) IsNew() bool { return s.isNew }
+
+func (s *session) Get(key string) (string, bool) {
+	value, ok := s.record.Values[key]
+	return value, ok
+}
+
+func (s *session) Set(key, value string) {
+	if s.record.Values == nil {
+		s.record.Values = make(map[string]string)
+	}
+	s.record.Values[key] = value
+	s.dirty = true
+}
+
+func (s *session) Delete(key string) {
+	if _, ok := s.record.Values[key]; ok {
+		delete(s.record.Values, key)
+		s.dirty = true
+	}
+}
+
+func (s *session) Clear() {
+	s.record.Values = nil
+	s.record.Flashes = nil
+	s.dirty = true
+}
+
+func (s *session) AddFlash(key, message string) {
+	if s.record.Flashes == nil {
+		s.record.Flashes = make(map[string][]string)
+	}
+	s.record.Flashes[key] = append(s.record.Flashes[key], message)
+	s.dirty = true
+}
+
+func (s *session) Flashes(key string) []string {
+	messages := s.record.Flashes[key]
+	if len(messages) > 0 {
+		delete(s.record.Flashes, key)
+		s.dirty = true
+	}
+	return messages
+}
+
+func (s *session) Regenerate() {
+	s.regenerate = true
+	s.dirty = true
+}
+
+func (s *session) Destroy() {
+	s.destroyed = true
+}
+
+// newSessionID returns a random 256-bit session ID
+func newSessionID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// principalFingerprint summarises the subject, roles and permissions of a principal
+func principalFingerprint(principal Principal) string {
+	if principal == nil {
+		return ""
+	}
+
+	parts := []string{principal.GetSubject()}
+	if holder, ok := principal.(RoleHolder); ok {
+		roles := append([]string(nil), holder.GetRoles()...)
+		sort.Strings(roles)
+		parts = append(parts, strings.Join(roles, ","))
+	}
+	if holder, ok := principal.(PermissionHolder); ok {
+		permissions := append([]string(nil), holder.GetPermissions()...)
+		sort.Strings(permissions)
+		parts = append(parts, strings.Join(permissions, ","))
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// MemorySessionStore keeps sessions in memory; expired sessions are purged as they are found
+type MemorySessionStore struct {
+	mu       sync.Mutex
+	records  map[string]SessionRecord
+	now      func() time.Time
+	lastScan time.Time
+}
+
+// NewMemorySessionStore creates an empty in-memory session store
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{records: make(map[string]SessionRecord), now: time.Now}
+}
+
+// Load returns a copy of the stored record
+func (s *MemorySessionStore) Load(id string) (*SessionRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[id]
+	if !ok {
+		return nil, nil
+	}
+	if !s.now().Before(record.ExpiresAt) {
+		delete(s.records, id)
+		return nil, nil
+	}
+	return copySessionRecord(&record), nil
+}
+
+// Save stores a copy of the record and purges expired records at most once a minute
+func (s *MemorySessionStore) Save(record *SessionRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastScan) >= time.Minute {
+		for id, existing := range s.records {
+			if !now.Before(existing.ExpiresAt) {
+				delete(s.records, id)
+			}
+		}
+		s.lastScan = now
+	}
+
+	s.records[record.ID] = *copySessionRecord(record)
+	return nil
+}
+
+// Delete removes a record
+func (s *MemorySessionStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, id)
+	return nil
+}
+
+// Len returns the number of stored records, including expired ones not purged yet
+func (s *MemorySessionStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
+
+// copySessionRecord deep-copies a record so callers cannot mutate stored state
+func copySessionRecord(record *SessionRecord) *SessionRecord {
+	cp := *record
+	if record.Values != nil {
+		cp.Values = make(map[string]string, len(record.Values))
+		for k, v := range record.Values {
+			cp.Values[k] = v
+		}
+	}
+	if record.Flashes != nil {
+		cp.Flashes = make(map[string][]string, len(record.Flashes))
+		for k, v := range record.Flashes {
+			cp.Flashes[k] = append([]string(nil), v...)
+		}
+	}
+	return &cp
+}
+
+// FileSessionStore keeps one JSON file per session in a directory. File names
+// are hashes of the session ID, so IDs never appear on disk.
+type FileSessionStore struct {
+	dir string
+	now func() time.Time
+}
+
+// NewFileSessionStore creates a file session store, creating dir if needed
+func NewFileSessionStore(dir string) (*FileSessionStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create session directory %s: %w", dir, err)
+	}
+	return &FileSessionStore{dir: dir, now: time.Now}, nil
+}
+
+// Load reads a record from disk, removing it if it has expired
+func (s *FileSessionStore) Load(id string) (*SessionRecord, error) {
+	path := s.path(id)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+
+	var record SessionRecord
+	if err := json.Unmarshal(data, &record); err != nil || record.ID != id {
+		// Corrupt or foreign file: treat as missing
+		_ = os.Remove(path)
+		return nil, nil
+	}
+	if !s.now().Before(record.ExpiresAt) {
+		_ = os.Remove(path)
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// Save writes the record atomically
+func (s *FileSessionStore) Save(record *SessionRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".session-*")
+	if err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(record.ID)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a record file
+func (s *FileSessionStore) Delete(id string) error {
+	if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// Cleanup removes expired session files; call it periodically for long-running servers
+func (s *FileSessionStore) Cleanup() error {
+	matches, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	for _, path := range matches {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var record SessionRecord
+		if json.Unmarshal(data, &record) != nil || !now.Before(record.ExpiresAt) {
+			_ = os.Remove(path)
+		}
+	}
+	return nil
+}
+
+// path returns the file used for a session ID
+func (s *FileSessionStore) path(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,315 @@
+package axon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sessionClock is a controllable clock shared by a manager and its store
+type sessionClock struct{ t time.Time }
+
+func (c *sessionClock) now() time.Time          { return c.t }
+func (c *sessionClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestSessionManager(config SessionConfig) (*SessionManager, *MemorySessionStore, *sessionClock) {
+	clock := &sessionClock{t: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewMemorySessionStore()
+	store.now = clock.now
+	manager := NewSessionManager(config, store)
+	manager.now = clock.now
+	return manager, store, clock
+}
+
+// sessionRequest runs fn with the session loaded for a request carrying cookie (if set)
+// and returns the session cookie written by Save, if any
+func sessionRequest(t *testing.T, manager *SessionManager, cookie string, principal Principal, fn func(Session)) (AxonCookie, bool) {
+	t.Helper()
+	c := newValueRequestContext()
+	if cookie != "" {
+		c.withCookie("axon_session", cookie)
+	}
+	if principal != nil {
+		SetPrincipal(c, principal)
+	}
+
+	session, err := manager.Load(c)
+	require.NoError(t, err)
+	fn(session)
+	require.NoError(t, manager.Save(c, session))
+	return c.response.cookie("axon_session")
+}
+
+func TestSessionManager_Lifecycle(t *testing.T) {
+	manager, store, _ := newTestSessionManager(SessionConfig{})
+
+	// A new session that is never written to is not persisted
+	_, set := sessionRequest(t, manager, "", nil, func(s Session) {
+		assert.True(t, s.IsNew())
+		assert.Empty(t, s.ID())
+	})
+	assert.False(t, set)
+	assert.Equal(t, 0, store.Len())
+
+	cookie, set := sessionRequest(t, manager, "", nil, func(s Session) {
+		s.Set("cart", "3")
+		s.AddFlash("notice", "saved")
+	})
+	require.True(t, set)
+	assert.Len(t, cookie.Value, 43)
+	assert.True(t, cookie.HttpOnly)
+	assert.True(t, cookie.Secure)
+	assert.Equal(t, SameSiteLaxMode, cookie.SameSite)
+	assert.Equal(t, "/", cookie.Path)
+
+	// The cookie is not rewritten while the ID is unchanged; flashes are returned once
+	_, set = sessionRequest(t, manager, cookie.Value, nil, func(s Session) {
+		assert.False(t, s.IsNew())
+		assert.Equal(t, cookie.Value, s.ID())
+		value, ok := s.Get("cart")
+		assert.True(t, ok)
+		assert.Equal(t, "3", value)
+		assert.Equal(t, []string{"saved"}, s.Flashes("notice"))
+	})
+	assert.False(t, set)
+
+	sessionRequest(t, manager, cookie.Value, nil, func(s Session) {
+		assert.Empty(t, s.Flashes("notice"))
+		s.Delete("cart")
+	})
+	sessionRequest(t, manager, cookie.Value, nil, func(s Session) {
+		_, ok := s.Get("cart")
+		assert.False(t, ok)
+	})
+
+	// Destroy removes the record and expires the cookie
+	expired, set := sessionRequest(t, manager, cookie.Value, nil, func(s Session) { s.Destroy() })
+	require.True(t, set)
+	assert.Equal(t, -1, expired.MaxAge)
+	assert.Equal(t, 0, store.Len())
+	sessionRequest(t, manager, cookie.Value, nil, func(s Session) { assert.True(t, s.IsNew()) })
+}
+
+func TestSessionManager_Rotation(t *testing.T) {
+	manager, store, _ := newTestSessionManager(SessionConfig{})
+
+	cookie, _ := sessionRequest(t, manager, "", nil, func(s Session) { s.Set("step", "1") })
+
+	// Explicit regeneration keeps data under a new ID and deletes the old one
+	rotated, set := sessionRequest(t, manager, cookie.Value, nil, func(s Session) { s.Regenerate() })
+	require.True(t, set)
+	assert.NotEqual(t, cookie.Value, rotated.Value)
+	assert.Equal(t, 1, store.Len())
+	sessionRequest(t, manager, cookie.Value, nil, func(s Session) { assert.True(t, s.IsNew()) })
+
+	// A change of principal (login, new roles) rotates automatically
+	user := &BasicPrincipal{Subject: "42", Roles: []string{"user"}}
+	afterLogin, set := sessionRequest(t, manager, rotated.Value, user, func(s Session) {})
+	require.True(t, set)
+	assert.NotEqual(t, rotated.Value, afterLogin.Value)
+
+	_, set = sessionRequest(t, manager, afterLogin.Value, &BasicPrincipal{Subject: "42", Roles: []string{"user"}}, func(s Session) {})
+	assert.False(t, set, "same principal must not rotate")
+
+	promoted, set := sessionRequest(t, manager, afterLogin.Value, &BasicPrincipal{Subject: "42", Roles: []string{"admin", "user"}}, func(s Session) {
+		value, _ := s.Get("step")
+		assert.Equal(t, "1", value)
+	})
+	require.True(t, set)
+	assert.NotEqual(t, afterLogin.Value, promoted.Value)
+}
+
+func TestSessionManager_AnonymousRequests(t *testing.T) {
+	manager, store, _ := newTestSessionManager(SessionConfig{})
+	user := &BasicPrincipal{Subject: "42", Roles: []string{"user"}}
+
+	cookie, set := sessionRequest(t, manager, "", user, func(s Session) { s.Set("cart", "3") })
+	require.True(t, set)
+
+	// Public routes have no principal; alternating with authenticated routes
+	// keeps the session ID, so requests in flight with the cookie stay valid
+	for i := 0; i < 2; i++ {
+		_, set = sessionRequest(t, manager, cookie.Value, nil, func(s Session) { s.Set("seen", "public") })
+		assert.False(t, set, "anonymous request must not rotate")
+		_, set = sessionRequest(t, manager, cookie.Value, user, func(s Session) { s.Set("seen", "private") })
+		assert.False(t, set, "same principal must not rotate after an anonymous request")
+	}
+	assert.Equal(t, 1, store.Len())
+
+	record, err := store.Load(cookie.Value)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, principalFingerprint(user), record.Privilege)
+}
+
+// committedResponse drops cookies set after the response was written, as a
+// real response does once its headers are sent
+type committedResponse struct{ *recordingResponse }
+
+func (r committedResponse) SetCookie(cookie AxonCookie) {
+	if !r.Written() {
+		r.recordingResponse.SetCookie(cookie)
+	}
+}
+
+func TestSessionManager_SaveOnWrite(t *testing.T) {
+	manager, store, _ := newTestSessionManager(SessionConfig{})
+
+	// An error-only handler writes its own response; the cookie goes out first
+	recorded := newValueRequestContext()
+	var c RequestContext = WithResponse(recorded, committedResponse{recorded.response})
+	session, err := manager.Load(c)
+	require.NoError(t, err)
+
+	c = manager.SaveOnWrite(c, session)
+	session.Set("user_id", "42")
+	require.NoError(t, c.Response().String(200, "welcome"))
+	session.Set("theme", "dark")
+	require.NoError(t, manager.Save(c, session))
+
+	cookie, ok := recorded.response.cookie("axon_session")
+	require.True(t, ok, "the session cookie must be set before the body is written")
+	assert.Equal(t, "welcome", string(recorded.response.body))
+	assert.Equal(t, 1, store.Len())
+
+	// Changes made after the write are persisted by the final Save
+	sessionRequest(t, manager, cookie.Value, nil, func(s Session) {
+		theme, _ := s.Get("theme")
+		assert.Equal(t, "dark", theme)
+	})
+}
+
+func TestSessionManager_Expiry(t *testing.T) {
+	manager, _, clock := newTestSessionManager(SessionConfig{
+		IdleTimeout:     10 * time.Minute,
+		AbsoluteTimeout: time.Hour,
+	})
+
+	cookie, _ := sessionRequest(t, manager, "", nil, func(s Session) { s.Set("k", "v") })
+
+	// Activity within the idle timeout keeps the session alive until the absolute timeout
+	for i := 0; i < 5; i++ {
+		clock.advance(9 * time.Minute)
+		sessionRequest(t, manager, cookie.Value, nil, func(s Session) {
+			assert.False(t, s.IsNew(), "request %d", i)
+		})
+	}
+	clock.advance(9 * time.Minute) // 54 minutes
+	sessionRequest(t, manager, cookie.Value, nil, func(s Session) { assert.False(t, s.IsNew()) })
+	clock.advance(7 * time.Minute) // 61 minutes
+	sessionRequest(t, manager, cookie.Value, nil, func(s Session) {
+		assert.True(t, s.IsNew(), "absolute timeout")
+	})
+
+	idle, _ := sessionRequest(t, manager, "", nil, func(s Session) { s.Set("k", "v") })
+	clock.advance(10 * time.Minute)
+	sessionRequest(t, manager, idle.Value, nil, func(s Session) {
+		assert.True(t, s.IsNew(), "idle timeout")
+	})
+}
+
+func TestSessionManager_Config(t *testing.T) {
+	manager, _, _ := newTestSessionManager(SessionConfig{
+		CookieName:     "sid",
+		InsecureCookie: true,
+		SameSite:       SameSiteStrictMode,
+	})
+
+	c := newValueRequestContext()
+	session, err := manager.Load(c)
+	require.NoError(t, err)
+	session.Set("k", "v")
+	require.NoError(t, manager.Save(c, session))
+
+	cookie, ok := c.response.cookie("sid")
+	require.True(t, ok)
+	assert.False(t, cookie.Secure)
+	assert.Equal(t, SameSiteStrictMode, cookie.SameSite)
+
+	assert.Error(t, manager.Save(c, fakeSession{}))
+}
+
+// fakeSession is a Session not created by a SessionManager
+type fakeSession struct{ Session }
+
+func TestFileSessionStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sessions")
+	store, err := NewFileSessionStore(dir)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	record := &SessionRecord{
+		ID:        "session-id",
+		Values:    map[string]string{"user": "42"},
+		Flashes:   map[string][]string{"notice": {"hi"}},
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Minute),
+	}
+	require.NoError(t, store.Save(record))
+
+	files, _ := os.ReadDir(dir)
+	require.Len(t, files, 1)
+	assert.False(t, strings.Contains(files[0].Name(), "session-id"), "IDs must not appear on disk")
+
+	loaded, err := store.Load("session-id")
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+	assert.Equal(t, "42", loaded.Values["user"])
+	assert.Equal(t, []string{"hi"}, loaded.Flashes["notice"])
+
+	missing, err := store.Load("other")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+
+	// Expired records are treated as missing and removed
+	now = now.Add(2 * time.Minute)
+	expired, err := store.Load("session-id")
+	assert.NoError(t, err)
+	assert.Nil(t, expired)
+	files, _ = os.ReadDir(dir)
+	assert.Empty(t, files)
+
+	require.NoError(t, store.Save(&SessionRecord{ID: "a", ExpiresAt: now.Add(time.Minute)}))
+	require.NoError(t, store.Save(&SessionRecord{ID: "b", ExpiresAt: now.Add(-time.Minute)}))
+	require.NoError(t, store.Cleanup())
+	files, _ = os.ReadDir(dir)
+	assert.Len(t, files, 1)
+
+	require.NoError(t, store.Delete("a"))
+	require.NoError(t, store.Delete("a"))
+	files, _ = os.ReadDir(dir)
+	assert.Empty(t, files)
+}
+
+func TestFileSessionStore_WithManager(t *testing.T) {
+	store, err := NewFileSessionStore(t.TempDir())
+	require.NoError(t, err)
+	manager := NewSessionManager(SessionConfig{}, store)
+
+	cookie, _ := sessionRequest(t, manager, "", nil, func(s Session) { s.Set("user", "42") })
+	sessionRequest(t, manager, cookie.Value, nil, func(s Session) {
+		value, _ := s.Get("user")
+		assert.Equal(t, "42", value)
+	})
+}
+
+func TestMemorySessionStore_Isolation(t *testing.T) {
+	store := NewMemorySessionStore()
+	record := &SessionRecord{ID: "a", Values: map[string]string{"k": "v"}, ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, store.Save(record))
+
+	record.Values["k"] = "changed"
+	loaded, _ := store.Load("a")
+	assert.Equal(t, "v", loaded.Values["k"])
+
+	loaded.Values["k"] = "changed"
+	again, _ := store.Load("a")
+	assert.Equal(t, "v", again.Values["k"])
+}
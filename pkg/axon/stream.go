@@ -0,0 +1,257 @@
+package axon
+
+import (
+	"encoding/json"
+	"io"
+	"iter"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// NDJSONContentType is the content type of newline-delimited JSON streams
+	NDJSONContentType = "application/x-ndjson"
+
+	// streamFlushInterval is how often a stream sends what it has written so far
+	streamFlushInterval = 100 * time.Millisecond
+)
+
+// StreamWriter is the body of a streamed response, given to the write
+// function of ResponseInterface.WriteStream
+type StreamWriter interface {
+	io.Writer
+
+	// Flush sends what was written so far to the client. It fails once the
+	// client has disconnected.
+	Flush() error
+
+	// Done is closed when the client disconnects, or nil if the adapter
+	// cannot tell before a write fails
+	Done() <-chan struct{}
+}
+
+// StreamSeq streams the values of seq as a JSON array, or as NDJSON when the
+// client prefers application/x-ndjson in its Accept header. Generated
+// wrappers call it for handlers returning an iter.Seq[T].
+func StreamSeq[T any](c RequestContext, seq iter.Seq[T]) error {
+	return StreamSeq2(c, func(yield func(T, error) bool) {
+		for value := range seq {
+			if !yield(value, nil) {
+				return
+			}
+		}
+	})
+}
+
+// StreamSeq2 streams the values of seq like StreamSeq. An error yielded before
+// the first value is returned as is, so it is answered like any handler
+// error; a later error ends the stream early, leaving a JSON array
+// unterminated so that clients cannot take it for a complete response.
+// Generated wrappers call it for handlers returning an iter.Seq2[T, error].
+func StreamSeq2[T any](c RequestContext, seq iter.Seq2[T, error]) error {
+	next, stop := iter.Pull2(seq)
+	return streamValues(c, func(<-chan struct{}) (T, error, bool) {
+		return next()
+	}, stop)
+}
+
+// StreamChan streams the values received from ch like StreamSeq, until ch is
+// closed. When the client disconnects it stops receiving, so producers that
+// must stop early are better written as iterators. Generated wrappers call
+// it for handlers returning a <-chan T.
+func StreamChan[T any](c RequestContext, ch <-chan T) error {
+	return streamValues(c, func(done <-chan struct{}) (value T, err error, ok bool) {
+		select {
+		case value, ok = <-ch:
+		case <-done:
+		}
+		return value, nil, ok
+	}, func() {})
+}
+
+// streamValues writes the values returned by next until it reports no more.
+// The first value is read before the response is started, so that errors
+// can still change its status; later errors are logged, as the status has
+// been sent. stop releases next.
+func streamValues[T any](c RequestContext, next func(done <-chan struct{}) (T, error, bool), stop func()) error {
+	first, err, ok := next(nil)
+	if err != nil {
+		stop()
+		return err
+	}
+
+	ndjson := prefersNDJSON(c.Request().Header("Accept"))
+	contentType := "application/json"
+	if ndjson {
+		contentType = NDJSONContentType
+	}
+	// The stream may be written after the handler returns, so the request
+	// context is not used from the write function
+	logger := GetLogger(c)
+
+	return c.Response().WriteStream(http.StatusOK, contentType, func(w StreamWriter) error {
+		defer stop()
+		out := newFlushingWriter(w)
+		defer out.close()
+
+		encoder := json.NewEncoder(out)
+		encoder.SetEscapeHTML(false)
+		if !ndjson {
+			io.WriteString(out, "[")
+		}
+
+		value := first
+		for i := 0; ok; i++ {
+			if !ndjson && i > 0 {
+				io.WriteString(out, ",")
+			}
+			// Encode ends each value with a newline, as NDJSON requires
+			if err := encoder.Encode(value); err != nil && !out.gone() {
+				logger.Error("stream value could not be encoded", "error", err)
+				return nil
+			}
+			if out.gone() {
+				logger.Debug("stream client disconnected")
+				return nil
+			}
+
+			value, err, ok = next(out.done)
+			if err != nil {
+				logger.Error("stream ended early", "error", err)
+				return nil
+			}
+		}
+
+		if !ndjson {
+			io.WriteString(out, "]")
+		}
+		out.Flush()
+		return nil
+	})
+}
+
+// flushingWriter flushes a StreamWriter periodically while values are
+// written to it, and records when the client has gone
+type flushingWriter struct {
+	mu      sync.Mutex
+	w       StreamWriter
+	dirty   bool
+	err     error
+	failed  chan struct{} // closed on the first failed write or flush
+	done    <-chan struct{}
+	stopped chan struct{}
+	flusher sync.WaitGroup
+}
+
+func newFlushingWriter(w StreamWriter) *flushingWriter {
+	f := &flushingWriter{w: w, failed: make(chan struct{}), stopped: make(chan struct{})}
+	f.done = f.failed
+	if clientDone := w.Done(); clientDone != nil {
+		done := make(chan struct{})
+		f.done = done
+		go func() {
+			defer close(done)
+			select {
+			case <-clientDone:
+			case <-f.failed:
+			case <-f.stopped:
+			}
+		}()
+	}
+	f.flusher.Add(1)
+	go f.flushPeriodically()
+	return f
+}
+
+func (f *flushingWriter) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return 0, f.err
+	}
+	n, err := f.w.Write(p)
+	f.dirty = true
+	f.setErr(err)
+	return n, err
+}
+
+// Flush sends what was written so far
+func (f *flushingWriter) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.dirty = false
+	err := f.w.Flush()
+	f.setErr(err)
+	return err
+}
+
+// setErr records the first failed write or flush, which means the client
+// has gone
+func (f *flushingWriter) setErr(err error) {
+	if err != nil && f.err == nil {
+		f.err = err
+		close(f.failed)
+	}
+}
+
+// gone reports whether the client has disconnected
+func (f *flushingWriter) gone() bool {
+	select {
+	case <-f.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (f *flushingWriter) flushPeriodically() {
+	defer f.flusher.Done()
+	ticker := time.NewTicker(streamFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			f.mu.Lock()
+			if f.dirty && f.err == nil {
+				f.dirty = false
+				f.setErr(f.w.Flush())
+			}
+			f.mu.Unlock()
+		case <-f.stopped:
+			return
+		}
+	}
+}
+
+// close stops the periodic flushing
+func (f *flushingWriter) close() {
+	close(f.stopped)
+	f.flusher.Wait()
+}
+
+// prefersNDJSON reports whether an Accept header prefers NDJSON over JSON
+func prefersNDJSON(accept string) bool {
+	ndjson, jsonQ := 0.0, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case NDJSONContentType:
+			ndjson = q
+		case "application/json":
+			jsonQ = q
+		}
+	}
+	return ndjson > 0 && ndjson >= jsonQ
+}
@@ -0,0 +1,140 @@
+package axon
+
+import (
+	"iter"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type streamItem struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestStreamSeq(t *testing.T) {
+	items := []streamItem{{1, "a"}, {2, "<b>"}}
+
+	c := newValueRequestContext()
+	require.NoError(t, StreamSeq(c, slices.Values(items)))
+	assert.Equal(t, http.StatusOK, c.response.status)
+	assert.Equal(t, "application/json", c.response.headers.Get("Content-Type"))
+	assert.JSONEq(t, `[{"id":1,"name":"a"},{"id":2,"name":"<b>"}]`, string(c.response.body))
+	assert.Positive(t, c.response.stream.flushes, "the end of the stream is flushed")
+
+	c = newValueRequestContext().withHeader("Accept", "application/x-ndjson")
+	require.NoError(t, StreamSeq(c, slices.Values(items)))
+	assert.Equal(t, NDJSONContentType, c.response.headers.Get("Content-Type"))
+	assert.Equal(t, "{\"id\":1,\"name\":\"a\"}\n{\"id\":2,\"name\":\"<b>\"}\n", string(c.response.body))
+
+	c = newValueRequestContext()
+	require.NoError(t, StreamSeq(c, slices.Values([]streamItem(nil))))
+	assert.JSONEq(t, `[]`, string(c.response.body), "empty sequences are empty arrays")
+}
+
+func TestStreamSeq2_Errors(t *testing.T) {
+	failAt := func(n int) iter.Seq2[int, error] {
+		return func(yield func(int, error) bool) {
+			for i := 0; ; i++ {
+				if i == n {
+					yield(0, NewHTTPError(http.StatusConflict, "boom"))
+					return
+				}
+				if !yield(i, nil) {
+					return
+				}
+			}
+		}
+	}
+
+	// An error before the first value is returned to be handled as usual
+	c := newValueRequestContext()
+	err := StreamSeq2(c, failAt(0))
+	assert.Equal(t, http.StatusConflict, ErrorStatus(err))
+	assert.Zero(t, c.response.status, "no response is started")
+
+	// A later error ends the stream, leaving the array unterminated
+	c = newValueRequestContext()
+	require.NoError(t, StreamSeq2(c, failAt(2)))
+	assert.Equal(t, http.StatusOK, c.response.status)
+	assert.Equal(t, "[0\n,1\n", string(c.response.body))
+}
+
+func TestStreamChan(t *testing.T) {
+	ch := make(chan string, 3)
+	ch <- "a"
+	ch <- "b"
+	close(ch)
+
+	c := newValueRequestContext().withHeader("Accept", "application/x-ndjson")
+	require.NoError(t, StreamChan(c, ch))
+	assert.Equal(t, "\"a\"\n\"b\"\n", string(c.response.body))
+}
+
+func TestStream_ClientDisconnect(t *testing.T) {
+	c := newValueRequestContext()
+	c.response.stream = &bufferStream{done: make(chan struct{})}
+
+	// The producer blocks after the first value until the client disconnects
+	ch := make(chan int)
+	produced := make(chan struct{})
+	go func() {
+		ch <- 1
+		close(produced)
+	}()
+
+	result := make(chan error, 1)
+	go func() { result <- StreamChan(c, ch) }()
+
+	<-produced
+	close(c.response.stream.done)
+	select {
+	case err := <-result:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("stream did not stop when the client disconnected")
+	}
+
+	// Iterators are stopped too
+	stopped := false
+	seq := func(yield func(int) bool) {
+		defer func() { stopped = true }()
+		for i := 0; yield(i); i++ {
+		}
+	}
+	c = newValueRequestContext()
+	c.response.stream = &bufferStream{done: make(chan struct{})}
+	close(c.response.stream.done)
+	require.NoError(t, StreamSeq(c, seq))
+	assert.True(t, stopped, "the iterator is stopped when the client has gone")
+}
+
+func TestPrefersNDJSON(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"application/json", false},
+		{"*/*", false},
+		{"application/x-ndjson", true},
+		{"application/json, application/x-ndjson", true},
+		{"application/json, application/x-ndjson;q=0.5", false},
+		{"application/json;q=0.5, Application/X-NDJSON", true},
+		{"application/x-ndjson;q=0", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, prefersNDJSON(tt.accept), tt.accept)
+	}
+}
+
+func TestStreamSeq_EncodeError(t *testing.T) {
+	c := newValueRequestContext()
+	values := []any{1, func() {}, 3}
+	require.NoError(t, StreamSeq(c, slices.Values(values)))
+	assert.Equal(t, "[1\n,", string(c.response.body), "values after an encoding error are not sent")
+}
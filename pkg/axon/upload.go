@@ -0,0 +1,533 @@
+package axon
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// uploadMemoryLimit is how much of a form's files is kept in memory; parts
+	// that do not fit are written to temporary files
+	uploadMemoryLimit = 1 << 20
+
+	// formValueLimit is the total size of a form's non-file values
+	formValueLimit = 1 << 20
+
+	// sniffLen is the number of bytes http.DetectContentType looks at
+	sniffLen = 512
+)
+
+// UploadedFile is a file of a multipart/form-data request. Handlers receive
+// uploads through *UploadedFile and []*UploadedFile fields of their body
+// struct, bound by the form tag:
+//
+//	type AvatarUpload struct {
+//		Caption string               `form:"caption"`
+//		Avatar  *axon.UploadedFile   `form:"avatar" maxSize:"5MB" accept:"image/png,image/jpeg" required:"true"`
+//		Photos  []*axon.UploadedFile `form:"photos" maxCount:"4"`
+//	}
+//
+// Files are removed when the handler returns; save or copy them before.
+type UploadedFile struct {
+	// Filename is the base name of the file on the client
+	Filename string
+
+	// Size is the size of the file in bytes
+	Size int64
+
+	// ContentType is the media type of the content, detected from its first
+	// bytes; the declared Content-Type of the part is used when detection
+	// is inconclusive, unless detection would have recognised it
+	ContentType string
+
+	// Header is the MIME header of the part
+	Header textproto.MIMEHeader
+
+	content []byte // the file in memory, if it was not spilled
+	path    string // the temporary file otherwise
+}
+
+// Open opens the file for reading
+func (f *UploadedFile) Open() (multipart.File, error) {
+	if f.path != "" {
+		return os.Open(f.path)
+	}
+	return sectionReadCloser{io.NewSectionReader(bytes.NewReader(f.content), 0, int64(len(f.content)))}, nil
+}
+
+// SaveTo writes the file to path
+func (f *UploadedFile) SaveTo(path string) error {
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// sectionReadCloser makes an in-memory file a multipart.File
+type sectionReadCloser struct {
+	*io.SectionReader
+}
+
+func (sectionReadCloser) Close() error {
+	return nil
+}
+
+// UploadRule limits the files of one form field. Generated wrappers build the
+// rules from the tags of the body struct's *UploadedFile and []*UploadedFile
+// fields.
+type UploadRule struct {
+	// Field is the name of the form field
+	Field string
+
+	// MaxCount is the largest number of files in the field; 0 for no limit
+	MaxCount int
+
+	// MaxSize is the largest accepted file, in bytes; 0 for no limit
+	MaxSize int64
+
+	// Accept lists the allowed media types, such as "image/png" or
+	// "image/*"; empty to allow any type
+	Accept []string
+
+	// Required rejects forms without a file in the field
+	Required bool
+}
+
+// accepts reports whether the rule allows files of contentType
+func (r UploadRule) accepts(contentType string) bool {
+	if len(r.Accept) == 0 {
+		return true
+	}
+	mediaType := baseMediaType(contentType)
+	for _, accept := range r.Accept {
+		accept = strings.ToLower(strings.TrimSpace(accept))
+		if accept == "*/*" || accept == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(accept, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// UploadForm is a multipart/form-data request read by ReadMultipartForm
+type UploadForm struct {
+	values map[string][]string
+	files  map[string][]*UploadedFile
+	memory int64 // memory left for files
+}
+
+// ReadMultipartForm reads a multipart/form-data request body as it streams
+// in, enforcing rules on its files. Files in fields without a rule are
+// rejected. Violations fail with an *HTTPError: 413 for files over MaxSize,
+// 415 for types not in Accept and 400 otherwise. Files that do not fit in
+// memory are written to temporary files, which RemoveAll deletes. Requests
+// rejected before their body is read get Connection: close.
+func ReadMultipartForm(c RequestContext, rules ...UploadRule) (*UploadForm, error) {
+	mediaType, params, err := mime.ParseMediaType(c.Request().ContentType())
+	if err != nil || mediaType != "multipart/form-data" {
+		return nil, rejectBody(c, NewHTTPError(http.StatusUnsupportedMediaType, "Request body must be multipart/form-data"))
+	}
+	if params["boundary"] == "" {
+		return nil, rejectBody(c, NewHTTPError(http.StatusBadRequest, "Multipart boundary is missing"))
+	}
+
+	fieldRules := make(map[string]UploadRule, len(rules))
+	for _, rule := range rules {
+		fieldRules[rule.Field] = rule
+	}
+
+	form := &UploadForm{
+		values: make(map[string][]string),
+		files:  make(map[string][]*UploadedFile),
+		memory: uploadMemoryLimit,
+	}
+	if err := form.read(multipart.NewReader(GetBodyStream(c), params["boundary"]), fieldRules); err != nil {
+		form.RemoveAll()
+		return nil, rejectBody(c, err)
+	}
+
+	for _, rule := range rules {
+		if rule.Required && len(form.files[rule.Field]) == 0 {
+			form.RemoveAll()
+			return nil, NewHTTPError(http.StatusBadRequest, fmt.Sprintf("File %q is required", rule.Field))
+		}
+	}
+	return form, nil
+}
+
+// read reads the parts of a form
+func (f *UploadForm) read(reader *multipart.Reader, rules map[string]UploadRule) error {
+	valueBytes := int64(formValueLimit)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return multipartError(err)
+		}
+
+		name := part.FormName()
+		if name == "" {
+			part.Close()
+			continue
+		}
+
+		if part.FileName() == "" {
+			value, err := io.ReadAll(io.LimitReader(part, valueBytes+1))
+			part.Close()
+			if err != nil {
+				return multipartError(err)
+			}
+			valueBytes -= int64(len(value))
+			if valueBytes < 0 {
+				return NewHTTPError(http.StatusRequestEntityTooLarge,
+					fmt.Sprintf("Form values must not exceed %s", FormatByteSize(formValueLimit)))
+			}
+			f.values[name] = append(f.values[name], string(value))
+			continue
+		}
+
+		rule, ok := rules[name]
+		if !ok {
+			part.Close()
+			return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unexpected file field %q", name))
+		}
+		if rule.MaxCount > 0 && len(f.files[name]) >= rule.MaxCount {
+			part.Close()
+			return NewHTTPError(http.StatusBadRequest,
+				fmt.Sprintf("At most %d file(s) can be uploaded in %q", rule.MaxCount, name))
+		}
+
+		file, err := f.readFile(part, rule)
+		part.Close()
+		if err != nil {
+			return err
+		}
+		f.files[name] = append(f.files[name], file)
+	}
+}
+
+// readFile reads one file part, keeping it in memory while the form's memory
+// allows it
+func (f *UploadForm) readFile(part *multipart.Part, rule UploadRule) (*UploadedFile, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(part, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, multipartError(err)
+	}
+	head = head[:n]
+
+	file := &UploadedFile{
+		Filename:    part.FileName(),
+		ContentType: detectContentType(head, part.Header.Get("Content-Type")),
+		Header:      part.Header,
+	}
+	if !rule.accepts(file.ContentType) {
+		return nil, NewHTTPError(http.StatusUnsupportedMediaType,
+			fmt.Sprintf("File type %s is not allowed in %q", baseMediaType(file.ContentType), rule.Field))
+	}
+
+	content := io.MultiReader(bytes.NewReader(head), part)
+	if rule.MaxSize > 0 {
+		// One byte more than allowed tells a file of exactly MaxSize from a larger one
+		content = io.LimitReader(content, rule.MaxSize+1)
+	}
+
+	var buf bytes.Buffer
+	size, err := io.CopyN(&buf, content, f.memory+1)
+	if err != nil && err != io.EOF {
+		return nil, multipartError(err)
+	}
+	if size <= f.memory {
+		f.memory -= size
+		file.content = buf.Bytes()
+	} else {
+		spilled, err := spill(&buf, content)
+		if err != nil {
+			return nil, err
+		}
+		file.path = spilled.name
+		size = spilled.size
+	}
+	file.Size = size
+
+	if rule.MaxSize > 0 && size > rule.MaxSize {
+		file.remove()
+		return nil, NewHTTPError(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("File %q must not exceed %s", rule.Field, FormatByteSize(rule.MaxSize)))
+	}
+	return file, nil
+}
+
+// spilledFile is an upload written to a temporary file
+type spilledFile struct {
+	name string
+	size int64
+}
+
+// spill writes the buffered start of a file and the rest of it to a
+// temporary file
+func spill(buf *bytes.Buffer, rest io.Reader) (spilledFile, error) {
+	tmp, err := os.CreateTemp("", "axon-upload-*")
+	if err != nil {
+		return spilledFile{}, err
+	}
+	size, err := io.Copy(tmp, io.MultiReader(buf, rest))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return spilledFile{}, multipartError(err)
+	}
+	return spilledFile{name: tmp.Name(), size: size}, nil
+}
+
+// remove deletes the temporary file of a spilled upload
+func (f *UploadedFile) remove() error {
+	if f.path == "" {
+		return nil
+	}
+	err := os.Remove(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Value returns the first value of a form field, or "" if there is none
+func (f *UploadForm) Value(name string) string {
+	if values := f.values[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Values returns the non-file values of the form
+func (f *UploadForm) Values() map[string][]string {
+	return f.values
+}
+
+// File returns the first file of a form field, or nil if there is none
+func (f *UploadForm) File(name string) *UploadedFile {
+	if files := f.files[name]; len(files) > 0 {
+		return files[0]
+	}
+	return nil
+}
+
+// Files returns the files of a form field
+func (f *UploadForm) Files(name string) []*UploadedFile {
+	return f.files[name]
+}
+
+// RemoveAll deletes the temporary files of the form
+func (f *UploadForm) RemoveAll() error {
+	var errs []error
+	for _, files := range f.files {
+		for _, file := range files {
+			if err := file.remove(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var uploadedFileType = reflect.TypeOf((*UploadedFile)(nil))
+
+// Bind sets the fields of the struct dst points to from the form, by their
+// form tag. *UploadedFile and []*UploadedFile fields get the field's files;
+// strings, booleans, numbers and slices of them are parsed from its values.
+func (f *UploadForm) Bind(dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("form can only be bound to a pointer to a struct, not %T", dst)
+	}
+	return f.bindStruct(v.Elem())
+}
+
+func (f *UploadForm) bindStruct(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, tagged := field.Tag.Lookup("form")
+		name, _, _ := strings.Cut(tag, ",")
+		if !tagged {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				if err := f.bindStruct(v.Field(i)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+
+		switch field.Type {
+		case uploadedFileType:
+			v.Field(i).Set(reflect.ValueOf(f.File(name)))
+			continue
+		case reflect.SliceOf(uploadedFileType):
+			v.Field(i).Set(reflect.ValueOf(f.Files(name)))
+			continue
+		}
+
+		values := f.values[name]
+		if len(values) == 0 {
+			continue
+		}
+		if err := setFormValue(v.Field(i), values); err != nil {
+			return fmt.Errorf("invalid value for form field %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setFormValue parses the values of a form field into a struct field
+func setFormValue(field reflect.Value, values []string) error {
+	switch field.Kind() {
+	case reflect.Pointer:
+		value := reflect.New(field.Type().Elem())
+		if err := setFormValue(value.Elem(), values); err != nil {
+			return err
+		}
+		field.Set(value)
+		return nil
+	case reflect.Slice:
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setFormValue(slice.Index(i), []string{value}); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+
+	value := values[0]
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// detectContentType returns the media type of a file from its first bytes.
+// The declared type is used when detection only finds generic binary data,
+// unless content of the declared type would have been detected, or when it
+// finds plain text while a more specific text type is declared.
+func detectContentType(head []byte, declared string) string {
+	detected := http.DetectContentType(head)
+	declaredType := baseMediaType(declared)
+	if declaredType == "" {
+		return detected
+	}
+	switch baseMediaType(detected) {
+	case "application/octet-stream":
+		if !sniffedMediaTypes[declaredType] {
+			return declared
+		}
+	case "text/plain":
+		if isTextMediaType(declaredType) {
+			return declared
+		}
+	}
+	return detected
+}
+
+// sniffedMediaTypes are the binary media types http.DetectContentType
+// recognises, with their common aliases. Files declared as one of them but
+// not detected as such are generic binary data, so arbitrary bytes cannot
+// pass an accept rule such as image/* by their declared type.
+var sniffedMediaTypes = map[string]bool{
+	"image/x-icon": true, "image/vnd.microsoft.icon": true, "image/bmp": true,
+	"image/gif": true, "image/webp": true, "image/png": true, "image/jpeg": true, "image/jpg": true,
+	"audio/basic": true, "audio/aiff": true, "audio/mpeg": true, "audio/midi": true,
+	"audio/wave": true, "audio/wav": true, "audio/x-wav": true,
+	"application/ogg": true, "video/avi": true, "video/mp4": true, "video/webm": true,
+	"font/ttf": true, "font/otf": true, "font/collection": true, "font/woff": true, "font/woff2": true,
+	"application/x-gzip": true, "application/gzip": true, "application/zip": true,
+	"application/x-rar-compressed": true, "application/vnd.rar": true,
+	"application/pdf": true, "application/postscript": true,
+	"application/vnd.ms-fontobject": true, "application/wasm": true,
+}
+
+// isTextMediaType reports whether a media type is textual, so that plain text
+// content can be taken for it
+func isTextMediaType(mediaType string) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/json", mediaType == "application/xml",
+		strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	return false
+}
+
+// baseMediaType returns the lower-cased media type of a Content-Type value,
+// without parameters
+func baseMediaType(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+// multipartError turns an error reading a multipart body into an HTTP error;
+// body size errors are kept as they are
+func multipartError(err error) error {
+	if errors.Is(err, ErrBodyTooLarge) {
+		return err
+	}
+	return NewHTTPError(http.StatusBadRequest, "Invalid multipart body", err)
+}
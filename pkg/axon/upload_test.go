@@ -0,0 +1,226 @@
+package axon
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+// formPart is one part of a multipart test request
+type formPart struct {
+	field, filename, contentType string
+	content                      []byte
+}
+
+func multipartRequest(t *testing.T, parts ...formPart) *valueRequestContext {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for _, part := range parts {
+		if part.filename == "" {
+			require.NoError(t, writer.WriteField(part.field, string(part.content)))
+			continue
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", `form-data; name="`+part.field+`"; filename="`+part.filename+`"`)
+		if part.contentType != "" {
+			header.Set("Content-Type", part.contentType)
+		}
+		w, err := writer.CreatePart(header)
+		require.NoError(t, err)
+		_, err = w.Write(part.content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	c := newValueRequestContext().withHeader("Content-Type", writer.FormDataContentType())
+	c.method = http.MethodPost
+	c.request.body = buf.Bytes()
+	return c
+}
+
+type profileUpload struct {
+	Caption string          `form:"caption"`
+	Tags    []string        `form:"tag"`
+	Age     *int            `form:"age"`
+	Avatar  *UploadedFile   `form:"avatar"`
+	Photos  []*UploadedFile `form:"photos"`
+	Ignored string
+}
+
+func readUpload(t *testing.T, file *UploadedFile) []byte {
+	f, err := file.Open()
+	require.NoError(t, err)
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	return data
+}
+
+func TestReadMultipartForm(t *testing.T) {
+	avatar := append(append([]byte{}, pngHeader...), "avatar"...)
+	c := multipartRequest(t,
+		formPart{field: "caption", content: []byte("hello")},
+		formPart{field: "tag", content: []byte("a")},
+		formPart{field: "tag", content: []byte("b")},
+		formPart{field: "age", content: []byte("42")},
+		formPart{field: "avatar", filename: "me.png", contentType: "application/octet-stream", content: avatar},
+		formPart{field: "photos", filename: "1.txt", contentType: "text/plain", content: []byte("one")},
+		formPart{field: "photos", filename: "2.csv", contentType: "text/csv", content: []byte("a,b")},
+	)
+
+	form, err := ReadMultipartForm(c,
+		UploadRule{Field: "avatar", MaxCount: 1, MaxSize: 1 << 10, Accept: []string{"image/png"}, Required: true},
+		UploadRule{Field: "photos", Accept: []string{"text/*"}},
+	)
+	require.NoError(t, err)
+	defer form.RemoveAll()
+
+	var upload profileUpload
+	require.NoError(t, form.Bind(&upload))
+	assert.Equal(t, "hello", upload.Caption)
+	assert.Equal(t, []string{"a", "b"}, upload.Tags)
+	require.NotNil(t, upload.Age)
+	assert.Equal(t, 42, *upload.Age)
+	assert.Empty(t, upload.Ignored)
+
+	require.NotNil(t, upload.Avatar)
+	assert.Equal(t, "me.png", upload.Avatar.Filename)
+	assert.Equal(t, int64(len(avatar)), upload.Avatar.Size)
+	assert.Equal(t, "image/png", upload.Avatar.ContentType, "content type is detected, not declared")
+	assert.Equal(t, avatar, readUpload(t, upload.Avatar))
+
+	require.Len(t, upload.Photos, 2)
+	assert.Equal(t, "text/plain", upload.Photos[0].ContentType)
+	assert.Equal(t, "text/csv", upload.Photos[1].ContentType, "a declared text type refines plain text")
+	assert.Equal(t, []byte("a,b"), readUpload(t, upload.Photos[1]))
+}
+
+func TestReadMultipartForm_Spill(t *testing.T) {
+	large := bytes.Repeat([]byte("x"), uploadMemoryLimit+10)
+	c := multipartRequest(t,
+		formPart{field: "files", filename: "small.bin", content: []byte("small")},
+		formPart{field: "files", filename: "large.bin", content: large},
+	)
+
+	form, err := ReadMultipartForm(c, UploadRule{Field: "files"})
+	require.NoError(t, err)
+
+	files := form.Files("files")
+	require.Len(t, files, 2)
+	assert.Empty(t, files[0].path, "small files stay in memory")
+	require.NotEmpty(t, files[1].path, "files over the memory limit are written to disk")
+	assert.Equal(t, int64(len(large)), files[1].Size)
+	assert.Equal(t, large, readUpload(t, files[1]))
+
+	saved := t.TempDir() + "/saved.bin"
+	require.NoError(t, files[1].SaveTo(saved))
+	data, err := os.ReadFile(saved)
+	require.NoError(t, err)
+	assert.Equal(t, large, data)
+
+	require.NoError(t, form.RemoveAll())
+	_, err = os.Stat(files[1].path)
+	assert.True(t, os.IsNotExist(err), "RemoveAll deletes spilled files")
+}
+
+func TestReadMultipartForm_Rules(t *testing.T) {
+	png := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{0}, 100)...)
+	rules := []UploadRule{
+		{Field: "avatar", MaxCount: 1, MaxSize: 64, Accept: []string{"image/*"}, Required: true},
+	}
+
+	tests := []struct {
+		name   string
+		parts  []formPart
+		status int
+		msg    string
+	}{
+		{"too large", []formPart{{field: "avatar", filename: "a.png", content: png}}, http.StatusRequestEntityTooLarge, `File "avatar" must not exceed 64B`},
+		{"wrong type", []formPart{{field: "avatar", filename: "a.png", contentType: "image/png", content: []byte("not an image")}}, http.StatusUnsupportedMediaType, "File type text/plain is not allowed"},
+		{"binary data declared as image", []formPart{{field: "avatar", filename: "a.png", contentType: "image/png", content: []byte{0, 1, 2}}}, http.StatusUnsupportedMediaType, "File type application/octet-stream is not allowed"},
+		{"too many", []formPart{
+			{field: "avatar", filename: "a.png", content: pngHeader},
+			{field: "avatar", filename: "b.png", content: pngHeader},
+		}, http.StatusBadRequest, `At most 1 file(s) can be uploaded in "avatar"`},
+		{"unexpected field", []formPart{
+			{field: "avatar", filename: "a.png", content: pngHeader},
+			{field: "other", filename: "b.png", content: pngHeader},
+		}, http.StatusBadRequest, `Unexpected file field "other"`},
+		{"missing required", []formPart{{field: "caption", content: []byte("hi")}}, http.StatusBadRequest, `File "avatar" is required`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := multipartRequest(t, tt.parts...)
+			_, err := ReadMultipartForm(c, rules...)
+			require.Error(t, err)
+			assert.Equal(t, tt.status, ErrorStatus(err))
+			httpErr, ok := err.(*HTTPError)
+			require.True(t, ok)
+			assert.Contains(t, httpErr.Message, tt.msg)
+			if tt.name != "missing required" {
+				assert.Equal(t, "close", c.response.headers.Get("Connection"), "the rest of the body is not read")
+			}
+		})
+	}
+
+	// Only multipart/form-data bodies are read
+	c := newValueRequestContext().withHeader("Content-Type", "application/json")
+	_, err := ReadMultipartForm(c, rules...)
+	assert.Equal(t, http.StatusUnsupportedMediaType, ErrorStatus(err))
+}
+
+func TestReadMultipartForm_BodyLimit(t *testing.T) {
+	c := multipartRequest(t, formPart{field: "file", filename: "a.bin", content: bytes.Repeat([]byte("x"), 200)})
+	c.request.chunked = true
+
+	handler := WithBodyLimit(BodyLimitPolicy{MaxSize: 100, Stream: true})(func(c RequestContext) error {
+		_, err := ReadMultipartForm(c, UploadRule{Field: "file"})
+		return err
+	})
+	err := handler(c)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, ErrorStatus(err))
+	assert.ErrorIs(t, err, ErrBodyTooLarge)
+}
+
+func TestUploadFormBind(t *testing.T) {
+	form, err := ReadMultipartForm(multipartRequest(t, formPart{field: "age", content: []byte("old")}))
+	require.NoError(t, err)
+
+	var upload profileUpload
+	err = form.Bind(&upload)
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), `form field "age"`))
+
+	assert.Error(t, form.Bind(upload), "Bind needs a pointer to a struct")
+}
+
+func TestDetectContentType(t *testing.T) {
+	tests := []struct {
+		name     string
+		head     []byte
+		declared string
+		want     string
+	}{
+		{"detected over declared", pngHeader, "text/plain", "image/png"},
+		{"declared for binary data", []byte{0, 1, 2}, "application/x-protobuf", "application/x-protobuf"},
+		{"declared image for binary data", []byte{0, 1, 2}, "image/png", "application/octet-stream"},
+		{"declared zip for binary data", []byte{0, 1, 2}, "application/zip", "application/octet-stream"},
+		{"binary data undeclared", []byte{0, 1, 2}, "", "application/octet-stream"},
+		{"declared json for text", []byte(`{"a":1}`), "application/json", "application/json"},
+		{"declared image for text", []byte("hello"), "image/png", "text/plain; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, detectContentType(tt.head, tt.declared), tt.name)
+	}
+}
@@ -2,6 +2,9 @@ package axon
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"time"
 )
 
@@ -34,7 +37,12 @@ type RequestContext interface {
 	// Request data
 	Method() string
 	Path() string
+
+	// RealIP, Scheme and Host describe the client side of the request. They
+	// honour forwarding headers only from trusted proxies (see TrustedProxies).
 	RealIP() string
+	Scheme() string
+	Host() string
 
 	// Parameters
 	Param(key string) string
@@ -71,10 +79,28 @@ type RequestInterface interface {
 	Header(key string) string
 	SetHeader(key, value string)
 	Body() []byte
+
+	// SetBody replaces the request body, e.g. after decompressing it; Body and
+	// Bind read the new body afterwards
+	SetBody(body []byte)
+
+	// BodyStream returns the request body without buffering it. It can be
+	// read once, instead of Body or Bind.
+	BodyStream() io.ReadCloser
+
+	// ContentLength returns the declared body size, or -1 if unknown
 	ContentLength() int64
 	ContentType() string
 	Cookies() []AxonCookie
 	Cookie(name string) (AxonCookie, error)
+
+	// SignedCookie returns the verified value of a cookie set with Signed.
+	// Returns http.ErrNoCookie if absent and ErrInvalidCookie if tampered with.
+	SignedCookie(name string) (string, error)
+
+	// EncryptedCookie returns the decrypted value of a cookie set with Encrypted.
+	// Returns http.ErrNoCookie if absent and ErrInvalidCookie if tampered with.
+	EncryptedCookie(name string) (string, error)
 }
 
 // ResponseInterface provides response writing capabilities
@@ -86,6 +112,7 @@ type ResponseInterface interface {
 	// Headers
 	Header(key string) string
 	SetHeader(key, value string)
+	AddHeader(key, value string) // adds a value, keeping those already set
 
 	// Content
 	JSON(code int, i interface{}) error
@@ -95,6 +122,11 @@ type ResponseInterface interface {
 	Blob(code int, contentType string, b []byte) error
 	Stream(code int, contentType string, r interface{}) error
 
+	// WriteStream starts a response and lets write produce its body
+	// incrementally, flushing as it goes. Adapters may call write after the
+	// handler has returned, so it must not use the RequestContext.
+	WriteStream(code int, contentType string, write func(w StreamWriter) error) error
+
 	// Cookies
 	SetCookie(cookie AxonCookie)
 
@@ -121,6 +153,10 @@ type AxonCookie struct {
 	Secure   bool
 	HttpOnly bool
 	SameSite SameSiteMode
+
+	// Signed and Encrypted protect the value with the adapter's Keyring when the cookie is set
+	Signed    bool
+	Encrypted bool
 }
 
 // SameSiteMode defines cookie SameSite attribute modes
@@ -138,7 +174,7 @@ type FileHeader interface {
 	Filename() string
 	Header() map[string][]string
 	Size() int64
-	Open() (interface{}, error) // Returns framework-specific file
+	Open() (multipart.File, error)
 }
 
 // MultipartForm represents a parsed multipart form
@@ -159,7 +195,15 @@ func (he *HTTPError) Error() string {
 	if he.Internal != nil {
 		return he.Internal.Error()
 	}
-	return he.Message.(string)
+	if message, ok := he.Message.(string); ok {
+		return message
+	}
+	return fmt.Sprint(he.Message)
+}
+
+// Unwrap returns the internal error, so errors.Is and errors.As can inspect it
+func (he *HTTPError) Unwrap() error {
+	return he.Internal
 }
 
 // NewHTTPError creates a new HTTPError instance